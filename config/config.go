@@ -14,13 +14,40 @@ const (
 
 // ServiceConfig holds the configuration for the service.
 type ServiceConfig struct {
-	ServiceName    string            `yaml:"service_name" validate:"required"`
-	LogLevel       string            `yaml:"loglevel" validate:"required"`
-	Host           string            `yaml:"host" validate:"required"`
-	Port           string            `yaml:"port" validate:"required"`
-	PrivateKeyPath string            `yaml:"private_key_path" validate:"required"`
-	Database       Database          `yaml:"database" validate:"required"`
-	RateLimiter    RateLimiterConfig `yaml:"rate_limiter" validate:"required"`
+	ServiceName       string                  `yaml:"service_name" validate:"required"`
+	LogLevel          string                  `yaml:"loglevel" validate:"required"`
+	Host              string                  `yaml:"host" validate:"required"`
+	Port              string                  `yaml:"port" validate:"required"`
+	PrivateKeyPath    string                  `yaml:"private_key_path" validate:"required_without=KeyRing.Dir"`
+	KeyRing           KeyRingConfig           `yaml:"key_ring" validate:"omitempty"`
+	Database          Database                `yaml:"database" validate:"required"`
+	RateLimiter       RateLimiterConfig       `yaml:"rate_limiter" validate:"required"`
+	RefreshToken      RefreshTokenConfig      `yaml:"refresh_token" validate:"omitempty"`
+	Revocation        RevocationConfig        `yaml:"revocation" validate:"omitempty"`
+	Token             TokenConfig             `yaml:"token" validate:"omitempty"`
+	Cookie            CookieConfig            `yaml:"cookie" validate:"omitempty"`
+	OAuth             OAuthConfig             `yaml:"oauth" validate:"omitempty"`
+	MFA               MFAConfig               `yaml:"mfa" validate:"omitempty"`
+	WebAuthn          WebAuthnConfig          `yaml:"webauthn" validate:"omitempty"`
+	Mail              MailConfig              `yaml:"mail" validate:"omitempty"`
+	PasswordReset     PasswordResetConfig     `yaml:"password_reset" validate:"omitempty"`
+	EmailVerification EmailVerificationConfig `yaml:"email_verification" validate:"omitempty"`
+	MagicLink         MagicLinkConfig         `yaml:"magic_link" validate:"omitempty"`
+	Lockout           LockoutConfig           `yaml:"lockout" validate:"omitempty"`
+	PasswordPolicy    PasswordPolicyConfig    `yaml:"password_policy" validate:"omitempty"`
+	PasswordHashing   PasswordHashingConfig   `yaml:"password_hashing" validate:"omitempty"`
+	RBAC              RBACConfig              `yaml:"rbac" validate:"omitempty"`
+	Authorization     AuthorizationConfig     `yaml:"authorization" validate:"omitempty"`
+	APIKeys           APIKeyConfig            `yaml:"api_keys" validate:"omitempty"`
+}
+
+// KeyRingConfig holds the signing key rotation configuration.
+// When Dir is set it takes precedence over PrivateKeyPath. Scheduled rotation
+// needs a directory, so keys survive restarts, and at least one retired key.
+type KeyRingConfig struct {
+	Dir              string        `yaml:"dir" validate:"required_with=RotationInterval"`
+	MaxRetired       int           `yaml:"max_retired" validate:"required_with=RotationInterval,gte=0"`
+	RotationInterval time.Duration `yaml:"rotation_interval" validate:"gte=0"`
 }
 
 type Database struct {
@@ -67,6 +94,204 @@ type RateLimiterConfig struct {
 	Limit    int           `yaml:"limit" validate:"required"`
 }
 
+// RefreshTokenConfig holds the refresh token configuration.
+type RefreshTokenConfig struct {
+	TTL time.Duration `yaml:"ttl" validate:"gte=0"`
+}
+
+// RevocationConfig selects where revoked token IDs are stored. "memory" keeps
+// them in the process; "database" uses the configured database.
+type RevocationConfig struct {
+	Store string `yaml:"store" validate:"omitempty,oneof=memory database"`
+}
+
+// TokenConfig holds the session token claims configuration. Unset fields keep
+// the built-in defaults. RSAAlgorithm (RS256 or PS256) only applies to RSA keys.
+type TokenConfig struct {
+	Lifetime     time.Duration `yaml:"lifetime" validate:"gte=0"`
+	Issuer       string        `yaml:"issuer"`
+	Subject      string        `yaml:"subject"`
+	Audience     []string      `yaml:"audience"`
+	Leeway       time.Duration `yaml:"leeway" validate:"gte=0"`
+	RSAAlgorithm string        `yaml:"rsa_algorithm" validate:"omitempty,oneof=RS256 PS256"`
+}
+
+// CookieConfig holds the attributes of the session and refresh token cookies.
+// A zero MaxAge ties the cookie lifetime to the token it carries.
+type CookieConfig struct {
+	Domain   string        `yaml:"domain"`
+	Secure   bool          `yaml:"secure"`
+	SameSite string        `yaml:"same_site" validate:"omitempty,oneof=lax strict none"`
+	MaxAge   time.Duration `yaml:"max_age" validate:"gte=0"`
+}
+
+// OAuthConfig holds the OAuth 2.0 authorization server configuration.
+// UserScopes are the scopes every user holds and may delegate to clients, in
+// addition to the permissions of their roles. The account scope is reserved
+// for session tokens.
+type OAuthConfig struct {
+	AuthorizationCodeTTL time.Duration `yaml:"authorization_code_ttl" validate:"gte=0"`
+	UserScopes           []string      `yaml:"user_scopes" validate:"dive,ne=account"`
+}
+
+// MFAConfig holds the multi-factor authentication configuration. TOTP secrets
+// are encrypted with the key at EncryptionKeyPath, which is created when
+// missing; MFA enrollment is disabled when it is empty.
+type MFAConfig struct {
+	Issuer            string        `yaml:"issuer"`
+	EncryptionKeyPath string        `yaml:"encryption_key_path"`
+	ChallengeTTL      time.Duration `yaml:"challenge_ttl" validate:"gte=0"`
+}
+
+// WebAuthnConfig holds the passkey configuration. RPID is the domain passkeys
+// are bound to and Origins the web origins allowed to use them; passkeys are
+// disabled when RPID is empty.
+type WebAuthnConfig struct {
+	RPID                    string        `yaml:"rp_id"`
+	RPName                  string        `yaml:"rp_name"`
+	Origins                 []string      `yaml:"origins" validate:"required_with=RPID,dive,url"`
+	RequireUserVerification bool          `yaml:"require_user_verification"`
+	Attestation             string        `yaml:"attestation" validate:"omitempty,oneof=none indirect direct"`
+	Timeout                 time.Duration `yaml:"timeout" validate:"gte=0"`
+}
+
+// MailConfig selects how emails are delivered: "smtp" through a relay, "file"
+// appended to FilePath, or "memory" discarded after being kept in the process.
+// Emails cannot be sent when Type is empty.
+type MailConfig struct {
+	Type     string     `yaml:"type" validate:"omitempty,oneof=smtp file memory"`
+	From     string     `yaml:"from" validate:"required_with=Type"`
+	FilePath string     `yaml:"file_path" validate:"required_if=Type file"`
+	SMTP     SMTPConfig `yaml:"smtp" validate:"omitempty"`
+}
+
+// SMTPConfig holds the SMTP relay settings. Authentication is skipped when
+// Username is empty.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" validate:"gte=0"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// PasswordResetConfig holds the password reset configuration. URL is the page
+// the mailed links point to, with the reset token appended as the "token"
+// query parameter; password reset is disabled when it is empty.
+type PasswordResetConfig struct {
+	URL string        `yaml:"url" validate:"omitempty,url"`
+	TTL time.Duration `yaml:"ttl" validate:"gte=0"`
+}
+
+// EmailVerificationConfig holds the email verification configuration. URL is
+// the page the links mailed at signup point to, with the verification token
+// appended as the "token" query parameter; no links are sent when it is empty.
+// Required rejects password logins of users who have not verified their email.
+type EmailVerificationConfig struct {
+	Required bool          `yaml:"required"`
+	URL      string        `yaml:"url" validate:"omitempty,url"`
+	TTL      time.Duration `yaml:"ttl" validate:"gte=0"`
+}
+
+// MagicLinkConfig holds the passwordless login configuration. URL is the page
+// the mailed login links point to, with the link token appended as the
+// "token" query parameter; magic link login is disabled when it is empty.
+type MagicLinkConfig struct {
+	URL string        `yaml:"url" validate:"omitempty,url"`
+	TTL time.Duration `yaml:"ttl" validate:"gte=0"`
+}
+
+// LockoutConfig throttles failed password logins per account. After
+// FreeAttempts consecutive failures each further failure refuses logins for
+// BaseDelay, doubling up to MaxDelay; after MaxAttempts failures the account
+// is locked for Duration. Zero values disable the backoff or the lockout.
+type LockoutConfig struct {
+	FreeAttempts int           `yaml:"free_attempts" validate:"gte=0"`
+	BaseDelay    time.Duration `yaml:"base_delay" validate:"gte=0"`
+	MaxDelay     time.Duration `yaml:"max_delay" validate:"gte=0"`
+	MaxAttempts  int           `yaml:"max_attempts" validate:"gte=0"`
+	Duration     time.Duration `yaml:"duration" validate:"gte=0"`
+}
+
+// PasswordPolicyConfig decides which passwords are accepted at signup and
+// password reset. Lengths must stay within the 8 to 64 characters accepted at
+// login and default to those bounds. MinStrength is the lowest accepted
+// strength score from 0 to 4, and BreachedPasswordsPath is a local copy of the
+// Have I Been Pwned Pwned Passwords data, either a directory of range files
+// or a single file of hashes; see passwordpolicy.BreachList.
+type PasswordPolicyConfig struct {
+	MinLength             int    `yaml:"min_length" validate:"omitempty,min=8,max=64"`
+	MaxLength             int    `yaml:"max_length" validate:"omitempty,min=8,max=64"`
+	RequireLowercase      bool   `yaml:"require_lowercase"`
+	RequireUppercase      bool   `yaml:"require_uppercase"`
+	RequireDigit          bool   `yaml:"require_digit"`
+	RequireSymbol         bool   `yaml:"require_symbol"`
+	DisallowUsername      bool   `yaml:"disallow_username"`
+	MinStrength           int    `yaml:"min_strength" validate:"gte=0,lte=4"`
+	BreachedPasswordsPath string `yaml:"breached_passwords_path"`
+}
+
+// PasswordHashingConfig selects the algorithm new passwords are hashed with,
+// bcrypt when Algorithm is empty. Stored hashes of another algorithm, with
+// other parameters or another pepper are upgraded when their user next logs in.
+type PasswordHashingConfig struct {
+	Algorithm string         `yaml:"algorithm" validate:"omitempty,oneof=bcrypt argon2id"`
+	Bcrypt    BcryptConfig   `yaml:"bcrypt" validate:"omitempty"`
+	Argon2id  Argon2idConfig `yaml:"argon2id" validate:"omitempty"`
+	Pepper    PepperConfig   `yaml:"pepper" validate:"omitempty"`
+}
+
+// PepperConfig holds the secrets applied to passwords before hashing, which
+// must be kept apart from the database. Current is the ID of the pepper of
+// new hashes; retired peppers stay listed until their users have logged in
+// again. Passwords are hashed without pepper when Current is empty.
+type PepperConfig struct {
+	Current string            `yaml:"current" validate:"required_with=Keys"`
+	Keys    []PepperKeyConfig `yaml:"keys" validate:"dive"`
+}
+
+// PepperKeyConfig locates a base64 encoded pepper of at least 32 bytes, read
+// from File or, when File is empty, from the environment variable Env.
+type PepperKeyConfig struct {
+	ID   string `yaml:"id" validate:"required"`
+	File string `yaml:"file" validate:"required_without=Env"`
+	Env  string `yaml:"env" validate:"required_without=File"`
+}
+
+// BcryptConfig holds the bcrypt cost, the default cost when 0.
+type BcryptConfig struct {
+	Cost int `yaml:"cost" validate:"omitempty,min=4,max=31"`
+}
+
+// Argon2idConfig holds the Argon2id parameters; Memory is in KiB. Parameters
+// left 0 take the defaults of the passwordhash package.
+type Argon2idConfig struct {
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"salt_length"`
+	KeyLength   uint32 `yaml:"key_length"`
+}
+
+// RBACConfig lists the roles that may be assigned to users with the
+// permissions each grants. DefaultRoles are assigned to new users.
+type RBACConfig struct {
+	Roles        map[string][]string `yaml:"roles"`
+	DefaultRoles []string            `yaml:"default_roles"`
+}
+
+// AuthorizationConfig locates the YAML file of the policies evaluated by the
+// policy decision endpoint, which denies every request when PolicyPath is empty.
+type AuthorizationConfig struct {
+	PolicyPath string `yaml:"policy_path"`
+}
+
+// APIKeyConfig bounds the lifetime of personal API keys. DefaultTTL applies
+// when a key is created without one and MaxTTL caps what users may request.
+type APIKeyConfig struct {
+	DefaultTTL time.Duration `yaml:"default_ttl" validate:"gte=0"`
+	MaxTTL     time.Duration `yaml:"max_ttl" validate:"gte=0"`
+}
+
 // ReadLocalConfig reads the service configuration from a YAML file at the specified path.
 // It unmarshals the YAML content into a ServiceConfig struct and returns it.
 // If there is an error reading the file or unmarshaling the content, it returns an error.
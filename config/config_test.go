@@ -52,6 +52,101 @@ func TestReadLocalConfig(t *testing.T) {
 					Interval:     5 * time.Minute,
 					Limit:       5,
 				},
+				RefreshToken: RefreshTokenConfig{
+					TTL: 168 * time.Hour,
+				},
+				Revocation: RevocationConfig{
+					Store: "memory",
+				},
+				Token: TokenConfig{
+					Lifetime:     15 * time.Minute,
+					Issuer:       "github.com/haguru/sasuke.com",
+					Subject:      "AUTHENTICATION",
+					Audience:     []string{"apigithub.com/haguru/sasuke.com"},
+					Leeway:       30 * time.Second,
+					RSAAlgorithm: "RS256",
+				},
+				Cookie: CookieConfig{
+					Secure:   false,
+					SameSite: "lax",
+				},
+				OAuth: OAuthConfig{
+					AuthorizationCodeTTL: time.Minute,
+					UserScopes:           []string{"openid", "authorize"},
+				},
+				MFA: MFAConfig{
+					Issuer:            "sasuke",
+					EncryptionKeyPath: "./res/mfa_secret.key",
+					ChallengeTTL:      5 * time.Minute,
+				},
+				WebAuthn: WebAuthnConfig{
+					RPID:        "localhost",
+					RPName:      "sasuke",
+					Origins:     []string{"http://localhost:50051"},
+					Attestation: "none",
+				Timeout:     5 * time.Minute,
+				},
+				Mail: MailConfig{
+					Type:     "file",
+					From:     "sasuke <noreply@localhost>",
+					FilePath: "./res/outbox.eml",
+					SMTP: SMTPConfig{
+						Host: "localhost",
+						Port: 587,
+					},
+				},
+				PasswordReset: PasswordResetConfig{
+					URL: "http://localhost:50051/reset-password",
+					TTL: 30 * time.Minute,
+				},
+				EmailVerification: EmailVerificationConfig{
+					URL: "http://localhost:50051/email/verify",
+					TTL: 24 * time.Hour,
+				},
+				MagicLink: MagicLinkConfig{
+					URL: "http://localhost:50051/login/magic/callback",
+					TTL: 10 * time.Minute,
+				},
+				Lockout: LockoutConfig{
+					FreeAttempts: 3,
+					BaseDelay:    time.Second,
+					MaxDelay:     time.Minute,
+					MaxAttempts:  10,
+					Duration:     15 * time.Minute,
+				},
+				PasswordPolicy: PasswordPolicyConfig{
+					MinLength:        8,
+					MaxLength:        64,
+					DisallowUsername: true,
+					MinStrength:      2,
+				},
+				PasswordHashing: PasswordHashingConfig{
+					Algorithm: "argon2id",
+					Bcrypt: BcryptConfig{
+						Cost: 10,
+					},
+					Argon2id: Argon2idConfig{
+						Memory:      65536,
+						Iterations:  3,
+						Parallelism: 4,
+						SaltLength:  16,
+						KeyLength:   32,
+					},
+				},
+				RBAC: RBACConfig{
+					Roles: map[string][]string{
+						"admin": {"create", "roles:read", "roles:assign"},
+						"user":  {},
+					},
+					DefaultRoles: []string{"user"},
+				},
+				Authorization: AuthorizationConfig{
+					PolicyPath: "./res/policies.yaml",
+				},
+				APIKeys: APIKeyConfig{
+					DefaultTTL: 720 * time.Hour,
+					MaxTTL:     8760 * time.Hour,
+				},
 				// Assuming the database configuration is also part of the config file
 				Database: Database{
 					Type: "mongo",
@@ -59,8 +154,17 @@ func TestReadLocalConfig(t *testing.T) {
 						DSN:              "mongodb://localhost:27017/sasukeDB",
 						DatabaseName:     "sasukeDB",
 						Timeout:          10 * time.Second,
-						ValidCollections: []string{"users"},
-						ValidFields:      []string{"username", "hashed_password"},
+						ValidCollections: []string{"users", "refresh_tokens", "revoked_tokens",
+							"oauth_clients", "authorization_codes", "webauthn_credentials", "password_reset_tokens",
+							"revoked_subjects", "api_keys"},
+						ValidFields: []string{"username", "hashed_password", "token_hash", "family_id",
+							"expires_at", "used", "jti", "client_id", "client_secret_hash", "name",
+							"redirect_uris", "scopes", "grant_types", "public_key", "created_at", "code_hash",
+							"redirect_uri", "scope", "code_challenge", "code_challenge_method", "nonce", "auth_time",
+							"totp_secret", "totp_enabled", "totp_last_counter", "amr", "recovery_codes",
+							"credential_id", "sign_count", "aaguid", "attestation_format", "transports", "last_used_at",
+							"subject", "revoked_before", "email", "email_verified",
+							"failed_logins", "locked_until", "pepper_id", "roles", "key_id", "key_hash"},
 						Options: MongoServerOptions{
 							APIVersion:           "1",
 							SetStrict:            true,
@@ -0,0 +1,5 @@
+package constants
+
+const (
+	APIKeysCollection = "api_keys"
+)
@@ -0,0 +1,125 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haguru/sasuke/internal/apikeyrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+
+	"github.com/go-viper/mapstructure/v2"
+	mongoClient "github.com/haguru/sasuke/pkg/databases/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongosdk "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAPIKeyRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewMongoAPIKeyRepository returns a new MongoAPIKeyRepository.
+func NewMongoAPIKeyRepository(dbClient interfaces.DBClient) (interfaces.APIKeyRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type MongoDBClient
+	if _, ok := dbClient.(*mongoClient.MongoDBClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a MongoDB client")
+	}
+	return &MongoAPIKeyRepository{dbClient: dbClient}, nil
+}
+
+// AddAPIKey saves a new API key to MongoDB via DBClient.
+func (r *MongoAPIKeyRepository) AddAPIKey(ctx context.Context, key models.APIKey) error {
+	keyMap := make(map[string]interface{})
+	if err := mapstructure.Decode(key, &keyMap); err != nil {
+		return fmt.Errorf("failed to decode api key model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.APIKeysCollection, keyMap); err != nil {
+		return fmt.Errorf("failed to add api key to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey fetches an API key by hash, returns nil if not found.
+func (r *MongoAPIKeyRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	filter := map[string]any{"key_hash": keyHash}
+	err := r.dbClient.FindOne(ctx, constants.APIKeysCollection, filter, &key)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // API key not found
+		}
+		return nil, fmt.Errorf("failed to get api key from MongoDB: %w", err)
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys fetches the API keys of a user.
+func (r *MongoAPIKeyRepository) ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	filter := map[string]any{"username": username}
+	docs, err := r.dbClient.FindMany(ctx, constants.APIKeysCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys from MongoDB: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(docs))
+	for _, doc := range docs {
+		var key models.APIKey
+		if err := mapstructure.Decode(doc, &key); err != nil {
+			return nil, fmt.Errorf("failed to decode api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey deletes the API key keyID of a user.
+func (r *MongoAPIKeyRepository) DeleteAPIKey(ctx context.Context, username, keyID string) (bool, error) {
+	filter := map[string]any{"username": username, "key_id": keyID}
+	deleted, err := r.dbClient.DeleteOne(ctx, constants.APIKeysCollection, filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete api key from MongoDB: %w", err)
+	}
+
+	return deleted == 1, nil
+}
+
+// SetAPIKeyLastUsed records the last use of an API key.
+func (r *MongoAPIKeyRepository) SetAPIKeyLastUsed(ctx context.Context, keyID string, lastUsedAt int64) error {
+	filter := map[string]any{"key_id": keyID}
+	update := map[string]any{"$set": map[string]any{"last_used_at": lastUsedAt}}
+	if _, err := r.dbClient.UpdateOne(ctx, constants.APIKeysCollection, filter, update); err != nil {
+		return fmt.Errorf("failed to update api key last use in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndices creates unique indexes on the key hash and ID and an index on the username.
+func (r *MongoAPIKeyRepository) EnsureIndices(ctx context.Context) error {
+	hashIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"key_hash": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.APIKeysCollection, hashIndex); err != nil {
+		return err
+	}
+
+	keyIDIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"key_id": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.APIKeysCollection, keyIDIndex); err != nil {
+		return err
+	}
+
+	usernameIndex := mongosdk.IndexModel{
+		Keys: bson.M{"username": 1},
+	}
+	return r.dbClient.EnsureSchema(ctx, constants.APIKeysCollection, usernameIndex)
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/haguru/sasuke/internal/apikeyrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/pkg/databases/postgres"
+)
+
+var ensureAPIKeysSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			key_id TEXT NOT NULL UNIQUE,
+			key_hash TEXT NOT NULL UNIQUE,
+			username TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			scopes TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			last_used_at BIGINT NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS api_keys_username_idx ON api_keys (username);
+	`
+
+type PostgresAPIKeyRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewPostgresAPIKeyRepository returns a new PostgresAPIKeyRepository using the provided dbClient.
+func NewPostgresAPIKeyRepository(dbClient interfaces.DBClient) (interfaces.APIKeyRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type PostgresDatabaseClient
+	if _, ok := dbClient.(*postgres.PostgresDatabaseClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a PostgreSQL client")
+	}
+	return &PostgresAPIKeyRepository{dbClient: dbClient}, nil
+}
+
+// AddAPIKey inserts an API key.
+func (r *PostgresAPIKeyRepository) AddAPIKey(ctx context.Context, key models.APIKey) error {
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(key, &doc); err != nil {
+		return fmt.Errorf("failed to decode api key model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.APIKeysCollection, doc); err != nil {
+		return fmt.Errorf("failed to add api key to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key by hash and returns nil if it is not found.
+func (r *PostgresAPIKeyRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	filter := map[string]interface{}{"key_hash": keyHash}
+	if err := r.dbClient.FindOne(ctx, constants.APIKeysCollection, filter, &key); err != nil {
+		return nil, fmt.Errorf("failed to get api key from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if key.KeyID == "" {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// ListAPIKeys retrieves the API keys of a user.
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	filter := map[string]interface{}{"username": username}
+	rows, err := r.dbClient.FindMany(ctx, constants.APIKeysCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys from PostgreSQL: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		var key models.APIKey
+		if err := mapstructure.Decode(row, &key); err != nil {
+			return nil, fmt.Errorf("failed to decode api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey deletes the API key keyID of a user.
+func (r *PostgresAPIKeyRepository) DeleteAPIKey(ctx context.Context, username, keyID string) (bool, error) {
+	filter := map[string]interface{}{"username": username, "key_id": keyID}
+	deleted, err := r.dbClient.DeleteOne(ctx, constants.APIKeysCollection, filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete api key from PostgreSQL: %w", err)
+	}
+
+	return deleted == 1, nil
+}
+
+// SetAPIKeyLastUsed records the last use of an API key.
+func (r *PostgresAPIKeyRepository) SetAPIKeyLastUsed(ctx context.Context, keyID string, lastUsedAt int64) error {
+	filter := map[string]interface{}{"key_id": keyID}
+	update := map[string]interface{}{"last_used_at": lastUsedAt}
+	if _, err := r.dbClient.UpdateOne(ctx, constants.APIKeysCollection, filter, update); err != nil {
+		return fmt.Errorf("failed to update api key last use in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndices creates the API keys table.
+func (r *PostgresAPIKeyRepository) EnsureIndices(ctx context.Context) error {
+	return r.dbClient.EnsureSchema(ctx, constants.APIKeysCollection, ensureAPIKeysSchemaSQL)
+}
@@ -2,19 +2,41 @@ package app
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/haguru/sasuke/config"
+	mongoAPIKeyRepo "github.com/haguru/sasuke/internal/apikeyrepo/mongo"
+	postgresAPIKeyRepo "github.com/haguru/sasuke/internal/apikeyrepo/postgres"
 	"github.com/haguru/sasuke/internal/auth"
+	mongoClientRepo "github.com/haguru/sasuke/internal/clientrepo/mongo"
+	postgresClientRepo "github.com/haguru/sasuke/internal/clientrepo/postgres"
+	mongoCredentialRepo "github.com/haguru/sasuke/internal/credentialrepo/mongo"
+	postgresCredentialRepo "github.com/haguru/sasuke/internal/credentialrepo/postgres"
 	"github.com/haguru/sasuke/internal/interfaces"
+	fileMailer "github.com/haguru/sasuke/internal/mailer/file"
+	memoryMailer "github.com/haguru/sasuke/internal/mailer/memory"
+	smtpMailer "github.com/haguru/sasuke/internal/mailer/smtp"
 	"github.com/haguru/sasuke/internal/middleware"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/passwordhash"
+	"github.com/haguru/sasuke/internal/passwordpolicy"
+	"github.com/haguru/sasuke/internal/policy"
+	memoryRevocationStore "github.com/haguru/sasuke/internal/revocationstore/memory"
+	mongoRevocationStore "github.com/haguru/sasuke/internal/revocationstore/mongo"
+	postgresRevocationStore "github.com/haguru/sasuke/internal/revocationstore/postgres"
 	"github.com/haguru/sasuke/internal/routes"
 	"github.com/haguru/sasuke/internal/server"
 	mongoUserRepo "github.com/haguru/sasuke/internal/userrepo/mongo"
 	postgresUserRepo "github.com/haguru/sasuke/internal/userrepo/postgres"
 	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/haguru/sasuke/internal/webauthn"
 	"github.com/haguru/sasuke/pkg/databases/mongo"
 	"github.com/haguru/sasuke/pkg/databases/postgres"
 	"github.com/haguru/sasuke/pkg/metrics"
@@ -28,9 +50,9 @@ import (
 // App represents the main application, containing server and configuration.
 // It initializes with a config file, validates settings, and manages routes.
 type App struct {
-	Server     interfaces.Server
-	Config     *config.ServiceConfig
-	privateKey *ecdsa.PrivateKey
+	Server  interfaces.Server
+	Config  *config.ServiceConfig
+	keyring *auth.Keyring
 }
 
 // NewApp creates and configures a new App instance.
@@ -58,8 +80,8 @@ func NewApp(configPath string) (*App, error) {
 
 	metricsInstance := app.initializeMetrics()
 
-	if err := app.initializePrivateKey(); err != nil {
-		return nil, fmt.Errorf("failed to initialize private key: %v", err)
+	if err := app.initializeKeyring(); err != nil {
+		return nil, fmt.Errorf("failed to initialize keyring: %v", err)
 	}
 
 	dbClient, err := app.initializeDBClient()
@@ -72,9 +94,110 @@ func NewApp(configPath string) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize user repository: %v", err)
 	}
 
+	revocations, err := app.initializeRevocationStore(dbClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize revocation store: %v", err)
+	}
+
+	clientRepo, err := app.initializeClientRepo(dbClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize client repository: %v", err)
+	}
+
+	credentialRepo, err := app.initializeCredentialRepo(dbClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize credential repository: %v", err)
+	}
+
+	apiKeyRepo, err := app.initializeAPIKeyRepo(dbClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize api key repository: %v", err)
+	}
+
 	userService := userservice.NewUserService(userRepo)
+	userService.RefreshTokenTTL = cfg.RefreshToken.TTL
+	userService.TOTPIssuer = cfg.MFA.Issuer
+	if cfg.MFA.EncryptionKeyPath != "" {
+		key, err := auth.LoadSecretKey(cfg.MFA.EncryptionKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mfa encryption key: %v", err)
+		}
+		userService.SecretCipher, err = auth.NewSecretCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize mfa encryption: %v", err)
+		}
+	}
 
-	route := routes.NewRoute(metricsInstance, userService, app.privateKey, validator)
+	if cfg.WebAuthn.RPID != "" {
+		userService.Credentials = credentialRepo
+		userService.RelyingParty = &webauthn.RelyingParty{
+			ID:                      cfg.WebAuthn.RPID,
+			Name:                    cfg.WebAuthn.RPName,
+			Origins:                 cfg.WebAuthn.Origins,
+			RequireUserVerification: cfg.WebAuthn.RequireUserVerification,
+		}
+	}
+
+	mailer, err := app.initializeMailer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %v", err)
+	}
+	userService.Mailer = mailer
+	userService.PasswordResetURL = cfg.PasswordReset.URL
+	userService.PasswordResetTTL = cfg.PasswordReset.TTL
+	userService.EmailVerificationURL = cfg.EmailVerification.URL
+	userService.MagicLinkURL = cfg.MagicLink.URL
+	userService.Lockout = lockoutPolicy(cfg.Lockout)
+	userService.PasswordPolicy, err = passwordPolicy(cfg.PasswordPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize password policy: %v", err)
+	}
+	userService.PasswordHasher, err = passwordHasher(cfg.PasswordHashing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize password hasher: %v", err)
+	}
+	userService.Peppers, err = passwordPeppers(cfg.PasswordHashing.Pepper)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load password peppers: %v", err)
+	}
+	userService.Roles, err = roleSet(cfg.RBAC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize roles: %v", err)
+	}
+	userService.DefaultRoles = cfg.RBAC.DefaultRoles
+	userService.UserScopes = cfg.OAuth.UserScopes
+	userService.APIKeys = apiKeyRepo
+	userService.APIKeyTTL = cfg.APIKeys.DefaultTTL
+	userService.APIKeyMaxTTL = cfg.APIKeys.MaxTTL
+
+	oauthService := oauthservice.NewOAuthService(clientRepo)
+	oauthService.AuthorizationCodeTTL = cfg.OAuth.AuthorizationCodeTTL
+	oauthService.UsedAssertions = revocations
+	oauthService.Leeway = cfg.Token.Leeway
+
+	tokenConfig := auth.TokenConfig{
+		Lifetime:     cfg.Token.Lifetime,
+		Issuer:       cfg.Token.Issuer,
+		Subject:      cfg.Token.Subject,
+		Audience:     cfg.Token.Audience,
+		Leeway:       cfg.Token.Leeway,
+		RSAAlgorithm: cfg.Token.RSAAlgorithm,
+	}
+
+	route := routes.NewRoute(metricsInstance, userService, app.keyring, revocations, validator)
+	route.TokenConfig = tokenConfig
+	route.Cookie = cfg.Cookie
+	route.OAuthService = oauthService
+	route.MFAChallengeTTL = cfg.MFA.ChallengeTTL
+	route.WebAuthnCeremonyTTL = cfg.WebAuthn.Timeout
+	route.WebAuthnAttestation = cfg.WebAuthn.Attestation
+	route.RequireVerifiedEmail = cfg.EmailVerification.Required
+	route.EmailVerificationTTL = cfg.EmailVerification.TTL
+	route.MagicLinkTTL = cfg.MagicLink.TTL
+	route.Policies, err = policyEngine(cfg.Authorization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load authorization policies: %v", err)
+	}
 
 	metricsHandler := promhttp.HandlerFor(
 		metricsInstance.GetRegistry(),
@@ -87,18 +210,76 @@ func NewApp(configPath string) (*App, error) {
 		return nil, fmt.Errorf("failed to add metrics route: %v", err)
 	}
 
-	err = app.Server.AddRoute(routes.CreateRouteAPI, route.Create)
+	// Routes wrapped by authMiddleware require a valid session token.
+	authMiddleware := middleware.AuthMiddleware(app.keyring, tokenConfig, revocations)
+	// Routes wrapped by apiAuthMiddleware also accept personal API keys.
+	apiAuthMiddleware := middleware.APIKeyAuthMiddleware(app.keyring, tokenConfig, revocations, userService)
+	// Routes wrapped by accountMiddleware manage the credentials of the user and
+	// require the account scope, which only session tokens carry.
+	accountMiddleware := func(next http.Handler) http.Handler {
+		return authMiddleware(middleware.RequireScope(auth.ScopeAccount)(next))
+	}
+	// Routes wrapped by permissionMiddleware require permission both as a grant
+	// of the user and as a scope of the token, so API keys and tokens delegated
+	// to clients only act within the scope granted to them.
+	permissionMiddleware := func(permission string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return apiAuthMiddleware(middleware.RequireScope(permission)(middleware.RequirePermission(permission)(next)))
+		}
+	}
+	createHandler := permissionMiddleware(auth.PermissionCreate)(http.HandlerFunc(route.Create))
+
+	err = app.Server.AddRoute(routes.CreateRouteAPI, createHandler.ServeHTTP)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add create route: %v", err)
 	}
 	fmt.Println("Create route added successfully")
 
+	rolesHandler := permissionMiddleware(auth.PermissionRolesRead)(http.HandlerFunc(route.UserRoles))
+	err = app.Server.AddRoute(routes.RolesRouteAPI, rolesHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add roles route: %v", err)
+	}
+	fmt.Println("Roles route added successfully")
+
+	assignRoleHandler := permissionMiddleware(auth.PermissionRolesAssign)(http.HandlerFunc(route.AssignRole))
+	err = app.Server.AddRoute(routes.AssignRoleRouteAPI, assignRoleHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add assign role route: %v", err)
+	}
+	fmt.Println("Assign role route added successfully")
+
+	revokeRoleHandler := permissionMiddleware(auth.PermissionRolesAssign)(http.HandlerFunc(route.RevokeRole))
+	err = app.Server.AddRoute(routes.RevokeRoleRouteAPI, revokeRoleHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add revoke role route: %v", err)
+	}
+	fmt.Println("Revoke role route added successfully")
+
 	err = app.Server.AddRoute(routes.SignupRouteAPI, route.Signup)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add signup route: %v", err)
 	}
 	fmt.Println("Signup route added successfully")
 
+	err = app.Server.AddRoute(routes.RefreshRouteAPI, route.Refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add refresh route: %v", err)
+	}
+	fmt.Println("Refresh route added successfully")
+
+	err = app.Server.AddRoute(routes.LogoutRouteAPI, route.Logout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add logout route: %v", err)
+	}
+	fmt.Println("Logout route added successfully")
+
+	err = app.Server.AddRoute(routes.JWKSRouteAPI, route.JWKS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add jwks route: %v", err)
+	}
+	fmt.Println("JWKS route added successfully")
+
 	loginLimiter := rate.NewLimiter(rate.Every(cfg.RateLimiter.Interval), cfg.RateLimiter.Limit)
 
 	// Wrap the login handler with rate limiting middleware.
@@ -111,10 +292,194 @@ func NewApp(configPath string) (*App, error) {
 	}
 	fmt.Println("Login route added successfully")
 
+	// the second factor step shares the login rate limit so codes cannot be guessed
+	loginMFAHandler := rateLimiter(http.HandlerFunc(route.LoginMFA))
+	err = app.Server.AddRoute(routes.LoginMFARouteAPI, loginMFAHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add login mfa route: %v", err)
+	}
+	fmt.Println("Login MFA route added successfully")
+
+	totpEnrollHandler := accountMiddleware(http.HandlerFunc(route.EnrollTOTP))
+	err = app.Server.AddRoute(routes.TOTPEnrollRouteAPI, totpEnrollHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add totp enroll route: %v", err)
+	}
+	fmt.Println("TOTP enroll route added successfully")
+
+	totpConfirmHandler := accountMiddleware(http.HandlerFunc(route.ConfirmTOTP))
+	err = app.Server.AddRoute(routes.TOTPConfirmRouteAPI, totpConfirmHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add totp confirm route: %v", err)
+	}
+	fmt.Println("TOTP confirm route added successfully")
+
+	totpDisableHandler := accountMiddleware(http.HandlerFunc(route.DisableTOTP))
+	err = app.Server.AddRoute(routes.TOTPDisableRouteAPI, totpDisableHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add totp disable route: %v", err)
+	}
+	fmt.Println("TOTP disable route added successfully")
+
+	recoveryCodesHandler := accountMiddleware(http.HandlerFunc(route.RecoveryCodes))
+	err = app.Server.AddRoute(routes.RecoveryCodesRouteAPI, recoveryCodesHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add recovery codes route: %v", err)
+	}
+	fmt.Println("Recovery codes route added successfully")
+
+	passkeyRegisterBeginHandler := accountMiddleware(http.HandlerFunc(route.BeginPasskeyRegistration))
+	err = app.Server.AddRoute(routes.PasskeyRegisterBeginRouteAPI, passkeyRegisterBeginHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add passkey register begin route: %v", err)
+	}
+	fmt.Println("Passkey register begin route added successfully")
+
+	passkeyRegisterFinishHandler := accountMiddleware(http.HandlerFunc(route.FinishPasskeyRegistration))
+	err = app.Server.AddRoute(routes.PasskeyRegisterFinishRouteAPI, passkeyRegisterFinishHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add passkey register finish route: %v", err)
+	}
+	fmt.Println("Passkey register finish route added successfully")
+
+	err = app.Server.AddRoute(routes.PasskeyLoginBeginRouteAPI, route.BeginPasskeyLogin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add passkey login begin route: %v", err)
+	}
+	fmt.Println("Passkey login begin route added successfully")
+
+	passkeyLoginHandler := rateLimiter(http.HandlerFunc(route.FinishPasskeyLogin))
+	err = app.Server.AddRoute(routes.PasskeyLoginFinishRouteAPI, passkeyLoginHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add passkey login finish route: %v", err)
+	}
+	fmt.Println("Passkey login finish route added successfully")
+
+	forgotPasswordHandler := rateLimiter(http.HandlerFunc(route.ForgotPassword))
+	err = app.Server.AddRoute(routes.ForgotPasswordRouteAPI, forgotPasswordHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add forgot password route: %v", err)
+	}
+	fmt.Println("Forgot password route added successfully")
+
+	resetPasswordHandler := rateLimiter(http.HandlerFunc(route.ResetPassword))
+	err = app.Server.AddRoute(routes.ResetPasswordRouteAPI, resetPasswordHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add reset password route: %v", err)
+	}
+	fmt.Println("Reset password route added successfully")
+
+	err = app.Server.AddRoute(routes.VerifyEmailRouteAPI, route.VerifyEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add verify email route: %v", err)
+	}
+	fmt.Println("Verify email route added successfully")
+
+	resendVerificationHandler := rateLimiter(http.HandlerFunc(route.ResendEmailVerification))
+	err = app.Server.AddRoute(routes.ResendVerificationRouteAPI, resendVerificationHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add resend verification route: %v", err)
+	}
+	fmt.Println("Resend verification route added successfully")
+
+	magicLinkHandler := rateLimiter(http.HandlerFunc(route.RequestMagicLink))
+	err = app.Server.AddRoute(routes.MagicLinkRouteAPI, magicLinkHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add magic link route: %v", err)
+	}
+	fmt.Println("Magic link route added successfully")
+
+	err = app.Server.AddRoute(routes.MagicLinkCallbackRouteAPI, route.MagicLinkCallback)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add magic link callback route: %v", err)
+	}
+	fmt.Println("Magic link callback route added successfully")
+
+	// Only the credential step of the authorization endpoint is rate limited,
+	// so clients with a session can still be redirected freely.
+	limitedAuthorize := rateLimiter(http.HandlerFunc(route.Authorize))
+	authorizeHandler := func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			limitedAuthorize.ServeHTTP(w, req)
+			return
+		}
+		route.Authorize(w, req)
+	}
+
+	err = app.Server.AddRoute(routes.AuthorizeRouteAPI, authorizeHandler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add authorize route: %v", err)
+	}
+	fmt.Println("Authorize route added successfully")
+
+	err = app.Server.AddRoute(routes.TokenRouteAPI, route.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add token route: %v", err)
+	}
+	fmt.Println("Token route added successfully")
+
+	err = app.Server.AddRoute(routes.IntrospectRouteAPI, route.Introspect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add introspect route: %v", err)
+	}
+	fmt.Println("Introspect route added successfully")
+
+	err = app.Server.AddRoute(routes.RevokeRouteAPI, route.Revoke)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add revoke route: %v", err)
+	}
+	fmt.Println("Revoke route added successfully")
+
+	err = app.Server.AddRoute(routes.OpenIDConfigurationRouteAPI, route.OpenIDConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add openid configuration route: %v", err)
+	}
+	fmt.Println("OpenID configuration route added successfully")
+
+	// OpenID Connect only releases claims to tokens with the openid scope
+	userInfoHandler := apiAuthMiddleware(middleware.RequireScope(oauthservice.ScopeOpenID)(http.HandlerFunc(route.UserInfo)))
+	err = app.Server.AddRoute(routes.UserInfoRouteAPI, userInfoHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add userinfo route: %v", err)
+	}
+	fmt.Println("UserInfo route added successfully")
+
+	authorizeCheckHandler := apiAuthMiddleware(middleware.RequireScope(auth.ScopeAuthorize)(http.HandlerFunc(route.AuthorizeCheck)))
+	err = app.Server.AddRoute(routes.AuthorizeCheckRouteAPI, authorizeCheckHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add authorize check route: %v", err)
+	}
+	fmt.Println("Authorize check route added successfully")
+
+	apiKeysHandler := accountMiddleware(http.HandlerFunc(route.APIKeys))
+	err = app.Server.AddRoute(routes.APIKeysRouteAPI, apiKeysHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add api keys route: %v", err)
+	}
+	fmt.Println("API keys route added successfully")
+
+	revokeAPIKeyHandler := accountMiddleware(http.HandlerFunc(route.RevokeAPIKey))
+	err = app.Server.AddRoute(routes.RevokeAPIKeyRouteAPI, revokeAPIKeyHandler.ServeHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add revoke api key route: %v", err)
+	}
+	fmt.Println("Revoke API key route added successfully")
+
 	return app, nil
 }
 
 func (app *App) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// rotate the signing key on a schedule if configured
+	if app.Config.KeyRing.RotationInterval > 0 {
+		app.keyring.StartRotation(ctx, app.Config.KeyRing.RotationInterval)
+	}
+
+	// pick up keys rotated by the rotate-keys admin command on SIGHUP
+	app.reloadKeyringOnSignal(ctx)
+
 	// start the server
 	if err := app.Server.ListenAndServe(); err != nil {
 		return fmt.Errorf("failed to start server: %v", err)
@@ -141,6 +506,70 @@ func (app *App) initializeMetrics() interfaces.Metrics {
 		routes.LoginDurationSecondsHelp,
 		routes.LoginDurationSecondsBuckets)
 
+	appMetrics.RegisterCounter(routes.RefreshRequestsTotal, routes.RefreshRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.RefreshSuccessTotal, routes.RefreshSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.RefreshFailedTotal, routes.RefreshFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.RefreshReuseTotal, routes.RefreshReuseTotalHelp)
+
+	appMetrics.RegisterCounter(routes.LogoutRequestsTotal, routes.LogoutRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.LogoutSuccessTotal, routes.LogoutSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.LogoutFailedTotal, routes.LogoutFailedTotalHelp)
+
+	appMetrics.RegisterCounter(routes.AuthorizeRequestsTotal, routes.AuthorizeRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeCodesIssuedTotal, routes.AuthorizeCodesIssuedTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeFailedTotal, routes.AuthorizeFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.TokenRequestsTotal, routes.TokenRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.TokenSuccessTotal, routes.TokenSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.TokenFailedTotal, routes.TokenFailedTotalHelp)
+
+	appMetrics.RegisterCounter(routes.UserInfoRequestsTotal, routes.UserInfoRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.UserInfoFailedTotal, routes.UserInfoFailedTotalHelp)
+
+	appMetrics.RegisterCounter(routes.IntrospectRequestsTotal, routes.IntrospectRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.IntrospectFailedTotal, routes.IntrospectFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.RevokeRequestsTotal, routes.RevokeRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.RevokeFailedTotal, routes.RevokeFailedTotalHelp)
+
+	appMetrics.RegisterCounter(routes.LoginMFAChallengesTotal, routes.LoginMFAChallengesTotalHelp)
+	appMetrics.RegisterCounter(routes.LoginMFARequestsTotal, routes.LoginMFARequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.LoginMFASuccessTotal, routes.LoginMFASuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.LoginMFAFailedTotal, routes.LoginMFAFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.MFAEnrollRequestsTotal, routes.MFAEnrollRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.MFAFailedTotal, routes.MFAFailedTotalHelp)
+
+	appMetrics.RegisterCounter(routes.PasskeyRegisterRequestsTotal, routes.PasskeyRegisterRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.PasskeyRegisterSuccessTotal, routes.PasskeyRegisterSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.PasskeyRegisterFailedTotal, routes.PasskeyRegisterFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.PasskeyLoginRequestsTotal, routes.PasskeyLoginRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.PasskeyLoginSuccessTotal, routes.PasskeyLoginSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.PasskeyLoginFailedTotal, routes.PasskeyLoginFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.PasswordResetRequestsTotal, routes.PasswordResetRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.PasswordResetSuccessTotal, routes.PasswordResetSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.PasswordResetFailedTotal, routes.PasswordResetFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.EmailVerificationRequestsTotal, routes.EmailVerificationRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.EmailVerificationSentTotal, routes.EmailVerificationSentTotalHelp)
+	appMetrics.RegisterCounter(routes.EmailVerificationSuccessTotal, routes.EmailVerificationSuccessTotalHelp)
+	appMetrics.RegisterCounter(routes.EmailVerificationFailedTotal, routes.EmailVerificationFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.MagicLinkRequestsTotal, routes.MagicLinkRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.MagicLinkSentTotal, routes.MagicLinkSentTotalHelp)
+	appMetrics.RegisterCounter(routes.MagicLinkLoginRequestsTotal, routes.MagicLinkLoginRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.MagicLinkFailedTotal, routes.MagicLinkFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.AccountLockoutsTotal, routes.AccountLockoutsTotalHelp)
+	appMetrics.RegisterCounter(routes.LoginLockedTotal, routes.LoginLockedTotalHelp)
+	appMetrics.RegisterCounter(routes.PasswordPolicyRejectedTotal, routes.PasswordPolicyRejectedTotalHelp)
+	appMetrics.RegisterCounter(routes.RoleRequestsTotal, routes.RoleRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.RoleAssignmentsTotal, routes.RoleAssignmentsTotalHelp)
+	appMetrics.RegisterCounter(routes.RoleRevocationsTotal, routes.RoleRevocationsTotalHelp)
+	appMetrics.RegisterCounter(routes.RoleFailedTotal, routes.RoleFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeCheckRequestsTotal, routes.AuthorizeCheckRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeCheckAllowedTotal, routes.AuthorizeCheckAllowedTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeCheckDeniedTotal, routes.AuthorizeCheckDeniedTotalHelp)
+	appMetrics.RegisterCounter(routes.AuthorizeCheckFailedTotal, routes.AuthorizeCheckFailedTotalHelp)
+	appMetrics.RegisterCounter(routes.APIKeyRequestsTotal, routes.APIKeyRequestsTotalHelp)
+	appMetrics.RegisterCounter(routes.APIKeyCreatedTotal, routes.APIKeyCreatedTotalHelp)
+	appMetrics.RegisterCounter(routes.APIKeyRevokedTotal, routes.APIKeyRevokedTotalHelp)
+	appMetrics.RegisterCounter(routes.APIKeyFailedTotal, routes.APIKeyFailedTotalHelp)
+
 	return appMetrics
 }
 
@@ -203,16 +632,504 @@ func (app *App) initializeUserRepo(dbClient interfaces.DBClient) (interfaces.Use
 	return userRepo, nil
 }
 
-func (app *App) initializePrivateKey() error {
-	if app.Config.PrivateKeyPath == "" {
-		return fmt.Errorf("private key path is not provided in the configuration")
+func (app *App) initializeClientRepo(dbClient interfaces.DBClient) (interfaces.ClientRepository, error) {
+	var clientRepo interfaces.ClientRepository
+	var err error
+
+	switch app.Config.Database.Type {
+	case "mongo":
+		clientRepo, err = mongoClientRepo.NewMongoClientRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MongoDB client repository: %v", err)
+		}
+
+	case "postgres":
+		clientRepo, err = postgresClientRepo.NewPostgresClientRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize PostgreSQL client repository: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", app.Config.Database.Type)
+	}
+
+	if err = clientRepo.EnsureIndices(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ensure indices: %v", err)
+	}
+
+	return clientRepo, nil
+}
+
+func (app *App) initializeCredentialRepo(dbClient interfaces.DBClient) (interfaces.CredentialRepository, error) {
+	var credentialRepo interfaces.CredentialRepository
+	var err error
+
+	switch app.Config.Database.Type {
+	case "mongo":
+		credentialRepo, err = mongoCredentialRepo.NewMongoCredentialRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MongoDB credential repository: %v", err)
+		}
+
+	case "postgres":
+		credentialRepo, err = postgresCredentialRepo.NewPostgresCredentialRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize PostgreSQL credential repository: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", app.Config.Database.Type)
+	}
+
+	if err = credentialRepo.EnsureIndices(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ensure indices: %v", err)
+	}
+
+	return credentialRepo, nil
+}
+
+func (app *App) initializeAPIKeyRepo(dbClient interfaces.DBClient) (interfaces.APIKeyRepository, error) {
+	var apiKeyRepo interfaces.APIKeyRepository
+	var err error
+
+	switch app.Config.Database.Type {
+	case "mongo":
+		apiKeyRepo, err = mongoAPIKeyRepo.NewMongoAPIKeyRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MongoDB api key repository: %v", err)
+		}
+
+	case "postgres":
+		apiKeyRepo, err = postgresAPIKeyRepo.NewPostgresAPIKeyRepository(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize PostgreSQL api key repository: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", app.Config.Database.Type)
+	}
+
+	if err = apiKeyRepo.EnsureIndices(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ensure indices: %v", err)
+	}
+
+	return apiKeyRepo, nil
+}
+
+func (app *App) initializeRevocationStore(dbClient interfaces.DBClient) (interfaces.RevocationStore, error) {
+	if app.Config.Revocation.Store != "database" {
+		return memoryRevocationStore.NewMemoryRevocationStore(), nil
 	}
 
-	privateKey, err := auth.LoadECDSAPrivateKey(app.Config.PrivateKeyPath)
+	var revocations interfaces.RevocationStore
+	var err error
+
+	switch app.Config.Database.Type {
+	case "mongo":
+		revocations, err = mongoRevocationStore.NewMongoRevocationStore(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MongoDB revocation store: %v", err)
+		}
+
+	case "postgres":
+		revocations, err = postgresRevocationStore.NewPostgresRevocationStore(dbClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize PostgreSQL revocation store: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", app.Config.Database.Type)
+	}
+
+	if err = revocations.EnsureIndices(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ensure indices: %v", err)
+	}
+
+	return revocations, nil
+}
+
+// initializeMailer returns the configured mailer, or nil when emails are disabled.
+func (app *App) initializeMailer() (interfaces.Mailer, error) {
+	mailCfg := app.Config.Mail
+
+	switch mailCfg.Type {
+	case "":
+		return nil, nil
+	case "smtp":
+		return smtpMailer.NewSMTPMailer(mailCfg.SMTP.Host, mailCfg.SMTP.Port, mailCfg.SMTP.Username, mailCfg.SMTP.Password, mailCfg.From)
+	case "file":
+		return fileMailer.NewFileMailer(mailCfg.FilePath, mailCfg.From)
+	case "memory":
+		return memoryMailer.NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail type: %s", mailCfg.Type)
+	}
+}
+
+func (app *App) initializeKeyring() error {
+	keyring, err := LoadKeyring(app.Config)
 	if err != nil {
-		return fmt.Errorf("failed to load private key: %v", err)
+		return err
 	}
 
-	app.privateKey = privateKey
+	app.keyring = keyring
+	fmt.Printf("Keyring loaded, active kid %s\n", keyring.ActiveKeyID())
 	return nil
 }
+
+func (app *App) reloadKeyringOnSignal(ctx context.Context) {
+	if app.Config.KeyRing.Dir == "" {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := app.keyring.Reload(); err != nil {
+					fmt.Printf("Failed to reload keyring: %v\n", err)
+					continue
+				}
+				fmt.Printf("Keyring reloaded, active kid %s\n", app.keyring.ActiveKeyID())
+			}
+		}
+	}()
+}
+
+// LoadKeyring builds the signing keyring described by the configuration.
+// A key directory enables rotation; otherwise the single key at PrivateKeyPath is used.
+func LoadKeyring(cfg *config.ServiceConfig) (*auth.Keyring, error) {
+	if cfg.KeyRing.Dir != "" {
+		keyring, err := auth.LoadKeyring(cfg.KeyRing.Dir, cfg.KeyRing.MaxRetired)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keyring: %v", err)
+		}
+		keyring.SetRetention(keyRetention(cfg))
+		return keyring, nil
+	}
+
+	if cfg.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("private key path is not provided in the configuration")
+	}
+
+	privateKey, err := auth.LoadPrivateKey(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key: %v", err)
+	}
+
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	keyring.SetRetention(keyRetention(cfg))
+	return keyring, nil
+}
+
+// keyRetention returns how long retired signing keys are kept: the lifetime
+// of the longest lived token signed with them plus the leeway.
+func keyRetention(cfg *config.ServiceConfig) time.Duration {
+	lifetime := func(ttl, fallback time.Duration) time.Duration {
+		if ttl > 0 {
+			return ttl
+		}
+		return fallback
+	}
+	return max(
+		lifetime(cfg.Token.Lifetime, auth.DefaultTokenLifetime),
+		lifetime(cfg.MFA.ChallengeTTL, auth.DefaultMFAChallengeTTL),
+		lifetime(cfg.WebAuthn.Timeout, auth.DefaultWebAuthnCeremonyTTL),
+		lifetime(cfg.EmailVerification.TTL, auth.DefaultEmailVerificationTTL),
+		lifetime(cfg.MagicLink.TTL, auth.DefaultMagicLinkTTL),
+	) + cfg.Token.Leeway
+}
+
+// RotateKeys is the rotate-keys admin command. It generates a new active key in
+// the configured keyring directory; running servers pick it up on SIGHUP.
+func RotateKeys(configPath string) error {
+	cfg, err := config.ReadLocalConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if cfg.KeyRing.Dir == "" {
+		return fmt.Errorf("key rotation requires key_ring.dir in the configuration")
+	}
+	if cfg.KeyRing.MaxRetired < 1 {
+		return fmt.Errorf("key rotation requires key_ring.max_retired of at least 1")
+	}
+
+	keyring, err := LoadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Rotate(); err != nil {
+		return fmt.Errorf("failed to rotate keys: %v", err)
+	}
+
+	fmt.Printf("Signing key rotated, active kid %s\n", keyring.ActiveKeyID())
+	return nil
+}
+
+// RegisterClient is the register-client admin command. It registers an OAuth
+// client and prints its credentials; the secret of a confidential client is
+// only shown once.
+func RegisterClient(configPath string, args []string) error {
+	flags := flag.NewFlagSet("register-client", flag.ContinueOnError)
+	name := flags.String("name", "", "client name shown on the sign-in page")
+	redirectURIs := flags.String("redirect-uris", "", "comma separated list of allowed redirect URIs")
+	scopes := flags.String("scopes", "", "comma separated list of scopes the client may request")
+	confidential := flags.Bool("confidential", false, "issue a client secret for a server-side client")
+	grantTypes := flags.String("grant-types", "", "comma separated list of grant types (authorization_code, client_credentials)")
+	publicKeyPath := flags.String("public-key", "", "PEM public key the client signs private_key_jwt assertions with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	publicKey := ""
+	if *publicKeyPath != "" {
+		keyData, err := os.ReadFile(*publicKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+		publicKey = string(keyData)
+	}
+
+	cfg, err := config.ReadLocalConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	app := &App{Config: cfg}
+	dbClient, err := app.initializeDBClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database client: %v", err)
+	}
+	defer func() {
+		_ = dbClient.Disconnect(context.Background())
+	}()
+
+	clientRepo, err := app.initializeClientRepo(dbClient)
+	if err != nil {
+		return fmt.Errorf("failed to initialize client repository: %v", err)
+	}
+
+	oauthService := oauthservice.NewOAuthService(clientRepo)
+	client, secret, err := oauthService.RegisterClient(context.Background(), oauthservice.ClientRegistration{
+		Name:         *name,
+		RedirectURIs: splitList(*redirectURIs),
+		Scopes:       splitList(*scopes),
+		GrantTypes:   splitList(*grantTypes),
+		Confidential: *confidential,
+		PublicKey:    publicKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Client registered\nclient_id: %s\n", client.ClientID)
+	if secret != "" {
+		fmt.Printf("client_secret: %s\n", secret)
+	}
+	return nil
+}
+
+// UnlockUser is the unlock-user admin command. It clears the failed logins
+// and any lockout of a user.
+func UnlockUser(configPath string, args []string) error {
+	flags := flag.NewFlagSet("unlock-user", flag.ContinueOnError)
+	username := flags.String("username", "", "user to unlock")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username is required")
+	}
+
+	cfg, err := config.ReadLocalConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	app := &App{Config: cfg}
+	dbClient, err := app.initializeDBClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database client: %v", err)
+	}
+	defer func() {
+		_ = dbClient.Disconnect(context.Background())
+	}()
+
+	userRepo, err := app.initializeUserRepo(dbClient)
+	if err != nil {
+		return fmt.Errorf("failed to initialize user repository: %v", err)
+	}
+
+	if err := userservice.NewUserService(userRepo).UnlockUser(context.Background(), *username); err != nil {
+		return err
+	}
+
+	fmt.Printf("User %s unlocked\n", *username)
+	return nil
+}
+
+// AssignRole is the admin command that assigns a configured role to a user,
+// which bootstraps the first administrator.
+func AssignRole(configPath string, args []string) error {
+	flags := flag.NewFlagSet("assign-role", flag.ContinueOnError)
+	username := flags.String("username", "", "user to assign the role to")
+	role := flags.String("role", "", "role to assign")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *role == "" {
+		return fmt.Errorf("-username and -role are required")
+	}
+
+	cfg, err := config.ReadLocalConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	app := &App{Config: cfg}
+	dbClient, err := app.initializeDBClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database client: %v", err)
+	}
+	defer func() {
+		_ = dbClient.Disconnect(context.Background())
+	}()
+
+	userRepo, err := app.initializeUserRepo(dbClient)
+	if err != nil {
+		return fmt.Errorf("failed to initialize user repository: %v", err)
+	}
+
+	userService := userservice.NewUserService(userRepo)
+	userService.Roles, err = roleSet(cfg.RBAC)
+	if err != nil {
+		return err
+	}
+	if err := userService.AssignRole(context.Background(), *username, *role); err != nil {
+		return err
+	}
+
+	fmt.Printf("Role %s assigned to user %s\n", *role, *username)
+	return nil
+}
+
+// lockoutPolicy converts the lockout configuration.
+func lockoutPolicy(cfg config.LockoutConfig) userservice.LockoutPolicy {
+	return userservice.LockoutPolicy{
+		FreeAttempts: cfg.FreeAttempts,
+		BaseDelay:    cfg.BaseDelay,
+		MaxDelay:     cfg.MaxDelay,
+		MaxAttempts:  cfg.MaxAttempts,
+		Duration:     cfg.Duration,
+	}
+}
+
+// passwordPolicy converts the password policy configuration, opening the
+// breached password list when one is configured.
+func passwordPolicy(cfg config.PasswordPolicyConfig) (*passwordpolicy.Policy, error) {
+	policy := passwordpolicy.Default()
+	if cfg.MinLength > 0 {
+		policy.MinLength = cfg.MinLength
+	}
+	if cfg.MaxLength > 0 {
+		policy.MaxLength = cfg.MaxLength
+	}
+	policy.RequireLowercase = cfg.RequireLowercase
+	policy.RequireUppercase = cfg.RequireUppercase
+	policy.RequireDigit = cfg.RequireDigit
+	policy.RequireSymbol = cfg.RequireSymbol
+	policy.DisallowUsername = cfg.DisallowUsername
+	policy.MinStrength = cfg.MinStrength
+
+	if cfg.BreachedPasswordsPath != "" {
+		breaches, err := passwordpolicy.OpenBreachList(cfg.BreachedPasswordsPath)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breaches = breaches
+	}
+	return policy, nil
+}
+
+// passwordHasher creates the hasher of new passwords selected by the
+// configuration.
+func passwordHasher(cfg config.PasswordHashingConfig) (interfaces.PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case "", passwordhash.AlgorithmBcrypt:
+		return passwordhash.NewBcryptHasher(cfg.Bcrypt.Cost)
+	case passwordhash.AlgorithmArgon2id:
+		return passwordhash.NewArgon2idHasher(passwordhash.Argon2idParams{
+			Memory:      cfg.Argon2id.Memory,
+			Iterations:  cfg.Argon2id.Iterations,
+			Parallelism: cfg.Argon2id.Parallelism,
+			SaltLength:  cfg.Argon2id.SaltLength,
+			KeyLength:   cfg.Argon2id.KeyLength,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm: %s", cfg.Algorithm)
+	}
+}
+
+// roleSet converts the role configuration, checking that the default roles exist.
+func roleSet(cfg config.RBACConfig) (auth.RoleSet, error) {
+	roles := auth.RoleSet(cfg.Roles)
+	for _, role := range cfg.DefaultRoles {
+		if _, ok := roles[role]; !ok {
+			return nil, fmt.Errorf("default role %q is not defined", role)
+		}
+	}
+	return roles, nil
+}
+
+// policyEngine loads the authorization policies, none when no file is configured.
+func policyEngine(cfg config.AuthorizationConfig) (*policy.Engine, error) {
+	if cfg.PolicyPath == "" {
+		return policy.NewEngine(nil)
+	}
+	engine, err := policy.LoadEngine(cfg.PolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Loaded %d authorization policies\n", engine.Policies())
+	return engine, nil
+}
+
+// passwordPeppers loads the configured peppers, nil if none is configured.
+func passwordPeppers(cfg config.PepperConfig) (*passwordhash.Peppers, error) {
+	if cfg.Current == "" {
+		return nil, nil
+	}
+	keys := make(map[string][]byte, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		if _, ok := keys[keyCfg.ID]; ok {
+			return nil, fmt.Errorf("duplicate pepper id %s", keyCfg.ID)
+		}
+		key, err := passwordhash.LoadPepper(keyCfg.File, keyCfg.Env)
+		if err != nil {
+			return nil, fmt.Errorf("pepper %s: %w", keyCfg.ID, err)
+		}
+		keys[keyCfg.ID] = key
+	}
+	return passwordhash.NewPeppers(cfg.Current, keys)
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+)
+
+const (
+	// AlgorithmES256 is ECDSA using P-256 and SHA-256.
+	AlgorithmES256 = "ES256"
+	// AlgorithmES384 is ECDSA using P-384 and SHA-384.
+	AlgorithmES384 = "ES384"
+	// AlgorithmES512 is ECDSA using P-521 and SHA-512.
+	AlgorithmES512 = "ES512"
+	// AlgorithmRS256 is RSASSA-PKCS1-v1_5 using SHA-256.
+	AlgorithmRS256 = "RS256"
+	// AlgorithmPS256 is RSASSA-PSS using SHA-256.
+	AlgorithmPS256 = "PS256"
+	// AlgorithmEdDSA is EdDSA using Ed25519.
+	AlgorithmEdDSA = "EdDSA"
+
+	// MinRSAKeyBits is the smallest accepted RSA modulus.
+	MinRSAKeyBits = 2048
+)
+
+// SupportedAlgorithms lists every JWS algorithm accepted when verifying tokens.
+var SupportedAlgorithms = []string{
+	AlgorithmES256, AlgorithmES384, AlgorithmES512,
+	AlgorithmRS256, AlgorithmPS256,
+	AlgorithmEdDSA,
+}
+
+// AlgorithmForKey returns the JWS algorithm used to sign with the key type of
+// publicKey. RSA keys default to RS256.
+func AlgorithmForKey(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return AlgorithmES256, nil
+		case elliptic.P384():
+			return AlgorithmES384, nil
+		case elliptic.P521():
+			return AlgorithmES512, nil
+		default:
+			return "", fmt.Errorf("unsupported curve: %s", key.Curve.Params().Name)
+		}
+	case *rsa.PublicKey:
+		return AlgorithmRS256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+}
+
+// signingAlgorithm returns the algorithm used to sign with publicKey, honouring
+// the configured RSA algorithm for RSA keys.
+func signingAlgorithm(publicKey crypto.PublicKey, rsaAlgorithm string) (string, error) {
+	alg, err := AlgorithmForKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	if alg == AlgorithmRS256 {
+		switch rsaAlgorithm {
+		case "", AlgorithmRS256:
+		case AlgorithmPS256:
+			alg = AlgorithmPS256
+		default:
+			return "", fmt.Errorf("unsupported RSA signing algorithm: %s", rsaAlgorithm)
+		}
+	}
+	return alg, nil
+}
+
+// keyAcceptsAlgorithm reports whether a token signed with alg may be verified
+// with publicKey. RSA keys accept both RS256 and PS256.
+func keyAcceptsAlgorithm(publicKey crypto.PublicKey, alg string) bool {
+	keyAlg, err := AlgorithmForKey(publicKey)
+	if err != nil {
+		return false
+	}
+	if keyAlg == AlgorithmRS256 {
+		return alg == AlgorithmRS256 || alg == AlgorithmPS256
+	}
+	return keyAlg == alg
+}
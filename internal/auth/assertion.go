@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// ClientAssertionType is the client_assertion_type of private_key_jwt
+	// client authentication (RFC 7523 section 2.2).
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// MaxClientAssertionLifetime bounds how long a client assertion may be valid,
+	// which bounds how long its jti has to be remembered to detect replays.
+	MaxClientAssertionLifetime = 5 * time.Minute
+)
+
+// ClientAssertionIssuer returns the unverified iss claim of a client assertion
+// so the key of the client can be looked up before verifying it.
+func ClientAssertionIssuer(assertion string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, claims); err != nil {
+		return "", fmt.Errorf("malformed client assertion: %v", err)
+	}
+	if claims.Issuer == "" {
+		return "", fmt.Errorf("client assertion has no issuer")
+	}
+	return claims.Issuer, nil
+}
+
+// VerifyClientAssertion validates a client assertion signed with the private
+// key of clientID. Its iss and sub must be the client, its aud must contain one
+// of audiences, and it must carry a jti and expire within MaxClientAssertionLifetime.
+func VerifyClientAssertion(assertion, clientID string, publicKey crypto.PublicKey, audiences []string, leeway time.Duration) (*jwt.RegisteredClaims, error) {
+	token, err := jwt.ParseWithClaims(assertion, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if !keyAcceptsAlgorithm(publicKey, token.Method.Alg()) {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods(SupportedAlgorithms), jwt.WithIssuer(clientID), jwt.WithSubject(clientID),
+		jwt.WithExpirationRequired(), jwt.WithLeeway(leeway))
+	if err != nil {
+		return nil, fmt.Errorf("client assertion parsing error: %v", err)
+	}
+
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid client assertion or claims")
+	}
+	if !hasAudience(claims.Audience, audiences) {
+		return nil, fmt.Errorf("client assertion has invalid audience: %v", claims.Audience)
+	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("client assertion has no jti")
+	}
+	if time.Until(claims.ExpiresAt.Time) > MaxClientAssertionLifetime+leeway {
+		return nil, fmt.Errorf("client assertion expires too far in the future")
+	}
+	return claims, nil
+}
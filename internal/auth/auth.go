@@ -1,10 +1,15 @@
 package auth
 
 import (
-	"crypto/ecdsa"
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/haguru/sasuke/internal/interfaces"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -19,26 +24,176 @@ const (
 
 // var jwtSecret = []byte(SECRETKEY)
 
+// ErrTokenRevoked is returned by VerifyToken for tokens on the revocation list.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// CustomClaims are the claims of session and access tokens. Tokens issued to
+// machine clients have PrincipalType PrincipalTypeClient, carry the client in
+// ClientID and have no UserID. Tokens a user delegated to a client carry both.
 type CustomClaims struct {
-	UserID string `json:"userid"`
+	UserID        string `json:"userid,omitempty"`
+	PrincipalType string `json:"principal_type,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	// AMR lists the authentication methods used to sign in (RFC 8176).
+	AMR []string `json:"amr,omitempty"`
+	// Roles and Permissions are the grants of the user when the session
+	// token was issued.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func CreateToken(userName string, privateKey *ecdsa.PrivateKey) (string, error) {
+const (
+	// PrincipalTypeUser marks tokens issued to a user.
+	PrincipalTypeUser = "user"
+	// PrincipalTypeClient marks tokens issued to a machine client.
+	PrincipalTypeClient = "client"
+)
+
+// IsClient reports whether the token was issued to a machine client rather than a user.
+func (c *CustomClaims) IsClient() bool {
+	return c.PrincipalType == PrincipalTypeClient
+}
+
+// IsSession reports whether the token is a session the user signed in for,
+// rather than a token issued to or delegated to an OAuth client.
+func (c *CustomClaims) IsSession() bool {
+	return !c.IsClient() && c.ClientID == ""
+}
+
+// HasScope reports whether the scope claim of the token contains scope.
+func (c *CustomClaims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+const (
+	// KeyIDHeader is the JOSE header carrying the ID of the signing key.
+	KeyIDHeader = "kid"
+)
+
+// TokenConfig controls the registered claims of issued session tokens and the
+// issuer and audience accepted when verifying them. Zero fields fall back to
+// DefaultTokenLifetime, ISSUER, SUBJECT and DefaultAudience.
+type TokenConfig struct {
+	Lifetime time.Duration
+	Issuer   string
+	Subject  string
+	Audience []string
+	// Leeway tolerates clock skew when validating exp, nbf and iat.
+	Leeway time.Duration
+	// RSAAlgorithm selects RS256 (default) or PS256 when signing with an RSA key.
+	RSAAlgorithm string
+}
+
+const (
+	// DefaultTokenLifetime is the session token lifetime when none is configured.
+	DefaultTokenLifetime = 15 * time.Minute
+	// DefaultAudience is the session token audience when none is configured.
+	DefaultAudience = "api" + ISSUER
+)
+
+// withDefaults returns a copy of cfg with unset fields filled in.
+func (cfg TokenConfig) withDefaults() TokenConfig {
+	if cfg.Lifetime <= 0 {
+		cfg.Lifetime = DefaultTokenLifetime
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = ISSUER
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = SUBJECT
+	}
+	if len(cfg.Audience) == 0 {
+		cfg.Audience = []string{DefaultAudience}
+	}
+	return cfg
+}
+
+// CreateToken signs a session token for userName with the keyring's active key
+// and stamps the key ID in the token header.
+func CreateToken(userName string, keyring *Keyring, cfg TokenConfig) (string, error) {
+	return CreateSessionToken(userName, nil, Grants{}, keyring, cfg)
+}
+
+// CreateSessionToken signs a session token for userName recording the
+// authentication methods amr the user signed in with and the roles,
+// permissions and scopes of grants. Session tokens also carry ScopeAccount.
+func CreateSessionToken(userName string, amr []string, grants Grants, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+
+	scopes := append(slices.Clone(grants.Scopes), ScopeAccount)
+	slices.Sort(scopes)
 	claims := CustomClaims{
-		UserID: userName,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    ISSUER,
-			Subject:   SUBJECT,
-			Audience:  []string{"api" + ISSUER},
-			ID:        uuid.NewString(),
-		},
+		UserID:           userName,
+		PrincipalType:    PrincipalTypeUser,
+		AMR:              amr,
+		Roles:            grants.Roles,
+		Permissions:      grants.Permissions,
+		Scope:            strings.Join(slices.Compact(scopes), " "),
+		RegisteredClaims: newRegisteredClaims(cfg, cfg.Subject),
 	}
+	return signClaims(claims, keyring, cfg)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+// CreateDelegatedToken signs an access token for userName issued to an OAuth
+// client with the authorization code grant, recording the client and the
+// granted scope. The roles of the user are not delegated.
+func CreateDelegatedToken(userName, clientID, scope string, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+
+	claims := CustomClaims{
+		UserID:           userName,
+		PrincipalType:    PrincipalTypeUser,
+		ClientID:         clientID,
+		Scope:            scope,
+		RegisteredClaims: newRegisteredClaims(cfg, cfg.Subject),
+	}
+	return signClaims(claims, keyring, cfg)
+}
+
+// CreateClientToken signs an access token for a machine client authenticated
+// with the client credentials grant. The client is the token subject.
+func CreateClientToken(clientID, scope string, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+
+	claims := CustomClaims{
+		PrincipalType:    PrincipalTypeClient,
+		ClientID:         clientID,
+		Scope:            scope,
+		RegisteredClaims: newRegisteredClaims(cfg, clientID),
+	}
+	return signClaims(claims, keyring, cfg)
+}
+
+// newRegisteredClaims returns the registered claims of a token issued now.
+func newRegisteredClaims(cfg TokenConfig, subject string) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Lifetime)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    cfg.Issuer,
+		Subject:   subject,
+		Audience:  cfg.Audience,
+		ID:        uuid.NewString(),
+	}
+}
+
+// signClaims signs claims with the keyring's active key.
+func signClaims(claims jwt.Claims, keyring *Keyring, cfg TokenConfig) (string, error) {
+	kid, privateKey, err := keyring.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	alg, err := signingAlgorithm(privateKey.Public(), cfg.RSAAlgorithm)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), claims)
+	token.Header[KeyIDHeader] = kid
 
 	signToken, err := token.SignedString(privateKey)
 	if err != nil {
@@ -48,22 +203,67 @@ func CreateToken(userName string, privateKey *ecdsa.PrivateKey) (string, error)
 	return signToken, nil
 }
 
-func VerifyToken(tokenString string, publicKey *ecdsa.PublicKey) (*CustomClaims, error) {
-	// check key type for the correct signing method
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return publicKey, nil
-	})
+// VerifyToken validates tokenString against the keyring key selected by its "kid" header.
+// The token must carry the configured issuer and at least one of the configured
+// audiences. When revocations is not nil the token ID is checked against the
+// revocation list, and tokens of users whose sessions were revoked after the
+// token was issued are rejected.
+func VerifyToken(ctx context.Context, tokenString string, keyring *Keyring, cfg TokenConfig, revocations interfaces.RevocationStore) (*CustomClaims, error) {
+	cfg = cfg.withDefaults()
+
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, keyring.keyFunc, jwt.WithValidMethods(SupportedAlgorithms), jwt.WithIssuer(cfg.Issuer), jwt.WithExpirationRequired(), jwt.WithLeeway(cfg.Leeway))
 	if err != nil {
 		return nil, fmt.Errorf("token parsing error: %v", err)
 	}
 
 	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
+		if !hasAudience(claims.Audience, cfg.Audience) {
+			return nil, fmt.Errorf("token has invalid audience: %v", claims.Audience)
+		}
+		if revocations != nil && claims.ID != "" {
+			revoked, err := revocations.IsRevoked(ctx, claims.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check token revocation: %w", err)
+			}
+			if revoked {
+				return nil, ErrTokenRevoked
+			}
+		}
+		if revocations != nil && claims.UserID != "" && claims.IssuedAt != nil {
+			revoked, err := revocations.IsSubjectRevoked(ctx, claims.UserID, claims.IssuedAt.Time)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check session revocation: %w", err)
+			}
+			if revoked {
+				return nil, ErrTokenRevoked
+			}
+		}
 		return claims, nil
 	}
 
 	return nil, fmt.Errorf("invalid token or claims")
 }
+
+// RevokeUserSessions revokes every session token issued to userName so far.
+// Entries are kept until the longest lived of those tokens would have expired.
+func RevokeUserSessions(ctx context.Context, revocations interfaces.RevocationStore, userName string, cfg TokenConfig) error {
+	cfg = cfg.withDefaults()
+
+	now := time.Now()
+	if err := revocations.RevokeSubject(ctx, userName, now, now.Add(cfg.Lifetime+cfg.Leeway)); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// hasAudience reports whether the token audience contains one of the accepted audiences.
+func hasAudience(tokenAudience jwt.ClaimStrings, accepted []string) bool {
+	for _, aud := range tokenAudience {
+		for _, want := range accepted {
+			if aud == want {
+				return true
+			}
+		}
+	}
+	return false
+}
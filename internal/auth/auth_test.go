@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/stretchr/testify/mock"
 )
 
 // Global variable for the JWT private key for testing purposes
@@ -143,7 +146,12 @@ func TestCreateToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotTokenString, err := CreateToken(tt.args.userName, tt.args.privateKey)
+			keyring, err := NewKeyring(tt.args.privateKey)
+			if err != nil {
+				t.Fatalf("Failed to create keyring: %v", err)
+			}
+
+			gotTokenString, err := CreateToken(tt.args.userName, keyring, TokenConfig{})
 
 			// Check if the error expectation matches
 			if (err != nil) != tt.wantErr {
@@ -184,6 +192,12 @@ func TestCreateToken(t *testing.T) {
 					t.Fatal("Failed to cast claims to *CustomClaims")
 				}
 
+				// The header must carry the signing key ID
+				wantKid, _ := KeyID(publicKey)
+				if parsedToken.Header[KeyIDHeader] != wantKid {
+					t.Errorf("Expected kid header to be %s, got %v", wantKid, parsedToken.Header[KeyIDHeader])
+				}
+
 				// Check custom claim (UserID)
 				if claims.UserID != tt.args.userName {
 					t.Errorf("Expected UserID to be %s, got %s", tt.args.userName, claims.UserID)
@@ -277,16 +291,20 @@ func TestVerifyToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.args.privateKey)
+			if err != nil {
+				t.Fatalf("Failed to create keyring: %v", err)
+			}
+
 			if tt.name == "Successful token verification with valid token" {
-				var err error
 				// Create a valid token for this test case
-				tt.args.tokenString, err = CreateToken("testuser123", tt.args.privateKey)
+				tt.args.tokenString, err = CreateToken("testuser123", keyring, TokenConfig{})
 				if err != nil {
 					t.Fatalf("Failed to create token for test: %v", err)
 				}
 			}
 
-			gotClaims, err := VerifyToken(tt.args.tokenString, &tt.args.privateKey.PublicKey)
+			gotClaims, err := VerifyToken(context.Background(), tt.args.tokenString, keyring, TokenConfig{}, nil)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("VerifyToken() error = %v, wantErr %v", err, tt.wantErr)
@@ -312,3 +330,184 @@ func TestVerifyToken(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyToken_Revocation(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		revoked        bool
+		subjectRevoked bool
+		storeErr       error
+		wantErr        error
+		wantError      bool
+	}{
+		{
+			name:      "token not revoked",
+			revoked:   false,
+			wantError: false,
+		},
+		{
+			name:      "revoked token",
+			revoked:   true,
+			wantErr:   ErrTokenRevoked,
+			wantError: true,
+		},
+		{
+			name:           "sessions of the user revoked",
+			subjectRevoked: true,
+			wantErr:        ErrTokenRevoked,
+			wantError:      true,
+		},
+		{
+			name:      "revocation store failure",
+			storeErr:  fmt.Errorf("store unavailable"),
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := CreateToken("testuser123", keyring, TokenConfig{})
+			if err != nil {
+				t.Fatalf("Failed to create token for test: %v", err)
+			}
+
+			revocations := mocks.NewMockRevocationStore(t)
+			revocations.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(tt.revoked, tt.storeErr).Once()
+			revocations.On("IsSubjectRevoked", mock.Anything, "testuser123", mock.AnythingOfType("time.Time")).Return(tt.subjectRevoked, nil).Maybe()
+
+			_, err = VerifyToken(context.Background(), tokenString, keyring, TokenConfig{}, revocations)
+			if (err != nil) != tt.wantError {
+				t.Errorf("VerifyToken() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("VerifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyToken_IssuerAndAudience(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	issued := TokenConfig{
+		Lifetime: time.Hour,
+		Issuer:   "https://auth.staging.example.com",
+		Subject:  "session",
+		Audience: []string{"billing", "orders"},
+	}
+
+	tests := []struct {
+		name    string
+		verify  TokenConfig
+		wantErr bool
+	}{
+		{
+			name:    "matching issuer and audience",
+			verify:  TokenConfig{Issuer: issued.Issuer, Audience: []string{"orders"}},
+			wantErr: false,
+		},
+		{
+			name:    "one of several accepted audiences",
+			verify:  TokenConfig{Issuer: issued.Issuer, Audience: []string{"inventory", "billing"}},
+			wantErr: false,
+		},
+		{
+			name:    "issuer mismatch",
+			verify:  TokenConfig{Issuer: "https://auth.example.com", Audience: []string{"orders"}},
+			wantErr: true,
+		},
+		{
+			name:    "audience mismatch",
+			verify:  TokenConfig{Issuer: issued.Issuer, Audience: []string{"inventory"}},
+			wantErr: true,
+		},
+		{
+			name:    "defaults reject configured token",
+			verify:  TokenConfig{},
+			wantErr: true,
+		},
+	}
+
+	tokenString, err := CreateToken("testuser123", keyring, issued)
+	if err != nil {
+		t.Fatalf("Failed to create token for test: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := VerifyToken(context.Background(), tokenString, keyring, tt.verify, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if claims.Subject != issued.Subject {
+				t.Errorf("Expected Subject to be %s, got %s", issued.Subject, claims.Subject)
+			}
+			if claims.ExpiresAt.Sub(claims.IssuedAt.Time) != issued.Lifetime {
+				t.Errorf("Expected lifetime %v, got %v", issued.Lifetime, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+			}
+		})
+	}
+}
+
+func TestCreateClientToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	clientToken, err := CreateClientToken("machine", "reports:read", keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateClientToken() error = %v", err)
+	}
+	claims, err := VerifyToken(context.Background(), clientToken, keyring, TokenConfig{}, nil)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if !claims.IsClient() || claims.ClientID != "machine" || claims.Subject != "machine" {
+		t.Errorf("expected a machine principal, got %+v", claims)
+	}
+	if claims.UserID != "" || claims.Scope != "reports:read" {
+		t.Errorf("unexpected user %q or scope %q", claims.UserID, claims.Scope)
+	}
+
+	userToken, err := CreateToken("testuser", keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	claims, err = VerifyToken(context.Background(), userToken, keyring, TokenConfig{}, nil)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if claims.IsClient() || claims.PrincipalType != PrincipalTypeUser || !claims.IsSession() {
+		t.Errorf("expected a user session, got %+v", claims)
+	}
+
+	delegatedToken, err := CreateDelegatedToken("testuser", "client-1", "openid", keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateDelegatedToken() error = %v", err)
+	}
+	claims, err = VerifyToken(context.Background(), delegatedToken, keyring, TokenConfig{}, nil)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if claims.IsClient() || claims.IsSession() || claims.UserID != "testuser" {
+		t.Errorf("expected a user principal delegated to a client, got %+v", claims)
+	}
+	if claims.HasScope(ScopeAccount) {
+		t.Errorf("expected the account scope not to be delegated, got %q", claims.Scope)
+	}
+}
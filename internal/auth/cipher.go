@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// SecretKeyBytes is the size of the AES-256 key used by SecretCipher.
+	SecretKeyBytes = 32
+)
+
+// SecretCipher encrypts secrets that must be stored recoverably, such as TOTP
+// seeds, with AES-256-GCM. Ciphertexts are bound to an associated value, for
+// example the owning username, so they cannot be moved between records.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher returns a SecretCipher using a SecretKeyBytes long key.
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) != SecretKeyBytes {
+		return nil, fmt.Errorf("secret key must be %d bytes, got %d", SecretKeyBytes, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt seals plaintext and returns the base64 encoded nonce and ciphertext.
+func (c *SecretCipher) Encrypt(plaintext, associatedData string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(associatedData))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same associated data.
+func (c *SecretCipher) Decrypt(ciphertext, associatedData string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext encoding: %v", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(associatedData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// LoadSecretKey reads a raw SecretKeyBytes long key from keyPath. A missing
+// file is created with a new random key, readable only by the owner.
+func LoadSecretKey(keyPath string) ([]byte, error) {
+	key, err := os.ReadFile(keyPath)
+	if err == nil {
+		if len(key) != SecretKeyBytes {
+			return nil, fmt.Errorf("secret key %s must be %d bytes, got %d", keyPath, SecretKeyBytes, len(key))
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read secret key: %w", err)
+	}
+
+	key = make([]byte, SecretKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create secret key directory: %w", err)
+	}
+	// O_EXCL keeps a concurrently started process from overwriting the key
+	file, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret key: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(key); err != nil {
+		return nil, fmt.Errorf("failed to write secret key: %w", err)
+	}
+	return key, nil
+}
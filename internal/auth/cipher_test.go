@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretCipher(t *testing.T) {
+	cipher, err := NewSecretCipher(bytes.Repeat([]byte{7}, SecretKeyBytes))
+	if err != nil {
+		t.Fatalf("NewSecretCipher() error = %v", err)
+	}
+
+	ciphertext, err := cipher.Encrypt(rfc6238Secret, "testuser")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if ciphertext == rfc6238Secret {
+		t.Fatal("expected the secret to be encrypted")
+	}
+
+	plaintext, err := cipher.Decrypt(ciphertext, "testuser")
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if plaintext != rfc6238Secret {
+		t.Errorf("Decrypt() = %s, want %s", plaintext, rfc6238Secret)
+	}
+
+	// a secret copied to another user's record must not decrypt
+	if _, err := cipher.Decrypt(ciphertext, "otheruser"); err == nil {
+		t.Error("expected decryption with other associated data to fail")
+	}
+
+	other, _ := NewSecretCipher(bytes.Repeat([]byte{8}, SecretKeyBytes))
+	if _, err := other.Decrypt(ciphertext, "testuser"); err == nil {
+		t.Error("expected decryption with another key to fail")
+	}
+
+	if _, err := NewSecretCipher([]byte("short")); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+}
+
+func TestLoadSecretKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "mfa", "secret.key")
+
+	key, err := LoadSecretKey(keyPath)
+	if err != nil {
+		t.Fatalf("LoadSecretKey() error = %v", err)
+	}
+	if len(key) != SecretKeyBytes {
+		t.Fatalf("expected a %d byte key, got %d", SecretKeyBytes, len(key))
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("expected key file to be created: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	reloaded, err := LoadSecretKey(keyPath)
+	if err != nil {
+		t.Fatalf("LoadSecretKey() reload error = %v", err)
+	}
+	if !bytes.Equal(key, reloaded) {
+		t.Error("expected the stored key to be reused")
+	}
+
+	if err := os.WriteFile(keyPath, []byte("short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSecretKey(keyPath); err == nil {
+		t.Error("expected a key of the wrong size to be rejected")
+	}
+}
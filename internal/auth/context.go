@@ -0,0 +1,27 @@
+package auth
+
+import "context"
+
+// claimsContextKey is the context key under which verified claims are stored.
+type claimsContextKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying the verified token claims.
+func ContextWithClaims(ctx context.Context, claims *CustomClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the verified token claims stored in ctx, if any.
+func ClaimsFromContext(ctx context.Context) (*CustomClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*CustomClaims)
+	return claims, ok && claims != nil
+}
+
+// UsernameFromContext returns the username of the authenticated caller, or an
+// empty string if the request was not authenticated.
+func UsernameFromContext(ctx context.Context) string {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return claims.UserID
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmailVerificationToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	cfg := TokenConfig{}
+
+	token, err := CreateEmailVerificationToken("testuser", "alice@example.com", time.Hour, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateEmailVerificationToken() error = %v", err)
+	}
+
+	claims, err := VerifyEmailVerificationToken(token, keyring, cfg)
+	if err != nil {
+		t.Fatalf("VerifyEmailVerificationToken() error = %v", err)
+	}
+	if claims.UserID != "testuser" || claims.Email != "alice@example.com" {
+		t.Errorf("unexpected user %s or email %s", claims.UserID, claims.Email)
+	}
+
+	// a verification link is not a session
+	if _, err := VerifyToken(context.Background(), token, keyring, cfg, nil); err == nil {
+		t.Error("expected email verification token to be rejected as a session token")
+	}
+	// and a session is not a verification link
+	session, err := CreateToken("testuser", keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if _, err := VerifyEmailVerificationToken(session, keyring, cfg); err == nil {
+		t.Error("expected session token to be rejected as an email verification token")
+	}
+
+	expired, _ := CreateEmailVerificationToken("testuser", "alice@example.com", time.Nanosecond, keyring, TokenConfig{Leeway: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	if _, err := VerifyEmailVerificationToken(expired, keyring, TokenConfig{Leeway: time.Nanosecond}); err == nil {
+		t.Error("expected expired email verification token to be rejected")
+	}
+}
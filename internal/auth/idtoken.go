@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// IDTokenClaims are the claims of an OpenID Connect ID token.
+type IDTokenClaims struct {
+	Nonce             string           `json:"nonce,omitempty"`
+	AuthTime          *jwt.NumericDate `json:"auth_time,omitempty"`
+	AccessTokenHash   string           `json:"at_hash,omitempty"`
+	PreferredUsername string           `json:"preferred_username,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IDTokenParams describes the authentication an ID token is issued for.
+type IDTokenParams struct {
+	// Username is the authenticated user and becomes the sub claim.
+	Username string
+	// ClientID is the relying party the token is issued to (aud).
+	ClientID string
+	// Nonce is echoed from the authentication request.
+	Nonce string
+	// AuthTime is when the user authenticated.
+	AuthTime time.Time
+	// AccessToken, if set, is bound to the ID token with at_hash.
+	AccessToken string
+}
+
+// SigningAlgorithm returns the JWS algorithm of tokens signed with the keyring's active key.
+func SigningAlgorithm(keyring *Keyring, cfg TokenConfig) (string, error) {
+	_, privateKey, err := keyring.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	return signingAlgorithm(privateKey.Public(), cfg.RSAAlgorithm)
+}
+
+// CreateIDToken signs an OpenID Connect ID token with the keyring's active key.
+// The token uses the configured issuer and lifetime; its audience is the client.
+func CreateIDToken(params IDTokenParams, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+
+	alg, err := SigningAlgorithm(keyring, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := IDTokenClaims{
+		Nonce:             params.Nonce,
+		PreferredUsername: params.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.Lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    cfg.Issuer,
+			Subject:   params.Username,
+			Audience:  jwt.ClaimStrings{params.ClientID},
+			ID:        uuid.NewString(),
+		},
+	}
+	if !params.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(params.AuthTime)
+	}
+	if params.AccessToken != "" {
+		claims.AccessTokenHash, err = AccessTokenHash(params.AccessToken, alg)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return signClaims(claims, keyring, cfg)
+}
+
+// VerifyIDToken validates an ID token issued to clientID.
+func VerifyIDToken(tokenString, clientID string, keyring *Keyring, cfg TokenConfig) (*IDTokenClaims, error) {
+	cfg = cfg.withDefaults()
+
+	token, err := jwt.ParseWithClaims(tokenString, &IDTokenClaims{}, keyring.keyFunc, jwt.WithValidMethods(SupportedAlgorithms), jwt.WithIssuer(cfg.Issuer), jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(), jwt.WithLeeway(cfg.Leeway))
+	if err != nil {
+		return nil, fmt.Errorf("id token parsing error: %v", err)
+	}
+
+	claims, ok := token.Claims.(*IDTokenClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid id token or claims")
+	}
+	return claims, nil
+}
+
+// AccessTokenHash computes the at_hash of accessToken for an ID token signed
+// with alg: the base64url encoded left half of the access token hash, using
+// the hash function of alg (OpenID Connect Core section 3.1.3.6). EdDSA uses SHA-512.
+func AccessTokenHash(accessToken, alg string) (string, error) {
+	var sum []byte
+	switch alg {
+	case AlgorithmES256, AlgorithmRS256, AlgorithmPS256:
+		digest := sha256.Sum256([]byte(accessToken))
+		sum = digest[:]
+	case AlgorithmES384:
+		digest := sha512.Sum384([]byte(accessToken))
+		sum = digest[:]
+	case AlgorithmES512, AlgorithmEdDSA:
+		digest := sha512.Sum512([]byte(accessToken))
+		sum = digest[:]
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
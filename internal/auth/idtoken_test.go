@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		alg     string
+		want    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			// example from OpenID Connect Core appendix A.3
+			name: "RS256 example",
+			alg:  AlgorithmRS256,
+			want: "77QmUPtjPfzWtF2AnpK9RQ",
+		},
+		{
+			name:    "ES256 uses SHA-256",
+			alg:     AlgorithmES256,
+			want:    "77QmUPtjPfzWtF2AnpK9RQ",
+			wantLen: 22,
+		},
+		{
+			name:    "ES384 uses SHA-384",
+			alg:     AlgorithmES384,
+			wantLen: 32,
+		},
+		{
+			name:    "EdDSA uses SHA-512",
+			alg:     AlgorithmEdDSA,
+			wantLen: 43,
+		},
+		{
+			name:    "unsupported algorithm",
+			alg:     "HS256",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AccessTokenHash("jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", tt.alg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AccessTokenHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("AccessTokenHash() = %s, want %s", got, tt.want)
+			}
+			if tt.wantLen != 0 && len(got) != tt.wantLen {
+				t.Errorf("AccessTokenHash() length = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestCreateIDToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	cfg := TokenConfig{Issuer: "https://auth.example.com"}
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	accessToken, err := CreateToken("testuser", keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	idToken, err := CreateIDToken(IDTokenParams{
+		Username:    "testuser",
+		ClientID:    "client-1",
+		Nonce:       "n-0S6_WzA2Mj",
+		AuthTime:    authTime,
+		AccessToken: accessToken,
+	}, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateIDToken() error = %v", err)
+	}
+
+	claims, err := VerifyIDToken(idToken, "client-1", keyring, cfg)
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if claims.Subject != "testuser" || claims.Issuer != "https://auth.example.com" {
+		t.Errorf("unexpected sub %s or iss %s", claims.Subject, claims.Issuer)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" {
+		t.Errorf("expected nonce to be echoed, got %s", claims.Nonce)
+	}
+	if claims.AuthTime == nil || !claims.AuthTime.Time.Equal(authTime) {
+		t.Errorf("expected auth_time %v, got %v", authTime, claims.AuthTime)
+	}
+	wantHash, _ := AccessTokenHash(accessToken, AlgorithmES256)
+	if claims.AccessTokenHash != wantHash {
+		t.Errorf("expected at_hash %s, got %s", wantHash, claims.AccessTokenHash)
+	}
+
+	// ID tokens are only accepted by the client they were issued to
+	if _, err := VerifyIDToken(idToken, "client-2", keyring, cfg); err == nil {
+		t.Error("expected ID token for another client to be rejected")
+	}
+	// and are not session tokens
+	if _, err := VerifyToken(context.Background(), idToken, keyring, cfg, nil); err == nil {
+		t.Error("expected ID token to be rejected as a session token")
+	}
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// KeyTypeEC is the JWK key type for elliptic curve keys.
+	KeyTypeEC = "EC"
+	// KeyTypeRSA is the JWK key type for RSA keys.
+	KeyTypeRSA = "RSA"
+	// KeyTypeOKP is the JWK key type for octet key pairs such as Ed25519.
+	KeyTypeOKP = "OKP"
+	// CurveP256 is the JWK curve name for NIST P-256.
+	CurveP256 = "P-256"
+	// CurveP384 is the JWK curve name for NIST P-384.
+	CurveP384 = "P-384"
+	// CurveP521 is the JWK curve name for NIST P-521.
+	CurveP521 = "P-521"
+	// CurveEd25519 is the JWK curve name for Ed25519.
+	CurveEd25519 = "Ed25519"
+	// KeyUseSignature marks a JWK as a signature verification key.
+	KeyUseSignature = "sig"
+)
+
+// JWK is a JSON Web Key as described in RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use"`
+}
+
+// JWKSet is a JSON Web Key Set as described in RFC 7517 section 5.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK serializes the public half of an ECDSA, RSA or Ed25519 key as a JWK.
+// RSA keys carry no "alg" member since they verify both RS256 and PS256 tokens.
+func NewJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	jwk, err := publicJWK(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := KeyID(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = kid
+	jwk.Use = KeyUseSignature
+
+	if jwk.Kty != KeyTypeRSA {
+		jwk.Alg, err = AlgorithmForKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return jwk, nil
+}
+
+// NewJWKSet builds a JWK set containing the given public keys.
+func NewJWKSet(publicKeys ...crypto.PublicKey) (*JWKSet, error) {
+	set := &JWKSet{Keys: make([]JWK, 0, len(publicKeys))}
+	for _, publicKey := range publicKeys {
+		jwk, err := NewJWK(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
+
+// KeyID returns the RFC 7638 JWK thumbprint of the public key.
+// The thumbprint is stable for a given key, so it can be used as the "kid".
+func KeyID(publicKey crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	// RFC 7638 requires the required members only, in lexicographic order.
+	var thumbprintInput []byte
+	switch jwk.Kty {
+	case KeyTypeEC:
+		thumbprintInput, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X, Y: jwk.Y})
+	case KeyTypeRSA:
+		thumbprintInput, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: jwk.E, Kty: jwk.Kty, N: jwk.N})
+	default:
+		thumbprintInput, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal thumbprint input: %w", err)
+	}
+
+	sum := sha256.Sum256(thumbprintInput)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// publicJWK returns the key type specific members of the JWK for publicKey.
+func publicJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if key == nil {
+			return nil, fmt.Errorf("public key is nil")
+		}
+		crv, err := curveName(key.Curve)
+		if err != nil {
+			return nil, err
+		}
+		x, y, err := ecdsaCoordinates(key)
+		if err != nil {
+			return nil, err
+		}
+		return &JWK{Kty: KeyTypeEC, Crv: crv, X: x, Y: y}, nil
+
+	case *rsa.PublicKey:
+		if key == nil {
+			return nil, fmt.Errorf("public key is nil")
+		}
+		return &JWK{
+			Kty: KeyTypeRSA,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(key))
+		}
+		return &JWK{Kty: KeyTypeOKP, Crv: CurveEd25519, X: base64.RawURLEncoding.EncodeToString(key)}, nil
+
+	case nil:
+		return nil, fmt.Errorf("public key is nil")
+
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+}
+
+// curveName returns the JWK name of a supported NIST curve.
+func curveName(curve elliptic.Curve) (string, error) {
+	switch curve {
+	case elliptic.P256():
+		return CurveP256, nil
+	case elliptic.P384():
+		return CurveP384, nil
+	case elliptic.P521():
+		return CurveP521, nil
+	default:
+		return "", fmt.Errorf("unsupported curve: %s", curve.Params().Name)
+	}
+}
+
+// ecdsaCoordinates returns the base64url encoded X and Y coordinates of a public key.
+func ecdsaCoordinates(publicKey *ecdsa.PublicKey) (string, string, error) {
+	ecdhKey, err := publicKey.ECDH()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to convert public key: %w", err)
+	}
+
+	// uncompressed point encoding: 0x04 || X || Y
+	point := ecdhKey.Bytes()
+	size := (len(point) - 1) / 2
+	x := base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+	y := base64.RawURLEncoding.EncodeToString(point[1+size:])
+
+	return x, y, nil
+}
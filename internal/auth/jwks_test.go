@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+func TestNewJWK(t *testing.T) {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate P-384 key: %v", err)
+	}
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate P-224 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey crypto.PublicKey
+		wantKty   string
+		wantCrv   string
+		wantAlg   string
+		wantErr   bool
+	}{
+		{
+			name:      "valid P-256 key",
+			publicKey: &testJwtPrivateKey.PublicKey,
+			wantKty:   KeyTypeEC,
+			wantCrv:   CurveP256,
+			wantAlg:   AlgorithmES256,
+		},
+		{
+			name:      "valid P-384 key",
+			publicKey: &p384Key.PublicKey,
+			wantKty:   KeyTypeEC,
+			wantCrv:   CurveP384,
+			wantAlg:   AlgorithmES384,
+		},
+		{
+			name:      "RSA key without alg",
+			publicKey: &rsaKey.PublicKey,
+			wantKty:   KeyTypeRSA,
+		},
+		{
+			name:      "Ed25519 key",
+			publicKey: edPublicKey,
+			wantKty:   KeyTypeOKP,
+			wantCrv:   CurveEd25519,
+			wantAlg:   AlgorithmEdDSA,
+		},
+		{
+			name:      "nil key",
+			publicKey: nil,
+			wantErr:   true,
+		},
+		{
+			name:      "unsupported curve",
+			publicKey: &p224Key.PublicKey,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJWK(tt.publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewJWK() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Kty != tt.wantKty || got.Crv != tt.wantCrv || got.Alg != tt.wantAlg || got.Use != KeyUseSignature {
+				t.Errorf("unexpected JWK metadata: %+v", got)
+			}
+
+			// the key material must round trip to the original public key
+			var decoded crypto.PublicKey
+			switch got.Kty {
+			case KeyTypeEC:
+				x, _ := base64.RawURLEncoding.DecodeString(got.X)
+				y, _ := base64.RawURLEncoding.DecodeString(got.Y)
+				curve := tt.publicKey.(*ecdsa.PublicKey).Curve
+				decoded = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			case KeyTypeRSA:
+				n, _ := base64.RawURLEncoding.DecodeString(got.N)
+				e, _ := base64.RawURLEncoding.DecodeString(got.E)
+				decoded = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			case KeyTypeOKP:
+				x, _ := base64.RawURLEncoding.DecodeString(got.X)
+				decoded = ed25519.PublicKey(x)
+			}
+			if !tt.publicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(decoded) {
+				t.Error("decoded JWK does not match the original public key")
+			}
+
+			kid, err := KeyID(tt.publicKey)
+			if err != nil {
+				t.Fatalf("KeyID() error = %v", err)
+			}
+			if got.Kid != kid {
+				t.Errorf("expected kid %s, got %s", kid, got.Kid)
+			}
+		})
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	first, err := KeyID(&testJwtPrivateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	second, err := KeyID(&testJwtPrivateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	if first != second {
+		t.Errorf("expected KeyID to be stable, got %s and %s", first, second)
+	}
+
+	other, err := KeyID(&otherKey.PublicKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	if first == other {
+		t.Error("expected different keys to have different key IDs")
+	}
+}
+
+func TestKeyID_RFC7638(t *testing.T) {
+	// RSA key and thumbprint from RFC 7638 section 3.1
+	n, _ := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+	got, err := KeyID(publicKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("KeyID() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// KeyFileExtension is the extension of key files inside a keyring directory.
+	KeyFileExtension = ".pem"
+	// KeyFilePrefix is the prefix of key files generated by Rotate.
+	KeyFilePrefix = "sasuke-"
+	// keyFileTimeFormat sorts lexically in creation order.
+	keyFileTimeFormat = "20060102T150405.000000000Z"
+	// DefaultKeyRetention is how long retired keys are kept at least when no
+	// retention is set, long enough for session tokens of the default lifetime.
+	DefaultKeyRetention = DefaultTokenLifetime
+)
+
+// keyEntry is a single key held by the keyring.
+type keyEntry struct {
+	kid        string
+	privateKey crypto.Signer
+	path       string
+	created    time.Time
+}
+
+// Keyring holds one active signing key plus retired keys that are still
+// accepted when verifying tokens. A retired key is kept while it is among the
+// maxRetired newest ones or until the retention has passed since it was
+// replaced, so tokens it signed can be verified until they expire.
+//
+// When backed by a directory, every *.pem file in it is a key and the file
+// with the lexically greatest name is the active one. Rotate writes new keys
+// with a timestamped name so that ordering holds.
+type Keyring struct {
+	mu         sync.RWMutex
+	dir        string
+	maxRetired int
+	retention  time.Duration
+	active     *keyEntry
+	retired    []*keyEntry // newest first
+}
+
+// NewKeyring returns an in-memory keyring with privateKey as the active signing key.
+func NewKeyring(privateKey crypto.Signer) (*Keyring, error) {
+	entry, err := newKeyEntry(privateKey, "", time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{retention: DefaultKeyRetention, active: entry}, nil
+}
+
+// LoadKeyring loads the keys stored in dir. At most maxRetired keys besides the
+// active one are kept for verification. If the directory holds no keys a new
+// ECDSA P-256 key is generated.
+func LoadKeyring(dir string, maxRetired int) (*Keyring, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("keyring directory is not provided")
+	}
+	if maxRetired < 0 {
+		return nil, fmt.Errorf("max retired keys cannot be negative")
+	}
+
+	keyring := &Keyring{dir: dir, maxRetired: maxRetired, retention: DefaultKeyRetention}
+	if err := keyring.Reload(); err != nil {
+		return nil, err
+	}
+
+	if keyring.active == nil {
+		if err := keyring.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+// Reload re-reads the keyring directory, picking up keys rotated by another process.
+func (k *Keyring) Reload() error {
+	if k.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(k.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create keyring directory: %w", err)
+	}
+
+	files, err := os.ReadDir(k.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read keyring directory: %w", err)
+	}
+
+	keyFiles := make([]os.DirEntry, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), KeyFileExtension) {
+			continue
+		}
+		keyFiles = append(keyFiles, file)
+	}
+	// newest first
+	sort.Slice(keyFiles, func(i, j int) bool { return keyFiles[i].Name() > keyFiles[j].Name() })
+
+	created := make([]time.Time, len(keyFiles))
+	for i, file := range keyFiles {
+		created[i], err = keyFileCreated(file)
+		if err != nil {
+			return fmt.Errorf("failed to read key %s: %w", file.Name(), err)
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keyFiles = keyFiles[:k.keepCount(created, time.Now())]
+	entries := make([]*keyEntry, 0, len(keyFiles))
+	for i, file := range keyFiles {
+		path := filepath.Join(k.dir, file.Name())
+		privateKey, err := LoadPrivateKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", file.Name(), err)
+		}
+
+		entry, err := newKeyEntry(privateKey, path, created[i])
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", file.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		k.active = nil
+		k.retired = nil
+		return nil
+	}
+	k.active = entries[0]
+	k.retired = entries[1:]
+
+	return nil
+}
+
+// Rotate generates a new signing key of the same type as the active one and
+// retires the current one. Retired keys that are neither among the maxRetired
+// newest nor within the retention are dropped and, for directory backed
+// keyrings, removed from disk.
+func (k *Keyring) Rotate() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var template crypto.PublicKey
+	if k.active != nil {
+		template = k.active.privateKey.Public()
+	}
+
+	privateKey, err := GenerateKeyLike(template)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	now := time.Now()
+	path := ""
+	if k.dir != "" {
+		pemData, err := EncodePrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+
+		path = filepath.Join(k.dir, KeyFilePrefix+now.UTC().Format(keyFileTimeFormat)+KeyFileExtension)
+		if err := os.WriteFile(path, pemData, 0o600); err != nil {
+			return fmt.Errorf("failed to write signing key: %w", err)
+		}
+	}
+
+	entry, err := newKeyEntry(privateKey, path, now)
+	if err != nil {
+		return err
+	}
+
+	if k.active != nil {
+		k.retired = append([]*keyEntry{k.active}, k.retired...)
+	}
+	k.active = entry
+
+	created := []time.Time{k.active.created}
+	for _, retired := range k.retired {
+		created = append(created, retired.created)
+	}
+	keep := k.keepCount(created, now) - 1
+	for _, expired := range k.retired[keep:] {
+		if expired.path == "" {
+			continue
+		}
+		if err := os.Remove(expired.path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Keyring: failed to remove expired key %s: %v\n", expired.path, err)
+		}
+	}
+	k.retired = k.retired[:keep]
+
+	return nil
+}
+
+// SetRetention sets how long retired keys are kept at least after they were
+// replaced; it should cover the lifetime of every token the keyring signs.
+func (k *Keyring) SetRetention(retention time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.retention = retention
+}
+
+// keepCount returns how many of the keys created at created, newest first and
+// active key included, are kept. A retired key was replaced when the next
+// newer key was created.
+func (k *Keyring) keepCount(created []time.Time, now time.Time) int {
+	keep := min(len(created), k.maxRetired+1)
+	for keep < len(created) && now.Sub(created[keep-1]) < k.retention {
+		keep++
+	}
+	return keep
+}
+
+// keyFileCreated returns when the key in file was created, taken from the
+// name of files written by Rotate and from the modification time otherwise.
+func keyFileCreated(file os.DirEntry) (time.Time, error) {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(file.Name(), KeyFilePrefix), KeyFileExtension)
+	if created, err := time.Parse(keyFileTimeFormat, stamp); err == nil {
+		return created, nil
+	}
+	info, err := file.Info()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// StartRotation rotates the signing key every interval until ctx is cancelled.
+func (k *Keyring) StartRotation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.Rotate(); err != nil {
+					fmt.Printf("Keyring: scheduled rotation failed: %v\n", err)
+					continue
+				}
+				fmt.Printf("Keyring: rotated signing key, active kid %s\n", k.ActiveKeyID())
+			}
+		}
+	}()
+}
+
+// SigningKey returns the key ID and private key used to sign new tokens.
+func (k *Keyring) SigningKey() (string, crypto.Signer, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.active == nil {
+		return "", nil, fmt.Errorf("keyring has no active signing key")
+	}
+	return k.active.kid, k.active.privateKey, nil
+}
+
+// ActiveKeyID returns the key ID of the active signing key.
+func (k *Keyring) ActiveKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.active == nil {
+		return ""
+	}
+	return k.active.kid
+}
+
+// VerificationKey returns the public key for kid. An empty kid selects the
+// active key so tokens issued before key IDs were stamped remain valid.
+func (k *Keyring) VerificationKey(kid string) (crypto.PublicKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.active == nil {
+		return nil, fmt.Errorf("keyring has no active signing key")
+	}
+
+	if kid == "" || kid == k.active.kid {
+		return k.active.privateKey.Public(), nil
+	}
+	for _, entry := range k.retired {
+		if entry.kid == kid {
+			return entry.privateKey.Public(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key id: %s", kid)
+}
+
+// PublicKeys returns the public keys accepted for verification, active key first.
+func (k *Keyring) PublicKeys() []crypto.PublicKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	publicKeys := make([]crypto.PublicKey, 0, len(k.retired)+1)
+	if k.active != nil {
+		publicKeys = append(publicKeys, k.active.privateKey.Public())
+	}
+	for _, entry := range k.retired {
+		publicKeys = append(publicKeys, entry.privateKey.Public())
+	}
+	return publicKeys
+}
+
+func newKeyEntry(privateKey crypto.Signer, path string, created time.Time) (*keyEntry, error) {
+	if privateKey == nil {
+		return nil, fmt.Errorf("private key is nil")
+	}
+
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key id: %w", err)
+	}
+
+	return &keyEntry{kid: kid, privateKey: privateKey, path: path, created: created}, nil
+}
+
+// keyFunc selects the verification key by the "kid" header of token. The
+// algorithm must match the type of the selected key.
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[KeyIDHeader].(string)
+	publicKey, err := k.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !keyAcceptsAlgorithm(publicKey, token.Method.Alg()) {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(dir string)
+		maxRetired int
+		wantErr    bool
+	}{
+		{
+			name:       "empty directory generates a key",
+			setup:      func(dir string) {},
+			maxRetired: 2,
+			wantErr:    false,
+		},
+		{
+			name: "existing key is loaded",
+			setup: func(dir string) {
+				pemData, _ := EncodeECDSAPrivateKey(testJwtPrivateKey)
+				_ = os.WriteFile(filepath.Join(dir, "existing.pem"), pemData, 0o600)
+			},
+			maxRetired: 2,
+			wantErr:    false,
+		},
+		{
+			name: "invalid key file",
+			setup: func(dir string) {
+				_ = os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600)
+			},
+			maxRetired: 2,
+			wantErr:    true,
+		},
+		{
+			name:       "negative retired keys",
+			setup:      func(dir string) {},
+			maxRetired: -1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(dir)
+
+			keyring, err := LoadKeyring(dir, tt.maxRetired)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadKeyring() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if keyring.ActiveKeyID() == "" {
+				t.Error("expected an active signing key")
+			}
+		})
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	keyring, err := LoadKeyring(dir, 1)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+	// without a retention only max_retired keys are kept
+	keyring.SetRetention(0)
+
+	firstToken, err := CreateToken("testuser123", keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	firstKid := keyring.ActiveKeyID()
+
+	if err := keyring.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if keyring.ActiveKeyID() == firstKid {
+		t.Fatal("expected a new active key after rotation")
+	}
+
+	// tokens signed by the retired key are still accepted
+	if _, err := VerifyToken(context.Background(), firstToken, keyring, TokenConfig{}, nil); err != nil {
+		t.Errorf("expected token signed by retired key to verify, got %v", err)
+	}
+	if got := len(keyring.PublicKeys()); got != 2 {
+		t.Errorf("expected 2 public keys, got %d", got)
+	}
+
+	// a second rotation pushes the first key out of the keyring
+	if err := keyring.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := VerifyToken(context.Background(), firstToken, keyring, TokenConfig{}, nil); err == nil {
+		t.Error("expected token signed by dropped key to be rejected")
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read keyring directory: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 key files on disk, got %d", len(files))
+	}
+
+	// another process sees the same active key after reloading the directory
+	reloaded, err := LoadKeyring(dir, 1)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+	if reloaded.ActiveKeyID() != keyring.ActiveKeyID() {
+		t.Errorf("expected reloaded active kid %s, got %s", keyring.ActiveKeyID(), reloaded.ActiveKeyID())
+	}
+}
+
+func TestKeyring_Rotate_Retention(t *testing.T) {
+	inMemory, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	onDisk, err := LoadKeyring(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+
+	// keyrings with the default configuration keep no retired keys by count
+	for name, keyring := range map[string]*Keyring{"in memory": inMemory, "directory": onDisk} {
+		t.Run(name, func(t *testing.T) {
+			token, err := CreateToken("testuser123", keyring, TokenConfig{})
+			if err != nil {
+				t.Fatalf("CreateToken() error = %v", err)
+			}
+			for range 2 {
+				if err := keyring.Rotate(); err != nil {
+					t.Fatalf("Rotate() error = %v", err)
+				}
+			}
+
+			if _, err := VerifyToken(context.Background(), token, keyring, TokenConfig{}, nil); err != nil {
+				t.Errorf("expected a token signed before the rotation to verify, got %v", err)
+			}
+			if got := len(keyring.PublicKeys()); got != 3 {
+				t.Errorf("expected 3 public keys within the retention, got %d", got)
+			}
+
+			if err := keyring.Reload(); err != nil {
+				t.Fatalf("Reload() error = %v", err)
+			}
+			if _, err := VerifyToken(context.Background(), token, keyring, TokenConfig{}, nil); err != nil {
+				t.Errorf("expected a token signed before the rotation to verify after a reload, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyring_VerificationKey(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		wantErr bool
+	}{
+		{
+			name:    "active key id",
+			kid:     keyring.ActiveKeyID(),
+			wantErr: false,
+		},
+		{
+			name:    "missing key id falls back to active key",
+			kid:     "",
+			wantErr: false,
+		},
+		{
+			name:    "unknown key id",
+			kid:     "unknown",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyring.VerificationKey(tt.kid)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerificationKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !testJwtPrivateKey.PublicKey.Equal(got) {
+				t.Error("expected the active public key")
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.kid) {
+				t.Errorf("expected error to mention kid %s, got %v", tt.kid, err)
+			}
+		})
+	}
+}
+
+func TestKeyring_RotateKeepsKeyType(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	dir := t.TempDir()
+	pemData, err := EncodePrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKey() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "existing.pem"), pemData, 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	keyring, err := LoadKeyring(dir, 1)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+	if err := keyring.Rotate(); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	_, signer, err := keyring.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey() error = %v", err)
+	}
+	if _, ok := signer.Public().(ed25519.PublicKey); !ok {
+		t.Errorf("expected rotated key to be Ed25519, got %T", signer.Public())
+	}
+}
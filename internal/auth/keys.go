@@ -1,15 +1,31 @@
 package auth
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"os"
 )
 
-// LoadECDSAPrivateKey loads ECDSA private key from file or environment
-func LoadECDSAPrivateKey(keyPath string) (*ecdsa.PrivateKey, error) {
+const (
+	// ECPrivateKeyPEMType is the PEM block type for SEC1 encoded EC private keys.
+	ECPrivateKeyPEMType = "EC PRIVATE KEY"
+	// RSAPrivateKeyPEMType is the PEM block type for PKCS#1 encoded RSA private keys.
+	RSAPrivateKeyPEMType = "RSA PRIVATE KEY"
+	// PrivateKeyPEMType is the PEM block type for PKCS#8 encoded private keys.
+	PrivateKeyPEMType = "PRIVATE KEY"
+	// PublicKeyPEMType is the PEM block type for PKIX encoded public keys.
+	PublicKeyPEMType = "PUBLIC KEY"
+)
+
+// LoadPrivateKey loads an RSA, ECDSA or Ed25519 private key from a PEM file.
+func LoadPrivateKey(keyPath string) (crypto.Signer, error) {
 	// check if keyPath exists
 	if _, err := os.Stat(keyPath); err != nil {
 		return nil, fmt.Errorf("private key path does not exist: %v", err)
@@ -20,6 +36,106 @@ func LoadECDSAPrivateKey(keyPath string) (*ecdsa.PrivateKey, error) {
 		return nil, fmt.Errorf("failed to read key file: %w", err)
 	}
 
+	return ParsePrivateKey(keyData)
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS#8, PKCS#1 (RSA) or SEC1 (ECDSA)
+// private key. RSA keys shorter than MinRSAKeyBits and EC curves other than
+// P-256, P-384 and P-521 are rejected.
+func ParsePrivateKey(keyData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case PrivateKeyPEMType:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case RSAPrivateKeyPEMType:
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case ECPrivateKeyPEMType:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch privateKey := key.(type) {
+	case *rsa.PrivateKey:
+		if privateKey.N.BitLen() < MinRSAKeyBits {
+			return nil, fmt.Errorf("RSA key of %d bits is too short, at least %d are required", privateKey.N.BitLen(), MinRSAKeyBits)
+		}
+		return privateKey, nil
+	case *ecdsa.PrivateKey:
+		if _, err := AlgorithmForKey(&privateKey.PublicKey); err != nil {
+			return nil, err
+		}
+		return privateKey, nil
+	case ed25519.PrivateKey:
+		return privateKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+}
+
+// ParsePublicKey parses a PEM encoded PKIX RSA, ECDSA or Ed25519 public key
+// with the same restrictions as ParsePrivateKey.
+func ParsePublicKey(keyData []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	if block.Type != PublicKeyPEMType {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	if rsaKey, ok := key.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < MinRSAKeyBits {
+		return nil, fmt.Errorf("RSA key of %d bits is too short, at least %d are required", rsaKey.N.BitLen(), MinRSAKeyBits)
+	}
+	if _, err := AlgorithmForKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodePublicKey encodes an RSA, ECDSA or Ed25519 public key as a PEM PKIX block.
+func EncodePublicKey(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PublicKeyPEMType,
+		Bytes: der,
+	}), nil
+}
+
+// LoadECDSAPrivateKey loads ECDSA private key from file or environment
+func LoadECDSAPrivateKey(keyPath string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := LoadPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an ECDSA key: %T", privateKey)
+	}
+	return ecdsaKey, nil
+}
+
+// ParseECDSAPrivateKey parses a PEM encoded SEC1 ECDSA private key.
+func ParseECDSAPrivateKey(keyData []byte) (*ecdsa.PrivateKey, error) {
 	block, _ := pem.Decode(keyData)
 	if block == nil {
 		return nil, fmt.Errorf("failed to decode PEM block")
@@ -32,3 +148,47 @@ func LoadECDSAPrivateKey(keyPath string) (*ecdsa.PrivateKey, error) {
 
 	return privateKey, nil
 }
+
+// EncodeECDSAPrivateKey encodes an ECDSA private key as a PEM SEC1 block.
+func EncodeECDSAPrivateKey(privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ECDSA private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  ECPrivateKeyPEMType,
+		Bytes: der,
+	}), nil
+}
+
+// EncodePrivateKey encodes an RSA, ECDSA or Ed25519 private key as a PEM PKCS#8 block.
+func EncodePrivateKey(privateKey crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PrivateKeyPEMType,
+		Bytes: der,
+	}), nil
+}
+
+// GenerateKeyLike generates a new private key of the same type and size as
+// template. A nil template yields an ECDSA P-256 key.
+func GenerateKeyLike(template crypto.PublicKey) (crypto.Signer, error) {
+	switch key := template.(type) {
+	case nil:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case *ecdsa.PublicKey:
+		return ecdsa.GenerateKey(key.Curve, rand.Reader)
+	case *rsa.PublicKey:
+		return rsa.GenerateKey(rand.Reader, key.N.BitLen())
+	case ed25519.PublicKey:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", template)
+	}
+}
@@ -1,69 +1,242 @@
 package auth
 
 import (
+	"context"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func TestLoadECDSAPrivateKey(t *testing.T) {
+func TestParsePrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	shortRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate P-521 key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	pkcs8 := func(key any) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal PKCS#8 key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: PrivateKeyPEMType, Bytes: der})
+	}
+	sec1, _ := EncodeECDSAPrivateKey(testJwtPrivateKey)
+
 	tests := []struct {
 		name    string
-		keyPath string
+		keyData []byte
+		wantAlg string
 		wantErr bool
 	}{
 		{
-			name:    "load valid key",
-			keyPath: "test_valid_private.pem",
-			wantErr: false,
+			name:    "SEC1 P-256",
+			keyData: sec1,
+			wantAlg: AlgorithmES256,
 		},
 		{
-			name:    "load invalid key",
-			keyPath: "test_invalid_private.pem",
+			name:    "PKCS#8 P-521",
+			keyData: pkcs8(p521Key),
+			wantAlg: AlgorithmES512,
+		},
+		{
+			name:    "PKCS#1 RSA",
+			keyData: pem.EncodeToMemory(&pem.Block{Type: RSAPrivateKeyPEMType, Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			wantAlg: AlgorithmRS256,
+		},
+		{
+			name:    "PKCS#8 RSA",
+			keyData: pkcs8(rsaKey),
+			wantAlg: AlgorithmRS256,
+		},
+		{
+			name:    "PKCS#8 Ed25519",
+			keyData: pkcs8(edKey),
+			wantAlg: AlgorithmEdDSA,
+		},
+		{
+			name:    "RSA key too short",
+			keyData: pkcs8(shortRSAKey),
 			wantErr: true,
 		},
 		{
-			name:    "file does not exist",
-			keyPath: "non_existent_key.pem",
+			name:    "unsupported PEM type",
+			keyData: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")}),
 			wantErr: true,
 		},
 		{
-			name:    "empty key path",
-			keyPath: "",
+			name:    "not PEM",
+			keyData: []byte("not a key"),
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := LoadECDSAPrivateKey(tt.keyPath)
+			got, err := ParsePrivateKey(tt.keyData)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			alg, err := AlgorithmForKey(got.Public())
+			if err != nil {
+				t.Fatalf("AlgorithmForKey() error = %v", err)
+			}
+			if alg != tt.wantAlg {
+				t.Errorf("AlgorithmForKey() = %s, want %s", alg, tt.wantAlg)
+			}
+		})
+	}
+}
+
+func TestCreateToken_KeyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate P-384 key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		privateKey crypto.Signer
+		config     TokenConfig
+		wantAlg    string
+		wantErr    bool
+	}{
+		{
+			name:       "RSA defaults to RS256",
+			privateKey: rsaKey,
+			wantAlg:    AlgorithmRS256,
+		},
+		{
+			name:       "RSA with PS256",
+			privateKey: rsaKey,
+			config:     TokenConfig{RSAAlgorithm: AlgorithmPS256},
+			wantAlg:    AlgorithmPS256,
+		},
+		{
+			name:       "ECDSA P-384",
+			privateKey: p384Key,
+			wantAlg:    AlgorithmES384,
+		},
+		{
+			name:       "Ed25519",
+			privateKey: edKey,
+			config:     TokenConfig{RSAAlgorithm: AlgorithmPS256},
+			wantAlg:    AlgorithmEdDSA,
+		},
+		{
+			name:       "unsupported RSA algorithm",
+			privateKey: rsaKey,
+			config:     TokenConfig{RSAAlgorithm: "RS512"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.privateKey)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+
+			tokenString, err := CreateToken("testuser123", keyring, tt.config)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("LoadECDSAPrivateKey() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("CreateToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
 				return
 			}
-			if !tt.wantErr && got != nil {
-				checkECDSAPrivateKey(t, got)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &CustomClaims{})
+			if err != nil {
+				t.Fatalf("failed to parse token: %v", err)
+			}
+			if token.Method.Alg() != tt.wantAlg {
+				t.Errorf("got alg %s, want %s", token.Method.Alg(), tt.wantAlg)
+			}
+
+			claims, err := VerifyToken(context.Background(), tokenString, keyring, tt.config, nil)
+			if err != nil {
+				t.Fatalf("VerifyToken() error = %v", err)
+			}
+			if claims.UserID != "testuser123" {
+				t.Errorf("Expected UserID to be testuser123, got %s", claims.UserID)
 			}
 		})
 	}
 }
 
-func checkECDSAPrivateKey(t *testing.T, key *ecdsa.PrivateKey) {
-	if key == nil {
-		t.Error("Expected non-nil key")
-		return
+func TestVerifyToken_AlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	keyring, err := NewKeyring(rsaKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	// an HS256 token keyed with the public modulus must not verify
+	claims := CustomClaims{
+		UserID: "attacker",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ISSUER,
+			Audience:  []string{DefaultAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(DefaultTokenLifetime)),
+		},
 	}
-	if key.Curve != elliptic.P256() {
-		t.Errorf("Expected P256 curve")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header[KeyIDHeader] = keyring.ActiveKeyID()
+	tokenString, err := token.SignedString(rsaKey.PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	if _, err := VerifyToken(context.Background(), tokenString, keyring, TokenConfig{}, nil); err == nil {
+		t.Error("expected token with mismatched algorithm to be rejected")
 	}
-	// Verify we can sign and verify with the key
-	hash := []byte("test message")
-	r, s, err := ecdsa.Sign(rand.Reader, key, hash)
+
+	// an ES256 token presented with the kid of an RSA key must not verify
+	ecToken := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	ecToken.Header[KeyIDHeader] = keyring.ActiveKeyID()
+	ecTokenString, err := ecToken.SignedString(testJwtPrivateKey)
 	if err != nil {
-		t.Errorf("Failed to sign with loaded key: %v", err)
+		t.Fatalf("failed to sign token: %v", err)
 	}
-	if !ecdsa.Verify(&key.PublicKey, hash, r, s) {
-		t.Errorf("Failed to verify signature with loaded key: %v", err)
+	if _, err := VerifyToken(context.Background(), ecTokenString, keyring, TokenConfig{}, nil); err == nil {
+		t.Error("expected ES256 token for an RSA key to be rejected")
 	}
 }
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMagicLinkToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	cfg := TokenConfig{}
+	ctx := context.Background()
+
+	link, err := CreateMagicLinkToken("testuser", "alice@example.com", time.Minute, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateMagicLinkToken() error = %v", err)
+	}
+
+	claims, err := VerifyMagicLinkToken(ctx, link, keyring, cfg, nil)
+	if err != nil {
+		t.Fatalf("VerifyMagicLinkToken() error = %v", err)
+	}
+	if claims.UserID != "testuser" || claims.Email != "alice@example.com" {
+		t.Errorf("unexpected user %s or email %s", claims.UserID, claims.Email)
+	}
+
+	// a magic link is not a session, and neither is an email verification link a magic link
+	if _, err := VerifyToken(ctx, link, keyring, cfg, nil); err == nil {
+		t.Error("expected magic link to be rejected as a session token")
+	}
+	verification, err := CreateEmailVerificationToken("testuser", "alice@example.com", time.Minute, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateEmailVerificationToken() error = %v", err)
+	}
+	if _, err := VerifyMagicLinkToken(ctx, verification, keyring, cfg, nil); err == nil {
+		t.Error("expected email verification token to be rejected as a magic link")
+	}
+
+	revocations := mocks.NewMockRevocationStore(t)
+	revocations.On("IsRevoked", mock.Anything, claims.ID).Return(true, nil).Once()
+	if _, err := VerifyMagicLinkToken(ctx, link, keyring, cfg, revocations); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked for a used magic link, got %v", err)
+	}
+
+	expired, _ := CreateMagicLinkToken("testuser", "alice@example.com", time.Nanosecond, keyring, TokenConfig{Leeway: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	if _, err := VerifyMagicLinkToken(ctx, expired, keyring, TokenConfig{Leeway: time.Nanosecond}, nil); err == nil {
+		t.Error("expected expired magic link to be rejected")
+	}
+}
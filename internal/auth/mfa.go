@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+)
+
+// Authentication method references recorded in the amr claim (RFC 8176).
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
+const (
+	// MFAChallengeAudience is the audience of MFA challenge tokens. It differs
+	// from session token audiences so a challenge cannot be used as a session.
+	MFAChallengeAudience = "mfa" + ISSUER
+	// DefaultMFAChallengeTTL is the MFA challenge lifetime when none is configured.
+	DefaultMFAChallengeTTL = 5 * time.Minute
+)
+
+// MFAChallengeClaims are the claims of the token returned by the first login
+// step of a user with a second factor. AMR lists the methods already used.
+type MFAChallengeClaims struct {
+	UserID string   `json:"userid"`
+	AMR    []string `json:"amr,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CreateMFAChallengeToken signs a challenge token for userName, who has
+// completed the amr methods and must still present a second factor.
+func CreateMFAChallengeToken(userName string, amr []string, ttl time.Duration, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+	if ttl <= 0 {
+		ttl = DefaultMFAChallengeTTL
+	}
+
+	now := time.Now()
+	claims := MFAChallengeClaims{
+		UserID: userName,
+		AMR:    amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    cfg.Issuer,
+			Subject:   userName,
+			Audience:  jwt.ClaimStrings{MFAChallengeAudience},
+			ID:        uuid.NewString(),
+		},
+	}
+	return signClaims(claims, keyring, cfg)
+}
+
+// VerifyMFAChallengeToken validates a challenge token. When revocations is not
+// nil, challenges that were already completed are rejected.
+func VerifyMFAChallengeToken(ctx context.Context, tokenString string, keyring *Keyring, cfg TokenConfig, revocations interfaces.RevocationStore) (*MFAChallengeClaims, error) {
+	cfg = cfg.withDefaults()
+
+	token, err := jwt.ParseWithClaims(tokenString, &MFAChallengeClaims{}, keyring.keyFunc, jwt.WithValidMethods(SupportedAlgorithms),
+		jwt.WithIssuer(cfg.Issuer), jwt.WithAudience(MFAChallengeAudience), jwt.WithExpirationRequired(), jwt.WithLeeway(cfg.Leeway))
+	if err != nil {
+		return nil, fmt.Errorf("mfa challenge parsing error: %v", err)
+	}
+
+	claims, ok := token.Claims.(*MFAChallengeClaims)
+	if !ok || !token.Valid || claims.UserID == "" {
+		return nil, fmt.Errorf("invalid mfa challenge or claims")
+	}
+
+	if revocations != nil && claims.ID != "" {
+		revoked, err := revocations.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check mfa challenge revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
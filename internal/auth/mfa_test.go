@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMFAChallengeToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	cfg := TokenConfig{}
+	ctx := context.Background()
+
+	challenge, err := CreateMFAChallengeToken("testuser", []string{AMRPassword}, time.Minute, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateMFAChallengeToken() error = %v", err)
+	}
+
+	claims, err := VerifyMFAChallengeToken(ctx, challenge, keyring, cfg, nil)
+	if err != nil {
+		t.Fatalf("VerifyMFAChallengeToken() error = %v", err)
+	}
+	if claims.UserID != "testuser" || !reflect.DeepEqual(claims.AMR, []string{AMRPassword}) {
+		t.Errorf("unexpected user %s or amr %v", claims.UserID, claims.AMR)
+	}
+
+	// a challenge is not a session
+	if _, err := VerifyToken(ctx, challenge, keyring, cfg, nil); err == nil {
+		t.Error("expected MFA challenge to be rejected as a session token")
+	}
+	// and a session is not a challenge
+	session, err := CreateSessionToken("testuser", []string{AMRPassword}, Grants{}, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateSessionToken() error = %v", err)
+	}
+	if _, err := VerifyMFAChallengeToken(ctx, session, keyring, cfg, nil); err == nil {
+		t.Error("expected session token to be rejected as an MFA challenge")
+	}
+
+	expired, _ := CreateMFAChallengeToken("testuser", nil, time.Nanosecond, keyring, TokenConfig{Leeway: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	if _, err := VerifyMFAChallengeToken(ctx, expired, keyring, TokenConfig{Leeway: time.Nanosecond}, nil); err == nil {
+		t.Error("expected expired MFA challenge to be rejected")
+	}
+}
+
+func TestVerifyMFAChallengeToken_Revoked(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	challenge, err := CreateMFAChallengeToken("testuser", []string{AMRPassword}, time.Minute, keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateMFAChallengeToken() error = %v", err)
+	}
+
+	revocations := mocks.NewMockRevocationStore(t)
+	revocations.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(true, nil).Once()
+
+	if _, err := VerifyMFAChallengeToken(context.Background(), challenge, keyring, TokenConfig{}, revocations); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked for a used challenge, got %v", err)
+	}
+}
+
+func TestCreateSessionToken_AMR(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	amr := []string{AMRPassword, AMROTP, AMRMFA}
+	session, err := CreateSessionToken("testuser", amr, Grants{}, keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateSessionToken() error = %v", err)
+	}
+
+	claims, err := VerifyToken(context.Background(), session, keyring, TokenConfig{}, nil)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if !reflect.DeepEqual(claims.AMR, amr) {
+		t.Errorf("expected amr %v, got %v", amr, claims.AMR)
+	}
+}
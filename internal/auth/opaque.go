@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// OpaqueTokenBytes is the amount of randomness in an opaque token.
+	OpaqueTokenBytes = 32
+)
+
+// NewOpaqueToken returns a random URL-safe token together with the hash that
+// should be persisted in its place.
+func NewOpaqueToken() (string, string, error) {
+	raw := make([]byte, OpaqueTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate opaque token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex encoded SHA-256 hash of an opaque token.
+// Opaque tokens carry enough entropy that a fast hash is sufficient.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
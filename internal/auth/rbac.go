@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"slices"
+	"sort"
+)
+
+// Permissions checked by the built-in routes.
+const (
+	PermissionCreate      = "create"
+	PermissionRolesRead   = "roles:read"
+	PermissionRolesAssign = "roles:assign"
+)
+
+// Scopes required by the built-in routes besides the permissions above.
+const (
+	// ScopeAccount lets a token manage the credentials of its user. Only
+	// session tokens carry it; it cannot be delegated to clients.
+	ScopeAccount = "account"
+	// ScopeAuthorize lets a token ask the policy decision endpoint for decisions.
+	ScopeAuthorize = "authorize"
+)
+
+// Grants are the roles of a user, the permissions they confer and the scopes
+// the user holds, as carried in session tokens.
+type Grants struct {
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+}
+
+// RoleSet maps each defined role to the permissions it grants.
+type RoleSet map[string][]string
+
+// Grants returns the defined roles among roles and the union of their
+// permissions, both sorted. Roles that are no longer defined grant nothing.
+func (rs RoleSet) Grants(roles []string) Grants {
+	var grants Grants
+	seen := map[string]bool{}
+	for _, role := range roles {
+		permissions, ok := rs[role]
+		if !ok || slices.Contains(grants.Roles, role) {
+			continue
+		}
+		grants.Roles = append(grants.Roles, role)
+		for _, permission := range permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				grants.Permissions = append(grants.Permissions, permission)
+			}
+		}
+	}
+	sort.Strings(grants.Roles)
+	sort.Strings(grants.Permissions)
+	return grants
+}
+
+// HasPermission reports whether the token grants permission.
+func (c *CustomClaims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRoleSet_Grants(t *testing.T) {
+	roles := RoleSet{
+		"admin":  {PermissionRolesAssign, PermissionRolesRead, PermissionCreate},
+		"editor": {PermissionCreate},
+		"user":   {},
+	}
+
+	tests := []struct {
+		name            string
+		roles           []string
+		wantRoles       []string
+		wantPermissions []string
+	}{
+		{name: "no roles"},
+		{name: "role without permissions", roles: []string{"user"}, wantRoles: []string{"user"}},
+		{
+			name:            "permissions are merged",
+			roles:           []string{"editor", "admin", "editor"},
+			wantRoles:       []string{"admin", "editor"},
+			wantPermissions: []string{PermissionCreate, PermissionRolesAssign, PermissionRolesRead},
+		},
+		{name: "undefined role grants nothing", roles: []string{"root", "editor"}, wantRoles: []string{"editor"}, wantPermissions: []string{PermissionCreate}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grants := roles.Grants(tt.roles)
+			if !reflect.DeepEqual(grants.Roles, tt.wantRoles) {
+				t.Errorf("expected roles %v, got %v", tt.wantRoles, grants.Roles)
+			}
+			if !reflect.DeepEqual(grants.Permissions, tt.wantPermissions) {
+				t.Errorf("expected permissions %v, got %v", tt.wantPermissions, grants.Permissions)
+			}
+		})
+	}
+}
+
+func TestCreateSessionToken_Grants(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	grants := Grants{Roles: []string{"editor"}, Permissions: []string{PermissionCreate}, Scopes: []string{PermissionCreate, "openid"}}
+	session, err := CreateSessionToken("testuser", []string{AMRPassword}, grants, keyring, TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateSessionToken() error = %v", err)
+	}
+
+	claims, err := VerifyToken(context.Background(), session, keyring, TokenConfig{}, nil)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if !reflect.DeepEqual(claims.Roles, grants.Roles) {
+		t.Errorf("expected roles %v, got %v", grants.Roles, claims.Roles)
+	}
+	if !claims.HasPermission(PermissionCreate) || claims.HasPermission(PermissionRolesAssign) {
+		t.Errorf("unexpected permissions %v", claims.Permissions)
+	}
+	// session tokens always carry the account scope
+	if claims.Scope != "account create openid" || !claims.HasScope("openid") || claims.HasScope("open") {
+		t.Errorf("unexpected scope %q", claims.Scope)
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// SessionCookieName is the cookie carrying the session token.
+	SessionCookieName = "session_token"
+	// AuthorizationHeader is the header carrying bearer tokens.
+	AuthorizationHeader = "Authorization"
+	// BearerPrefix prefixes bearer tokens in the Authorization header.
+	BearerPrefix = "Bearer "
+)
+
+// TokenFromRequest returns the session token of the request, read from an
+// "Authorization: Bearer" header or, failing that, the session_token cookie.
+// It returns an empty string if the request carries no token.
+func TokenFromRequest(req *http.Request) string {
+	header := req.Header.Get(AuthorizationHeader)
+	if len(header) > len(BearerPrefix) && strings.EqualFold(header[:len(BearerPrefix)], BearerPrefix) {
+		return strings.TrimSpace(header[len(BearerPrefix):])
+	}
+
+	if cookie, err := req.Cookie(SessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238 appendix B, base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 vectors, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current, _ := TOTPCode(rfc6238Secret, now)
+	previous, _ := TOTPCode(rfc6238Secret, now.Add(-TOTPPeriod))
+	stale, _ := TOTPCode(rfc6238Secret, now.Add(-2*TOTPPeriod))
+
+	tests := []struct {
+		name        string
+		secret      string
+		code        string
+		wantCounter int64
+		wantOK      bool
+	}{
+		{name: "current step", secret: rfc6238Secret, code: current, wantCounter: 37037037, wantOK: true},
+		{name: "previous step within skew", secret: rfc6238Secret, code: previous, wantCounter: 37037036, wantOK: true},
+		{name: "step outside skew", secret: rfc6238Secret, code: stale},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000"},
+		{name: "wrong length", secret: rfc6238Secret, code: current[:5]},
+		{name: "invalid secret", secret: "not base32!", code: current},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && counter != tt.wantCounter {
+				t.Errorf("ValidateTOTP() counter = %d, want %d", counter, tt.wantCounter)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	// 20 bytes encode to 32 base32 characters
+	if len(secret) != 32 {
+		t.Errorf("expected a 32 character secret, got %d", len(secret))
+	}
+
+	code, err := TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	if _, ok := ValidateTOTP(secret, code, time.Now()); !ok {
+		t.Error("expected a generated code to validate")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("sasuke", "test user", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("failed to parse provisioning URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("unexpected scheme %s or type %s", uri.Scheme, uri.Host)
+	}
+	if uri.Path != "/sasuke:test user" {
+		t.Errorf("unexpected label %s", uri.Path)
+	}
+
+	query := uri.Query()
+	if query.Get("secret") != rfc6238Secret || query.Get("issuer") != "sasuke" {
+		t.Errorf("unexpected secret %s or issuer %s", query.Get("secret"), query.Get("issuer"))
+	}
+	if query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected digits %s or period %s", query.Get("digits"), query.Get("period"))
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+)
+
+// AMRHardwareKey is the amr value of logins with a passkey (RFC 8176).
+const AMRHardwareKey = "hwk"
+
+const (
+	// WebAuthnRegistrationAudience and WebAuthnLoginAudience are the audiences
+	// of WebAuthn ceremony tokens, so a token of one ceremony cannot be used
+	// for the other or as a session.
+	WebAuthnRegistrationAudience = "webauthn-register" + ISSUER
+	WebAuthnLoginAudience        = "webauthn-login" + ISSUER
+	// DefaultWebAuthnCeremonyTTL is the ceremony lifetime when none is configured.
+	DefaultWebAuthnCeremonyTTL = 5 * time.Minute
+)
+
+// WebAuthnCeremonyClaims carry the challenge of a WebAuthn ceremony between
+// its begin and finish requests, so the server keeps no ceremony state.
+// UserID is empty for passkey logins, where the user is not known up front.
+type WebAuthnCeremonyClaims struct {
+	UserID    string `json:"userid,omitempty"`
+	Challenge string `json:"challenge"` // base64url
+	jwt.RegisteredClaims
+}
+
+// ChallengeBytes returns the decoded ceremony challenge.
+func (c *WebAuthnCeremonyClaims) ChallengeBytes() ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(c.Challenge)
+}
+
+// CreateWebAuthnCeremonyToken signs a ceremony token for audience holding challenge.
+func CreateWebAuthnCeremonyToken(audience, userName string, challenge []byte, ttl time.Duration, keyring *Keyring, cfg TokenConfig) (string, error) {
+	cfg = cfg.withDefaults()
+	if ttl <= 0 {
+		ttl = DefaultWebAuthnCeremonyTTL
+	}
+
+	now := time.Now()
+	claims := WebAuthnCeremonyClaims{
+		UserID:    userName,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    cfg.Issuer,
+			Subject:   userName,
+			Audience:  jwt.ClaimStrings{audience},
+			ID:        uuid.NewString(),
+		},
+	}
+	return signClaims(claims, keyring, cfg)
+}
+
+// VerifyWebAuthnCeremonyToken validates a ceremony token for audience. When
+// revocations is not nil, ceremonies that were already completed are rejected.
+func VerifyWebAuthnCeremonyToken(ctx context.Context, tokenString, audience string, keyring *Keyring, cfg TokenConfig, revocations interfaces.RevocationStore) (*WebAuthnCeremonyClaims, error) {
+	cfg = cfg.withDefaults()
+
+	token, err := jwt.ParseWithClaims(tokenString, &WebAuthnCeremonyClaims{}, keyring.keyFunc, jwt.WithValidMethods(SupportedAlgorithms),
+		jwt.WithIssuer(cfg.Issuer), jwt.WithAudience(audience), jwt.WithExpirationRequired(), jwt.WithLeeway(cfg.Leeway))
+	if err != nil {
+		return nil, fmt.Errorf("webauthn ceremony parsing error: %v", err)
+	}
+
+	claims, ok := token.Claims.(*WebAuthnCeremonyClaims)
+	if !ok || !token.Valid || claims.Challenge == "" {
+		return nil, fmt.Errorf("invalid webauthn ceremony or claims")
+	}
+
+	if revocations != nil && claims.ID != "" {
+		revoked, err := revocations.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check webauthn ceremony revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
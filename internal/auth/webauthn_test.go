@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWebAuthnCeremonyToken(t *testing.T) {
+	keyring, err := NewKeyring(testJwtPrivateKey)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	cfg := TokenConfig{}
+	ctx := context.Background()
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	ceremony, err := CreateWebAuthnCeremonyToken(WebAuthnRegistrationAudience, "testuser", challenge, time.Minute, keyring, cfg)
+	if err != nil {
+		t.Fatalf("CreateWebAuthnCeremonyToken() error = %v", err)
+	}
+
+	claims, err := VerifyWebAuthnCeremonyToken(ctx, ceremony, WebAuthnRegistrationAudience, keyring, cfg, nil)
+	if err != nil {
+		t.Fatalf("VerifyWebAuthnCeremonyToken() error = %v", err)
+	}
+	got, err := claims.ChallengeBytes()
+	if err != nil || !bytes.Equal(got, challenge) || claims.UserID != "testuser" {
+		t.Errorf("unexpected user %s or challenge %x", claims.UserID, got)
+	}
+
+	// a registration ceremony is neither a login ceremony nor a session
+	if _, err := VerifyWebAuthnCeremonyToken(ctx, ceremony, WebAuthnLoginAudience, keyring, cfg, nil); err == nil {
+		t.Error("expected registration ceremony to be rejected as a login ceremony")
+	}
+	if _, err := VerifyToken(ctx, ceremony, keyring, cfg, nil); err == nil {
+		t.Error("expected webauthn ceremony to be rejected as a session token")
+	}
+
+	expired, _ := CreateWebAuthnCeremonyToken(WebAuthnLoginAudience, "", challenge, time.Nanosecond, keyring, TokenConfig{Leeway: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	if _, err := VerifyWebAuthnCeremonyToken(ctx, expired, WebAuthnLoginAudience, keyring, TokenConfig{Leeway: time.Nanosecond}, nil); err == nil {
+		t.Error("expected expired webauthn ceremony to be rejected")
+	}
+}
@@ -0,0 +1,6 @@
+package constants
+
+const (
+	ClientsCollection            = "oauth_clients"
+	AuthorizationCodesCollection = "authorization_codes"
+)
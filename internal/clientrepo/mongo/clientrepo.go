@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haguru/sasuke/internal/clientrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+
+	"github.com/go-viper/mapstructure/v2"
+	mongoClient "github.com/haguru/sasuke/pkg/databases/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongosdk "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoClientRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewMongoClientRepository returns a new MongoClientRepository.
+func NewMongoClientRepository(dbClient interfaces.DBClient) (interfaces.ClientRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type MongoDBClient
+	if _, ok := dbClient.(*mongoClient.MongoDBClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a MongoDB client")
+	}
+	return &MongoClientRepository{dbClient: dbClient}, nil
+}
+
+// AddClient saves a new OAuth client to MongoDB via DBClient.
+func (r *MongoClientRepository) AddClient(ctx context.Context, client models.OAuthClient) error {
+	clientMap := make(map[string]interface{})
+	if err := mapstructure.Decode(client, &clientMap); err != nil {
+		return fmt.Errorf("failed to decode client model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.ClientsCollection, clientMap); err != nil {
+		return fmt.Errorf("failed to add client to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetClient fetches a client by ID, returns nil if not found.
+func (r *MongoClientRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	filter := map[string]any{"client_id": clientID}
+	err := r.dbClient.FindOne(ctx, constants.ClientsCollection, filter, &client)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // Client not found
+		}
+		return nil, fmt.Errorf("failed to get client from MongoDB: %w", err)
+	}
+
+	return &client, nil
+}
+
+// AddAuthorizationCode saves a new authorization code to MongoDB via DBClient.
+func (r *MongoClientRepository) AddAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	codeMap := make(map[string]interface{})
+	if err := mapstructure.Decode(code, &codeMap); err != nil {
+		return fmt.Errorf("failed to decode authorization code model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.AuthorizationCodesCollection, codeMap); err != nil {
+		return fmt.Errorf("failed to add authorization code to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetAuthorizationCode fetches an authorization code by hash, returns nil if not found.
+func (r *MongoClientRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	filter := map[string]any{"code_hash": codeHash}
+	err := r.dbClient.FindOne(ctx, constants.AuthorizationCodesCollection, filter, &code)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // Code not found
+		}
+		return nil, fmt.Errorf("failed to get authorization code from MongoDB: %w", err)
+	}
+
+	return &code, nil
+}
+
+// MarkAuthorizationCodeUsed flags the authorization code as used if it was not already.
+func (r *MongoClientRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) (bool, error) {
+	filter := map[string]any{"code_hash": codeHash, "used": false}
+	update := map[string]any{"$set": map[string]any{"used": true}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.AuthorizationCodesCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark authorization code used in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// EnsureIndices creates unique indices on the client ID and the authorization code hash.
+func (r *MongoClientRepository) EnsureIndices(ctx context.Context) error {
+	clientIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"client_id": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.ClientsCollection, clientIndex); err != nil {
+		return err
+	}
+
+	codeIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"code_hash": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	return r.dbClient.EnsureSchema(ctx, constants.AuthorizationCodesCollection, codeIndex)
+}
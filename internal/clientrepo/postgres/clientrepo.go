@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/haguru/sasuke/internal/clientrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/pkg/databases/postgres"
+)
+
+var ensureClientsSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS oauth_clients (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			client_id TEXT NOT NULL UNIQUE,
+			client_secret_hash TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL,
+			redirect_uris TEXT NOT NULL DEFAULT '',
+			scopes TEXT NOT NULL DEFAULT '',
+			grant_types TEXT NOT NULL DEFAULT '',
+			public_key TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL
+		);
+	`
+
+var ensureAuthorizationCodesSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS authorization_codes (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			code_hash TEXT NOT NULL UNIQUE,
+			client_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			redirect_uri TEXT NOT NULL,
+			scope TEXT NOT NULL DEFAULT '',
+			code_challenge TEXT NOT NULL,
+			code_challenge_method TEXT NOT NULL,
+			nonce TEXT NOT NULL DEFAULT '',
+			auth_time BIGINT NOT NULL DEFAULT 0,
+			expires_at BIGINT NOT NULL,
+			used BOOLEAN NOT NULL DEFAULT FALSE
+		);
+	`
+
+type PostgresClientRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewPostgresClientRepository returns a new PostgresClientRepository using the provided dbClient.
+func NewPostgresClientRepository(dbClient interfaces.DBClient) (interfaces.ClientRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type PostgresDatabaseClient
+	if _, ok := dbClient.(*postgres.PostgresDatabaseClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a PostgreSQL client")
+	}
+	return &PostgresClientRepository{dbClient: dbClient}, nil
+}
+
+// AddClient inserts an OAuth client.
+func (r *PostgresClientRepository) AddClient(ctx context.Context, client models.OAuthClient) error {
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(client, &doc); err != nil {
+		return fmt.Errorf("failed to decode client model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.ClientsCollection, doc); err != nil {
+		return fmt.Errorf("failed to add client to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetClient retrieves a client by ID and returns nil if it is not found.
+func (r *PostgresClientRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	filter := map[string]interface{}{"client_id": clientID}
+	if err := r.dbClient.FindOne(ctx, constants.ClientsCollection, filter, &client); err != nil {
+		return nil, fmt.Errorf("failed to get client from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if client.ClientID == "" {
+		return nil, nil
+	}
+	return &client, nil
+}
+
+// AddAuthorizationCode inserts an authorization code.
+func (r *PostgresClientRepository) AddAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(code, &doc); err != nil {
+		return fmt.Errorf("failed to decode authorization code model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.AuthorizationCodesCollection, doc); err != nil {
+		return fmt.Errorf("failed to add authorization code to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetAuthorizationCode retrieves an authorization code by hash and returns nil if it is not found.
+func (r *PostgresClientRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	filter := map[string]interface{}{"code_hash": codeHash}
+	if err := r.dbClient.FindOne(ctx, constants.AuthorizationCodesCollection, filter, &code); err != nil {
+		return nil, fmt.Errorf("failed to get authorization code from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if code.CodeHash == "" {
+		return nil, nil
+	}
+	return &code, nil
+}
+
+// MarkAuthorizationCodeUsed flags the authorization code as used if it was not already.
+func (r *PostgresClientRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) (bool, error) {
+	filter := map[string]interface{}{"code_hash": codeHash, "used": false}
+	update := map[string]interface{}{"used": true}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.AuthorizationCodesCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark authorization code used in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// EnsureIndices creates the clients and authorization codes tables.
+func (r *PostgresClientRepository) EnsureIndices(ctx context.Context) error {
+	if err := r.dbClient.EnsureSchema(ctx, constants.ClientsCollection, ensureClientsSchemaSQL); err != nil {
+		return err
+	}
+	return r.dbClient.EnsureSchema(ctx, constants.AuthorizationCodesCollection, ensureAuthorizationCodesSchemaSQL)
+}
@@ -0,0 +1,5 @@
+package constants
+
+const (
+	CredentialsCollection = "webauthn_credentials"
+)
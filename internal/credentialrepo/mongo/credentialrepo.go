@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haguru/sasuke/internal/credentialrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+
+	"github.com/go-viper/mapstructure/v2"
+	mongoClient "github.com/haguru/sasuke/pkg/databases/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongosdk "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCredentialRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewMongoCredentialRepository returns a new MongoCredentialRepository.
+func NewMongoCredentialRepository(dbClient interfaces.DBClient) (interfaces.CredentialRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type MongoDBClient
+	if _, ok := dbClient.(*mongoClient.MongoDBClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a MongoDB client")
+	}
+	return &MongoCredentialRepository{dbClient: dbClient}, nil
+}
+
+// AddCredential saves a new WebAuthn credential to MongoDB via DBClient.
+func (r *MongoCredentialRepository) AddCredential(ctx context.Context, credential models.WebAuthnCredential) error {
+	credentialMap := make(map[string]interface{})
+	if err := mapstructure.Decode(credential, &credentialMap); err != nil {
+		return fmt.Errorf("failed to decode credential model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.CredentialsCollection, credentialMap); err != nil {
+		return fmt.Errorf("failed to add credential to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetCredential fetches a credential by ID, returns nil if not found.
+func (r *MongoCredentialRepository) GetCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	filter := map[string]any{"credential_id": credentialID}
+	err := r.dbClient.FindOne(ctx, constants.CredentialsCollection, filter, &credential)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // Credential not found
+		}
+		return nil, fmt.Errorf("failed to get credential from MongoDB: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// ListCredentials fetches the credentials of a user.
+func (r *MongoCredentialRepository) ListCredentials(ctx context.Context, username string) ([]models.WebAuthnCredential, error) {
+	filter := map[string]any{"username": username}
+	docs, err := r.dbClient.FindMany(ctx, constants.CredentialsCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials from MongoDB: %w", err)
+	}
+
+	credentials := make([]models.WebAuthnCredential, 0, len(docs))
+	for _, doc := range docs {
+		var credential models.WebAuthnCredential
+		if err := mapstructure.Decode(doc, &credential); err != nil {
+			return nil, fmt.Errorf("failed to decode credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// UpdateSignCount sets the sign count and last use of the credential if its sign count is still previous.
+func (r *MongoCredentialRepository) UpdateSignCount(ctx context.Context, credentialID string, previous, signCount, lastUsedAt int64) (bool, error) {
+	filter := map[string]any{"credential_id": credentialID, "sign_count": previous}
+	update := map[string]any{"$set": map[string]any{"sign_count": signCount, "last_used_at": lastUsedAt}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.CredentialsCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update credential sign count in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// EnsureIndices creates a unique index on the credential ID and an index on the username.
+func (r *MongoCredentialRepository) EnsureIndices(ctx context.Context) error {
+	credentialIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"credential_id": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.CredentialsCollection, credentialIndex); err != nil {
+		return err
+	}
+
+	usernameIndex := mongosdk.IndexModel{
+		Keys: bson.M{"username": 1},
+	}
+	return r.dbClient.EnsureSchema(ctx, constants.CredentialsCollection, usernameIndex)
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/haguru/sasuke/internal/credentialrepo/constants"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/pkg/databases/postgres"
+)
+
+var ensureCredentialsSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS webauthn_credentials (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			credential_id TEXT NOT NULL UNIQUE,
+			username TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			public_key TEXT NOT NULL,
+			sign_count BIGINT NOT NULL DEFAULT 0,
+			aaguid TEXT NOT NULL DEFAULT '',
+			attestation_format TEXT NOT NULL DEFAULT '',
+			transports TEXT NOT NULL DEFAULT '',
+			created_at BIGINT NOT NULL,
+			last_used_at BIGINT NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS webauthn_credentials_username_idx ON webauthn_credentials (username);
+	`
+
+type PostgresCredentialRepository struct {
+	dbClient interfaces.DBClient
+}
+
+// NewPostgresCredentialRepository returns a new PostgresCredentialRepository using the provided dbClient.
+func NewPostgresCredentialRepository(dbClient interfaces.DBClient) (interfaces.CredentialRepository, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type PostgresDatabaseClient
+	if _, ok := dbClient.(*postgres.PostgresDatabaseClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a PostgreSQL client")
+	}
+	return &PostgresCredentialRepository{dbClient: dbClient}, nil
+}
+
+// AddCredential inserts a WebAuthn credential.
+func (r *PostgresCredentialRepository) AddCredential(ctx context.Context, credential models.WebAuthnCredential) error {
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(credential, &doc); err != nil {
+		return fmt.Errorf("failed to decode credential model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.CredentialsCollection, doc); err != nil {
+		return fmt.Errorf("failed to add credential to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetCredential retrieves a credential by ID and returns nil if it is not found.
+func (r *PostgresCredentialRepository) GetCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	filter := map[string]interface{}{"credential_id": credentialID}
+	if err := r.dbClient.FindOne(ctx, constants.CredentialsCollection, filter, &credential); err != nil {
+		return nil, fmt.Errorf("failed to get credential from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if credential.CredentialID == "" {
+		return nil, nil
+	}
+	return &credential, nil
+}
+
+// ListCredentials retrieves the credentials of a user.
+func (r *PostgresCredentialRepository) ListCredentials(ctx context.Context, username string) ([]models.WebAuthnCredential, error) {
+	filter := map[string]interface{}{"username": username}
+	rows, err := r.dbClient.FindMany(ctx, constants.CredentialsCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials from PostgreSQL: %w", err)
+	}
+
+	credentials := make([]models.WebAuthnCredential, 0, len(rows))
+	for _, row := range rows {
+		var credential models.WebAuthnCredential
+		if err := mapstructure.Decode(row, &credential); err != nil {
+			return nil, fmt.Errorf("failed to decode credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// UpdateSignCount sets the sign count and last use of the credential if its sign count is still previous.
+func (r *PostgresCredentialRepository) UpdateSignCount(ctx context.Context, credentialID string, previous, signCount, lastUsedAt int64) (bool, error) {
+	filter := map[string]interface{}{"credential_id": credentialID, "sign_count": previous}
+	update := map[string]interface{}{"sign_count": signCount, "last_used_at": lastUsedAt}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.CredentialsCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update credential sign count in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// EnsureIndices creates the WebAuthn credentials table.
+func (r *PostgresCredentialRepository) EnsureIndices(ctx context.Context) error {
+	return r.dbClient.EnsureSchema(ctx, constants.CredentialsCollection, ensureCredentialsSchemaSQL)
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// APIKeyRepository stores the hashed API keys of users.
+type APIKeyRepository interface {
+	// AddAPIKey stores a new API key.
+	AddAPIKey(ctx context.Context, key models.APIKey) error
+	// GetAPIKey returns the API key with the given hash, or nil if not found.
+	GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// ListAPIKeys returns the API keys of a user.
+	ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error)
+	// DeleteAPIKey deletes the API key keyID of a user. It returns false if
+	// the user has no such key.
+	DeleteAPIKey(ctx context.Context, username, keyID string) (bool, error)
+	// SetAPIKeyLastUsed records when an API key was last used.
+	SetAPIKeyLastUsed(ctx context.Context, keyID string, lastUsedAt int64) error
+
+	EnsureIndices(ctx context.Context) error
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// ClientRepository stores OAuth 2.0 clients and the authorization codes issued to them.
+type ClientRepository interface {
+	// AddClient registers a new client.
+	AddClient(ctx context.Context, client models.OAuthClient) error
+	// GetClient returns the client with the given ID, or nil if not found.
+	GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+
+	// AddAuthorizationCode stores a new authorization code.
+	AddAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error
+	// GetAuthorizationCode returns the authorization code with the given hash, or nil if not found.
+	GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error)
+	// MarkAuthorizationCodeUsed atomically flags an unused authorization code as used.
+	// It returns false if the code had already been used.
+	MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) (bool, error)
+
+	EnsureIndices(ctx context.Context) error
+}
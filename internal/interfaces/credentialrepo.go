@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// CredentialRepository stores the WebAuthn credentials (passkeys) of users.
+type CredentialRepository interface {
+	// AddCredential registers a new credential.
+	AddCredential(ctx context.Context, credential models.WebAuthnCredential) error
+	// GetCredential returns the credential with the given ID, or nil if not found.
+	GetCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	// ListCredentials returns the credentials of a user.
+	ListCredentials(ctx context.Context, username string) ([]models.WebAuthnCredential, error)
+	// UpdateSignCount atomically replaces the sign count of a credential if it is
+	// still previous and records its use. It returns false if the count had changed.
+	UpdateSignCount(ctx context.Context, credentialID string, previous, signCount, lastUsedAt int64) (bool, error)
+
+	EnsureIndices(ctx context.Context) error
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// Mailer delivers emails such as password reset links to users.
+type Mailer interface {
+	// Send delivers message to message.To.
+	Send(ctx context.Context, message models.MailMessage) error
+}
@@ -38,6 +38,120 @@ func (_m *MockUserRepository) EXPECT() *MockUserRepository_Expecter {
 	return &MockUserRepository_Expecter{mock: &_m.Mock}
 }
 
+// AddPasswordResetToken provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) AddPasswordResetToken(ctx context.Context, token models.PasswordResetToken) error {
+	ret := _mock.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddPasswordResetToken")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.PasswordResetToken) error); ok {
+		r0 = returnFunc(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_AddPasswordResetToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddPasswordResetToken'
+type MockUserRepository_AddPasswordResetToken_Call struct {
+	*mock.Call
+}
+
+// AddPasswordResetToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token models.PasswordResetToken
+func (_e *MockUserRepository_Expecter) AddPasswordResetToken(ctx interface{}, token interface{}) *MockUserRepository_AddPasswordResetToken_Call {
+	return &MockUserRepository_AddPasswordResetToken_Call{Call: _e.mock.On("AddPasswordResetToken", ctx, token)}
+}
+
+func (_c *MockUserRepository_AddPasswordResetToken_Call) Run(run func(ctx context.Context, token models.PasswordResetToken)) *MockUserRepository_AddPasswordResetToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.PasswordResetToken
+		if args[1] != nil {
+			arg1 = args[1].(models.PasswordResetToken)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_AddPasswordResetToken_Call) Return(err error) *MockUserRepository_AddPasswordResetToken_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_AddPasswordResetToken_Call) RunAndReturn(run func(ctx context.Context, token models.PasswordResetToken) error) *MockUserRepository_AddPasswordResetToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddRefreshToken provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	ret := _mock.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddRefreshToken")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.RefreshToken) error); ok {
+		r0 = returnFunc(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_AddRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRefreshToken'
+type MockUserRepository_AddRefreshToken_Call struct {
+	*mock.Call
+}
+
+// AddRefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token models.RefreshToken
+func (_e *MockUserRepository_Expecter) AddRefreshToken(ctx interface{}, token interface{}) *MockUserRepository_AddRefreshToken_Call {
+	return &MockUserRepository_AddRefreshToken_Call{Call: _e.mock.On("AddRefreshToken", ctx, token)}
+}
+
+func (_c *MockUserRepository_AddRefreshToken_Call) Run(run func(ctx context.Context, token models.RefreshToken)) *MockUserRepository_AddRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.RefreshToken
+		if args[1] != nil {
+			arg1 = args[1].(models.RefreshToken)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_AddRefreshToken_Call) Return(err error) *MockUserRepository_AddRefreshToken_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_AddRefreshToken_Call) RunAndReturn(run func(ctx context.Context, token models.RefreshToken) error) *MockUserRepository_AddRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // AddUser provides a mock function for the type MockUserRepository
 func (_mock *MockUserRepository) AddUser(ctx context.Context, user models.User) (string, error) {
 	ret := _mock.Called(ctx, user)
@@ -155,6 +269,63 @@ func (_c *MockUserRepository_Close_Call) RunAndReturn(run func(ctx context.Conte
 	return _c
 }
 
+// DeletePasswordResetTokens provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) DeletePasswordResetTokens(ctx context.Context, username string) error {
+	ret := _mock.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePasswordResetTokens")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_DeletePasswordResetTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeletePasswordResetTokens'
+type MockUserRepository_DeletePasswordResetTokens_Call struct {
+	*mock.Call
+}
+
+// DeletePasswordResetTokens is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *MockUserRepository_Expecter) DeletePasswordResetTokens(ctx interface{}, username interface{}) *MockUserRepository_DeletePasswordResetTokens_Call {
+	return &MockUserRepository_DeletePasswordResetTokens_Call{Call: _e.mock.On("DeletePasswordResetTokens", ctx, username)}
+}
+
+func (_c *MockUserRepository_DeletePasswordResetTokens_Call) Run(run func(ctx context.Context, username string)) *MockUserRepository_DeletePasswordResetTokens_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_DeletePasswordResetTokens_Call) Return(err error) *MockUserRepository_DeletePasswordResetTokens_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_DeletePasswordResetTokens_Call) RunAndReturn(run func(ctx context.Context, username string) error) *MockUserRepository_DeletePasswordResetTokens_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // EnsureIndices provides a mock function for the type MockUserRepository
 func (_mock *MockUserRepository) EnsureIndices(ctx context.Context) error {
 	ret := _mock.Called(ctx)
@@ -206,47 +377,183 @@ func (_c *MockUserRepository_EnsureIndices_Call) RunAndReturn(run func(ctx conte
 	return _c
 }
 
-// GetUserByUsername provides a mock function for the type MockUserRepository
-func (_mock *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	ret := _mock.Called(ctx, username)
+// GetPasswordResetToken provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	ret := _mock.Called(ctx, tokenHash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByUsername")
+		panic("no return value specified for GetPasswordResetToken")
+	}
+
+	var r0 *models.PasswordResetToken
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.PasswordResetToken, error)); ok {
+		return returnFunc(ctx, tokenHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.PasswordResetToken); ok {
+		r0 = returnFunc(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PasswordResetToken)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_GetPasswordResetToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPasswordResetToken'
+type MockUserRepository_GetPasswordResetToken_Call struct {
+	*mock.Call
+}
+
+// GetPasswordResetToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+func (_e *MockUserRepository_Expecter) GetPasswordResetToken(ctx interface{}, tokenHash interface{}) *MockUserRepository_GetPasswordResetToken_Call {
+	return &MockUserRepository_GetPasswordResetToken_Call{Call: _e.mock.On("GetPasswordResetToken", ctx, tokenHash)}
+}
+
+func (_c *MockUserRepository_GetPasswordResetToken_Call) Run(run func(ctx context.Context, tokenHash string)) *MockUserRepository_GetPasswordResetToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetPasswordResetToken_Call) Return(passwordResetToken *models.PasswordResetToken, err error) *MockUserRepository_GetPasswordResetToken_Call {
+	_c.Call.Return(passwordResetToken, err)
+	return _c
+}
+
+func (_c *MockUserRepository_GetPasswordResetToken_Call) RunAndReturn(run func(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)) *MockUserRepository_GetPasswordResetToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRefreshToken provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ret := _mock.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefreshToken")
+	}
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.RefreshToken, error)); ok {
+		return returnFunc(ctx, tokenHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.RefreshToken); ok {
+		r0 = returnFunc(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_GetRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRefreshToken'
+type MockUserRepository_GetRefreshToken_Call struct {
+	*mock.Call
+}
+
+// GetRefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+func (_e *MockUserRepository_Expecter) GetRefreshToken(ctx interface{}, tokenHash interface{}) *MockUserRepository_GetRefreshToken_Call {
+	return &MockUserRepository_GetRefreshToken_Call{Call: _e.mock.On("GetRefreshToken", ctx, tokenHash)}
+}
+
+func (_c *MockUserRepository_GetRefreshToken_Call) Run(run func(ctx context.Context, tokenHash string)) *MockUserRepository_GetRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetRefreshToken_Call) Return(refreshToken *models.RefreshToken, err error) *MockUserRepository_GetRefreshToken_Call {
+	_c.Call.Return(refreshToken, err)
+	return _c
+}
+
+func (_c *MockUserRepository_GetRefreshToken_Call) RunAndReturn(run func(ctx context.Context, tokenHash string) (*models.RefreshToken, error)) *MockUserRepository_GetRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByEmail provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
 	}
 
 	var r0 *models.User
 	var r1 error
 	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
-		return returnFunc(ctx, username)
+		return returnFunc(ctx, email)
 	}
 	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
-		r0 = returnFunc(ctx, username)
+		r0 = returnFunc(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, username)
+		r1 = returnFunc(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockUserRepository_GetUserByUsername_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByUsername'
-type MockUserRepository_GetUserByUsername_Call struct {
+// MockUserRepository_GetUserByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByEmail'
+type MockUserRepository_GetUserByEmail_Call struct {
 	*mock.Call
 }
 
-// GetUserByUsername is a helper method to define mock.On call
+// GetUserByEmail is a helper method to define mock.On call
 //   - ctx context.Context
-//   - username string
-func (_e *MockUserRepository_Expecter) GetUserByUsername(ctx interface{}, username interface{}) *MockUserRepository_GetUserByUsername_Call {
-	return &MockUserRepository_GetUserByUsername_Call{Call: _e.mock.On("GetUserByUsername", ctx, username)}
+//   - email string
+func (_e *MockUserRepository_Expecter) GetUserByEmail(ctx interface{}, email interface{}) *MockUserRepository_GetUserByEmail_Call {
+	return &MockUserRepository_GetUserByEmail_Call{Call: _e.mock.On("GetUserByEmail", ctx, email)}
 }
 
-func (_c *MockUserRepository_GetUserByUsername_Call) Run(run func(ctx context.Context, username string)) *MockUserRepository_GetUserByUsername_Call {
+func (_c *MockUserRepository_GetUserByEmail_Call) Run(run func(ctx context.Context, email string)) *MockUserRepository_GetUserByEmail_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -264,12 +571,707 @@ func (_c *MockUserRepository_GetUserByUsername_Call) Run(run func(ctx context.Co
 	return _c
 }
 
-func (_c *MockUserRepository_GetUserByUsername_Call) Return(user *models.User, err error) *MockUserRepository_GetUserByUsername_Call {
+func (_c *MockUserRepository_GetUserByEmail_Call) Return(user *models.User, err error) *MockUserRepository_GetUserByEmail_Call {
 	_c.Call.Return(user, err)
 	return _c
 }
 
-func (_c *MockUserRepository_GetUserByUsername_Call) RunAndReturn(run func(ctx context.Context, username string) (*models.User, error)) *MockUserRepository_GetUserByUsername_Call {
+func (_c *MockUserRepository_GetUserByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*models.User, error)) *MockUserRepository_GetUserByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByUsername provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ret := _mock.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByUsername")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return returnFunc(ctx, username)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = returnFunc(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_GetUserByUsername_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByUsername'
+type MockUserRepository_GetUserByUsername_Call struct {
+	*mock.Call
+}
+
+// GetUserByUsername is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *MockUserRepository_Expecter) GetUserByUsername(ctx interface{}, username interface{}) *MockUserRepository_GetUserByUsername_Call {
+	return &MockUserRepository_GetUserByUsername_Call{Call: _e.mock.On("GetUserByUsername", ctx, username)}
+}
+
+func (_c *MockUserRepository_GetUserByUsername_Call) Run(run func(ctx context.Context, username string)) *MockUserRepository_GetUserByUsername_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_GetUserByUsername_Call) Return(user *models.User, err error) *MockUserRepository_GetUserByUsername_Call {
+	_c.Call.Return(user, err)
+	return _c
+}
+
+func (_c *MockUserRepository_GetUserByUsername_Call) RunAndReturn(run func(ctx context.Context, username string) (*models.User, error)) *MockUserRepository_GetUserByUsername_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkPasswordResetTokenUsed provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	ret := _mock.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkPasswordResetTokenUsed")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return returnFunc(ctx, tokenHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = returnFunc(ctx, tokenHash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_MarkPasswordResetTokenUsed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkPasswordResetTokenUsed'
+type MockUserRepository_MarkPasswordResetTokenUsed_Call struct {
+	*mock.Call
+}
+
+// MarkPasswordResetTokenUsed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+func (_e *MockUserRepository_Expecter) MarkPasswordResetTokenUsed(ctx interface{}, tokenHash interface{}) *MockUserRepository_MarkPasswordResetTokenUsed_Call {
+	return &MockUserRepository_MarkPasswordResetTokenUsed_Call{Call: _e.mock.On("MarkPasswordResetTokenUsed", ctx, tokenHash)}
+}
+
+func (_c *MockUserRepository_MarkPasswordResetTokenUsed_Call) Run(run func(ctx context.Context, tokenHash string)) *MockUserRepository_MarkPasswordResetTokenUsed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_MarkPasswordResetTokenUsed_Call) Return(b bool, err error) *MockUserRepository_MarkPasswordResetTokenUsed_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_MarkPasswordResetTokenUsed_Call) RunAndReturn(run func(ctx context.Context, tokenHash string) (bool, error)) *MockUserRepository_MarkPasswordResetTokenUsed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkRefreshTokenUsed provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	ret := _mock.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRefreshTokenUsed")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return returnFunc(ctx, tokenHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = returnFunc(ctx, tokenHash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_MarkRefreshTokenUsed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkRefreshTokenUsed'
+type MockUserRepository_MarkRefreshTokenUsed_Call struct {
+	*mock.Call
+}
+
+// MarkRefreshTokenUsed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+func (_e *MockUserRepository_Expecter) MarkRefreshTokenUsed(ctx interface{}, tokenHash interface{}) *MockUserRepository_MarkRefreshTokenUsed_Call {
+	return &MockUserRepository_MarkRefreshTokenUsed_Call{Call: _e.mock.On("MarkRefreshTokenUsed", ctx, tokenHash)}
+}
+
+func (_c *MockUserRepository_MarkRefreshTokenUsed_Call) Run(run func(ctx context.Context, tokenHash string)) *MockUserRepository_MarkRefreshTokenUsed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_MarkRefreshTokenUsed_Call) Return(b bool, err error) *MockUserRepository_MarkRefreshTokenUsed_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_MarkRefreshTokenUsed_Call) RunAndReturn(run func(ctx context.Context, tokenHash string) (bool, error)) *MockUserRepository_MarkRefreshTokenUsed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordLoginFailure provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) RecordLoginFailure(ctx context.Context, username string, previous int64, failures int64, lockedUntil int64) (bool, error) {
+	ret := _mock.Called(ctx, username, previous, failures, lockedUntil)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLoginFailure")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64, int64, int64) (bool, error)); ok {
+		return returnFunc(ctx, username, previous, failures, lockedUntil)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64, int64, int64) bool); ok {
+		r0 = returnFunc(ctx, username, previous, failures, lockedUntil)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64, int64, int64) error); ok {
+		r1 = returnFunc(ctx, username, previous, failures, lockedUntil)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_RecordLoginFailure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordLoginFailure'
+type MockUserRepository_RecordLoginFailure_Call struct {
+	*mock.Call
+}
+
+// RecordLoginFailure is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - previous int64
+//   - failures int64
+//   - lockedUntil int64
+func (_e *MockUserRepository_Expecter) RecordLoginFailure(ctx interface{}, username interface{}, previous interface{}, failures interface{}, lockedUntil interface{}) *MockUserRepository_RecordLoginFailure_Call {
+	return &MockUserRepository_RecordLoginFailure_Call{Call: _e.mock.On("RecordLoginFailure", ctx, username, previous, failures, lockedUntil)}
+}
+
+func (_c *MockUserRepository_RecordLoginFailure_Call) Run(run func(ctx context.Context, username string, previous int64, failures int64, lockedUntil int64)) *MockUserRepository_RecordLoginFailure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		var arg3 int64
+		if args[3] != nil {
+			arg3 = args[3].(int64)
+		}
+		var arg4 int64
+		if args[4] != nil {
+			arg4 = args[4].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RecordLoginFailure_Call) Return(b bool, err error) *MockUserRepository_RecordLoginFailure_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_RecordLoginFailure_Call) RunAndReturn(run func(ctx context.Context, username string, previous int64, failures int64, lockedUntil int64) (bool, error)) *MockUserRepository_RecordLoginFailure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeRefreshTokenFamily provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ret := _mock.Called(ctx, familyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_RevokeRefreshTokenFamily_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeRefreshTokenFamily'
+type MockUserRepository_RevokeRefreshTokenFamily_Call struct {
+	*mock.Call
+}
+
+// RevokeRefreshTokenFamily is a helper method to define mock.On call
+//   - ctx context.Context
+//   - familyID string
+func (_e *MockUserRepository_Expecter) RevokeRefreshTokenFamily(ctx interface{}, familyID interface{}) *MockUserRepository_RevokeRefreshTokenFamily_Call {
+	return &MockUserRepository_RevokeRefreshTokenFamily_Call{Call: _e.mock.On("RevokeRefreshTokenFamily", ctx, familyID)}
+}
+
+func (_c *MockUserRepository_RevokeRefreshTokenFamily_Call) Run(run func(ctx context.Context, familyID string)) *MockUserRepository_RevokeRefreshTokenFamily_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeRefreshTokenFamily_Call) Return(err error) *MockUserRepository_RevokeRefreshTokenFamily_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeRefreshTokenFamily_Call) RunAndReturn(run func(ctx context.Context, familyID string) error) *MockUserRepository_RevokeRefreshTokenFamily_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeUserRefreshTokens provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) RevokeUserRefreshTokens(ctx context.Context, username string) error {
+	ret := _mock.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserRefreshTokens")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_RevokeUserRefreshTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeUserRefreshTokens'
+type MockUserRepository_RevokeUserRefreshTokens_Call struct {
+	*mock.Call
+}
+
+// RevokeUserRefreshTokens is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *MockUserRepository_Expecter) RevokeUserRefreshTokens(ctx interface{}, username interface{}) *MockUserRepository_RevokeUserRefreshTokens_Call {
+	return &MockUserRepository_RevokeUserRefreshTokens_Call{Call: _e.mock.On("RevokeUserRefreshTokens", ctx, username)}
+}
+
+func (_c *MockUserRepository_RevokeUserRefreshTokens_Call) Run(run func(ctx context.Context, username string)) *MockUserRepository_RevokeUserRefreshTokens_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeUserRefreshTokens_Call) Return(err error) *MockUserRepository_RevokeUserRefreshTokens_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_RevokeUserRefreshTokens_Call) RunAndReturn(run func(ctx context.Context, username string) error) *MockUserRepository_RevokeUserRefreshTokens_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetRecoveryCodes provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) SetRecoveryCodes(ctx context.Context, username string, previous string, codes string) (bool, error) {
+	ret := _mock.Called(ctx, username, previous, codes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRecoveryCodes")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (bool, error)); ok {
+		return returnFunc(ctx, username, previous, codes)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) bool); ok {
+		r0 = returnFunc(ctx, username, previous, codes)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, username, previous, codes)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_SetRecoveryCodes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetRecoveryCodes'
+type MockUserRepository_SetRecoveryCodes_Call struct {
+	*mock.Call
+}
+
+// SetRecoveryCodes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - previous string
+//   - codes string
+func (_e *MockUserRepository_Expecter) SetRecoveryCodes(ctx interface{}, username interface{}, previous interface{}, codes interface{}) *MockUserRepository_SetRecoveryCodes_Call {
+	return &MockUserRepository_SetRecoveryCodes_Call{Call: _e.mock.On("SetRecoveryCodes", ctx, username, previous, codes)}
+}
+
+func (_c *MockUserRepository_SetRecoveryCodes_Call) Run(run func(ctx context.Context, username string, previous string, codes string)) *MockUserRepository_SetRecoveryCodes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_SetRecoveryCodes_Call) Return(b bool, err error) *MockUserRepository_SetRecoveryCodes_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_SetRecoveryCodes_Call) RunAndReturn(run func(ctx context.Context, username string, previous string, codes string) (bool, error)) *MockUserRepository_SetRecoveryCodes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTOTPLastCounter provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) SetTOTPLastCounter(ctx context.Context, username string, previous int64, counter int64) (bool, error) {
+	ret := _mock.Called(ctx, username, previous, counter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTOTPLastCounter")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64, int64) (bool, error)); ok {
+		return returnFunc(ctx, username, previous, counter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64, int64) bool); ok {
+		r0 = returnFunc(ctx, username, previous, counter)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64, int64) error); ok {
+		r1 = returnFunc(ctx, username, previous, counter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_SetTOTPLastCounter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTOTPLastCounter'
+type MockUserRepository_SetTOTPLastCounter_Call struct {
+	*mock.Call
+}
+
+// SetTOTPLastCounter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - previous int64
+//   - counter int64
+func (_e *MockUserRepository_Expecter) SetTOTPLastCounter(ctx interface{}, username interface{}, previous interface{}, counter interface{}) *MockUserRepository_SetTOTPLastCounter_Call {
+	return &MockUserRepository_SetTOTPLastCounter_Call{Call: _e.mock.On("SetTOTPLastCounter", ctx, username, previous, counter)}
+}
+
+func (_c *MockUserRepository_SetTOTPLastCounter_Call) Run(run func(ctx context.Context, username string, previous int64, counter int64)) *MockUserRepository_SetTOTPLastCounter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		var arg3 int64
+		if args[3] != nil {
+			arg3 = args[3].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_SetTOTPLastCounter_Call) Return(b bool, err error) *MockUserRepository_SetTOTPLastCounter_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_SetTOTPLastCounter_Call) RunAndReturn(run func(ctx context.Context, username string, previous int64, counter int64) (bool, error)) *MockUserRepository_SetTOTPLastCounter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserRoles provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) SetUserRoles(ctx context.Context, username string, previous string, roles string) (bool, error) {
+	ret := _mock.Called(ctx, username, previous, roles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRoles")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (bool, error)); ok {
+		return returnFunc(ctx, username, previous, roles)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) bool); ok {
+		r0 = returnFunc(ctx, username, previous, roles)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, username, previous, roles)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUserRepository_SetUserRoles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserRoles'
+type MockUserRepository_SetUserRoles_Call struct {
+	*mock.Call
+}
+
+// SetUserRoles is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - previous string
+//   - roles string
+func (_e *MockUserRepository_Expecter) SetUserRoles(ctx interface{}, username interface{}, previous interface{}, roles interface{}) *MockUserRepository_SetUserRoles_Call {
+	return &MockUserRepository_SetUserRoles_Call{Call: _e.mock.On("SetUserRoles", ctx, username, previous, roles)}
+}
+
+func (_c *MockUserRepository_SetUserRoles_Call) Run(run func(ctx context.Context, username string, previous string, roles string)) *MockUserRepository_SetUserRoles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_SetUserRoles_Call) Return(b bool, err error) *MockUserRepository_SetUserRoles_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUserRepository_SetUserRoles_Call) RunAndReturn(run func(ctx context.Context, username string, previous string, roles string) (bool, error)) *MockUserRepository_SetUserRoles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateUser provides a mock function for the type MockUserRepository
+func (_mock *MockUserRepository) UpdateUser(ctx context.Context, username string, fields map[string]interface{}) error {
+	ret := _mock.Called(ctx, username, fields)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUser")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}) error); ok {
+		r0 = returnFunc(ctx, username, fields)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockUserRepository_UpdateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateUser'
+type MockUserRepository_UpdateUser_Call struct {
+	*mock.Call
+}
+
+// UpdateUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - fields map[string]interface{}
+func (_e *MockUserRepository_Expecter) UpdateUser(ctx interface{}, username interface{}, fields interface{}) *MockUserRepository_UpdateUser_Call {
+	return &MockUserRepository_UpdateUser_Call{Call: _e.mock.On("UpdateUser", ctx, username, fields)}
+}
+
+func (_c *MockUserRepository_UpdateUser_Call) Run(run func(ctx context.Context, username string, fields map[string]interface{})) *MockUserRepository_UpdateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 map[string]interface{}
+		if args[2] != nil {
+			arg2 = args[2].(map[string]interface{})
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUserRepository_UpdateUser_Call) Return(err error) *MockUserRepository_UpdateUser_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockUserRepository_UpdateUser_Call) RunAndReturn(run func(ctx context.Context, username string, fields map[string]interface{}) error) *MockUserRepository_UpdateUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
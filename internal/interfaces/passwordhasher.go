@@ -0,0 +1,14 @@
+package interfaces
+
+// PasswordHasher turns passwords into self-describing hash strings that
+// record the algorithm and parameters used.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded, which may have been
+	// produced by any supported algorithm.
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded was produced with another
+	// algorithm or other parameters than Hash uses now.
+	NeedsRehash(encoded string) bool
+}
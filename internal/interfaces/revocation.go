@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationStore records the IDs (jti) of revoked tokens and the subjects
+// whose earlier tokens were all revoked. Entries only need to be kept until the
+// tokens would have expired on their own.
+type RevocationStore interface {
+	// Revoke marks the token ID as revoked until expiresAt.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked reports whether the token ID has been revoked.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeSubject revokes every token of subject issued at or before
+	// issuedBefore, compared in whole seconds like token issue times, so
+	// tokens issued in the same second are revoked too. The entry is kept
+	// until expiresAt.
+	RevokeSubject(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error
+	// IsSubjectRevoked reports whether tokens of subject issued at issuedAt
+	// have been revoked.
+	IsSubjectRevoked(ctx context.Context, subject string, issuedAt time.Time) (bool, error)
+	// EnsureIndices prepares the backing storage, if any.
+	EnsureIndices(ctx context.Context) error
+}
@@ -11,6 +11,46 @@ import (
 type UserRepository interface {
 	AddUser(ctx context.Context, user models.User) (string, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetUserByEmail returns the user with the given email address, or nil if not found.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	// UpdateUser sets the given fields of the user.
+	UpdateUser(ctx context.Context, username string, fields map[string]interface{}) error
+	// SetTOTPLastCounter atomically replaces the last accepted TOTP time step
+	// if it still equals previous. It returns false if it had changed.
+	SetTOTPLastCounter(ctx context.Context, username string, previous, counter int64) (bool, error)
+	// SetRecoveryCodes atomically replaces the recovery code hashes of the user
+	// if they still equal previous. It returns false if they had changed.
+	SetRecoveryCodes(ctx context.Context, username, previous, codes string) (bool, error)
+	// RecordLoginFailure atomically sets the failed login count of the user to
+	// failures and locks logins until lockedUntil if the count still equals
+	// previous. It returns false if it had changed.
+	RecordLoginFailure(ctx context.Context, username string, previous, failures, lockedUntil int64) (bool, error)
+	// SetUserRoles atomically replaces the space-delimited roles of the user
+	// if they still equal previous. It returns false if they had changed.
+	SetUserRoles(ctx context.Context, username, previous, roles string) (bool, error)
+
+	// AddRefreshToken stores a new refresh token.
+	AddRefreshToken(ctx context.Context, token models.RefreshToken) error
+	// GetRefreshToken returns the refresh token with the given hash, or nil if not found.
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkRefreshTokenUsed atomically flags an unused refresh token as used.
+	// It returns false if the token had already been used.
+	MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error)
+	// RevokeRefreshTokenFamily deletes every refresh token in the family.
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	// RevokeUserRefreshTokens deletes every refresh token of the user.
+	RevokeUserRefreshTokens(ctx context.Context, username string) error
+
+	// AddPasswordResetToken stores a new password reset token.
+	AddPasswordResetToken(ctx context.Context, token models.PasswordResetToken) error
+	// GetPasswordResetToken returns the password reset token with the given hash, or nil if not found.
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// MarkPasswordResetTokenUsed atomically flags an unused password reset token as used.
+	// It returns false if the token had already been used.
+	MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) (bool, error)
+	// DeletePasswordResetTokens deletes every password reset token of the user.
+	DeletePasswordResetTokens(ctx context.Context, username string) error
+
 	EnsureIndices(ctx context.Context) error
 	Close(ctx context.Context) error
 }
@@ -0,0 +1,49 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/haguru/sasuke/internal/mailer"
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// FileMailer appends formatted messages to a file instead of delivering them.
+// It is meant for tests and local development.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+// NewFileMailer returns a mailer writing messages from from to the file at path.
+func NewFileMailer(path, from string) (*FileMailer, error) {
+	if path == "" {
+		return nil, fmt.Errorf("mail file path is required")
+	}
+	return &FileMailer{path: path, from: from}, nil
+}
+
+// Send appends message to the file.
+func (m *FileMailer) Send(ctx context.Context, message models.MailMessage) error {
+	data, err := mailer.Format(m.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	if _, err := f.Write(append(data, "\r\n"...)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,45 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	m, err := NewFileMailer(path, "noreply@example.com")
+	if err != nil {
+		t.Fatalf("NewFileMailer() error = %v", err)
+	}
+
+	messages := []models.MailMessage{
+		{To: "alice@example.com", Subject: "first", Body: "hello alice"},
+		{To: "bob@example.com", Subject: "second", Body: "hello bob"},
+	}
+	for _, message := range messages {
+		if err := m.Send(context.Background(), message); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if err := m.Send(context.Background(), models.MailMessage{To: "not an address"}); err == nil {
+		t.Error("Send() with invalid recipient succeeded")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read mail file: %v", err)
+	}
+	for _, want := range []string{"To: alice@example.com", "hello alice", "To: bob@example.com", "hello bob"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("mail file does not contain %q", want)
+		}
+	}
+	if strings.Contains(string(data), "not an address") {
+		t.Error("mail file contains the rejected message")
+	}
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// MemoryMailer keeps sent messages in memory instead of delivering them. It is
+// meant for tests and local development.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []models.MailMessage
+}
+
+// NewMemoryMailer returns a mailer without any sent messages.
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records message.
+func (m *MemoryMailer) Send(ctx context.Context, message models.MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailer) Messages() []models.MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.MailMessage(nil), m.messages...)
+}
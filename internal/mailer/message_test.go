@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		from         string
+		message      models.MailMessage
+		wantErr      bool
+		wantContains []string
+	}{
+		{
+			name:    "plain message",
+			from:    "sasuke <noreply@example.com>",
+			message: models.MailMessage{To: "alice@example.com", Subject: "Reset your password", Body: "line one\nline two"},
+			wantContains: []string{
+				"From: sasuke <noreply@example.com>\r\n",
+				"To: alice@example.com\r\n",
+				"Subject: Reset your password\r\n",
+				"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n",
+				"\r\n\r\nline one\r\nline two\r\n",
+			},
+		},
+		{
+			name:         "non-ASCII subject is encoded",
+			from:         "noreply@example.com",
+			message:      models.MailMessage{To: "alice@example.com", Subject: "Passwort zurücksetzen"},
+			wantContains: []string{"Subject: =?utf-8?q?Passwort_zur=C3=BCcksetzen?=\r\n"},
+		},
+		{
+			name:    "header injection in subject",
+			from:    "noreply@example.com",
+			message: models.MailMessage{To: "alice@example.com", Subject: "hi\r\nBcc: eve@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "header injection in recipient",
+			from:    "noreply@example.com",
+			message: models.MailMessage{To: "alice@example.com\nBcc: eve@example.com", Subject: "hi"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid recipient",
+			from:    "noreply@example.com",
+			message: models.MailMessage{To: "alice", Subject: "hi"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid sender",
+			from:    "",
+			message: models.MailMessage{To: "alice@example.com", Subject: "hi"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.from, tt.message, date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("Format() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models/dto"
+)
+
+const (
+	// WWWAuthenticateHeader is the challenge header sent with 401 responses.
+	WWWAuthenticateHeader = "WWW-Authenticate"
+	// BearerChallenge is the challenge sent when a request is not authenticated.
+	BearerChallenge = `Bearer realm="sasuke"`
+)
+
+var (
+	// ErrMissingToken is reported when a request carries no session token.
+	ErrMissingToken = errors.New("session token is missing")
+	// ErrAPIKeyNotAccepted is reported when an API key is sent to a route that requires a session.
+	ErrAPIKeyNotAccepted = errors.New("api keys are not accepted for this route")
+)
+
+// AuthMiddleware verifies the session token of each request, read from an
+// "Authorization: Bearer" header or the session_token cookie, and stores its
+// claims in the request context. Requests without a valid token are rejected
+// with 401 Unauthorized. Handlers read the caller with auth.ClaimsFromContext.
+func AuthMiddleware(keyring *auth.Keyring, tokenConfig auth.TokenConfig, revocations interfaces.RevocationStore) func(http.Handler) http.Handler {
+	return APIKeyAuthMiddleware(keyring, tokenConfig, revocations, nil)
+}
+
+// APIKeyAuthenticator verifies API keys and returns the claims of the
+// requests they authenticate.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.CustomClaims, error)
+}
+
+// APIKeyAuthMiddleware is AuthMiddleware that also accepts API keys, sent as
+// bearer tokens, which apiKeys verifies. API keys are rejected when apiKeys is nil.
+func APIKeyAuthMiddleware(keyring *auth.Keyring, tokenConfig auth.TokenConfig, revocations interfaces.RevocationStore, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := auth.TokenFromRequest(r)
+			if tokenString == "" {
+				unauthorized(w, ErrMissingToken, "Authentication required")
+				return
+			}
+
+			if auth.IsAPIKey(tokenString) {
+				if apiKeys == nil {
+					unauthorized(w, ErrAPIKeyNotAccepted, "API keys are not accepted here")
+					return
+				}
+				claims, err := apiKeys.AuthenticateAPIKey(r.Context(), tokenString)
+				if err != nil {
+					unauthorized(w, err, "Invalid or expired API key")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.ContextWithClaims(r.Context(), claims)))
+				return
+			}
+
+			claims, err := auth.VerifyToken(r.Context(), tokenString, keyring, tokenConfig, revocations)
+			if err != nil {
+				unauthorized(w, err, "Invalid or expired session token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.ContextWithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, err error, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(WWWAuthenticateHeader, BearerChallenge)
+	w.WriteHeader(http.StatusUnauthorized)
+	resp := dto.AuthErrorResponse{Error: err.Error(), Message: message}
+	_ = json.NewEncoder(w).Encode(resp)
+}
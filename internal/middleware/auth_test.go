@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherKeyring, err := auth.NewKeyring(otherKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	validToken, err := auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	foreignToken, err := auth.CreateToken("testuser", otherKeyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		cookie         string
+		authorization  string
+		revoked        bool
+		wantStatusCode int
+		wantUsername   string
+	}{
+		{
+			name:           "valid session cookie",
+			cookie:         validToken,
+			wantStatusCode: http.StatusOK,
+			wantUsername:   "testuser",
+		},
+		{
+			name:           "valid bearer token",
+			authorization:  "Bearer " + validToken,
+			wantStatusCode: http.StatusOK,
+			wantUsername:   "testuser",
+		},
+		{
+			name:           "missing token",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "malformed token",
+			cookie:         "not-a-token",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "token signed by unknown key",
+			authorization:  "Bearer " + foreignToken,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "revoked token",
+			cookie:         validToken,
+			revoked:        true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revocations := mocks.NewMockRevocationStore(t)
+			revocations.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(tt.revoked, nil).Maybe()
+			revocations.On("IsSubjectRevoked", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil).Maybe()
+
+			gotUsername := ""
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUsername = auth.UsernameFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: tt.cookie})
+			}
+			if tt.authorization != "" {
+				req.Header.Set(auth.AuthorizationHeader, tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			AuthMiddleware(keyring, auth.TokenConfig{}, revocations)(next).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("got status %d, want %d", rr.Code, tt.wantStatusCode)
+			}
+			if gotUsername != tt.wantUsername {
+				t.Errorf("got username %q, want %q", gotUsername, tt.wantUsername)
+			}
+
+			if tt.wantStatusCode == http.StatusUnauthorized {
+				if rr.Header().Get(WWWAuthenticateHeader) == "" {
+					t.Error("expected a WWW-Authenticate challenge")
+				}
+				resp := &dto.AuthErrorResponse{}
+				if err := json.NewDecoder(rr.Body).Decode(resp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if resp.Error == "" || resp.Message == "" {
+					t.Errorf("expected error and message in response, got %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+// stubAPIKeys accepts the API keys it maps to claims.
+type stubAPIKeys map[string]*auth.CustomClaims
+
+func (s stubAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*auth.CustomClaims, error) {
+	claims, ok := s[key]
+	if !ok {
+		return nil, errors.New("invalid api key")
+	}
+	return claims, nil
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	sessionToken, err := auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	apiKey := auth.APIKeyPrefix + "valid"
+	apiKeys := stubAPIKeys{apiKey: {UserID: "ci-user", AMR: []string{auth.AMRAPIKey}}}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		apiKeys        APIKeyAuthenticator
+		wantStatusCode int
+		wantUsername   string
+	}{
+		{
+			name:           "valid api key",
+			authorization:  "Bearer " + apiKey,
+			apiKeys:        apiKeys,
+			wantStatusCode: http.StatusOK,
+			wantUsername:   "ci-user",
+		},
+		{
+			name:           "unknown api key",
+			authorization:  "Bearer " + auth.APIKeyPrefix + "unknown",
+			apiKeys:        apiKeys,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "api key where only sessions are accepted",
+			authorization:  "Bearer " + apiKey,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "session token",
+			authorization:  "Bearer " + sessionToken,
+			apiKeys:        apiKeys,
+			wantStatusCode: http.StatusOK,
+			wantUsername:   "testuser",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUsername := ""
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUsername = auth.UsernameFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set(auth.AuthorizationHeader, tt.authorization)
+			rr := httptest.NewRecorder()
+
+			APIKeyAuthMiddleware(keyring, auth.TokenConfig{}, nil, tt.apiKeys)(next).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("got status %d, want %d", rr.Code, tt.wantStatusCode)
+			}
+			if gotUsername != tt.wantUsername {
+				t.Errorf("got username %q, want %q", gotUsername, tt.wantUsername)
+			}
+			if rr.Code == http.StatusUnauthorized && rr.Header().Get(WWWAuthenticateHeader) == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
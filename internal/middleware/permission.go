@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+)
+
+// ErrMissingClaims is reported when a guarded route is reached without AuthMiddleware.
+var ErrMissingClaims = errors.New("request is not authenticated")
+
+// RequirePermission lets through requests whose verified token grants
+// permission and rejects the others with 403 Forbidden. It must be wrapped by
+// AuthMiddleware, which stores the claims it checks.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok {
+				unauthorized(w, ErrMissingClaims, "Authentication required")
+				return
+			}
+			if !claims.HasPermission(permission) {
+				forbidden(w, fmt.Errorf("permission %s is required", permission), "Insufficient permissions")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forbidden(w http.ResponseWriter, err error, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	resp := dto.AuthErrorResponse{Error: err.Error(), Message: message}
+	_ = json.NewEncoder(w).Encode(resp)
+}
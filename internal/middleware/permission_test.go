@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		wantStatusCode int
+	}{
+		{
+			name:           "permission granted",
+			claims:         &auth.CustomClaims{UserID: "admin", Roles: []string{"admin"}, Permissions: []string{auth.PermissionRolesRead, auth.PermissionCreate}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "other permissions only",
+			claims:         &auth.CustomClaims{UserID: "testuser", Roles: []string{"user"}, Permissions: []string{auth.PermissionRolesRead}},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "role without the permission name",
+			claims:         &auth.CustomClaims{UserID: "testuser", Roles: []string{auth.PermissionCreate}},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "no permissions",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "not authenticated",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := RequirePermission(auth.PermissionCreate)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/create", nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("got status %d, want %d", rr.Code, tt.wantStatusCode)
+			}
+			if rr.Code == http.StatusOK {
+				return
+			}
+			resp := dto.AuthErrorResponse{}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Error == "" {
+				t.Errorf("expected an error response, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+)
+
+// ErrorInsufficientScope is the RFC 6750 error code of tokens lacking a
+// required scope.
+const ErrorInsufficientScope = "insufficient_scope"
+
+// RequireScope lets through requests whose verified token has every scope of
+// scopes and rejects the others with 403 Forbidden and an RFC 6750
+// insufficient_scope challenge naming the scopes needed. It must be wrapped
+// by AuthMiddleware, which stores the claims it checks.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	required := strings.Join(scopes, " ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok {
+				unauthorized(w, ErrMissingClaims, "Authentication required")
+				return
+			}
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					insufficientScope(w, required, fmt.Sprintf("scope %s is required", scope))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// insufficientScope answers with the RFC 6750 section 3.1 insufficient_scope error.
+func insufficientScope(w http.ResponseWriter, scope, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(WWWAuthenticateHeader, fmt.Sprintf(`%s, error="%s", error_description="%s", scope="%s"`,
+		BearerChallenge, ErrorInsufficientScope, description, scope))
+	w.WriteHeader(http.StatusForbidden)
+	resp := dto.AuthErrorResponse{Error: ErrorInsufficientScope, Message: description}
+	_ = json.NewEncoder(w).Encode(resp)
+}
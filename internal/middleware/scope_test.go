@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+)
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		wantStatusCode int
+		wantChallenge  string
+	}{
+		{
+			name:           "all scopes granted",
+			claims:         &auth.CustomClaims{UserID: "testuser", ClientID: "client-1", Scope: "openid reports:read reports:write"},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "missing one scope",
+			claims:         &auth.CustomClaims{UserID: "testuser", ClientID: "client-1", Scope: "openid reports:read"},
+			wantStatusCode: http.StatusForbidden,
+			wantChallenge:  `Bearer realm="sasuke", error="insufficient_scope", error_description="scope reports:write is required", scope="reports:read reports:write"`,
+		},
+		{
+			name:           "scope prefix does not match",
+			claims:         &auth.CustomClaims{ClientID: "client-1", PrincipalType: auth.PrincipalTypeClient, Scope: "reports reports:read:all"},
+			wantStatusCode: http.StatusForbidden,
+			wantChallenge:  `Bearer realm="sasuke", error="insufficient_scope", error_description="scope reports:read is required", scope="reports:read reports:write"`,
+		},
+		{
+			name:           "no scope",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			wantStatusCode: http.StatusForbidden,
+			wantChallenge:  `Bearer realm="sasuke", error="insufficient_scope", error_description="scope reports:read is required", scope="reports:read reports:write"`,
+		},
+		{
+			name:           "not authenticated",
+			wantStatusCode: http.StatusUnauthorized,
+			wantChallenge:  BearerChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := RequireScope("reports:read", "reports:write")(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("got status %d, want %d", rr.Code, tt.wantStatusCode)
+			}
+			if got := rr.Header().Get(WWWAuthenticateHeader); got != tt.wantChallenge {
+				t.Errorf("got challenge %q, want %q", got, tt.wantChallenge)
+			}
+			if rr.Code != http.StatusForbidden {
+				return
+			}
+			resp := dto.AuthErrorResponse{}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Error != ErrorInsufficientScope {
+				t.Errorf("expected an insufficient_scope response, got %+v, %v", resp, err)
+			}
+		})
+	}
+}
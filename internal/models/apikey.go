@@ -0,0 +1,21 @@
+package models
+
+import "strings"
+
+// APIKey is a personal access token a user created for scripted access. Only
+// the hash of the key is stored; KeyID identifies it when listing and revoking.
+type APIKey struct {
+	KeyID      string `bson:"key_id" mapstructure:"key_id" db:"key_id"`
+	KeyHash    string `bson:"key_hash" mapstructure:"key_hash" db:"key_hash"`
+	Username   string `bson:"username" mapstructure:"username" db:"username"`
+	Name       string `bson:"name" mapstructure:"name" db:"name"`
+	Scopes     string `bson:"scopes" mapstructure:"scopes" db:"scopes"`                   // space-delimited
+	CreatedAt  int64  `bson:"created_at" mapstructure:"created_at" db:"created_at"`       // Unix seconds
+	ExpiresAt  int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"`       // Unix seconds
+	LastUsedAt int64  `bson:"last_used_at" mapstructure:"last_used_at" db:"last_used_at"` // Unix seconds, 0 if never used
+}
+
+// ScopeList returns the scopes of the key.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
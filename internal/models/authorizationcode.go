@@ -0,0 +1,19 @@
+package models
+
+// AuthorizationCode is a persisted OAuth 2.0 authorization code. Only the code
+// hash is stored, together with the request it was issued for so the token
+// endpoint can check that the exchange matches it. Nonce and AuthTime are
+// copied into the ID token of OpenID Connect requests.
+type AuthorizationCode struct {
+	CodeHash            string `bson:"code_hash" mapstructure:"code_hash" db:"code_hash"`
+	ClientID            string `bson:"client_id" mapstructure:"client_id" db:"client_id"`
+	Username            string `bson:"username" mapstructure:"username" db:"username"`
+	RedirectURI         string `bson:"redirect_uri" mapstructure:"redirect_uri" db:"redirect_uri"`
+	Scope               string `bson:"scope" mapstructure:"scope" db:"scope"`
+	CodeChallenge       string `bson:"code_challenge" mapstructure:"code_challenge" db:"code_challenge"`
+	CodeChallengeMethod string `bson:"code_challenge_method" mapstructure:"code_challenge_method" db:"code_challenge_method"`
+	Nonce               string `bson:"nonce" mapstructure:"nonce" db:"nonce"`
+	AuthTime            int64  `bson:"auth_time" mapstructure:"auth_time" db:"auth_time"`    // Unix seconds
+	ExpiresAt           int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"` // Unix seconds
+	Used                bool   `bson:"used" mapstructure:"used" db:"used"`
+}
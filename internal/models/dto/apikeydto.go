@@ -0,0 +1,41 @@
+package dto
+
+// APIKeyCreateRequestDTO creates an API key limited to Scopes. ExpiresIn is
+// the lifetime in seconds, the configured default when 0.
+type APIKeyCreateRequestDTO struct {
+	Name      string   `json:"name" validate:"required,max=64"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,max=32,dive,required,max=128"`
+	ExpiresIn int64    `json:"expires_in" validate:"gte=0"`
+}
+
+// APIKeyRevokeRequestDTO revokes the API key with the given ID.
+type APIKeyRevokeRequestDTO struct {
+	ID string `json:"id" validate:"required,max=64"`
+}
+
+// APIKeyDTO describes an API key without its secret. Times are Unix seconds.
+type APIKeyDTO struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	LastUsedAt int64    `json:"last_used_at,omitempty"`
+}
+
+// APIKeyCreateResponseDTO returns a new API key. Key is shown only once.
+type APIKeyCreateResponseDTO struct {
+	Message string `json:"message"`
+	Key     string `json:"key"`
+	APIKeyDTO
+}
+
+// APIKeyListResponseDTO lists the API keys of a user.
+type APIKeyListResponseDTO struct {
+	APIKeys []APIKeyDTO `json:"api_keys"`
+}
+
+// APIKeyRevokeResponseDTO confirms that an API key was revoked.
+type APIKeyRevokeResponseDTO struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+// AuthorizationCheckRequestDTO asks whether the caller may perform Action on
+// the resource described by Resource. Subject attributes complement the
+// claims of the access token, which take precedence.
+type AuthorizationCheckRequestDTO struct {
+	Action      string                 `json:"action" validate:"required,max=128"`
+	Resource    map[string]interface{} `json:"resource"`
+	Subject     map[string]interface{} `json:"subject,omitempty"`
+	Environment map[string]interface{} `json:"environment,omitempty"`
+}
+
+// AuthorizationCheckResponseDTO is the decision of a policy check.
+type AuthorizationCheckResponseDTO struct {
+	Allowed  bool   `json:"allowed"`
+	Decision string `json:"decision"`
+	PolicyID string `json:"policy_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
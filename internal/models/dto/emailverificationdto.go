@@ -0,0 +1,11 @@
+package dto
+
+// ResendVerificationRequestDTO asks for the email verification link of a user to be sent again.
+type ResendVerificationRequestDTO struct {
+	Username string `json:"username" validate:"required,max=64"`
+}
+
+// EmailVerificationResponseDTO is returned by the email verification routes.
+type EmailVerificationResponseDTO struct {
+	Message string `json:"message"`
+}
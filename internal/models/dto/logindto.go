@@ -6,7 +6,8 @@ type LoginRequestDTO struct {
 }
 
 type LoginResponseDTO struct {
-	Message string `json:"message"`
+	Message      string `json:"message"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 	// Optionally include a token if you return it in the response body
 	// Token   string `json:"token,omitempty"`
 }
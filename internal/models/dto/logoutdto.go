@@ -0,0 +1,5 @@
+package dto
+
+type LogoutResponseDTO struct {
+	Message string `json:"message"`
+}
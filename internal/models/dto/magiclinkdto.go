@@ -0,0 +1,11 @@
+package dto
+
+// MagicLinkRequestDTO asks for a login link to be mailed to an email address.
+type MagicLinkRequestDTO struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// MagicLinkResponseDTO is returned when a login link is requested.
+type MagicLinkResponseDTO struct {
+	Message string `json:"message"`
+}
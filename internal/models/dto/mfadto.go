@@ -0,0 +1,45 @@
+package dto
+
+// MFAChallengeResponseDTO is returned by the login route instead of a session
+// when the user must still present a second factor.
+type MFAChallengeResponseDTO struct {
+	Message     string   `json:"message"`
+	MFARequired bool     `json:"mfa_required"`
+	MFAToken    string   `json:"mfa_token"`
+	MFAMethods  []string `json:"mfa_methods"`
+}
+
+// MFALoginRequestDTO completes a login with the challenge token and either a
+// TOTP code or a recovery code.
+type MFALoginRequestDTO struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// TOTPEnrollmentResponseDTO holds the secret of a pending TOTP enrollment.
+// ProvisioningURI is the otpauth:// URI to render as a QR code.
+type TOTPEnrollmentResponseDTO struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TOTPCodeRequestDTO carries a TOTP code, or for the routes that accept one a
+// recovery code, to confirm a change to the second factor.
+type TOTPCodeRequestDTO struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// RecoveryCodesResponseDTO returns a new batch of recovery codes, which are
+// only shown once, and the number of unused codes.
+type RecoveryCodesResponseDTO struct {
+	Message       string   `json:"message,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	Remaining     int      `json:"remaining"`
+}
+
+// MFAResponseDTO is the response of the TOTP confirm and disable routes.
+type MFAResponseDTO struct {
+	Message string `json:"message"`
+}
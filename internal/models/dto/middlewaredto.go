@@ -3,4 +3,8 @@ package dto
 
 type RateLimitResponse struct {
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
+type AuthErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
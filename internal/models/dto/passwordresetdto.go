@@ -0,0 +1,17 @@
+package dto
+
+// ForgotPasswordRequestDTO asks for a password reset link to be mailed to the user.
+type ForgotPasswordRequestDTO struct {
+	Username string `json:"username" validate:"required,max=254"`
+}
+
+// ResetPasswordRequestDTO sets a new password with the token from a password reset link.
+type ResetPasswordRequestDTO struct {
+	Token    string `json:"token" validate:"required,max=128"`
+	Password string `json:"password" validate:"required,max=256"`
+}
+
+// PasswordResetResponseDTO is returned by the password reset routes.
+type PasswordResetResponseDTO struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+type RefreshRequestDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshResponseDTO struct {
+	Message      string `json:"message"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// RoleAssignmentRequestDTO assigns a role to or revokes a role from a user.
+type RoleAssignmentRequestDTO struct {
+	Username string `json:"username" validate:"required,max=254"`
+	Role     string `json:"role" validate:"required,max=64"`
+}
+
+// UserRolesResponseDTO lists the roles of a user and the permissions they grant.
+type UserRolesResponseDTO struct {
+	Message     string   `json:"message,omitempty"`
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -2,10 +2,25 @@ package dto
 
 type UserSignupRequestDTO struct {
 	Username string `json:"username" validate:"required,min=8,max=64"`
-	Password string `json:"password" validate:"required,min=8,max=64"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,max=256"`
 }
 
 type UserSignupResponseDTO struct {
 	Message string `json:"message"`
 	UserID  string `json:"user_id,omitempty"`
 }
+
+// PasswordViolationDTO is a rule of the password policy a password breaks.
+type PasswordViolationDTO struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyErrorResponseDTO is returned when a new password is refused
+// by the password policy.
+type PasswordPolicyErrorResponseDTO struct {
+	Error      string                 `json:"error"`
+	Message    string                 `json:"message"`
+	Violations []PasswordViolationDTO `json:"violations"`
+}
@@ -0,0 +1,115 @@
+package dto
+
+// Binary WebAuthn values are base64url encoded without padding, as in the
+// JSON form of PublicKeyCredential (WebAuthn Level 3 toJSON()).
+
+// PasskeyRegisterRequestDTO finishes a passkey registration.
+type PasskeyRegisterRequestDTO struct {
+	CeremonyToken string                          `json:"ceremony_token" validate:"required"`
+	Name          string                          `json:"name" validate:"max=64"`
+	Credential    PasskeyAttestationCredentialDTO `json:"credential"`
+}
+
+// PasskeyAttestationCredentialDTO is the PublicKeyCredential created by navigator.credentials.create().
+type PasskeyAttestationCredentialDTO struct {
+	ID       string                        `json:"id" validate:"required"`
+	Type     string                        `json:"type" validate:"eq=public-key"`
+	Response PasskeyAttestationResponseDTO `json:"response"`
+}
+
+// PasskeyAttestationResponseDTO is an AuthenticatorAttestationResponse.
+type PasskeyAttestationResponseDTO struct {
+	ClientDataJSON    string   `json:"clientDataJSON" validate:"required"`
+	AttestationObject string   `json:"attestationObject" validate:"required"`
+	Transports        []string `json:"transports" validate:"max=8,dive,max=32,alphanum"`
+}
+
+// PasskeyLoginRequestDTO finishes a passkey login.
+type PasskeyLoginRequestDTO struct {
+	CeremonyToken string                        `json:"ceremony_token" validate:"required"`
+	Credential    PasskeyAssertionCredentialDTO `json:"credential"`
+}
+
+// PasskeyAssertionCredentialDTO is the PublicKeyCredential returned by navigator.credentials.get().
+type PasskeyAssertionCredentialDTO struct {
+	ID       string                      `json:"id" validate:"required"`
+	Type     string                      `json:"type" validate:"eq=public-key"`
+	Response PasskeyAssertionResponseDTO `json:"response"`
+}
+
+// PasskeyAssertionResponseDTO is an AuthenticatorAssertionResponse.
+type PasskeyAssertionResponseDTO struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature" validate:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// PasskeyCeremonyResponseDTO starts a ceremony. PublicKey is passed to
+// navigator.credentials.create() or get(); CeremonyToken is sent back to
+// finish the ceremony.
+type PasskeyCeremonyResponseDTO struct {
+	CeremonyToken string      `json:"ceremony_token"`
+	PublicKey     interface{} `json:"public_key"`
+}
+
+// PublicKeyCredentialCreationOptionsDTO are the options of a registration ceremony.
+type PublicKeyCredentialCreationOptionsDTO struct {
+	Challenge              string                    `json:"challenge"`
+	RP                     RelyingPartyDTO           `json:"rp"`
+	User                   PasskeyUserDTO            `json:"user"`
+	PubKeyCredParams       []PubKeyCredParamDTO      `json:"pubKeyCredParams"`
+	Timeout                int64                     `json:"timeout,omitempty"` // milliseconds
+	ExcludeCredentials     []PasskeyDescriptorDTO    `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelectionDTO `json:"authenticatorSelection"`
+	Attestation            string                    `json:"attestation"`
+}
+
+// PublicKeyCredentialRequestOptionsDTO are the options of an authentication ceremony.
+type PublicKeyCredentialRequestOptionsDTO struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout,omitempty"` // milliseconds
+	AllowCredentials []PasskeyDescriptorDTO `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RelyingPartyDTO identifies the relying party.
+type RelyingPartyDTO struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PasskeyUserDTO identifies the user a passkey is created for.
+type PasskeyUserDTO struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PubKeyCredParamDTO is an accepted credential algorithm.
+type PubKeyCredParamDTO struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// PasskeyDescriptorDTO refers to an existing credential.
+type PasskeyDescriptorDTO struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AuthenticatorSelectionDTO asks for a discoverable credential, which
+// passkey login requires.
+type AuthenticatorSelectionDTO struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// PasskeyResponseDTO is the response of a passkey registration.
+type PasskeyResponseDTO struct {
+	Message      string `json:"message"`
+	CredentialID string `json:"credential_id"`
+	Name         string `json:"name,omitempty"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// MailMessage is a plain text email sent to a single recipient.
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
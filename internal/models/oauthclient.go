@@ -0,0 +1,44 @@
+package models
+
+import "strings"
+
+// OAuthClient is a registered OAuth 2.0 client. Public clients (SPAs, mobile
+// apps) have no secret and must use PKCE; confidential clients authenticate
+// with a secret of which only the hash is stored, or with a private_key_jwt
+// assertion verified with PublicKey (PEM). Lists are space delimited, as in
+// the OAuth "scope" parameter. An empty GrantTypes allows authorization_code.
+type OAuthClient struct {
+	ClientID         string `bson:"client_id" mapstructure:"client_id" db:"client_id"`
+	ClientSecretHash string `bson:"client_secret_hash" mapstructure:"client_secret_hash" db:"client_secret_hash"`
+	Name             string `bson:"name" mapstructure:"name" db:"name"`
+	RedirectURIs     string `bson:"redirect_uris" mapstructure:"redirect_uris" db:"redirect_uris"`
+	Scopes           string `bson:"scopes" mapstructure:"scopes" db:"scopes"`
+	GrantTypes       string `bson:"grant_types" mapstructure:"grant_types" db:"grant_types"`
+	PublicKey        string `bson:"public_key" mapstructure:"public_key" db:"public_key"`
+	CreatedAt        int64  `bson:"created_at" mapstructure:"created_at" db:"created_at"` // Unix seconds
+}
+
+// IsPublic reports whether the client has no credentials.
+func (c *OAuthClient) IsPublic() bool {
+	return c.ClientSecretHash == "" && c.PublicKey == ""
+}
+
+// UsesPrivateKeyJWT reports whether the client authenticates with a signed assertion.
+func (c *OAuthClient) UsesPrivateKeyJWT() bool {
+	return c.PublicKey != ""
+}
+
+// RedirectURIList returns the registered redirect URIs.
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+// ScopeList returns the scopes the client may request. An empty list allows any scope.
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// GrantTypeList returns the grant types the client may use.
+func (c *OAuthClient) GrantTypeList() []string {
+	return strings.Fields(c.GrantTypes)
+}
@@ -0,0 +1,10 @@
+package models
+
+// PasswordResetToken is a pending password reset. Only the hash of the token
+// mailed to the user is stored, and a token can be used once.
+type PasswordResetToken struct {
+	TokenHash string `bson:"token_hash" mapstructure:"token_hash" db:"token_hash"`
+	Username  string `bson:"username" mapstructure:"username" db:"username"`
+	ExpiresAt int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"` // Unix seconds
+	Used      bool   `bson:"used" mapstructure:"used" db:"used"`
+}
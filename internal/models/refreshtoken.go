@@ -0,0 +1,14 @@
+package models
+
+// RefreshToken is a persisted opaque refresh token. Only the token hash is stored.
+// Tokens issued from the same login share a FamilyID so that the whole chain can be
+// revoked when an already used token is presented again. AMR carries the
+// authentication methods of the original login across rotations.
+type RefreshToken struct {
+	TokenHash string `bson:"token_hash" mapstructure:"token_hash" db:"token_hash"`
+	FamilyID  string `bson:"family_id" mapstructure:"family_id" db:"family_id"`
+	Username  string `bson:"username" mapstructure:"username" db:"username"`
+	ExpiresAt int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"` // Unix seconds
+	Used      bool   `bson:"used" mapstructure:"used" db:"used"`
+	AMR       string `bson:"amr" mapstructure:"amr" db:"amr"` // space-delimited authentication methods of the login
+}
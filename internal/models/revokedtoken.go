@@ -0,0 +1,15 @@
+package models
+
+// RevokedToken is an entry of the token revocation list.
+type RevokedToken struct {
+	JTI       string `bson:"jti" mapstructure:"jti" db:"jti"`
+	ExpiresAt int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"` // Unix seconds
+}
+
+// RevokedSubject revokes every token of a subject issued at or before
+// RevokedBefore, e.g. all sessions of a user after a password reset.
+type RevokedSubject struct {
+	Subject       string `bson:"subject" mapstructure:"subject" db:"subject"`
+	RevokedBefore int64  `bson:"revoked_before" mapstructure:"revoked_before" db:"revoked_before"` // Unix seconds
+	ExpiresAt     int64  `bson:"expires_at" mapstructure:"expires_at" db:"expires_at"`             // Unix seconds
+}
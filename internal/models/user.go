@@ -1,8 +1,18 @@
 package models
 
 type User struct {
-	Username       string `bson:"username" mapstructure:"username" db:"username"`
-	HashedPassword string `bson:"hashed_password" mapstructure:"hashed_password" db:"hashed_password"`
+	Username        string `bson:"username" mapstructure:"username" db:"username"`
+	HashedPassword  string `bson:"hashed_password" mapstructure:"hashed_password" db:"hashed_password"`
+	TOTPSecret      string `bson:"totp_secret" mapstructure:"totp_secret" db:"totp_secret"`                   // encrypted, set once enrollment starts
+	TOTPEnabled     bool   `bson:"totp_enabled" mapstructure:"totp_enabled" db:"totp_enabled"`                // set once enrollment is confirmed
+	TOTPLastCounter int64  `bson:"totp_last_counter" mapstructure:"totp_last_counter" db:"totp_last_counter"` // last accepted time step
+	RecoveryCodes   string `bson:"recovery_codes" mapstructure:"recovery_codes" db:"recovery_codes"`          // space-delimited bcrypt hashes of unused codes
+	Email           string `bson:"email" mapstructure:"email" db:"email"`                                     // lower-cased, unique when set
+	EmailVerified   bool   `bson:"email_verified" mapstructure:"email_verified" db:"email_verified"`          // set once the verification link is opened
+	FailedLogins    int64  `bson:"failed_logins" mapstructure:"failed_logins" db:"failed_logins"`             // consecutive failed password logins
+	LockedUntil     int64  `bson:"locked_until" mapstructure:"locked_until" db:"locked_until"`                // Unix seconds, password logins are refused until then
+	PepperID        string `bson:"pepper_id" mapstructure:"pepper_id" db:"pepper_id"`                         // ID of the pepper applied before hashing, empty if none
+	Roles           string `bson:"roles" mapstructure:"roles" db:"roles"`                                     // space-delimited role names
 }
 
 
@@ -0,0 +1,18 @@
+package models
+
+// WebAuthnCredential is a passkey registered by a user. The credential ID is
+// base64url encoded and the COSE public key standard base64 encoded. The sign
+// count is the last signature counter reported by the authenticator; it is
+// used to detect cloned authenticators.
+type WebAuthnCredential struct {
+	CredentialID      string `bson:"credential_id" mapstructure:"credential_id" db:"credential_id"`
+	Username          string `bson:"username" mapstructure:"username" db:"username"`
+	Name              string `bson:"name" mapstructure:"name" db:"name"`
+	PublicKey         string `bson:"public_key" mapstructure:"public_key" db:"public_key"`
+	SignCount         int64  `bson:"sign_count" mapstructure:"sign_count" db:"sign_count"`
+	AAGUID            string `bson:"aaguid" mapstructure:"aaguid" db:"aaguid"` // hex
+	AttestationFormat string `bson:"attestation_format" mapstructure:"attestation_format" db:"attestation_format"`
+	Transports        string `bson:"transports" mapstructure:"transports" db:"transports"`       // space-delimited
+	CreatedAt         int64  `bson:"created_at" mapstructure:"created_at" db:"created_at"`       // Unix seconds
+	LastUsedAt        int64  `bson:"last_used_at" mapstructure:"last_used_at" db:"last_used_at"` // Unix seconds
+}
@@ -0,0 +1,77 @@
+package oauthservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+)
+
+// AuthenticateClientAssertion identifies a client by a private_key_jwt
+// assertion (RFC 7523 section 2.2) signed with the key registered for it. The
+// assertion audience must be one of audiences, normally the token endpoint URL
+// and the issuer. Each assertion is accepted once.
+func (s *OAuthService) AuthenticateClientAssertion(ctx context.Context, assertionType, assertion string, audiences []string) (*models.OAuthClient, error) {
+	if assertionType != auth.ClientAssertionType {
+		return nil, NewError(ErrorInvalidClient, "unsupported client_assertion_type")
+	}
+
+	clientID, err := auth.ClientAssertionIssuer(assertion)
+	if err != nil {
+		return nil, NewError(ErrorInvalidClient, "malformed client assertion")
+	}
+
+	client, err := s.ClientRepo.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving client: %w", err)
+	}
+	if client == nil || !client.UsesPrivateKeyJWT() {
+		return nil, NewError(ErrorInvalidClient, "client authentication failed")
+	}
+
+	publicKey, err := auth.ParsePublicKey([]byte(client.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key for client %s: %w", client.ClientID, err)
+	}
+
+	claims, err := auth.VerifyClientAssertion(assertion, client.ClientID, publicKey, audiences, s.Leeway)
+	if err != nil {
+		return nil, NewError(ErrorInvalidClient, "client authentication failed")
+	}
+
+	if s.UsedAssertions != nil {
+		// namespaced so client chosen IDs cannot collide with token IDs
+		jti := "client_assertion:" + client.ClientID + ":" + claims.ID
+		used, err := s.UsedAssertions.IsRevoked(ctx, jti)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check client assertion replay: %w", err)
+		}
+		if used {
+			return nil, NewError(ErrorInvalidClient, "client assertion has already been used")
+		}
+		if err := s.UsedAssertions.Revoke(ctx, jti, claims.ExpiresAt.Time.Add(s.Leeway)); err != nil {
+			return nil, fmt.Errorf("failed to record client assertion: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// GrantClientCredentials checks a client_credentials request (RFC 6749
+// section 4.4) by an authenticated client and returns the granted scope. An
+// empty scope grants every scope registered for the client.
+func (s *OAuthService) GrantClientCredentials(client *models.OAuthClient, scope string) (string, error) {
+	if client.IsPublic() || !allowsGrantType(client, GrantTypeClientCredentials) {
+		return "", NewError(ErrorUnauthorizedClient, "client may not use the client credentials grant")
+	}
+
+	if scope == "" {
+		return strings.Join(client.ScopeList(), " "), nil
+	}
+	if err := checkScope(client, scope); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(scope), " "), nil
+}
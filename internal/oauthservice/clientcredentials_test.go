@@ -0,0 +1,241 @@
+package oauthservice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/stretchr/testify/mock"
+)
+
+const testTokenEndpoint = "https://auth.example.com/token"
+
+// testClientKey returns a client signing key and its PEM public key.
+func testClientKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	publicKey, err := auth.EncodePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncodePublicKey() error = %v", err)
+	}
+	return privateKey, string(publicKey)
+}
+
+func signAssertion(t *testing.T, privateKey *ecdsa.PrivateKey, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign assertion: %v", err)
+	}
+	return assertion
+}
+
+func TestOAuthService_AuthenticateClientAssertion(t *testing.T) {
+	privateKey, publicKey := testClientKey(t)
+	otherKey, _ := testClientKey(t)
+
+	machine := &models.OAuthClient{
+		ClientID:   "machine",
+		Name:       "Batch Job",
+		GrantTypes: GrantTypeClientCredentials,
+		PublicKey:  publicKey,
+	}
+	validClaims := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Issuer:    "machine",
+			Subject:   "machine",
+			Audience:  jwt.ClaimStrings{testTokenEndpoint},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			ID:        "assertion-1",
+		}
+	}
+
+	tests := []struct {
+		name          string
+		assertionType string
+		signingKey    *ecdsa.PrivateKey
+		modify        func(claims *jwt.RegisteredClaims)
+		wantErr       bool
+	}{
+		{
+			name:          "valid assertion",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify:        func(claims *jwt.RegisteredClaims) {},
+		},
+		{
+			name:          "unsupported assertion type",
+			assertionType: "urn:example:other",
+			signingKey:    privateKey,
+			modify:        func(claims *jwt.RegisteredClaims) {},
+			wantErr:       true,
+		},
+		{
+			name:          "signed with another key",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    otherKey,
+			modify:        func(claims *jwt.RegisteredClaims) {},
+			wantErr:       true,
+		},
+		{
+			name:          "wrong audience",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify: func(claims *jwt.RegisteredClaims) {
+				claims.Audience = jwt.ClaimStrings{"https://other.example.com/token"}
+			},
+			wantErr: true,
+		},
+		{
+			name:          "subject is not the client",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify:        func(claims *jwt.RegisteredClaims) { claims.Subject = "someone-else" },
+			wantErr:       true,
+		},
+		{
+			name:          "expired",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify: func(claims *jwt.RegisteredClaims) {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			},
+			wantErr: true,
+		},
+		{
+			name:          "lifetime too long",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify: func(claims *jwt.RegisteredClaims) {
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+			},
+			wantErr: true,
+		},
+		{
+			name:          "missing jti",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify:        func(claims *jwt.RegisteredClaims) { claims.ID = "" },
+			wantErr:       true,
+		},
+		{
+			name:          "unknown client",
+			assertionType: auth.ClientAssertionType,
+			signingKey:    privateKey,
+			modify: func(claims *jwt.RegisteredClaims) {
+				claims.Issuer = "unknown"
+				claims.Subject = "unknown"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientRepo := mocks.NewMockClientRepository(t)
+			clientRepo.On("GetClient", mock.Anything, "machine").Return(machine, nil).Maybe()
+			clientRepo.On("GetClient", mock.Anything, "unknown").Return(nil, nil).Maybe()
+
+			claims := validClaims()
+			tt.modify(&claims)
+			assertion := signAssertion(t, tt.signingKey, claims)
+
+			service := NewOAuthService(clientRepo)
+			service.UsedAssertions = memory.NewMemoryRevocationStore()
+			client, err := service.AuthenticateClientAssertion(context.Background(), tt.assertionType, assertion, []string{testTokenEndpoint})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthenticateClientAssertion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != ErrorInvalidClient {
+					t.Errorf("expected invalid_client, got %v", err)
+				}
+				return
+			}
+			if client.ClientID != "machine" {
+				t.Errorf("expected client machine, got %s", client.ClientID)
+			}
+
+			// the same assertion cannot be used twice
+			if _, err := service.AuthenticateClientAssertion(context.Background(), tt.assertionType, assertion, []string{testTokenEndpoint}); err == nil {
+				t.Error("expected replayed assertion to be rejected")
+			}
+		})
+	}
+}
+
+func TestOAuthService_AuthenticateClient_PrivateKeyJWT(t *testing.T) {
+	_, publicKey := testClientKey(t)
+	machine := &models.OAuthClient{ClientID: "machine", GrantTypes: GrantTypeClientCredentials, PublicKey: publicKey}
+
+	clientRepo := mocks.NewMockClientRepository(t)
+	clientRepo.On("GetClient", mock.Anything, "machine").Return(machine, nil)
+
+	// a client registered with a key is not public and has no secret
+	service := NewOAuthService(clientRepo)
+	if _, err := service.AuthenticateClient(context.Background(), "machine", ""); err == nil {
+		t.Error("expected private_key_jwt client without assertion to be rejected")
+	}
+}
+
+func TestOAuthService_GrantClientCredentials(t *testing.T) {
+	machine := &models.OAuthClient{
+		ClientID:         "machine",
+		ClientSecretHash: auth.HashOpaqueToken("s3cret"),
+		Scopes:           "reports:read reports:write",
+		GrantTypes:       GrantTypeClientCredentials,
+	}
+	webApp := &models.OAuthClient{
+		ClientID:         "web",
+		ClientSecretHash: auth.HashOpaqueToken("s3cret"),
+		RedirectURIs:     testRedirectURI,
+	}
+
+	tests := []struct {
+		name      string
+		client    *models.OAuthClient
+		scope     string
+		wantScope string
+		wantCode  string
+	}{
+		{name: "default scope", client: machine, wantScope: "reports:read reports:write"},
+		{name: "requested scope", client: machine, scope: "reports:read", wantScope: "reports:read"},
+		{name: "scope not allowed", client: machine, scope: "admin", wantCode: ErrorInvalidScope},
+		{name: "client not registered for grant", client: webApp, wantCode: ErrorUnauthorizedClient},
+		{name: "public client", client: testClient(), wantCode: ErrorUnauthorizedClient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewOAuthService(mocks.NewMockClientRepository(t))
+			got, err := service.GrantClientCredentials(tt.client, tt.scope)
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("GrantClientCredentials() unexpected error = %v", err)
+				}
+				if got != tt.wantScope {
+					t.Errorf("GrantClientCredentials() = %q, want %q", got, tt.wantScope)
+				}
+				return
+			}
+			var oauthErr *Error
+			if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+				t.Errorf("GrantClientCredentials() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
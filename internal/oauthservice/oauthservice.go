@@ -0,0 +1,359 @@
+package oauthservice
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultAuthorizationCodeTTL is used when no authorization code lifetime is configured.
+	DefaultAuthorizationCodeTTL = time.Minute
+
+	// ResponseTypeCode is the only supported authorization response type.
+	ResponseTypeCode = "code"
+	// GrantTypeAuthorizationCode exchanges an authorization code at the token endpoint.
+	GrantTypeAuthorizationCode = "authorization_code"
+	// GrantTypeClientCredentials issues tokens to machine clients acting on their own behalf.
+	GrantTypeClientCredentials = "client_credentials"
+	// ScopeOpenID turns an authorization request into an OpenID Connect
+	// authentication request, so the token endpoint also returns an ID token.
+	ScopeOpenID = "openid"
+)
+
+// SupportedGrantTypes lists the grant types clients can be registered for.
+var SupportedGrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeClientCredentials}
+
+type OAuthService struct {
+	ClientRepo interfaces.ClientRepository
+	// AuthorizationCodeTTL is the lifetime of issued authorization codes.
+	AuthorizationCodeTTL time.Duration
+	// UsedAssertions records the jti of accepted client assertions so they
+	// cannot be replayed. Replays are not detected when it is nil.
+	UsedAssertions interfaces.RevocationStore
+	// Leeway tolerates clock skew when validating client assertions.
+	Leeway time.Duration
+}
+
+// NewOAuthService creates a new OAuthService instance.
+func NewOAuthService(repo interfaces.ClientRepository) *OAuthService {
+	return &OAuthService{ClientRepo: repo}
+}
+
+// AuthorizationRequest holds the parameters of an authorization request (RFC 6749 section 4.1.1
+// with the PKCE extension of RFC 7636). Nonce is the OpenID Connect nonce.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// ClientRegistration describes a client to register. Confidential clients get
+// a secret; clients with a PublicKey (PEM) authenticate with private_key_jwt
+// instead. Clients without either are public. GrantTypes defaults to
+// authorization_code, which requires at least one redirect URI.
+type ClientRegistration struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+	Confidential bool
+	PublicKey    string
+}
+
+// RegisterClient registers a new client and returns it with its plain secret.
+// Public clients get no secret and must use PKCE.
+func (s *OAuthService) RegisterClient(ctx context.Context, registration ClientRegistration) (*models.OAuthClient, string, error) {
+	if registration.Name == "" {
+		return nil, "", fmt.Errorf("client name is required")
+	}
+
+	grantTypes := registration.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{GrantTypeAuthorizationCode}
+	}
+	for _, grantType := range grantTypes {
+		if !slices.Contains(SupportedGrantTypes, grantType) {
+			return nil, "", fmt.Errorf("unsupported grant type %q", grantType)
+		}
+	}
+
+	if slices.Contains(grantTypes, GrantTypeAuthorizationCode) && len(registration.RedirectURIs) == 0 {
+		return nil, "", fmt.Errorf("at least one redirect URI is required")
+	}
+	for _, redirectURI := range registration.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if registration.Confidential && registration.PublicKey != "" {
+		return nil, "", fmt.Errorf("a client authenticates either with a secret or with a public key")
+	}
+	if registration.PublicKey != "" {
+		if _, err := auth.ParsePublicKey([]byte(registration.PublicKey)); err != nil {
+			return nil, "", fmt.Errorf("invalid client public key: %w", err)
+		}
+	}
+	// machine clients cannot be public, anyone could use them
+	if slices.Contains(grantTypes, GrantTypeClientCredentials) && !registration.Confidential && registration.PublicKey == "" {
+		return nil, "", fmt.Errorf("the client_credentials grant requires a secret or a public key")
+	}
+
+	client := models.OAuthClient{
+		ClientID:     uuid.NewString(),
+		Name:         registration.Name,
+		RedirectURIs: strings.Join(registration.RedirectURIs, " "),
+		Scopes:       strings.Join(registration.Scopes, " "),
+		GrantTypes:   strings.Join(grantTypes, " "),
+		PublicKey:    registration.PublicKey,
+		CreatedAt:    time.Now().Unix(),
+	}
+
+	secret := ""
+	if registration.Confidential {
+		var secretHash string
+		var err error
+		secret, secretHash, err = auth.NewOpaqueToken()
+		if err != nil {
+			return nil, "", err
+		}
+		client.ClientSecretHash = secretHash
+	}
+
+	if err := s.ClientRepo.AddClient(ctx, client); err != nil {
+		return nil, "", fmt.Errorf("failed to register client: %w", err)
+	}
+	return &client, secret, nil
+}
+
+// AuthenticateClient identifies the client at the token endpoint. Confidential
+// clients must present their secret; public clients must not present one.
+// Clients registered with a public key must use AuthenticateClientAssertion.
+func (s *OAuthService) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, NewError(ErrorInvalidClient, "client_id is required")
+	}
+
+	client, err := s.ClientRepo.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving client: %w", err)
+	}
+	if client == nil {
+		return nil, NewError(ErrorInvalidClient, "unknown client")
+	}
+
+	if client.UsesPrivateKeyJWT() {
+		return nil, NewError(ErrorInvalidClient, "client must authenticate with a client assertion")
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return nil, NewError(ErrorInvalidClient, "public clients must not send a secret")
+		}
+		return client, nil
+	}
+
+	secretHash := auth.HashOpaqueToken(clientSecret)
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.ClientSecretHash)) != 1 {
+		return nil, NewError(ErrorInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+// ValidateAuthorizationRequest checks an authorization request. If the returned
+// client is nil the client or redirect URI could not be verified and the error
+// must be shown to the user instead of being sent to the redirect URI.
+func (s *OAuthService) ValidateAuthorizationRequest(ctx context.Context, req AuthorizationRequest) (*models.OAuthClient, error) {
+	if req.ClientID == "" {
+		return nil, NewError(ErrorInvalidRequest, "client_id is required")
+	}
+
+	client, err := s.ClientRepo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving client: %w", err)
+	}
+	if client == nil {
+		return nil, NewError(ErrorInvalidRequest, "unknown client")
+	}
+
+	// redirect URIs must match a registered one exactly
+	if !slices.Contains(client.RedirectURIList(), req.RedirectURI) {
+		return nil, NewError(ErrorInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	if req.ResponseType != ResponseTypeCode {
+		return client, NewError(ErrorUnsupportedResponseType, "only the code response type is supported")
+	}
+	if !allowsGrantType(client, GrantTypeAuthorizationCode) {
+		return client, NewError(ErrorUnauthorizedClient, "client may not use the authorization code grant")
+	}
+	if req.CodeChallenge == "" {
+		return client, NewError(ErrorInvalidRequest, "code_challenge is required")
+	}
+	if req.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return client, NewError(ErrorInvalidRequest, "code_challenge_method must be S256")
+	}
+	if err := checkScope(client, req.Scope); err != nil {
+		return client, err
+	}
+
+	return client, nil
+}
+
+// IssueAuthorizationCode stores a new authorization code for a validated request
+// approved by username, who authenticated at authTime, and returns the code.
+func (s *OAuthService) IssueAuthorizationCode(ctx context.Context, req AuthorizationRequest, username string, authTime time.Time) (string, error) {
+	code, codeHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	ttl := s.AuthorizationCodeTTL
+	if ttl <= 0 {
+		ttl = DefaultAuthorizationCodeTTL
+	}
+
+	authorizationCode := models.AuthorizationCode{
+		CodeHash:            codeHash,
+		ClientID:            req.ClientID,
+		Username:            username,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		AuthTime:            authTime.Unix(),
+		ExpiresAt:           time.Now().Add(ttl).Unix(),
+		Used:                false,
+	}
+
+	if err := s.ClientRepo.AddAuthorizationCode(ctx, authorizationCode); err != nil {
+		return "", fmt.Errorf("failed to store authorization code: %w", err)
+	}
+	return code, nil
+}
+
+// ExchangeAuthorizationCode consumes code for client. The redirect URI must equal
+// the one of the authorization request and codeVerifier must match its PKCE challenge.
+func (s *OAuthService) ExchangeAuthorizationCode(ctx context.Context, client *models.OAuthClient, code, redirectURI, codeVerifier string) (*models.AuthorizationCode, error) {
+	if code == "" {
+		return nil, NewError(ErrorInvalidRequest, "code is required")
+	}
+	if codeVerifier == "" {
+		return nil, NewError(ErrorInvalidRequest, "code_verifier is required")
+	}
+	if !allowsGrantType(client, GrantTypeAuthorizationCode) {
+		return nil, NewError(ErrorUnauthorizedClient, "client may not use the authorization code grant")
+	}
+
+	codeHash := auth.HashOpaqueToken(code)
+	stored, err := s.ClientRepo.GetAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving authorization code: %w", err)
+	}
+	if stored == nil || stored.Used || time.Now().Unix() >= stored.ExpiresAt {
+		return nil, NewError(ErrorInvalidGrant, "authorization code is invalid or expired")
+	}
+	if stored.ClientID != client.ClientID {
+		return nil, NewError(ErrorInvalidGrant, "authorization code was issued to another client")
+	}
+	if stored.RedirectURI != redirectURI {
+		return nil, NewError(ErrorInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !VerifyCodeChallenge(codeVerifier, stored.CodeChallenge) {
+		return nil, NewError(ErrorInvalidGrant, "code_verifier does not match the code challenge")
+	}
+
+	// a concurrent request may have used the code since it was read
+	marked, err := s.ClientRepo.MarkAuthorizationCodeUsed(ctx, codeHash)
+	if err != nil {
+		return nil, fmt.Errorf("error consuming authorization code: %w", err)
+	}
+	if !marked {
+		return nil, NewError(ErrorInvalidGrant, "authorization code is invalid or expired")
+	}
+
+	return stored, nil
+}
+
+// HasScope reports whether the space-delimited scope contains want.
+func HasScope(scope, want string) bool {
+	return slices.Contains(strings.Fields(scope), want)
+}
+
+// NarrowScope returns the scopes of the space-delimited scope that are in
+// allowed, in the order they were requested.
+func NarrowScope(scope string, allowed []string) string {
+	granted := slices.DeleteFunc(strings.Fields(scope), func(s string) bool {
+		return !slices.Contains(allowed, s)
+	})
+	return strings.Join(granted, " ")
+}
+
+// allowsGrantType reports whether the client was registered for grantType.
+// Clients registered without grant types may only use authorization_code.
+func allowsGrantType(client *models.OAuthClient, grantType string) bool {
+	grantTypes := client.GrantTypeList()
+	if len(grantTypes) == 0 {
+		return grantType == GrantTypeAuthorizationCode
+	}
+	return slices.Contains(grantTypes, grantType)
+}
+
+// checkScope verifies that every requested scope is allowed for the client.
+func checkScope(client *models.OAuthClient, scope string) error {
+	allowed := client.ScopeList()
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, requested := range strings.Fields(scope) {
+		if !slices.Contains(allowed, requested) {
+			return NewError(ErrorInvalidScope, fmt.Sprintf("scope %q is not allowed for this client", requested))
+		}
+	}
+	return nil
+}
+
+// validateRedirectURI accepts absolute URIs without a fragment. Plain http is
+// only allowed for loopback hosts (RFC 8252 section 7.3).
+func validateRedirectURI(redirectURI string) error {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URI %q: %v", redirectURI, err)
+	}
+	if !parsed.IsAbs() {
+		return fmt.Errorf("redirect URI %q must be absolute", redirectURI)
+	}
+	if parsed.Fragment != "" {
+		return fmt.Errorf("redirect URI %q must not contain a fragment", redirectURI)
+	}
+	if parsed.Scheme == "http" && !isLoopback(parsed.Hostname()) {
+		return fmt.Errorf("redirect URI %q must use https", redirectURI)
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,403 @@
+package oauthservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	// code verifier and challenge from RFC 7636 appendix B
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	testRedirectURI   = "https://app.example.com/callback"
+)
+
+func testClient() *models.OAuthClient {
+	return &models.OAuthClient{
+		ClientID:     "client-1",
+		Name:         "Example App",
+		RedirectURIs: testRedirectURI + " http://127.0.0.1:8080/callback",
+		Scopes:       "openid profile",
+	}
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		want     bool
+	}{
+		{name: "matching verifier", verifier: testCodeVerifier, want: true},
+		{name: "different verifier", verifier: testCodeVerifier[:42] + "A", want: false},
+		{name: "too short", verifier: "short", want: false},
+		{name: "invalid characters", verifier: testCodeVerifier[:42] + "+", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyCodeChallenge(tt.verifier, testCodeChallenge); got != tt.want {
+				t.Errorf("VerifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOAuthService_ValidateAuthorizationRequest(t *testing.T) {
+	valid := AuthorizationRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientID:            "client-1",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid",
+		State:               "xyz",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: CodeChallengeMethodS256,
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(req *AuthorizationRequest)
+		wantClient bool
+		wantCode   string
+	}{
+		{
+			name:       "valid request",
+			modify:     func(req *AuthorizationRequest) {},
+			wantClient: true,
+		},
+		{
+			name:     "unknown client",
+			modify:   func(req *AuthorizationRequest) { req.ClientID = "unknown" },
+			wantCode: ErrorInvalidRequest,
+		},
+		{
+			name:     "unregistered redirect URI",
+			modify:   func(req *AuthorizationRequest) { req.RedirectURI = "https://evil.example.com/callback" },
+			wantCode: ErrorInvalidRequest,
+		},
+		{
+			name:     "redirect URI prefix match is rejected",
+			modify:   func(req *AuthorizationRequest) { req.RedirectURI = testRedirectURI + "/../other" },
+			wantCode: ErrorInvalidRequest,
+		},
+		{
+			name:       "unsupported response type",
+			modify:     func(req *AuthorizationRequest) { req.ResponseType = "token" },
+			wantClient: true,
+			wantCode:   ErrorUnsupportedResponseType,
+		},
+		{
+			name:       "missing code challenge",
+			modify:     func(req *AuthorizationRequest) { req.CodeChallenge = "" },
+			wantClient: true,
+			wantCode:   ErrorInvalidRequest,
+		},
+		{
+			name:       "plain code challenge method",
+			modify:     func(req *AuthorizationRequest) { req.CodeChallengeMethod = "plain" },
+			wantClient: true,
+			wantCode:   ErrorInvalidRequest,
+		},
+		{
+			name:       "scope not allowed",
+			modify:     func(req *AuthorizationRequest) { req.Scope = "openid admin" },
+			wantClient: true,
+			wantCode:   ErrorInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientRepo := mocks.NewMockClientRepository(t)
+			clientRepo.On("GetClient", mock.Anything, "client-1").Return(testClient(), nil).Maybe()
+			clientRepo.On("GetClient", mock.Anything, "unknown").Return(nil, nil).Maybe()
+
+			req := valid
+			tt.modify(&req)
+
+			service := NewOAuthService(clientRepo)
+			client, err := service.ValidateAuthorizationRequest(context.Background(), req)
+			if (client != nil) != tt.wantClient {
+				t.Errorf("ValidateAuthorizationRequest() client = %v, wantClient %v", client, tt.wantClient)
+			}
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("ValidateAuthorizationRequest() unexpected error = %v", err)
+				}
+				return
+			}
+			var oauthErr *Error
+			if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+				t.Errorf("ValidateAuthorizationRequest() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOAuthService_AuthenticateClient(t *testing.T) {
+	confidential := testClient()
+	confidential.ClientID = "confidential"
+	confidential.ClientSecretHash = auth.HashOpaqueToken("s3cret")
+
+	tests := []struct {
+		name     string
+		clientID string
+		secret   string
+		wantErr  bool
+	}{
+		{name: "public client without secret", clientID: "client-1", wantErr: false},
+		{name: "public client with secret", clientID: "client-1", secret: "s3cret", wantErr: true},
+		{name: "confidential client with secret", clientID: "confidential", secret: "s3cret", wantErr: false},
+		{name: "confidential client with wrong secret", clientID: "confidential", secret: "wrong", wantErr: true},
+		{name: "confidential client without secret", clientID: "confidential", wantErr: true},
+		{name: "unknown client", clientID: "unknown", wantErr: true},
+		{name: "missing client id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientRepo := mocks.NewMockClientRepository(t)
+			clientRepo.On("GetClient", mock.Anything, "client-1").Return(testClient(), nil).Maybe()
+			clientRepo.On("GetClient", mock.Anything, "confidential").Return(confidential, nil).Maybe()
+			clientRepo.On("GetClient", mock.Anything, "unknown").Return(nil, nil).Maybe()
+
+			service := NewOAuthService(clientRepo)
+			_, err := service.AuthenticateClient(context.Background(), tt.clientID, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuthenticateClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOAuthService_ExchangeAuthorizationCode(t *testing.T) {
+	validCode := "valid-code"
+	stored := models.AuthorizationCode{
+		CodeHash:            auth.HashOpaqueToken(validCode),
+		ClientID:            "client-1",
+		Username:            "testuser",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: CodeChallengeMethodS256,
+		ExpiresAt:           time.Now().Add(time.Minute).Unix(),
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		redirectURI string
+		verifier    string
+		modify      func(code *models.AuthorizationCode)
+		marked      bool
+		wantCode    string
+	}{
+		{
+			name:        "valid exchange",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			marked:      true,
+		},
+		{
+			name:        "wrong verifier",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    "wrong-verifier-wrong-verifier-wrong-verifier",
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "redirect URI mismatch",
+			code:        validCode,
+			redirectURI: "http://127.0.0.1:8080/callback",
+			verifier:    testCodeVerifier,
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "code issued to another client",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			modify:      func(code *models.AuthorizationCode) { code.ClientID = "client-2" },
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "expired code",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			modify:      func(code *models.AuthorizationCode) { code.ExpiresAt = time.Now().Add(-time.Second).Unix() },
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "used code",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			modify:      func(code *models.AuthorizationCode) { code.Used = true },
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "code used concurrently",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			marked:      false,
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "unknown code",
+			code:        "unknown-code",
+			redirectURI: testRedirectURI,
+			verifier:    testCodeVerifier,
+			wantCode:    ErrorInvalidGrant,
+		},
+		{
+			name:        "missing verifier",
+			code:        validCode,
+			redirectURI: testRedirectURI,
+			wantCode:    ErrorInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := stored
+			if tt.modify != nil {
+				tt.modify(&code)
+			}
+
+			clientRepo := mocks.NewMockClientRepository(t)
+			clientRepo.On("GetAuthorizationCode", mock.Anything, stored.CodeHash).Return(&code, nil).Maybe()
+			clientRepo.On("GetAuthorizationCode", mock.Anything, auth.HashOpaqueToken("unknown-code")).Return(nil, nil).Maybe()
+			clientRepo.On("MarkAuthorizationCodeUsed", mock.Anything, stored.CodeHash).Return(tt.marked, nil).Maybe()
+
+			service := NewOAuthService(clientRepo)
+			got, err := service.ExchangeAuthorizationCode(context.Background(), testClient(), tt.code, tt.redirectURI, tt.verifier)
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("ExchangeAuthorizationCode() unexpected error = %v", err)
+				}
+				if got.Username != "testuser" || got.Scope != "openid" {
+					t.Errorf("ExchangeAuthorizationCode() = %+v", got)
+				}
+				return
+			}
+			var oauthErr *Error
+			if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+				t.Errorf("ExchangeAuthorizationCode() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOAuthService_RegisterClient(t *testing.T) {
+	_, publicKey := testClientKey(t)
+
+	tests := []struct {
+		name         string
+		registration ClientRegistration
+		wantErr      bool
+	}{
+		{name: "public client", registration: ClientRegistration{RedirectURIs: []string{testRedirectURI}}},
+		{name: "confidential client", registration: ClientRegistration{RedirectURIs: []string{testRedirectURI}, Confidential: true}},
+		{name: "loopback http redirect", registration: ClientRegistration{RedirectURIs: []string{"http://localhost:3000/cb"}}},
+		{name: "native app scheme", registration: ClientRegistration{RedirectURIs: []string{"com.example.app:/callback"}}},
+		{name: "plain http redirect", registration: ClientRegistration{RedirectURIs: []string{"http://app.example.com/cb"}}, wantErr: true},
+		{name: "relative redirect", registration: ClientRegistration{RedirectURIs: []string{"/callback"}}, wantErr: true},
+		{name: "redirect with fragment", registration: ClientRegistration{RedirectURIs: []string{testRedirectURI + "#frag"}}, wantErr: true},
+		{name: "no redirect URIs", wantErr: true},
+		{
+			name:         "machine client with secret",
+			registration: ClientRegistration{GrantTypes: []string{GrantTypeClientCredentials}, Confidential: true},
+		},
+		{
+			name:         "machine client with public key",
+			registration: ClientRegistration{GrantTypes: []string{GrantTypeClientCredentials}, PublicKey: publicKey},
+		},
+		{
+			name:         "public machine client",
+			registration: ClientRegistration{GrantTypes: []string{GrantTypeClientCredentials}},
+			wantErr:      true,
+		},
+		{
+			name:         "secret and public key",
+			registration: ClientRegistration{GrantTypes: []string{GrantTypeClientCredentials}, Confidential: true, PublicKey: publicKey},
+			wantErr:      true,
+		},
+		{
+			name:         "invalid public key",
+			registration: ClientRegistration{GrantTypes: []string{GrantTypeClientCredentials}, PublicKey: "not a key"},
+			wantErr:      true,
+		},
+		{
+			name:         "unsupported grant type",
+			registration: ClientRegistration{GrantTypes: []string{"password"}, Confidential: true},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientRepo := mocks.NewMockClientRepository(t)
+			clientRepo.On("AddClient", mock.Anything, mock.AnythingOfType("models.OAuthClient")).Return(nil).Maybe()
+
+			registration := tt.registration
+			registration.Name = "Example App"
+
+			service := NewOAuthService(clientRepo)
+			client, secret, err := service.RegisterClient(context.Background(), registration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			switch {
+			case registration.Confidential:
+				if secret == "" || client.ClientSecretHash != auth.HashOpaqueToken(secret) {
+					t.Error("expected a secret whose hash is stored")
+				}
+			case registration.PublicKey != "":
+				if secret != "" || !client.UsesPrivateKeyJWT() {
+					t.Error("expected a private_key_jwt client without secret")
+				}
+			default:
+				if secret != "" || !client.IsPublic() {
+					t.Error("expected a public client without secret")
+				}
+			}
+		})
+	}
+}
+
+func TestNarrowScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   string
+		allowed []string
+		want    string
+	}{
+		{name: "all held", scope: "openid reports:read", allowed: []string{"reports:read", "openid"}, want: "openid reports:read"},
+		{name: "scopes not held are dropped", scope: "reports:write openid  reports:read", allowed: []string{"openid", "reports:read"}, want: "openid reports:read"},
+		{name: "nothing held", scope: "reports:read", allowed: nil, want: ""},
+		{name: "empty scope", scope: "", allowed: []string{"openid"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NarrowScope(tt.scope, tt.allowed); got != tt.want {
+				t.Errorf("NarrowScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
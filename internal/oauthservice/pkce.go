@@ -0,0 +1,46 @@
+package oauthservice
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	// CodeChallengeMethodS256 is the only PKCE method accepted.
+	CodeChallengeMethodS256 = "S256"
+
+	// MinCodeVerifierLength and MaxCodeVerifierLength bound the verifier length (RFC 7636 section 4.1).
+	MinCodeVerifierLength = 43
+	MaxCodeVerifierLength = 128
+)
+
+// S256CodeChallenge returns BASE64URL(SHA256(verifier)) as defined in RFC 7636 section 4.2.
+func S256CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge reports whether verifier matches the S256 challenge.
+func VerifyCodeChallenge(verifier, challenge string) bool {
+	if !validCodeVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(S256CodeChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// validCodeVerifier checks the verifier length and character set.
+func validCodeVerifier(verifier string) bool {
+	if len(verifier) < MinCodeVerifierLength || len(verifier) > MaxCodeVerifierLength {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' || c == '.' || c == '_' || c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2idPrefix starts Argon2id hashes in PHC string format.
+const argon2idPrefix = "$" + AlgorithmArgon2id + "$"
+
+// Default Argon2id parameters, the second recommended option of RFC 9106.
+const (
+	DefaultArgon2idMemory      = 64 * 1024
+	DefaultArgon2idIterations  = 3
+	DefaultArgon2idParallelism = 4
+	DefaultArgon2idSaltLength  = 16
+	DefaultArgon2idKeyLength   = 32
+)
+
+// Argon2idParams are the cost parameters of Argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2idHasher hashes passwords with Argon2id and encodes them in PHC
+// string format, such as
+// "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>" with unpadded base64.
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+// NewArgon2idHasher creates an Argon2idHasher, using the default of each
+// parameter left 0.
+func NewArgon2idHasher(params Argon2idParams) (*Argon2idHasher, error) {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idMemory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2idIterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2idParallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2idSaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2idKeyLength
+	}
+	// RFC 9106 section 3.1
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, fmt.Errorf("argon2id memory must be at least 8 KiB per lane")
+	}
+	if params.SaltLength < 8 || params.KeyLength < 4 {
+		return nil, fmt.Errorf("argon2id salt must be at least 8 bytes and keys at least 4 bytes")
+	}
+	return &Argon2idHasher{Params: params}, nil
+}
+
+// Hash returns the Argon2id hash of password with a random salt.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+	return encodeArgon2id(h.Params, salt, key), nil
+}
+
+// Verify reports whether password matches encoded.
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	return Verify(password, encoded)
+}
+
+// NeedsRehash reports whether encoded is not an Argon2id hash with the
+// configured parameters.
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params != h.Params
+}
+
+func verifyArgon2id(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id parses an Argon2id PHC string. Only the cost parameters of
+// the returned params are set.
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	fields := strings.Split(strings.TrimPrefix(encoded, argon2idPrefix), "$")
+	if !strings.HasPrefix(encoded, argon2idPrefix) || len(fields) != 4 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", fields[0])
+	}
+	if _, err := fmt.Sscanf(fields[1], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters %q: %w", fields[1], err)
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters %q", fields[1])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(fields[3])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,28 @@
+package passwordhash
+
+import (
+	"errors"
+	"strings"
+)
+
+// Algorithms selectable by configuration.
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// ErrUnsupportedHash is returned when verifying a hash of an unknown format.
+var ErrUnsupportedHash = errors.New("unsupported password hash format")
+
+// Verify reports whether password matches encoded, which is either a bcrypt
+// hash or an Argon2id hash in PHC string format.
+func Verify(password, encoded string) (bool, error) {
+	switch {
+	case isBcrypt(encoded):
+		return verifyBcrypt(password, encoded)
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return verifyArgon2id(password, encoded)
+	default:
+		return false, ErrUnsupportedHash
+	}
+}
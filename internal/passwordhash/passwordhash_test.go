@@ -0,0 +1,135 @@
+package passwordhash
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keep the tests fast; they are far too cheap for real use.
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHashers(t *testing.T) {
+	bcryptHasher, err := NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher() error = %v", err)
+	}
+	argon2idHasher, err := NewArgon2idHasher(testArgon2idParams)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher interfaces.PasswordHasher
+		format *regexp.Regexp
+		other  interfaces.PasswordHasher
+	}{
+		{name: "bcrypt", hasher: bcryptHasher, format: regexp.MustCompile(`^\$2a\$04\$`), other: argon2idHasher},
+		{name: "argon2id", hasher: argon2idHasher, format: regexp.MustCompile(`^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`), other: bcryptHasher},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !tt.format.MatchString(encoded) {
+				t.Errorf("Hash() = %s, does not match %s", encoded, tt.format)
+			}
+			if again, _ := tt.hasher.Hash("correct horse"); again == encoded {
+				t.Error("expected hashes of the same password to be salted differently")
+			}
+
+			for _, hasher := range []interfaces.PasswordHasher{tt.hasher, tt.other} {
+				if ok, err := hasher.Verify("correct horse", encoded); err != nil || !ok {
+					t.Errorf("Verify() of the password = %v, %v, want true", ok, err)
+				}
+				if ok, err := hasher.Verify("wrong horse", encoded); err != nil || ok {
+					t.Errorf("Verify() of another password = %v, %v, want false", ok, err)
+				}
+			}
+
+			if tt.hasher.NeedsRehash(encoded) {
+				t.Error("expected no rehash with the same hasher")
+			}
+			if !tt.other.NeedsRehash(encoded) {
+				t.Error("expected a rehash with another algorithm")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash_Parameters(t *testing.T) {
+	bcryptHasher, _ := NewBcryptHasher(bcrypt.MinCost)
+	costlier, _ := NewBcryptHasher(bcrypt.MinCost + 1)
+	encoded, _ := bcryptHasher.Hash("correct horse")
+	if !costlier.NeedsRehash(encoded) {
+		t.Error("expected a rehash when the bcrypt cost changed")
+	}
+
+	argon2idHasher, _ := NewArgon2idHasher(testArgon2idParams)
+	encoded, _ = argon2idHasher.Hash("correct horse")
+	for _, params := range []Argon2idParams{
+		{Memory: 128, Iterations: 1, Parallelism: 1},
+		{Memory: 64, Iterations: 2, Parallelism: 1},
+		{Memory: 64, Iterations: 1, Parallelism: 2},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, KeyLength: 64},
+	} {
+		hasher, err := NewArgon2idHasher(params)
+		if err != nil {
+			t.Fatalf("NewArgon2idHasher(%+v) error = %v", params, err)
+		}
+		if !hasher.NeedsRehash(encoded) {
+			t.Errorf("expected a rehash for %+v", params)
+		}
+	}
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	argon2idHasher, _ := NewArgon2idHasher(testArgon2idParams)
+	encoded, _ := argon2idHasher.Hash("correct horse")
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "empty", encoded: ""},
+		{name: "unknown algorithm", encoded: "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA"},
+		{name: "plain text", encoded: "correct horse"},
+		{name: "wrong version", encoded: strings.Replace(encoded, "v=19", "v=16", 1)},
+		{name: "missing field", encoded: encoded[:strings.LastIndex(encoded, "$")]},
+		{name: "zero memory", encoded: strings.Replace(encoded, "m=64", "m=0", 1)},
+		{name: "invalid salt", encoded: strings.Replace(encoded, "p=1$", "p=1$!", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, err := Verify("correct horse", tt.encoded); err == nil || ok {
+				t.Errorf("Verify() = %v, %v, want an error", ok, err)
+			}
+			if !argon2idHasher.NeedsRehash(tt.encoded) {
+				t.Error("expected invalid hashes to need a rehash")
+			}
+		})
+	}
+
+	// bcrypt only reads 72 bytes, which must not let longer passwords match
+	bcryptHasher, _ := NewBcryptHasher(bcrypt.MinCost)
+	password := strings.Repeat("a", 72)
+	encoded, err := bcryptHasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if ok, err := Verify(password+"b", encoded); err != nil || ok {
+		t.Errorf("Verify() of a longer password = %v, %v, want false", ok, err)
+	}
+	if _, err := bcryptHasher.Hash(password + "b"); err == nil {
+		t.Error("expected bcrypt to refuse passwords over 72 bytes")
+	}
+}
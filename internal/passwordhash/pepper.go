@@ -0,0 +1,92 @@
+package passwordhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MinPepperBytes is the length of the shortest pepper accepted.
+const MinPepperBytes = 32
+
+// ErrUnknownPepper is returned for hashes whose pepper is no longer configured.
+var ErrUnknownPepper = errors.New("unknown password pepper")
+
+// Peppers hold the server-side secrets that are mixed into passwords before
+// they are hashed, so that leaked hashes cannot be cracked without them. Each
+// hash is stored with the ID of its pepper, which lets retired peppers keep
+// verifying until their users log in and are rehashed with the current one.
+type Peppers struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// NewPeppers creates Peppers from keys by ID, applying the key currentID to
+// new hashes.
+func NewPeppers(currentID string, keys map[string][]byte) (*Peppers, error) {
+	if _, ok := keys[currentID]; !ok || currentID == "" {
+		return nil, fmt.Errorf("current pepper %q is not configured", currentID)
+	}
+	for id, key := range keys {
+		if id == "" {
+			return nil, fmt.Errorf("pepper ID must not be empty")
+		}
+		if len(key) < MinPepperBytes {
+			return nil, fmt.Errorf("pepper %s must be at least %d bytes, got %d", id, MinPepperBytes, len(key))
+		}
+	}
+	return &Peppers{currentID: currentID, keys: keys}, nil
+}
+
+// CurrentID returns the ID of the pepper of new hashes, empty when p is nil
+// and passwords are hashed without pepper.
+func (p *Peppers) CurrentID() string {
+	if p == nil {
+		return ""
+	}
+	return p.currentID
+}
+
+// Apply returns the HMAC-SHA256 of password keyed with the pepper id, encoded
+// in base64 so that it also fits the 72 bytes bcrypt uses. An empty id
+// returns password unchanged.
+func (p *Peppers) Apply(id, password string) (string, error) {
+	if id == "" {
+		return password, nil
+	}
+	if p == nil || p.keys[id] == nil {
+		return "", fmt.Errorf("%w %s", ErrUnknownPepper, id)
+	}
+	mac := hmac.New(sha256.New, p.keys[id])
+	mac.Write([]byte(password))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// LoadPepper reads a base64 encoded pepper from the file at path or, when
+// path is empty, from the environment variable env.
+func LoadPepper(path, env string) ([]byte, error) {
+	var encoded string
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pepper: %w", err)
+		}
+		encoded = string(content)
+	} else {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("pepper environment variable %s is not set", env)
+		}
+		encoded = value
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pepper: %w", err)
+	}
+	return key, nil
+}
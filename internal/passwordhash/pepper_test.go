@@ -0,0 +1,106 @@
+package passwordhash
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPeppers_Apply(t *testing.T) {
+	peppers, err := NewPeppers("2", map[string][]byte{
+		"1": bytes.Repeat([]byte{1}, MinPepperBytes),
+		"2": bytes.Repeat([]byte{2}, MinPepperBytes),
+	})
+	if err != nil {
+		t.Fatalf("NewPeppers() error = %v", err)
+	}
+	if peppers.CurrentID() != "2" {
+		t.Errorf("CurrentID() = %q, want 2", peppers.CurrentID())
+	}
+
+	first, err := peppers.Apply("1", "correct horse")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	second, _ := peppers.Apply("2", "correct horse")
+	again, _ := peppers.Apply("1", "correct horse")
+	if first == second || first != again {
+		t.Errorf("expected peppers to be deterministic per key, got %q, %q and %q", first, again, second)
+	}
+	if len(first) > maxBcryptPasswordLength {
+		t.Errorf("expected peppered passwords to fit bcrypt, got %d bytes", len(first))
+	}
+
+	if plain, err := peppers.Apply("", "correct horse"); err != nil || plain != "correct horse" {
+		t.Errorf("Apply() without pepper = %q, %v, want the password", plain, err)
+	}
+	if _, err := peppers.Apply("3", "correct horse"); !errors.Is(err, ErrUnknownPepper) {
+		t.Errorf("Apply() with a retired pepper error = %v, want %v", err, ErrUnknownPepper)
+	}
+
+	var none *Peppers
+	if none.CurrentID() != "" {
+		t.Error("expected no current pepper without peppers")
+	}
+	if _, err := none.Apply("1", "correct horse"); !errors.Is(err, ErrUnknownPepper) {
+		t.Errorf("Apply() without peppers error = %v, want %v", err, ErrUnknownPepper)
+	}
+}
+
+func TestNewPeppers_Invalid(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, MinPepperBytes)
+	tests := []struct {
+		name    string
+		current string
+		keys    map[string][]byte
+	}{
+		{name: "missing current", current: "2", keys: map[string][]byte{"1": key}},
+		{name: "empty current", current: "", keys: map[string][]byte{"": key}},
+		{name: "short key", current: "1", keys: map[string][]byte{"1": key[:MinPepperBytes-1]}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewPeppers(tt.current, tt.keys); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestLoadPepper(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, MinPepperBytes)
+	encoded := base64.StdEncoding.EncodeToString(key)
+
+	path := filepath.Join(t.TempDir(), "pepper")
+	if err := os.WriteFile(path, []byte(encoded+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PASSWORD_PEPPER", encoded)
+	t.Setenv("TEST_INVALID_PEPPER", "not base64!")
+
+	tests := []struct {
+		name    string
+		path    string
+		env     string
+		wantErr bool
+	}{
+		{name: "file", path: path},
+		{name: "environment", env: "TEST_PASSWORD_PEPPER"},
+		{name: "file takes precedence", path: path, env: "TEST_INVALID_PEPPER"},
+		{name: "missing file", path: path + ".missing", wantErr: true},
+		{name: "unset environment variable", env: "TEST_UNSET_PEPPER", wantErr: true},
+		{name: "invalid encoding", env: "TEST_INVALID_PEPPER", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := LoadPepper(tt.path, tt.env)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: LoadPepper() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if !tt.wantErr && !bytes.Equal(got, key) {
+			t.Errorf("%s: LoadPepper() = %x, want %x", tt.name, got, key)
+		}
+	}
+}
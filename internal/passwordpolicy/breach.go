@@ -0,0 +1,111 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PrefixLength is the number of hex characters of the SHA-1 hash that
+	// select a range in the k-anonymity format.
+	PrefixLength = 5
+	// RangeFileExtension is appended to the prefix to name range files.
+	RangeFileExtension = ".txt"
+
+	hashLength = sha1.Size * 2
+)
+
+// BreachList looks up passwords in a local copy of the Have I Been Pwned
+// Pwned Passwords data. The copy is either a directory holding one range file
+// per hash prefix, named like "21BD1.txt" and containing "SUFFIX:COUNT" lines
+// as served by the range API, or a single file of "HASH:COUNT" lines that is
+// loaded into memory. Entries with a count of 0 are padding and never match.
+type BreachList struct {
+	dir    string
+	hashes map[string]struct{}
+}
+
+// OpenBreachList opens the breached password data at path.
+func OpenBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	if info.IsDir() {
+		return &BreachList{dir: path}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	hashes := map[string]struct{}{}
+	err = scanHashes(file, func(hash string) bool {
+		if len(hash) == hashLength {
+			hashes[hash] = struct{}{}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return &BreachList{hashes: hashes}, nil
+}
+
+// Contains reports whether password appears in the breached password data.
+func (b *BreachList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if b.hashes != nil {
+		_, ok := b.hashes[hash]
+		return ok, nil
+	}
+
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+	file, err := os.Open(filepath.Join(b.dir, prefix+RangeFileExtension))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open breached password range: %w", err)
+	}
+	defer file.Close()
+
+	found := false
+	err = scanHashes(file, func(entry string) bool {
+		found = entry == suffix
+		return !found
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read breached password range: %w", err)
+	}
+	return found, nil
+}
+
+// scanHashes calls fn with the uppercased hash of each entry of r with a
+// non-zero count until fn returns false.
+func scanHashes(r io.Reader, fn func(hash string) bool) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		hash, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		padding := count != "" && strings.TrimLeft(count, "0") == ""
+		if hash == "" || padding {
+			continue
+		}
+		if !fn(strings.ToUpper(hash)) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
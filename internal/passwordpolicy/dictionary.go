@@ -0,0 +1,33 @@
+package passwordpolicy
+
+// commonWords lists frequently chosen passwords and the words they are built
+// from, most common first. It is deliberately short: the breached password
+// list catches whole passwords, this list catches them as parts of longer ones.
+var commonWords = []string{
+	"password", "123456", "123456789", "qwerty", "12345678", "111111", "1234567890",
+	"1234567", "abc123", "iloveyou", "admin", "welcome", "monkey", "login", "letmein",
+	"dragon", "football", "baseball", "master", "sunshine", "princess", "qwertyuiop",
+	"solo", "starwars", "shadow", "superman", "michael", "trustno1", "passw0rd",
+	"hello", "freedom", "whatever", "charlie", "donald", "batman", "zaq1zaq1",
+	"qazwsx", "access", "mustang", "jordan", "jennifer", "hunter", "ranger", "buster",
+	"soccer", "hockey", "killer", "george", "andrew", "thomas", "robert", "daniel",
+	"jessica", "pepper", "summer", "winter", "spring", "autumn", "secret", "flower",
+	"cheese", "computer", "internet", "service", "default", "changeme", "root",
+	"user", "guest", "test", "temp", "pass", "love", "god", "money", "lovely",
+	"ninja", "matrix", "orange", "banana", "apple", "purple", "silver", "golden",
+	"tigger", "ginger", "hannah", "maggie", "cookie", "chocolate", "blink182",
+	"asdf", "asdfgh", "zxcvbn", "zxcvbnm", "qwer", "1q2w3e4r", "1qaz2wsx", "abcdef",
+	"abcd1234", "654321", "666666", "121212", "000000", "123123", "987654321",
+	"company", "office", "family", "friend", "london", "paris", "berlin", "america",
+}
+
+// commonWordRanks maps each common word to its rank, starting at 1.
+var commonWordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonWords))
+	for i, word := range commonWords {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
@@ -0,0 +1,159 @@
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation codes reported by Check.
+const (
+	CodeTooShort         = "too_short"
+	CodeTooLong          = "too_long"
+	CodeMissingLowercase = "missing_lowercase"
+	CodeMissingUppercase = "missing_uppercase"
+	CodeMissingDigit     = "missing_digit"
+	CodeMissingSymbol    = "missing_symbol"
+	CodeContainsUsername = "contains_username"
+	CodeTooWeak          = "too_weak"
+	CodeBreached         = "breached"
+)
+
+const (
+	// DefaultMinLength and DefaultMaxLength bound passwords when no policy is configured.
+	DefaultMinLength = 8
+	DefaultMaxLength = 64
+
+	// minUsernameMatch is the shortest username that is looked for in passwords;
+	// shorter ones match too many passwords by chance.
+	minUsernameMatch = 3
+)
+
+// Violation is a single rule a password breaks.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Policy decides which passwords users may choose. Lengths count characters,
+// not bytes. MinStrength is the lowest accepted Strength score, 0 to disable
+// the check, and Breaches, when set, rejects passwords known from breaches.
+type Policy struct {
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowUsername bool
+	MinStrength      int
+	Breaches         *BreachList
+}
+
+// Default returns the policy applied when none is configured, which only
+// bounds the length.
+func Default() *Policy {
+	return &Policy{MinLength: DefaultMinLength, MaxLength: DefaultMaxLength}
+}
+
+// Check returns every rule of p that password, chosen by username, breaks.
+// An error is only returned when the breached password list cannot be read.
+func (p *Policy) Check(password, username string) ([]Violation, error) {
+	var violations []Violation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		add(CodeTooShort, "password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(CodeTooLong, "password must be at most %d characters long", p.MaxLength)
+	}
+
+	classes := characterClasses(password)
+	if p.RequireLowercase && classes&classLower == 0 {
+		add(CodeMissingLowercase, "password must contain a lowercase letter")
+	}
+	if p.RequireUppercase && classes&classUpper == 0 {
+		add(CodeMissingUppercase, "password must contain an uppercase letter")
+	}
+	if p.RequireDigit && classes&classDigit == 0 {
+		add(CodeMissingDigit, "password must contain a digit")
+	}
+	if p.RequireSymbol && classes&classSymbol == 0 {
+		add(CodeMissingSymbol, "password must contain a symbol")
+	}
+
+	if p.DisallowUsername && containsUsername(password, username) {
+		add(CodeContainsUsername, "password must not contain the username")
+	}
+
+	if p.MinStrength > 0 && Strength(password, usernameParts(username)...) < p.MinStrength {
+		add(CodeTooWeak, "password is too easy to guess")
+	}
+
+	if p.Breaches != nil {
+		breached, err := p.Breaches.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			add(CodeBreached, "password has appeared in a data breach")
+		}
+	}
+	return violations, nil
+}
+
+// Character classes found by characterClasses.
+const (
+	classLower = 1 << iota
+	classUpper
+	classDigit
+	classSymbol
+)
+
+func characterClasses(password string) int {
+	classes := 0
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			classes |= classLower
+		case unicode.IsUpper(r):
+			classes |= classUpper
+		case unicode.IsDigit(r):
+			classes |= classDigit
+		default:
+			classes |= classSymbol
+		}
+	}
+	return classes
+}
+
+// containsUsername reports whether password contains username, or the local
+// part of a username that is an email address, ignoring case.
+func containsUsername(password, username string) bool {
+	password = strings.ToLower(password)
+	for _, part := range usernameParts(username) {
+		if utf8.RuneCountInString(part) >= minUsernameMatch && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// usernameParts returns the lowercased username and, for email addresses,
+// its local part.
+func usernameParts(username string) []string {
+	username = strings.ToLower(username)
+	if username == "" {
+		return nil
+	}
+	parts := []string{username}
+	if local, _, ok := strings.Cut(username, "@"); ok && local != "" {
+		parts = append(parts, local)
+	}
+	return parts
+}
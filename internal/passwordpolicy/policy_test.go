@@ -0,0 +1,120 @@
+package passwordpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// passwordSHA1 is the SHA-1 hash of "password".
+const passwordSHA1 = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func TestPolicy_Check(t *testing.T) {
+	strict := &Policy{
+		MinLength:        10,
+		MaxLength:        20,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+		MinStrength:      3,
+	}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		password string
+		username string
+		want     []string
+	}{
+		{name: "default accepts any long enough password", policy: Default(), password: "password", username: "alice"},
+		{name: "default rejects short password", policy: Default(), password: "short", want: []string{CodeTooShort}},
+		{name: "default rejects long password", policy: Default(), password: string(make([]rune, 65)), want: []string{CodeTooLong}},
+		{name: "lengths count characters", policy: Default(), password: "pässwörd", username: "alice"},
+		{name: "strict accepts strong password", policy: strict, password: "zW8$kLp3@rT6", username: "alice"},
+		{
+			name:     "strict reports every violation",
+			policy:   strict,
+			password: "alice",
+			username: "Alice",
+			want:     []string{CodeTooShort, CodeMissingUppercase, CodeMissingDigit, CodeMissingSymbol, CodeContainsUsername, CodeTooWeak},
+		},
+		{name: "username ignoring case", policy: strict, password: "xx-ALICE-9k#Q", username: "alice", want: []string{CodeContainsUsername}},
+		{name: "email local part", policy: strict, password: "Bob-wK7#zQ2p", username: "bob@example.com", want: []string{CodeContainsUsername}},
+		{name: "weak despite character classes", policy: strict, password: "Password123!", username: "alice", want: []string{CodeTooWeak}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := tt.policy.Check(tt.password, tt.username)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			var got []string
+			for _, violation := range violations {
+				if violation.Message == "" {
+					t.Errorf("violation %s has no message", violation.Code)
+				}
+				got = append(got, violation.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreachList_Contains(t *testing.T) {
+	dir := t.TempDir()
+	ranges := filepath.Join(dir, "ranges")
+	if err := os.Mkdir(ranges, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// a range file as served by the range API, with a padding entry
+	rangeFile := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" +
+		passwordSHA1[PrefixLength:] + ":9659365\r\n" +
+		"1E4C9B93F3F0682250B6CF8331B7EE68FD9:0\r\n"
+	if err := os.WriteFile(filepath.Join(ranges, passwordSHA1[:PrefixLength]+RangeFileExtension), []byte(rangeFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	single := filepath.Join(dir, "pwned-passwords.txt")
+	if err := os.WriteFile(single, []byte("7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195\n"+passwordSHA1+":9659365\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{ranges, single} {
+		breaches, err := OpenBreachList(path)
+		if err != nil {
+			t.Fatalf("OpenBreachList(%s) error = %v", path, err)
+		}
+
+		tests := []struct {
+			password string
+			want     bool
+		}{
+			{password: "password", want: true},
+			{password: "Password"},
+			{password: "zW8$kLp3@rT6"},
+		}
+		for _, tt := range tests {
+			got, err := breaches.Contains(tt.password)
+			if err != nil {
+				t.Fatalf("Contains(%q) error = %v", tt.password, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s: Contains(%q) = %v, want %v", filepath.Base(path), tt.password, got, tt.want)
+			}
+		}
+
+		violations, err := (&Policy{Breaches: breaches}).Check("password", "alice")
+		if err != nil || len(violations) != 1 || violations[0].Code != CodeBreached {
+			t.Errorf("expected a breached violation, got %v, %v", violations, err)
+		}
+	}
+
+	if _, err := OpenBreachList(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing breached password list")
+	}
+}
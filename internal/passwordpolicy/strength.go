@@ -0,0 +1,180 @@
+package passwordpolicy
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Guess counts, as powers of ten, that separate the Strength scores. They
+// follow zxcvbn: below 10^3 guesses a password falls to online guessing even
+// when throttled, at 10^10 and above it resists offline attacks on slow hashes.
+var strengthThresholds = []float64{3, 6, 8, 10}
+
+const (
+	// minWordLength is the shortest dictionary word matched within a password.
+	minWordLength = 3
+	// runStartGuesses is the number of choices when a run starts, one each
+	// for repeating, counting up, counting down or walking the keyboard.
+	runStartGuesses = 4
+)
+
+// leetSubstitutions undoes common character substitutions before dictionary lookups.
+var leetSubstitutions = strings.NewReplacer(
+	"4", "a", "@", "a", "8", "b", "3", "e", "1", "i", "!", "i",
+	"0", "o", "$", "s", "5", "s", "7", "t", "+", "t", "2", "z",
+)
+
+// keyboardRows are walked by passwords such as "qwerty" or "asdf".
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// Strength scores how hard password is to guess, from 0 for trivially
+// guessable to 4 for very unguessable, on the scale of zxcvbn. The number of
+// guesses is estimated by splitting the password into common words, repeated
+// or sequential characters and random characters; userInputs, such as the
+// username, count as the most common words.
+func Strength(password string, userInputs ...string) int {
+	guesses := estimateGuesses(password, userInputs)
+	for score, threshold := range strengthThresholds {
+		if guesses < threshold {
+			return score
+		}
+	}
+	return len(strengthThresholds)
+}
+
+// estimateGuesses returns the base 10 logarithm of the number of guesses an
+// attacker needs for password.
+func estimateGuesses(password string, userInputs []string) float64 {
+	original := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	if len(lower) != len(original) {
+		// case folding changed the length, so positions do not line up
+		lower = original
+	}
+	unleeted := []rune(leetSubstitutions.Replace(string(lower)))
+	if len(unleeted) != len(lower) {
+		unleeted = lower
+	}
+
+	ranks := make(map[string]int, len(userInputs))
+	for _, input := range userInputs {
+		ranks[strings.ToLower(input)] = 1
+	}
+
+	perCharacter := math.Log10(float64(cardinality(original)))
+	guesses := 0.0
+	for i := 0; i < len(lower); {
+		if end, rank, leet := longestWord(lower, unleeted, i, ranks); end > i {
+			guesses += math.Log10(float64(rank + 1))
+			guesses += variations(original[i:end], lower[i:end], unleeted[i:end], leet)
+			i = end
+			continue
+		}
+
+		if i > 0 && continuesRun(lower[i-1], lower[i]) {
+			// only the start of a run adds guesses
+			if i < 2 || !continuesRun(lower[i-2], lower[i-1]) {
+				guesses += math.Log10(runStartGuesses)
+			}
+		} else {
+			guesses += perCharacter
+		}
+		i++
+	}
+
+	// nobody needs more guesses than brute force
+	return math.Min(guesses, perCharacter*float64(len(original)))
+}
+
+// longestWord returns the end and rank of the longest known word starting at
+// start, or start if there is none. Words are looked up as typed and with
+// substitutions undone; leet reports whether only the latter matched.
+func longestWord(lower, unleeted []rune, start int, userInputs map[string]int) (int, int, bool) {
+	for end := len(lower); end-start >= minWordLength; end-- {
+		if rank, ok := wordRank(string(lower[start:end]), userInputs); ok {
+			return end, rank, false
+		}
+		if rank, ok := wordRank(string(unleeted[start:end]), userInputs); ok {
+			return end, rank, true
+		}
+	}
+	return start, 0, false
+}
+
+func wordRank(word string, userInputs map[string]int) (int, bool) {
+	if rank, ok := userInputs[word]; ok {
+		return rank, true
+	}
+	rank, ok := commonWordRanks[word]
+	return rank, ok
+}
+
+// variations returns the logarithm of the ways a dictionary word can be
+// capitalized and, when leet is set, substituted into what the password contains.
+func variations(original, lower, unleeted []rune, leet bool) float64 {
+	guesses := 0.0
+	if string(original) != string(lower) {
+		// first letter or all letters capitalized are tried first
+		guesses += math.Log10(2)
+		upper := 0
+		for _, r := range original {
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+		if upper > 1 && upper < len(original) {
+			guesses += float64(upper) * math.Log10(2)
+		}
+	}
+	if leet {
+		for i := range lower {
+			if lower[i] != unleeted[i] {
+				guesses += math.Log10(2)
+			}
+		}
+	}
+	return guesses
+}
+
+// continuesRun reports whether current repeats previous, follows it in the
+// alphabet or on the digits, or is next to it on a keyboard row.
+func continuesRun(previous, current rune) bool {
+	if previous == current {
+		return true
+	}
+	if delta := current - previous; (delta == 1 || delta == -1) &&
+		(unicode.IsLetter(previous) && unicode.IsLetter(current) || unicode.IsDigit(previous) && unicode.IsDigit(current)) {
+		return true
+	}
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, previous)
+		j := strings.IndexRune(row, current)
+		if i >= 0 && j >= 0 && (i-j == 1 || j-i == 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// cardinality returns the size of the character set password is drawn from.
+func cardinality(password []rune) int {
+	size := 0
+	classes := characterClasses(string(password))
+	if classes&classLower != 0 {
+		size += 26
+	}
+	if classes&classUpper != 0 {
+		size += 26
+	}
+	if classes&classDigit != 0 {
+		size += 10
+	}
+	if classes&classSymbol != 0 {
+		size += 33
+	}
+	if size == 0 {
+		size = 1
+	}
+	return size
+}
@@ -0,0 +1,31 @@
+package passwordpolicy
+
+import "testing"
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		password   string
+		userInputs []string
+		want       int
+	}{
+		{password: "", want: 0},
+		{password: "password", want: 0},
+		{password: "P@ssw0rd", want: 0},
+		{password: "12345678", want: 0},
+		{password: "qwertyuiop", want: 0},
+		{password: "aaaaaaaaaaaa", want: 0},
+		{password: "abcdefgh", want: 0},
+		{password: "iloveyou2", want: 0},
+		{password: "alicealice", userInputs: []string{"alice"}, want: 0},
+		{password: "alice2024", userInputs: []string{"alice"}, want: 2},
+		{password: "n3w-Passw0rd", want: 3},
+		{password: "zW8$kLp3@rT6", want: 4},
+		{password: "correct horse battery staple", want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := Strength(tt.password, tt.userInputs...); got != tt.want {
+			t.Errorf("Strength(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package policy
+
+import "reflect"
+
+// Condition operators.
+const (
+	OperatorEquals      = "equals"
+	OperatorNotEquals   = "not_equals"
+	OperatorIn          = "in"
+	OperatorNotIn       = "not_in"
+	OperatorContains    = "contains"
+	OperatorExists      = "exists"
+	OperatorNotExists   = "not_exists"
+	OperatorGreaterThan = "greater_than"
+	OperatorLessThan    = "less_than"
+)
+
+var operators = []string{
+	OperatorEquals, OperatorNotEquals, OperatorIn, OperatorNotIn, OperatorContains,
+	OperatorExists, OperatorNotExists, OperatorGreaterThan, OperatorLessThan,
+}
+
+// holds reports whether condition c is true for req. Conditions on missing
+// attributes are false, except not_exists; not_equals and not_in also need
+// both sides present.
+func (req *Request) holds(c Condition) bool {
+	left, ok := req.lookup(c.Attribute)
+	switch c.Operator {
+	case OperatorExists:
+		return ok
+	case OperatorNotExists:
+		return !ok
+	}
+	if !ok {
+		return false
+	}
+
+	right := c.Value
+	if c.ValueFrom != "" {
+		if right, ok = req.lookup(c.ValueFrom); !ok {
+			return false
+		}
+	}
+
+	switch c.Operator {
+	case OperatorEquals:
+		return equal(left, right)
+	case OperatorNotEquals:
+		return !equal(left, right)
+	case OperatorIn:
+		return contains(right, left)
+	case OperatorNotIn:
+		_, isList := list(right)
+		return isList && !contains(right, left)
+	case OperatorContains:
+		return contains(left, right)
+	case OperatorGreaterThan, OperatorLessThan:
+		l, lok := number(left)
+		r, rok := number(right)
+		if !lok || !rok {
+			return false
+		}
+		if c.Operator == OperatorGreaterThan {
+			return l > r
+		}
+		return l < r
+	}
+	return false
+}
+
+// equal compares attribute values, numbers by value whatever their type.
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		return ok && x == y
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return false
+}
+
+// contains reports whether collection is a list with an element equal to value.
+func contains(collection, value interface{}) bool {
+	elements, ok := list(collection)
+	if !ok {
+		return false
+	}
+	for _, element := range elements {
+		if equal(element, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the elements of a slice of any element type.
+func list(value interface{}) ([]interface{}, bool) {
+	if elements, ok := value.([]interface{}); ok {
+		return elements, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	elements := make([]interface{}, v.Len())
+	for i := range elements {
+		elements[i] = v.Index(i).Interface()
+	}
+	return elements, true
+}
+
+// number converts the numeric types produced by YAML, JSON and Go callers.
+func number(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attributes describe the subject, resource or environment of a request.
+// Values may be nested maps, which condition paths walk with dots.
+type Attributes map[string]interface{}
+
+// Request asks whether Subject may perform Action on Resource.
+type Request struct {
+	Subject     Attributes
+	Action      string
+	Resource    Attributes
+	Environment Attributes
+}
+
+// Decision is the outcome of evaluating a request. PolicyID names the policy
+// that decided, empty when no policy applied.
+type Decision struct {
+	Allowed  bool
+	PolicyID string
+	Reason   string
+}
+
+// Engine evaluates requests against a set of policies. A request is denied
+// when a deny policy applies, allowed when an allow policy applies and denied
+// otherwise.
+type Engine struct {
+	policies []Policy
+}
+
+// NewEngine validates policies and returns an engine evaluating them.
+func NewEngine(policies []Policy) (*Engine, error) {
+	if err := Validate(policies); err != nil {
+		return nil, err
+	}
+	return &Engine{policies: policies}, nil
+}
+
+// LoadEngine returns an engine evaluating the policies of the YAML file at path.
+func LoadEngine(path string) (*Engine, error) {
+	policies, err := LoadPolicies(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{policies: policies}, nil
+}
+
+// Policies returns the number of policies of the engine.
+func (e *Engine) Policies() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.policies)
+}
+
+// Evaluate decides req. Conditions on attributes the request lacks do not
+// hold, so deny policies should not depend on attributes callers may omit.
+func (e *Engine) Evaluate(req Request) Decision {
+	if e == nil {
+		return Decision{Reason: "no policy applies"}
+	}
+
+	resourceType := req.Resource[ResourceTypeAttribute]
+	var allowedBy string
+	for i := range e.policies {
+		p := &e.policies[i]
+		if !p.matches(req.Action, resourceType) || !req.satisfies(p.Conditions) {
+			continue
+		}
+		if p.Effect == EffectDeny {
+			return Decision{PolicyID: p.ID, Reason: fmt.Sprintf("denied by policy %s", p.ID)}
+		}
+		if allowedBy == "" {
+			allowedBy = p.ID
+		}
+	}
+
+	if allowedBy == "" {
+		return Decision{Reason: "no policy applies"}
+	}
+	return Decision{Allowed: true, PolicyID: allowedBy, Reason: fmt.Sprintf("allowed by policy %s", allowedBy)}
+}
+
+// satisfies reports whether all conditions hold for req.
+func (req *Request) satisfies(conditions []Condition) bool {
+	for _, c := range conditions {
+		if !req.holds(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the attribute at path and whether it is present.
+func (req *Request) lookup(path string) (interface{}, bool) {
+	category, rest, _ := strings.Cut(path, ".")
+	var value interface{}
+	switch category {
+	case CategoryAction:
+		return req.Action, true
+	case CategorySubject:
+		value = map[string]interface{}(req.Subject)
+	case CategoryResource:
+		value = map[string]interface{}(req.Resource)
+	case CategoryEnvironment:
+		value = map[string]interface{}(req.Environment)
+	default:
+		return nil, false
+	}
+
+	for _, key := range strings.Split(rest, ".") {
+		switch attributes := value.(type) {
+		case map[string]interface{}:
+			value = attributes[key]
+		case Attributes:
+			value = attributes[key]
+		default:
+			return nil, false
+		}
+		if value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,140 @@
+package policy
+
+import "testing"
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := LoadEngine("../../res/policies.yaml")
+	if err != nil {
+		t.Fatalf("LoadEngine() error = %v", err)
+	}
+
+	alice := Attributes{"sub": "alice", "tenant": "acme"}
+	bob := Attributes{"sub": "bob", "tenant": "acme"}
+	document := Attributes{"type": "document", "owner": "alice", "tenant": "acme"}
+
+	tests := []struct {
+		name         string
+		request      Request
+		wantAllowed  bool
+		wantPolicyID string
+	}{
+		{
+			name:         "owner edits own document",
+			request:      Request{Subject: alice, Action: "edit", Resource: document},
+			wantAllowed:  true,
+			wantPolicyID: "document-owner",
+		},
+		{
+			name:         "tenant member reads document",
+			request:      Request{Subject: bob, Action: "read", Resource: document},
+			wantAllowed:  true,
+			wantPolicyID: "document-tenant-read",
+		},
+		{
+			name:    "tenant member cannot edit document of another owner",
+			request: Request{Subject: bob, Action: "edit", Resource: document},
+		},
+		{
+			name:    "owner in another tenant cannot edit document",
+			request: Request{Subject: Attributes{"sub": "alice", "tenant": "globex"}, Action: "edit", Resource: document},
+		},
+		{
+			name:         "deny overrides allow",
+			request:      Request{Subject: alice, Action: "edit", Resource: Attributes{"type": "document", "owner": "alice", "tenant": "acme", "archived": true}},
+			wantPolicyID: "archived-document-read-only",
+		},
+		{
+			name:    "missing attributes do not match",
+			request: Request{Subject: Attributes{"sub": "alice"}, Action: "edit", Resource: Attributes{"type": "document", "owner": "alice"}},
+		},
+		{
+			name:    "unknown resource type",
+			request: Request{Subject: alice, Action: "edit", Resource: Attributes{"type": "invoice", "owner": "alice", "tenant": "acme"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.request)
+			if decision.Allowed != tt.wantAllowed {
+				t.Errorf("expected allowed %v, got %v: %s", tt.wantAllowed, decision.Allowed, decision.Reason)
+			}
+			if decision.PolicyID != tt.wantPolicyID {
+				t.Errorf("expected policy %q, got %q", tt.wantPolicyID, decision.PolicyID)
+			}
+		})
+	}
+}
+
+func TestRequest_Holds(t *testing.T) {
+	req := &Request{
+		Subject:     Attributes{"sub": "alice", "roles": []string{"editor", "user"}, "profile": map[string]interface{}{"level": 3}},
+		Action:      "edit",
+		Resource:    Attributes{"size": 2048.0, "labels": []interface{}{"public"}},
+		Environment: Attributes{"time": int64(1700000000)},
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{name: "equals", condition: Condition{Attribute: "subject.sub", Operator: OperatorEquals, Value: "alice"}, want: true},
+		{name: "equals other type", condition: Condition{Attribute: "subject.sub", Operator: OperatorEquals, Value: 1}},
+		{name: "not equals", condition: Condition{Attribute: "subject.sub", Operator: OperatorNotEquals, Value: "bob"}, want: true},
+		{name: "not equals on missing attribute", condition: Condition{Attribute: "subject.tenant", Operator: OperatorNotEquals, Value: "acme"}},
+		{name: "in", condition: Condition{Attribute: "action", Operator: OperatorIn, Value: []interface{}{"read", "edit"}}, want: true},
+		{name: "not in", condition: Condition{Attribute: "action", Operator: OperatorNotIn, Value: []interface{}{"delete"}}, want: true},
+		{name: "contains in string list", condition: Condition{Attribute: "subject.roles", Operator: OperatorContains, Value: "editor"}, want: true},
+		{name: "contains in decoded list", condition: Condition{Attribute: "resource.labels", Operator: OperatorContains, Value: "secret"}},
+		{name: "nested attribute", condition: Condition{Attribute: "subject.profile.level", Operator: OperatorGreaterThan, Value: 2}, want: true},
+		{name: "numbers of different types", condition: Condition{Attribute: "resource.size", Operator: OperatorEquals, Value: 2048}, want: true},
+		{name: "less than attribute", condition: Condition{Attribute: "subject.profile.level", Operator: OperatorLessThan, ValueFrom: "resource.size"}, want: true},
+		{name: "greater than time", condition: Condition{Attribute: "environment.time", Operator: OperatorGreaterThan, Value: 1600000000}, want: true},
+		{name: "exists", condition: Condition{Attribute: "resource.labels", Operator: OperatorExists}, want: true},
+		{name: "not exists", condition: Condition{Attribute: "resource.owner", Operator: OperatorNotExists}, want: true},
+		{name: "path through a value", condition: Condition{Attribute: "subject.sub.name", Operator: OperatorExists}},
+		{name: "missing value_from", condition: Condition{Attribute: "subject.sub", Operator: OperatorEquals, ValueFrom: "resource.owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := req.holds(tt.condition); got != tt.want {
+				t.Errorf("holds(%v) = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	allow := func(conditions ...Condition) Policy {
+		return Policy{ID: "p", Effect: EffectAllow, Actions: []string{"read"}, Conditions: conditions}
+	}
+
+	tests := []struct {
+		name     string
+		policies []Policy
+		wantErr  bool
+	}{
+		{name: "valid", policies: []Policy{allow(Condition{Attribute: "resource.owner", Operator: OperatorEquals, ValueFrom: "subject.sub"})}},
+		{name: "missing id", policies: []Policy{{Effect: EffectAllow, Actions: []string{"read"}}}, wantErr: true},
+		{name: "duplicate id", policies: []Policy{allow(), allow()}, wantErr: true},
+		{name: "unknown effect", policies: []Policy{{ID: "p", Effect: "permit", Actions: []string{"read"}}}, wantErr: true},
+		{name: "no actions", policies: []Policy{{ID: "p", Effect: EffectDeny}}, wantErr: true},
+		{name: "unknown operator", policies: []Policy{allow(Condition{Attribute: "subject.sub", Operator: "matches", Value: "a"})}, wantErr: true},
+		{name: "unknown category", policies: []Policy{allow(Condition{Attribute: "user.sub", Operator: OperatorEquals, Value: "a"})}, wantErr: true},
+		{name: "category without attribute", policies: []Policy{allow(Condition{Attribute: "subject", Operator: OperatorExists})}, wantErr: true},
+		{name: "missing value", policies: []Policy{allow(Condition{Attribute: "subject.sub", Operator: OperatorEquals})}, wantErr: true},
+		{name: "value and value_from", policies: []Policy{allow(Condition{Attribute: "subject.sub", Operator: OperatorEquals, Value: "a", ValueFrom: "resource.owner"})}, wantErr: true},
+		{name: "in without list", policies: []Policy{allow(Condition{Attribute: "action", Operator: OperatorIn, Value: "read"})}, wantErr: true},
+		{name: "exists with value", policies: []Policy{allow(Condition{Attribute: "subject.sub", Operator: OperatorExists, Value: "a"})}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.policies); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Wildcard matches every action or resource type.
+const Wildcard = "*"
+
+// Attribute categories that condition paths start with.
+const (
+	CategorySubject     = "subject"
+	CategoryResource    = "resource"
+	CategoryEnvironment = "environment"
+	CategoryAction      = "action"
+)
+
+// ResourceTypeAttribute is the resource attribute matched against the
+// resource types of a policy.
+const ResourceTypeAttribute = "type"
+
+// Policy allows or denies actions on resources when all its conditions hold.
+// An empty Resources list matches every resource type.
+type Policy struct {
+	ID          string      `yaml:"id"`
+	Description string      `yaml:"description"`
+	Effect      string      `yaml:"effect"`
+	Actions     []string    `yaml:"actions"`
+	Resources   []string    `yaml:"resources"`
+	Conditions  []Condition `yaml:"conditions"`
+}
+
+// Condition compares the attribute at path Attribute, such as
+// "subject.tenant" or "resource.owner", with Value or with the attribute at
+// path ValueFrom.
+type Condition struct {
+	Attribute string      `yaml:"attribute"`
+	Operator  string      `yaml:"operator"`
+	Value     interface{} `yaml:"value"`
+	ValueFrom string      `yaml:"value_from"`
+}
+
+// policyFile is the layout of a policy file.
+type policyFile struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// LoadPolicies reads and validates the policies of the YAML file at path.
+func LoadPolicies(path string) ([]Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies: %w", err)
+	}
+
+	file := &policyFile{}
+	if err := yaml.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("failed to parse policies: %w", err)
+	}
+	if err := Validate(file.Policies); err != nil {
+		return nil, err
+	}
+	return file.Policies, nil
+}
+
+// Validate checks that policies have unique IDs, a known effect, at least
+// one action and well formed conditions.
+func Validate(policies []Policy) error {
+	ids := map[string]bool{}
+	for i, p := range policies {
+		if p.ID == "" {
+			return fmt.Errorf("policy %d has no id", i)
+		}
+		if ids[p.ID] {
+			return fmt.Errorf("policy %s is defined twice", p.ID)
+		}
+		ids[p.ID] = true
+
+		if p.Effect != EffectAllow && p.Effect != EffectDeny {
+			return fmt.Errorf("policy %s has invalid effect %q", p.ID, p.Effect)
+		}
+		if len(p.Actions) == 0 {
+			return fmt.Errorf("policy %s has no actions", p.ID)
+		}
+		for j, c := range p.Conditions {
+			if err := c.validate(); err != nil {
+				return fmt.Errorf("policy %s condition %d: %w", p.ID, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c Condition) validate() error {
+	if err := validatePath(c.Attribute); err != nil {
+		return err
+	}
+	if !slices.Contains(operators, c.Operator) {
+		return fmt.Errorf("unknown operator %q", c.Operator)
+	}
+	if c.Operator == OperatorExists || c.Operator == OperatorNotExists {
+		if c.Value != nil || c.ValueFrom != "" {
+			return fmt.Errorf("operator %s takes no value", c.Operator)
+		}
+		return nil
+	}
+
+	if c.ValueFrom != "" {
+		if c.Value != nil {
+			return fmt.Errorf("value and value_from are exclusive")
+		}
+		return validatePath(c.ValueFrom)
+	}
+	if c.Value == nil {
+		return fmt.Errorf("operator %s needs a value or value_from", c.Operator)
+	}
+	if _, ok := c.Value.([]interface{}); !ok && (c.Operator == OperatorIn || c.Operator == OperatorNotIn) {
+		return fmt.Errorf("operator %s needs a list value", c.Operator)
+	}
+	return nil
+}
+
+// validatePath checks that path names an attribute category.
+func validatePath(path string) error {
+	category, _, _ := strings.Cut(path, ".")
+	switch category {
+	case CategoryAction:
+		if path != CategoryAction {
+			return fmt.Errorf("action has no attributes: %q", path)
+		}
+		return nil
+	case CategorySubject, CategoryResource, CategoryEnvironment:
+		if !strings.Contains(path, ".") {
+			return fmt.Errorf("attribute path %q names no attribute", path)
+		}
+		return nil
+	default:
+		return fmt.Errorf("attribute path %q must start with subject, resource, environment or action", path)
+	}
+}
+
+// matches reports whether the policy targets action on resourceType.
+func (p *Policy) matches(action string, resourceType interface{}) bool {
+	if !slices.Contains(p.Actions, Wildcard) && !slices.Contains(p.Actions, action) {
+		return false
+	}
+	if len(p.Resources) == 0 || slices.Contains(p.Resources, Wildcard) {
+		return true
+	}
+	name, ok := resourceType.(string)
+	return ok && slices.Contains(p.Resources, name)
+}
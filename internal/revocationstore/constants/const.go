@@ -0,0 +1,6 @@
+package constants
+
+const (
+	RevokedTokensCollection   = "revoked_tokens"
+	RevokedSubjectsCollection = "revoked_subjects"
+)
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+)
+
+const (
+	// SweepInterval is the minimum time between sweeps of expired entries.
+	SweepInterval = time.Minute
+)
+
+// MemoryRevocationStore keeps revoked token IDs and subjects in memory. Each
+// entry lives until the remaining lifetime of its tokens has passed.
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time
+	subjects  map[string]revokedSubject
+	lastSweep time.Time
+}
+
+// revokedSubject revokes the tokens of a subject issued at or before before.
+type revokedSubject struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryRevocationStore returns an empty in-memory revocation store.
+func NewMemoryRevocationStore() interfaces.RevocationStore {
+	return &MemoryRevocationStore{
+		revoked:   make(map[string]time.Time),
+		subjects:  make(map[string]revokedSubject),
+		lastSweep: time.Now(),
+	}
+}
+
+// Revoke marks jti as revoked until expiresAt. Tokens that already expired are ignored.
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= SweepInterval {
+		s.sweep(now)
+	}
+
+	if !expiresAt.After(now) {
+		return nil
+	}
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked reports whether jti is revoked and its token has not expired yet.
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.revoked[jti]
+	if !ok {
+		return false, nil
+	}
+	if !expiresAt.After(time.Now()) {
+		delete(s.revoked, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+// RevokeSubject revokes the tokens of subject issued at or before issuedBefore
+// until expiresAt. A later cutoff replaces an earlier one.
+func (s *MemoryRevocationStore) RevokeSubject(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= SweepInterval {
+		s.sweep(now)
+	}
+
+	if !expiresAt.After(now) {
+		return nil
+	}
+	if s.subjects == nil {
+		s.subjects = make(map[string]revokedSubject)
+	}
+	if current, ok := s.subjects[subject]; ok && current.before.After(issuedBefore) {
+		return nil
+	}
+	s.subjects[subject] = revokedSubject{before: issuedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+// IsSubjectRevoked reports whether tokens of subject issued at issuedAt are
+// revoked. Token issue times have whole seconds, so tokens issued in the second
+// of the cutoff are revoked even when issued right after it.
+func (s *MemoryRevocationStore) IsSubjectRevoked(ctx context.Context, subject string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoked, ok := s.subjects[subject]
+	if !ok {
+		return false, nil
+	}
+	if !revoked.expiresAt.After(time.Now()) {
+		delete(s.subjects, subject)
+		return false, nil
+	}
+	return issuedAt.Unix() <= revoked.before.Unix(), nil
+}
+
+// EnsureIndices is a no-op for the in-memory store.
+func (s *MemoryRevocationStore) EnsureIndices(ctx context.Context) error {
+	return nil
+}
+
+// sweep removes expired entries. The caller must hold the lock.
+func (s *MemoryRevocationStore) sweep(now time.Time) {
+	for jti, expiresAt := range s.revoked {
+		if !expiresAt.After(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	for subject, revoked := range s.subjects {
+		if !revoked.expiresAt.After(now) {
+			delete(s.subjects, subject)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		jti         string
+		expiresAt   time.Time
+		wantRevoked bool
+	}{
+		{
+			name:        "revoked token within its lifetime",
+			jti:         "active-jti",
+			expiresAt:   time.Now().Add(time.Minute),
+			wantRevoked: true,
+		},
+		{
+			name:        "already expired token is not kept",
+			jti:         "expired-jti",
+			expiresAt:   time.Now().Add(-time.Minute),
+			wantRevoked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryRevocationStore()
+			ctx := context.Background()
+
+			if err := store.Revoke(ctx, tt.jti, tt.expiresAt); err != nil {
+				t.Fatalf("Revoke() error = %v", err)
+			}
+
+			got, err := store.IsRevoked(ctx, tt.jti)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.wantRevoked {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.wantRevoked)
+			}
+
+			unknown, err := store.IsRevoked(ctx, "unknown-jti")
+			if err != nil || unknown {
+				t.Errorf("IsRevoked() for unknown jti = %v, %v, want false, nil", unknown, err)
+			}
+		})
+	}
+}
+
+func TestMemoryRevocationStore_Expiry(t *testing.T) {
+	store := &MemoryRevocationStore{revoked: make(map[string]time.Time), lastSweep: time.Now()}
+	ctx := context.Background()
+
+	if err := store.Revoke(ctx, "short-lived", time.Now().Add(50*time.Millisecond)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	got, err := store.IsRevoked(ctx, "short-lived")
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if got {
+		t.Error("expected entry to expire with the token")
+	}
+	if len(store.revoked) != 0 {
+		t.Errorf("expected expired entry to be removed, %d left", len(store.revoked))
+	}
+}
+
+func TestMemoryRevocationStore_RevokeSubject(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+	cutoff := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+
+	if err := store.RevokeSubject(ctx, "testuser", cutoff, cutoff.Add(time.Minute)); err != nil {
+		t.Fatalf("RevokeSubject() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		subject     string
+		issuedAt    time.Time
+		wantRevoked bool
+	}{
+		{
+			name:        "token issued before the cutoff",
+			subject:     "testuser",
+			issuedAt:    cutoff.Add(-time.Minute),
+			wantRevoked: true,
+		},
+		{
+			name:        "token issued in the second before the cutoff",
+			subject:     "testuser",
+			issuedAt:    cutoff.Add(-time.Second),
+			wantRevoked: true,
+		},
+		{
+			// token issue times have whole seconds
+			name:        "token issued in the second of the cutoff",
+			subject:     "testuser",
+			issuedAt:    cutoff.Truncate(time.Second),
+			wantRevoked: true,
+		},
+		{
+			name:        "token issued after the cutoff",
+			subject:     "testuser",
+			issuedAt:    cutoff.Add(time.Second),
+			wantRevoked: false,
+		},
+		{
+			name:        "token of another subject",
+			subject:     "otheruser",
+			issuedAt:    cutoff.Add(-time.Minute),
+			wantRevoked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := store.IsSubjectRevoked(ctx, tt.subject, tt.issuedAt)
+		if err != nil {
+			t.Fatalf("%s: IsSubjectRevoked() error = %v", tt.name, err)
+		}
+		if got != tt.wantRevoked {
+			t.Errorf("%s: IsSubjectRevoked() = %v, want %v", tt.name, got, tt.wantRevoked)
+		}
+	}
+
+	// an earlier cutoff does not shorten a later one
+	if err := store.RevokeSubject(ctx, "testuser", cutoff.Add(-time.Hour), cutoff.Add(time.Minute)); err != nil {
+		t.Fatalf("RevokeSubject() error = %v", err)
+	}
+	if got, _ := store.IsSubjectRevoked(ctx, "testuser", cutoff.Add(-time.Minute)); !got {
+		t.Error("expected the later cutoff to be kept")
+	}
+}
@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/revocationstore/constants"
+
+	mongoClient "github.com/haguru/sasuke/pkg/databases/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongosdk "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DuplicateKeyErrorCode = "E11000 duplicate key error"
+)
+
+type MongoRevocationStore struct {
+	dbClient interfaces.DBClient
+}
+
+// revokedToken is a models.RevokedToken as stored in MongoDB. The expiry is a
+// date so that a TTL index deletes the entry once the token has expired.
+type revokedToken struct {
+	JTI       string    `bson:"jti"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// revokedSubject is a models.RevokedSubject as stored in MongoDB, expiring
+// like revokedToken.
+type revokedSubject struct {
+	Subject       string    `bson:"subject"`
+	RevokedBefore int64     `bson:"revoked_before"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
+
+// NewMongoRevocationStore returns a revocation store backed by MongoDB.
+func NewMongoRevocationStore(dbClient interfaces.DBClient) (interfaces.RevocationStore, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type MongoDBClient
+	if _, ok := dbClient.(*mongoClient.MongoDBClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a MongoDB client")
+	}
+	return &MongoRevocationStore{dbClient: dbClient}, nil
+}
+
+// Revoke records the token ID in MongoDB.
+func (s *MongoRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	revokedMap := map[string]interface{}{"jti": jti, "expires_at": expiresAt.UTC()}
+	if _, err := s.dbClient.InsertOne(ctx, constants.RevokedTokensCollection, revokedMap); err != nil {
+		if strings.Contains(err.Error(), DuplicateKeyErrorCode) {
+			return nil // already revoked
+		}
+		return fmt.Errorf("failed to revoke token in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token ID is on the revocation list and not yet expired.
+func (s *MongoRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked revokedToken
+	filter := map[string]any{"jti": jti}
+	err := s.dbClient.FindOne(ctx, constants.RevokedTokensCollection, filter, &revoked)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check revoked token in MongoDB: %w", err)
+	}
+
+	// the TTL monitor runs about once a minute, so expired entries may linger
+	return revoked.ExpiresAt.After(time.Now()), nil
+}
+
+// RevokeSubject records the subject revocation in MongoDB, replacing an earlier one.
+func (s *MongoRevocationStore) RevokeSubject(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	revokedMap := map[string]interface{}{"subject": subject, "revoked_before": issuedBefore.Unix(), "expires_at": expiresAt.UTC()}
+	_, err := s.dbClient.InsertOne(ctx, constants.RevokedSubjectsCollection, revokedMap)
+	if err == nil {
+		return nil
+	}
+	if !strings.Contains(err.Error(), DuplicateKeyErrorCode) {
+		return fmt.Errorf("failed to revoke subject in MongoDB: %w", err)
+	}
+
+	// the subject was revoked before; move its cutoff forward
+	filter := map[string]any{"subject": subject}
+	update := map[string]any{"$set": map[string]any{"revoked_before": issuedBefore.Unix(), "expires_at": expiresAt.UTC()}}
+	if _, err := s.dbClient.UpdateOne(ctx, constants.RevokedSubjectsCollection, filter, update); err != nil {
+		return fmt.Errorf("failed to revoke subject in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// IsSubjectRevoked reports whether tokens of subject issued at issuedAt are revoked.
+func (s *MongoRevocationStore) IsSubjectRevoked(ctx context.Context, subject string, issuedAt time.Time) (bool, error) {
+	var revoked revokedSubject
+	filter := map[string]any{"subject": subject}
+	err := s.dbClient.FindOne(ctx, constants.RevokedSubjectsCollection, filter, &revoked)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check revoked subject in MongoDB: %w", err)
+	}
+
+	return revoked.ExpiresAt.After(time.Now()) && issuedAt.Unix() <= revoked.RevokedBefore, nil
+}
+
+// EnsureIndices creates unique indices on the token ID and the revoked
+// subject, and TTL indices that delete entries once they expire.
+func (s *MongoRevocationStore) EnsureIndices(ctx context.Context) error {
+	indexModel := mongosdk.IndexModel{
+		Keys:    bson.M{"jti": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := s.dbClient.EnsureSchema(ctx, constants.RevokedTokensCollection, indexModel); err != nil {
+		return err
+	}
+
+	subjectIndex := mongosdk.IndexModel{
+		Keys:    bson.M{"subject": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	if err := s.dbClient.EnsureSchema(ctx, constants.RevokedSubjectsCollection, subjectIndex); err != nil {
+		return err
+	}
+
+	for _, collection := range []string{constants.RevokedTokensCollection, constants.RevokedSubjectsCollection} {
+		expiryIndex := mongosdk.IndexModel{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}
+		if err := s.dbClient.EnsureSchema(ctx, collection, expiryIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
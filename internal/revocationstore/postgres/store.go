@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/lib/pq"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/revocationstore/constants"
+	"github.com/haguru/sasuke/pkg/databases/postgres"
+)
+
+const (
+	// Unique_ErrorCode is the PostgreSQL error code for unique constraint violations.
+	Unique_ErrorCode = "23505"
+
+	// pruneInterval is how often writes delete expired revocations.
+	pruneInterval = time.Hour
+)
+
+var ensureSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			jti TEXT NOT NULL UNIQUE,
+			expires_at BIGINT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+	`
+
+var ensureSubjectsSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS revoked_subjects (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			subject TEXT NOT NULL UNIQUE,
+			revoked_before BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL
+		);
+	`
+
+type PostgresRevocationStore struct {
+	dbClient interfaces.DBClient
+	// lastPrune is the Unix time expired revocations were last deleted.
+	lastPrune atomic.Int64
+}
+
+// NewPostgresRevocationStore returns a revocation store backed by PostgreSQL.
+func NewPostgresRevocationStore(dbClient interfaces.DBClient) (interfaces.RevocationStore, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("dbClient cannot be nil")
+	}
+	// Ensure the dbClient is of type PostgresDatabaseClient
+	if _, ok := dbClient.(*postgres.PostgresDatabaseClient); !ok {
+		return nil, fmt.Errorf("dbClient must be a PostgreSQL client")
+	}
+	return &PostgresRevocationStore{dbClient: dbClient}, nil
+}
+
+// Revoke records the token ID in PostgreSQL.
+func (s *PostgresRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.pruneExpired(ctx)
+
+	revoked := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt.Unix()}
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(revoked, &doc); err != nil {
+		return fmt.Errorf("failed to decode revoked token model: %w", err)
+	}
+
+	if _, err := s.dbClient.InsertOne(ctx, constants.RevokedTokensCollection, doc); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == Unique_ErrorCode {
+			return nil // already revoked
+		}
+		return fmt.Errorf("failed to revoke token in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token ID is on the revocation list and not yet expired.
+func (s *PostgresRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked models.RevokedToken
+	filter := map[string]interface{}{"jti": jti}
+	if err := s.dbClient.FindOne(ctx, constants.RevokedTokensCollection, filter, &revoked); err != nil {
+		return false, fmt.Errorf("failed to check revoked token in PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if revoked.JTI == "" {
+		return false, nil
+	}
+	return revoked.ExpiresAt > time.Now().Unix(), nil
+}
+
+// RevokeSubject records the subject revocation in PostgreSQL, replacing an earlier one.
+func (s *PostgresRevocationStore) RevokeSubject(ctx context.Context, subject string, issuedBefore, expiresAt time.Time) error {
+	s.pruneExpired(ctx)
+
+	revoked := models.RevokedSubject{Subject: subject, RevokedBefore: issuedBefore.Unix(), ExpiresAt: expiresAt.Unix()}
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(revoked, &doc); err != nil {
+		return fmt.Errorf("failed to decode revoked subject model: %w", err)
+	}
+
+	_, err := s.dbClient.InsertOne(ctx, constants.RevokedSubjectsCollection, doc)
+	if err == nil {
+		return nil
+	}
+	if pgErr, ok := err.(*pq.Error); !ok || pgErr.Code != Unique_ErrorCode {
+		return fmt.Errorf("failed to revoke subject in PostgreSQL: %w", err)
+	}
+
+	// the subject was revoked before; move its cutoff forward
+	filter := map[string]interface{}{"subject": subject}
+	update := map[string]interface{}{"revoked_before": revoked.RevokedBefore, "expires_at": revoked.ExpiresAt}
+	if _, err := s.dbClient.UpdateOne(ctx, constants.RevokedSubjectsCollection, filter, update); err != nil {
+		return fmt.Errorf("failed to revoke subject in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// IsSubjectRevoked reports whether tokens of subject issued at issuedAt are revoked.
+func (s *PostgresRevocationStore) IsSubjectRevoked(ctx context.Context, subject string, issuedAt time.Time) (bool, error) {
+	var revoked models.RevokedSubject
+	filter := map[string]interface{}{"subject": subject}
+	if err := s.dbClient.FindOne(ctx, constants.RevokedSubjectsCollection, filter, &revoked); err != nil {
+		return false, fmt.Errorf("failed to check revoked subject in PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if revoked.Subject == "" {
+		return false, nil
+	}
+	return revoked.ExpiresAt > time.Now().Unix() && issuedAt.Unix() <= revoked.RevokedBefore, nil
+}
+
+// pruneExpired deletes the revocations of expired tokens, which PostgreSQL
+// does not remove on its own. It runs at most once per pruneInterval and a
+// failure is only logged, as the revocations stay correct without it.
+func (s *PostgresRevocationStore) pruneExpired(ctx context.Context) {
+	now := time.Now().Unix()
+	last := s.lastPrune.Load()
+	if now-last < int64(pruneInterval/time.Second) || !s.lastPrune.CompareAndSwap(last, now) {
+		return
+	}
+
+	client, ok := s.dbClient.(*postgres.PostgresDatabaseClient)
+	if !ok {
+		return
+	}
+	for _, table := range []string{constants.RevokedTokensCollection, constants.RevokedSubjectsCollection} {
+		if _, err := client.DeleteBefore(ctx, table, "expires_at", now); err != nil {
+			fmt.Printf("failed to prune expired revocations from %s in PostgreSQL: %v\n", table, err)
+		}
+	}
+}
+
+// EnsureIndices creates the revoked tokens and revoked subjects tables.
+func (s *PostgresRevocationStore) EnsureIndices(ctx context.Context) error {
+	if err := s.dbClient.EnsureSchema(ctx, constants.RevokedTokensCollection, ensureSchemaSQL); err != nil {
+		return err
+	}
+	return s.dbClient.EnsureSchema(ctx, constants.RevokedSubjectsCollection, ensureSubjectsSchemaSQL)
+}
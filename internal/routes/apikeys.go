@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// APIKeys lists the API keys of the caller on GET and creates one on POST.
+// Keys are managed with a session and limited to the scopes of that session.
+// A new key is returned once and cannot be retrieved again.
+func (r *Route) APIKeys(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(APIKeyRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, APIKeyFailedTotal)
+	if !ok {
+		return
+	}
+
+	if req.Method == http.MethodGet {
+		keys, err := r.UserService.ListAPIKeys(req.Context(), username)
+		if err != nil {
+			r.jsonError(w, apiKeyErrorStatus(err), err, "Failed to list API keys", APIKeyFailedTotal)
+			return
+		}
+
+		response := &dto.APIKeyListResponseDTO{APIKeys: make([]dto.APIKeyDTO, 0, len(keys))}
+		for i := range keys {
+			response.APIKeys = append(response.APIKeys, apiKeyDTO(&keys[i]))
+		}
+		w.Header().Set(ContentType, ContentTypeJson)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	createRequest := &dto.APIKeyCreateRequestDTO{}
+	if !r.decodeJSONRequest(w, req, createRequest, APIKeyFailedTotal) {
+		return
+	}
+	// a key never gets more scopes than the session creating it
+	claims, _ := auth.ClaimsFromContext(req.Context())
+	for _, scope := range createRequest.Scopes {
+		if !claims.HasScope(scope) {
+			r.jsonError(w, http.StatusBadRequest, fmt.Errorf("%w: %s", userservice.ErrAPIKeyScope, scope), "Failed to create API key", APIKeyFailedTotal)
+			return
+		}
+	}
+
+	key, apiKey, err := r.UserService.CreateAPIKey(req.Context(), username, createRequest.Name,
+		createRequest.Scopes, time.Duration(createRequest.ExpiresIn)*time.Second)
+	if err != nil {
+		r.jsonError(w, apiKeyErrorStatus(err), err, "Failed to create API key", APIKeyFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(APIKeyCreatedTotal)
+	}
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(&dto.APIKeyCreateResponseDTO{
+		Message:   "API key created, store it now as it will not be shown again",
+		Key:       key,
+		APIKeyDTO: apiKeyDTO(apiKey),
+	})
+}
+
+// RevokeAPIKey deletes an API key of the caller, which stops working at once.
+func (r *Route) RevokeAPIKey(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(APIKeyRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, APIKeyFailedTotal)
+	if !ok {
+		return
+	}
+
+	revokeRequest := &dto.APIKeyRevokeRequestDTO{}
+	if !r.decodeJSONRequest(w, req, revokeRequest, APIKeyFailedTotal) {
+		return
+	}
+
+	if err := r.UserService.RevokeAPIKey(req.Context(), username, revokeRequest.ID); err != nil {
+		r.jsonError(w, apiKeyErrorStatus(err), err, "Failed to revoke API key", APIKeyFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(APIKeyRevokedTotal)
+	}
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.APIKeyRevokeResponseDTO{Message: "API key revoked"})
+}
+
+// apiKeyDTO describes key without its hash.
+func apiKeyDTO(key *models.APIKey) dto.APIKeyDTO {
+	scopes := key.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return dto.APIKeyDTO{
+		ID:         key.KeyID,
+		Name:       key.Name,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+// apiKeyErrorStatus maps API key service errors to HTTP status codes.
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, userservice.ErrAPIKeyScope), errors.Is(err, userservice.ErrAPIKeyTTL):
+		return http.StatusBadRequest
+	case errors.Is(err, userservice.ErrAPIKeyNotFound), errors.Is(err, userservice.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, userservice.ErrAPIKeysNotConfigured):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,324 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+// apiKeyRoute returns a route backed by an in-memory API key repository
+// holding keys, for testuser with storedRoles.
+func apiKeyRoute(t *testing.T, storedRoles string, keys *[]models.APIKey) *Route {
+	t.Helper()
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+		Return(&models.User{Username: "testuser", Roles: storedRoles}, nil).Maybe()
+
+	apiKeys := mocks.NewMockAPIKeyRepository(t)
+	apiKeys.On("AddAPIKey", mock.Anything, mock.AnythingOfType("models.APIKey")).
+		Run(func(args mock.Arguments) {
+			*keys = append(*keys, args.Get(1).(models.APIKey))
+		}).
+		Return(nil).Maybe()
+	apiKeys.On("GetAPIKey", mock.Anything, mock.AnythingOfType("string")).
+		Return(func(_ context.Context, keyHash string) (*models.APIKey, error) {
+			for _, key := range *keys {
+				if key.KeyHash == keyHash {
+					return &key, nil
+				}
+			}
+			return nil, nil
+		}).Maybe()
+	apiKeys.On("ListAPIKeys", mock.Anything, "testuser").
+		Return(func(_ context.Context, _ string) ([]models.APIKey, error) {
+			return *keys, nil
+		}).Maybe()
+	apiKeys.On("DeleteAPIKey", mock.Anything, "testuser", mock.AnythingOfType("string")).
+		Return(func(_ context.Context, _, keyID string) (bool, error) {
+			for i, key := range *keys {
+				if key.KeyID == keyID {
+					*keys = append((*keys)[:i], (*keys)[i+1:]...)
+					return true, nil
+				}
+			}
+			return false, nil
+		}).Maybe()
+	apiKeys.On("SetAPIKeyLastUsed", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("int64")).
+		Run(func(args mock.Arguments) {
+			for i := range *keys {
+				if (*keys)[i].KeyID == args.String(1) {
+					(*keys)[i].LastUsedAt = args.Get(2).(int64)
+				}
+			}
+		}).
+		Return(nil).Maybe()
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+	return &Route{
+		Metrics: mockedMetrics,
+		UserService: &userservice.UserService{
+			UserRepo: userRepo,
+			APIKeys:  apiKeys,
+			Roles: auth.RoleSet{
+				"admin": {auth.PermissionCreate, auth.PermissionRolesRead, auth.PermissionRolesAssign},
+				"user":  {},
+			},
+			APIKeyMaxTTL: 90 * 24 * time.Hour,
+		},
+		validator: structValidator.New(),
+	}
+}
+
+func TestRoute_APIKeys(t *testing.T) {
+	existing := models.APIKey{
+		KeyID:     "key-1",
+		KeyHash:   auth.HashOpaqueToken(auth.APIKeyPrefix + "existing"),
+		Username:  "testuser",
+		Name:      "ci",
+		Scopes:    "openid",
+		CreatedAt: 1700000000,
+		ExpiresAt: 1800000000,
+	}
+
+	sessionClaims := &auth.CustomClaims{
+		UserID:        "testuser",
+		PrincipalType: auth.PrincipalTypeUser,
+		Scope:         "account create openid roles:assign roles:read",
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		route          string
+		body           string
+		claims         *auth.CustomClaims
+		expectedStatus int
+		wantKeys       int
+		wantScopes     []string
+	}{
+		{
+			name:           "Create API key",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":["roles:read","openid","roles:read"],"expires_in":3600}`,
+			expectedStatus: http.StatusCreated,
+			wantKeys:       2,
+			wantScopes:     []string{"openid", "roles:read"},
+		},
+		{
+			name:           "Create API key with scope not held",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":["admin:all"]}`,
+			expectedStatus: http.StatusBadRequest,
+			wantKeys:       1,
+		},
+		{
+			name:           "Create API key with scope missing from the session",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":["roles:read"]}`,
+			claims:         &auth.CustomClaims{UserID: "testuser", PrincipalType: auth.PrincipalTypeUser, Scope: "account openid"},
+			expectedStatus: http.StatusBadRequest,
+			wantKeys:       1,
+		},
+		{
+			name:           "Create API key above maximum lifetime",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":["openid"],"expires_in":31536000}`,
+			expectedStatus: http.StatusBadRequest,
+			wantKeys:       1,
+		},
+		{
+			name:           "Create API key without scopes",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":[]}`,
+			expectedStatus: http.StatusBadRequest,
+			wantKeys:       1,
+		},
+		{
+			name:           "List API keys",
+			method:         http.MethodGet,
+			route:          APIKeysRouteAPI,
+			expectedStatus: http.StatusOK,
+			wantKeys:       1,
+		},
+		{
+			name:           "Revoke API key",
+			method:         http.MethodPost,
+			route:          RevokeAPIKeyRouteAPI,
+			body:           `{"id":"key-1"}`,
+			expectedStatus: http.StatusOK,
+			wantKeys:       0,
+		},
+		{
+			name:           "Revoke unknown API key",
+			method:         http.MethodPost,
+			route:          RevokeAPIKeyRouteAPI,
+			body:           `{"id":"key-2"}`,
+			expectedStatus: http.StatusNotFound,
+			wantKeys:       1,
+		},
+		{
+			name:           "Clients have no API keys",
+			method:         http.MethodGet,
+			route:          APIKeysRouteAPI,
+			claims:         &auth.CustomClaims{ClientID: "client", PrincipalType: auth.PrincipalTypeClient},
+			expectedStatus: http.StatusForbidden,
+			wantKeys:       1,
+		},
+		{
+			name:           "Token delegated to a client cannot create API keys",
+			method:         http.MethodPost,
+			route:          APIKeysRouteAPI,
+			body:           `{"name":"deploy","scopes":["openid"]}`,
+			claims:         &auth.CustomClaims{UserID: "testuser", PrincipalType: auth.PrincipalTypeUser, ClientID: "client-1", Scope: "openid"},
+			expectedStatus: http.StatusForbidden,
+			wantKeys:       1,
+		},
+		{
+			name:           "API keys with wrong method",
+			method:         http.MethodDelete,
+			route:          APIKeysRouteAPI,
+			expectedStatus: http.StatusMethodNotAllowed,
+			wantKeys:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := []models.APIKey{existing}
+			r := apiKeyRoute(t, "admin", &keys)
+
+			claims := tt.claims
+			if claims == nil {
+				claims = sessionClaims
+			}
+			req := httptest.NewRequest(tt.method, tt.route, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+			rr := httptest.NewRecorder()
+			if tt.route == RevokeAPIKeyRouteAPI {
+				r.RevokeAPIKey(rr, req)
+			} else {
+				r.APIKeys(rr, req)
+			}
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if len(keys) != tt.wantKeys {
+				t.Errorf("got %d stored keys, want %d", len(keys), tt.wantKeys)
+			}
+			if strings.Contains(rr.Body.String(), existing.KeyHash) {
+				t.Errorf("response exposes a key hash: %s", rr.Body.String())
+			}
+
+			switch {
+			case tt.expectedStatus == http.StatusCreated:
+				response := &dto.APIKeyCreateResponseDTO{}
+				if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if !auth.IsAPIKey(response.Key) {
+					t.Errorf("got key %q, want prefix %q", response.Key, auth.APIKeyPrefix)
+				}
+				if rr.Header().Get(CacheControl) != NoStore {
+					t.Errorf("expected the new key not to be cached")
+				}
+				stored := keys[len(keys)-1]
+				if stored.KeyHash != auth.HashOpaqueToken(response.Key) || stored.KeyID != response.ID {
+					t.Errorf("stored key %+v does not match the returned key", stored)
+				}
+				if !reflect.DeepEqual(response.Scopes, tt.wantScopes) {
+					t.Errorf("got scopes %v, want %v", response.Scopes, tt.wantScopes)
+				}
+				if lifetime := response.ExpiresAt - response.CreatedAt; lifetime != 3600 {
+					t.Errorf("got lifetime %d, want 3600", lifetime)
+				}
+			case tt.method == http.MethodGet && tt.expectedStatus == http.StatusOK:
+				response := &dto.APIKeyListResponseDTO{}
+				if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				want := []dto.APIKeyDTO{{ID: "key-1", Name: "ci", Scopes: []string{"openid"}, CreatedAt: 1700000000, ExpiresAt: 1800000000}}
+				if !reflect.DeepEqual(response.APIKeys, want) {
+					t.Errorf("got keys %+v, want %+v", response.APIKeys, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoute_AuthenticateAPIKey(t *testing.T) {
+	var keys []models.APIKey
+	r := apiKeyRoute(t, "admin", &keys)
+
+	req := httptest.NewRequest(http.MethodPost, APIKeysRouteAPI, strings.NewReader(`{"name":"reader","scopes":["roles:read"]}`))
+	req.Header.Set(ContentType, ContentTypeJson)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.CustomClaims{UserID: "testuser", Scope: "account openid roles:read"}))
+	rr := httptest.NewRecorder()
+	r.APIKeys(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	created := &dto.APIKeyCreateResponseDTO{}
+	if err := json.Unmarshal(rr.Body.Bytes(), created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if lifetime := time.Duration(created.ExpiresAt-created.CreatedAt) * time.Second; lifetime != userservice.DefaultAPIKeyTTL {
+		t.Errorf("got lifetime %s, want %s", lifetime, userservice.DefaultAPIKeyTTL)
+	}
+
+	ctx := t.Context()
+	claims, err := r.UserService.AuthenticateAPIKey(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey() error = %v", err)
+	}
+	if claims.UserID != "testuser" || claims.ID != created.ID || !reflect.DeepEqual(claims.AMR, []string{auth.AMRAPIKey}) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if claims.Scope != auth.PermissionRolesRead || !reflect.DeepEqual(claims.Permissions, []string{auth.PermissionRolesRead}) {
+		t.Errorf("got scope %q and permissions %v, want only %s", claims.Scope, claims.Permissions, auth.PermissionRolesRead)
+	}
+	if keys[0].LastUsedAt == 0 {
+		t.Errorf("expected the last use of the key to be recorded")
+	}
+
+	invalid := []struct {
+		name string
+		key  string
+	}{
+		{name: "unknown key", key: auth.APIKeyPrefix + "unknown"},
+		{name: "session token", key: "eyJhbGciOiJSUzI1NiJ9.e30.sig"},
+	}
+	for _, tt := range invalid {
+		if _, err := r.UserService.AuthenticateAPIKey(ctx, tt.key); !errors.Is(err, userservice.ErrInvalidAPIKey) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, userservice.ErrInvalidAPIKey)
+		}
+	}
+
+	keys[0].ExpiresAt = time.Now().Add(-time.Second).Unix()
+	if _, err := r.UserService.AuthenticateAPIKey(ctx, created.Key); !errors.Is(err, userservice.ErrInvalidAPIKey) {
+		t.Errorf("expired key: got error %v, want %v", err, userservice.ErrInvalidAPIKey)
+	}
+}
@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/policy"
+)
+
+// Subject attributes taken from the access token and environment attributes
+// set by the server; callers cannot override them.
+const (
+	SubjectAttributeSub           = "sub"
+	SubjectAttributePrincipalType = "principal_type"
+	SubjectAttributeClientID      = "client_id"
+	SubjectAttributeScope         = "scope"
+	SubjectAttributeRoles         = "roles"
+	SubjectAttributePermissions   = "permissions"
+	SubjectAttributeAMR           = "amr"
+	EnvironmentAttributeTime      = "time"
+)
+
+// AuthorizeCheck is the policy decision endpoint. A service forwards the
+// access token of the subject acting on a resource as bearer token and
+// describes the action, the resource and the environment; the decision is
+// answered with 200 OK whether it allows or denies the request.
+func (r *Route) AuthorizeCheck(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(AuthorizeCheckRequestsTotal)
+	}
+
+	claims, ok := auth.ClaimsFromContext(req.Context())
+	if !ok {
+		r.jsonError(w, http.StatusUnauthorized, fmt.Errorf("request is not authenticated"), "Authentication required", AuthorizeCheckFailedTotal)
+		return
+	}
+
+	check := &dto.AuthorizationCheckRequestDTO{}
+	if !r.decodeJSONRequest(w, req, check, AuthorizeCheckFailedTotal) {
+		return
+	}
+
+	decision := r.Policies.Evaluate(policy.Request{
+		Subject:     subjectAttributes(claims, check.Subject),
+		Action:      check.Action,
+		Resource:    check.Resource,
+		Environment: environmentAttributes(check.Environment),
+	})
+
+	response := &dto.AuthorizationCheckResponseDTO{
+		Allowed:  decision.Allowed,
+		Decision: policy.EffectDeny,
+		PolicyID: decision.PolicyID,
+		Reason:   decision.Reason,
+	}
+	if decision.Allowed {
+		response.Decision = policy.EffectAllow
+	}
+	if r.Metrics != nil {
+		if decision.Allowed {
+			r.Metrics.IncCounter(AuthorizeCheckAllowedTotal)
+		} else {
+			r.Metrics.IncCounter(AuthorizeCheckDeniedTotal)
+		}
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// subjectAttributes merges the attributes sent by the caller with the claims
+// of the verified token, which replace attributes of the same name.
+func subjectAttributes(claims *auth.CustomClaims, sent map[string]interface{}) policy.Attributes {
+	subject := policy.Attributes{}
+	for name, value := range sent {
+		subject[name] = value
+	}
+
+	fromToken := map[string]interface{}{
+		SubjectAttributeSub:           claims.UserID,
+		SubjectAttributePrincipalType: claims.PrincipalType,
+		SubjectAttributeClientID:      claims.ClientID,
+		SubjectAttributeScope:         strings.Fields(claims.Scope),
+		SubjectAttributeRoles:         claims.Roles,
+		SubjectAttributePermissions:   claims.Permissions,
+		SubjectAttributeAMR:           claims.AMR,
+	}
+	if claims.IsClient() {
+		fromToken[SubjectAttributeSub] = claims.ClientID
+	}
+	for name, value := range fromToken {
+		// claims the token lacks are removed rather than left to the caller
+		delete(subject, name)
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				subject[name] = v
+			}
+		case []string:
+			if len(v) > 0 {
+				subject[name] = v
+			}
+		}
+	}
+	return subject
+}
+
+// environmentAttributes adds the current time, in seconds since the epoch,
+// to the environment sent by the caller.
+func environmentAttributes(sent map[string]interface{}) policy.Attributes {
+	environment := policy.Attributes{}
+	for name, value := range sent {
+		environment[name] = value
+	}
+	environment[EnvironmentAttributeTime] = time.Now().Unix()
+	return environment
+}
@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/policy"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoute_AuthorizeCheck(t *testing.T) {
+	engine, err := policy.NewEngine([]policy.Policy{
+		{
+			ID:        "owner-edit",
+			Effect:    policy.EffectAllow,
+			Actions:   []string{"edit"},
+			Resources: []string{"document"},
+			Conditions: []policy.Condition{
+				{Attribute: "resource.owner", Operator: policy.OperatorEquals, ValueFrom: "subject.sub"},
+				{Attribute: "resource.tenant", Operator: policy.OperatorEquals, ValueFrom: "subject.tenant"},
+			},
+		},
+		{
+			ID:         "editors-publish",
+			Effect:     policy.EffectAllow,
+			Actions:    []string{"publish"},
+			Conditions: []policy.Condition{{Attribute: "subject.roles", Operator: policy.OperatorContains, Value: "editor"}},
+		},
+		{
+			ID:         "expired-documents",
+			Effect:     policy.EffectDeny,
+			Actions:    []string{policy.Wildcard},
+			Conditions: []policy.Condition{{Attribute: "resource.expires_at", Operator: policy.OperatorLessThan, ValueFrom: "environment.time"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	alice := &auth.CustomClaims{UserID: "alice", PrincipalType: auth.PrincipalTypeUser}
+
+	tests := []struct {
+		name           string
+		method         string
+		claims         *auth.CustomClaims
+		body           string
+		expectedStatus int
+		wantAllowed    bool
+		wantPolicyID   string
+	}{
+		{
+			name:           "Owner in same tenant is allowed",
+			claims:         alice,
+			body:           `{"action":"edit","subject":{"tenant":"acme"},"resource":{"type":"document","owner":"alice","tenant":"acme"}}`,
+			expectedStatus: http.StatusOK,
+			wantAllowed:    true,
+			wantPolicyID:   "owner-edit",
+		},
+		{
+			name:           "Caller cannot impersonate another subject",
+			claims:         alice,
+			body:           `{"action":"edit","subject":{"sub":"bob","tenant":"acme"},"resource":{"type":"document","owner":"bob","tenant":"acme"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Roles come from the token",
+			claims:         alice,
+			body:           `{"action":"publish","subject":{"roles":["editor"]},"resource":{"type":"document"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Role in token is allowed",
+			claims:         &auth.CustomClaims{UserID: "alice", Roles: []string{"editor"}},
+			body:           `{"action":"publish","resource":{"type":"document"}}`,
+			expectedStatus: http.StatusOK,
+			wantAllowed:    true,
+			wantPolicyID:   "editors-publish",
+		},
+		{
+			name:           "Environment time is set by the server",
+			claims:         alice,
+			body:           `{"action":"edit","subject":{"tenant":"acme"},"resource":{"type":"document","owner":"alice","tenant":"acme","expires_at":1000},"environment":{"time":0}}`,
+			expectedStatus: http.StatusOK,
+			wantPolicyID:   "expired-documents",
+		},
+		{
+			name:           "Missing action",
+			claims:         alice,
+			body:           `{"resource":{"type":"document"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthenticated",
+			body:           `{"action":"edit"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Wrong method",
+			method:         http.MethodGet,
+			claims:         alice,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			r := &Route{
+				Metrics:   mockedMetrics,
+				Policies:  engine,
+				validator: structValidator.New(),
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, AuthorizeCheckRouteAPI, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			if tt.claims != nil {
+				req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+			}
+			rr := httptest.NewRecorder()
+			r.AuthorizeCheck(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			response := &dto.AuthorizationCheckResponseDTO{}
+			if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Allowed != tt.wantAllowed {
+				t.Errorf("got allowed %v, want %v: %s", response.Allowed, tt.wantAllowed, response.Reason)
+			}
+			if response.PolicyID != tt.wantPolicyID {
+				t.Errorf("got policy %q, want %q", response.PolicyID, tt.wantPolicyID)
+			}
+			wantDecision := policy.EffectDeny
+			if tt.wantAllowed {
+				wantDecision = policy.EffectAllow
+			}
+			if response.Decision != wantDecision {
+				t.Errorf("got decision %q, want %q", response.Decision, wantDecision)
+			}
+		})
+	}
+}
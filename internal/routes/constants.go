@@ -1,5 +1,7 @@
 package routes
 
+import "github.com/haguru/sasuke/internal/auth"
+
 var (
 	SignupDurationSecondsBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
 	LoginDurationSecondsBuckets  = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
@@ -11,28 +13,241 @@ const (
 	MetricsRouteAPI = "/metrics"
 	LoginRouteAPI   = "/login"
 	SignupRouteAPI  = "/signup"
+	JWKSRouteAPI    = "/.well-known/jwks.json"
+	RefreshRouteAPI = "/token/refresh"
+	LogoutRouteAPI  = "/logout"
+
+	// Multi-factor authentication route constants
+	LoginMFARouteAPI      = "/login/mfa"
+	TOTPEnrollRouteAPI    = "/mfa/totp/enroll"
+	TOTPConfirmRouteAPI   = "/mfa/totp/confirm"
+	TOTPDisableRouteAPI   = "/mfa/totp/disable"
+	RecoveryCodesRouteAPI = "/mfa/recovery-codes"
+
+	// Passkey route constants
+	PasskeyRegisterBeginRouteAPI  = "/webauthn/register/begin"
+	PasskeyRegisterFinishRouteAPI = "/webauthn/register/finish"
+	PasskeyLoginBeginRouteAPI     = "/login/passkey/begin"
+	PasskeyLoginFinishRouteAPI    = "/login/passkey/finish"
+
+	// Magic link route constants
+	MagicLinkRouteAPI         = "/login/magic"
+	MagicLinkCallbackRouteAPI = "/login/magic/callback"
+
+	// Password reset route constants
+	ForgotPasswordRouteAPI = "/password/forgot"
+	ResetPasswordRouteAPI  = "/password/reset"
+
+	// Email verification route constants
+	VerifyEmailRouteAPI        = "/email/verify"
+	ResendVerificationRouteAPI = "/email/verify/resend"
+
+	// Role route constants
+	RolesRouteAPI      = "/roles"
+	AssignRoleRouteAPI = "/roles/assign"
+	RevokeRoleRouteAPI = "/roles/revoke"
+
+	// Policy decision route constants
+	AuthorizeCheckRouteAPI = "/authorize/check"
+
+	// API key route constants
+	APIKeysRouteAPI      = "/apikeys"
+	RevokeAPIKeyRouteAPI = "/apikeys/revoke"
+
+	// OAuth 2.0 route constants
+	AuthorizeRouteAPI  = "/authorize"
+	TokenRouteAPI      = "/token"
+	IntrospectRouteAPI = "/introspect"
+	RevokeRouteAPI     = "/revoke"
+
+	// OpenID Connect route constants
+	OpenIDConfigurationRouteAPI = "/.well-known/openid-configuration"
+	UserInfoRouteAPI            = "/userinfo"
+
+	// Discovery metadata values
+	SubjectTypePublic           = "public"
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"
+	AuthMethodNone              = "none"
+
+	// Cookie constants
+	SessionCookieName = auth.SessionCookieName
+	RefreshCookieName = "refresh_token"
+
+	// SameSite cookie attribute values accepted in the cookie configuration
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
 
 	// Content-Type constants
 	ContentType     = "Content-Type"
 	ContentTypeJson = "application/json"
+	ContentTypeHTML = "text/html; charset=utf-8"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+
+	// Cache-Control constants
+	CacheControl     = "Cache-Control"
+	JWKSCacheControl = "public, max-age=3600"
+	NoStore          = "no-store"
+	Pragma           = "Pragma"
+	NoCache          = "no-cache"
+
+	// RetryAfter tells clients refused with 429 when to try again
+	RetryAfter = "Retry-After"
+
+	// TokenTypeBearer is the token_type of issued access tokens
+	TokenTypeBearer = "Bearer"
 
 	// metrics constants
-	SignupRequestsTotal       = "signup_requests_total"
-	SignupRequestsTotalHelp   = "Total number of signup requests received"
-	SignupSuccessTotal        = "signup_success_total"
-	SignupSuccessTotalHelp    = "Total number of successful signup requests"
-	SignupErrorsTotal         = "signup_errors_total"
-	SignupErrorsTotalHelp     = "Total number of errors during signup requests"
-	SignupDurationSeconds     = "signup_duration_seconds"
-	SignupDurationSecondsHelp = "Duration of signup requests in seconds"
-	LoginRequestsTotal        = "login_requests_total"
-	LoginRequestsTotalHelp    = "Total number of login requests received"
-	LoginSuccessTotal         = "login_success_total"
-	LoginSuccessTotalHelp     = "Total number of successful login requests"
-	LoginFailedTotal          = "login_failed_total"
-	LoginFailedTotalHelp      = "Total number of failed login requests"
-	LoginDurationSeconds      = "login_duration_seconds"
-	LoginDurationSecondsHelp  = "Duration of login requests in seconds"
-	LoginRateLimitedTotal     = "login_rate_limited_total"
-	LoginRateLimitedTotalHelp = "Total number of login requests that were rate limited"
+	SignupRequestsTotal           = "signup_requests_total"
+	SignupRequestsTotalHelp       = "Total number of signup requests received"
+	SignupSuccessTotal            = "signup_success_total"
+	SignupSuccessTotalHelp        = "Total number of successful signup requests"
+	SignupErrorsTotal             = "signup_errors_total"
+	SignupErrorsTotalHelp         = "Total number of errors during signup requests"
+	SignupDurationSeconds         = "signup_duration_seconds"
+	SignupDurationSecondsHelp     = "Duration of signup requests in seconds"
+	LoginRequestsTotal            = "login_requests_total"
+	LoginRequestsTotalHelp        = "Total number of login requests received"
+	LoginSuccessTotal             = "login_success_total"
+	LoginSuccessTotalHelp         = "Total number of successful login requests"
+	LoginFailedTotal              = "login_failed_total"
+	LoginFailedTotalHelp          = "Total number of failed login requests"
+	LoginDurationSeconds          = "login_duration_seconds"
+	LoginDurationSecondsHelp      = "Duration of login requests in seconds"
+	LoginRateLimitedTotal         = "login_rate_limited_total"
+	LoginRateLimitedTotalHelp     = "Total number of login requests that were rate limited"
+	RefreshRequestsTotal          = "refresh_requests_total"
+	RefreshRequestsTotalHelp      = "Total number of token refresh requests received"
+	RefreshSuccessTotal           = "refresh_success_total"
+	RefreshSuccessTotalHelp       = "Total number of successful token refresh requests"
+	RefreshFailedTotal            = "refresh_failed_total"
+	RefreshFailedTotalHelp        = "Total number of failed token refresh requests"
+	RefreshReuseTotal             = "refresh_reuse_detected_total"
+	RefreshReuseTotalHelp         = "Total number of refresh token reuses that revoked a token family"
+	LogoutRequestsTotal           = "logout_requests_total"
+	LogoutRequestsTotalHelp       = "Total number of logout requests received"
+	LogoutSuccessTotal            = "logout_success_total"
+	LogoutSuccessTotalHelp        = "Total number of successful logout requests"
+	LogoutFailedTotal             = "logout_failed_total"
+	LogoutFailedTotalHelp         = "Total number of failed logout requests"
+	AuthorizeRequestsTotal        = "authorize_requests_total"
+	AuthorizeRequestsTotalHelp    = "Total number of OAuth authorization requests received"
+	AuthorizeCodesIssuedTotal     = "authorize_codes_issued_total"
+	AuthorizeCodesIssuedTotalHelp = "Total number of OAuth authorization codes issued"
+	AuthorizeFailedTotal          = "authorize_failed_total"
+	AuthorizeFailedTotalHelp      = "Total number of failed OAuth authorization requests"
+	TokenRequestsTotal            = "token_requests_total"
+	TokenRequestsTotalHelp        = "Total number of OAuth token requests received"
+	TokenSuccessTotal             = "token_success_total"
+	TokenSuccessTotalHelp         = "Total number of successful OAuth token requests"
+	TokenFailedTotal              = "token_failed_total"
+	TokenFailedTotalHelp          = "Total number of failed OAuth token requests"
+	UserInfoRequestsTotal         = "userinfo_requests_total"
+	UserInfoRequestsTotalHelp     = "Total number of OpenID Connect userinfo requests received"
+	UserInfoFailedTotal           = "userinfo_failed_total"
+	UserInfoFailedTotalHelp       = "Total number of failed OpenID Connect userinfo requests"
+	IntrospectRequestsTotal       = "introspect_requests_total"
+	IntrospectRequestsTotalHelp   = "Total number of token introspection requests received"
+	IntrospectFailedTotal         = "introspect_failed_total"
+	IntrospectFailedTotalHelp     = "Total number of failed token introspection requests"
+	RevokeRequestsTotal           = "revoke_requests_total"
+	RevokeRequestsTotalHelp       = "Total number of token revocation requests received"
+	RevokeFailedTotal             = "revoke_failed_total"
+	RevokeFailedTotalHelp         = "Total number of failed token revocation requests"
+	LoginMFAChallengesTotal       = "login_mfa_challenges_total"
+	LoginMFAChallengesTotalHelp   = "Total number of logins that required a second factor"
+	LoginMFARequestsTotal         = "login_mfa_requests_total"
+	LoginMFARequestsTotalHelp     = "Total number of second factor login requests received"
+	LoginMFASuccessTotal          = "login_mfa_success_total"
+	LoginMFASuccessTotalHelp      = "Total number of successful second factor login requests"
+	LoginMFAFailedTotal           = "login_mfa_failed_total"
+	LoginMFAFailedTotalHelp       = "Total number of failed second factor login requests"
+	MFAEnrollRequestsTotal        = "mfa_enroll_requests_total"
+	MFAEnrollRequestsTotalHelp    = "Total number of TOTP enrollment, removal and recovery code requests received"
+	MFAFailedTotal                = "mfa_failed_total"
+	MFAFailedTotalHelp            = "Total number of failed TOTP enrollment, removal and recovery code requests"
+
+	// passkey metrics constants
+	PasskeyRegisterRequestsTotal     = "passkey_register_requests_total"
+	PasskeyRegisterRequestsTotalHelp = "Total number of passkey registration requests received"
+	PasskeyRegisterSuccessTotal      = "passkey_register_success_total"
+	PasskeyRegisterSuccessTotalHelp  = "Total number of passkeys registered"
+	PasskeyRegisterFailedTotal       = "passkey_register_failed_total"
+	PasskeyRegisterFailedTotalHelp   = "Total number of failed passkey registration requests"
+	PasskeyLoginRequestsTotal        = "passkey_login_requests_total"
+	PasskeyLoginRequestsTotalHelp    = "Total number of passkey login requests received"
+	PasskeyLoginSuccessTotal         = "passkey_login_success_total"
+	PasskeyLoginSuccessTotalHelp     = "Total number of successful passkey logins"
+	PasskeyLoginFailedTotal          = "passkey_login_failed_total"
+	PasskeyLoginFailedTotalHelp      = "Total number of failed passkey login requests"
+
+	// password reset metrics constants
+	PasswordResetRequestsTotal     = "password_reset_requests_total"
+	PasswordResetRequestsTotalHelp = "Total number of password reset link requests received"
+	PasswordResetSuccessTotal      = "password_reset_success_total"
+	PasswordResetSuccessTotalHelp  = "Total number of passwords reset"
+	PasswordResetFailedTotal       = "password_reset_failed_total"
+	PasswordResetFailedTotalHelp   = "Total number of failed password reset requests"
+
+	// email verification metrics constants
+	EmailVerificationRequestsTotal     = "email_verification_requests_total"
+	EmailVerificationRequestsTotalHelp = "Total number of email verification requests received"
+	EmailVerificationSentTotal         = "email_verification_sent_total"
+	EmailVerificationSentTotalHelp     = "Total number of email verification links sent"
+	EmailVerificationSuccessTotal      = "email_verification_success_total"
+	EmailVerificationSuccessTotalHelp  = "Total number of email addresses verified"
+	EmailVerificationFailedTotal       = "email_verification_failed_total"
+	EmailVerificationFailedTotalHelp   = "Total number of failed email verification requests"
+
+	// magic link metrics constants
+	MagicLinkRequestsTotal          = "magic_link_requests_total"
+	MagicLinkRequestsTotalHelp      = "Total number of login link requests received"
+	MagicLinkSentTotal              = "magic_link_sent_total"
+	MagicLinkSentTotalHelp          = "Total number of login links sent"
+	MagicLinkLoginRequestsTotal     = "magic_link_login_requests_total"
+	MagicLinkLoginRequestsTotalHelp = "Total number of login links opened"
+	MagicLinkFailedTotal            = "magic_link_failed_total"
+	MagicLinkFailedTotalHelp        = "Total number of failed login link requests"
+
+	// account lockout metrics constants
+	AccountLockoutsTotal     = "account_lockouts_total"
+	AccountLockoutsTotalHelp = "Total number of accounts locked out after too many failed logins"
+	LoginLockedTotal         = "login_locked_total"
+	LoginLockedTotalHelp     = "Total number of logins refused because the account was locked or backing off"
+
+	// password policy metrics constants
+	PasswordPolicyRejectedTotal     = "password_policy_rejected_total"
+	PasswordPolicyRejectedTotalHelp = "Total number of new passwords refused by the password policy"
+
+	// role metrics constants
+	RoleRequestsTotal        = "role_requests_total"
+	RoleRequestsTotalHelp    = "Total number of role lookup and assignment requests received"
+	RoleAssignmentsTotal     = "role_assignments_total"
+	RoleAssignmentsTotalHelp = "Total number of roles assigned"
+	RoleRevocationsTotal     = "role_revocations_total"
+	RoleRevocationsTotalHelp = "Total number of roles revoked"
+	RoleFailedTotal          = "role_failed_total"
+	RoleFailedTotalHelp      = "Total number of failed role requests"
+
+	// policy decision metrics constants
+	AuthorizeCheckRequestsTotal     = "authorize_check_requests_total"
+	AuthorizeCheckRequestsTotalHelp = "Total number of policy decision requests received"
+	AuthorizeCheckAllowedTotal      = "authorize_check_allowed_total"
+	AuthorizeCheckAllowedTotalHelp  = "Total number of policy decisions that allowed the request"
+	AuthorizeCheckDeniedTotal       = "authorize_check_denied_total"
+	AuthorizeCheckDeniedTotalHelp   = "Total number of policy decisions that denied the request"
+	AuthorizeCheckFailedTotal       = "authorize_check_failed_total"
+	AuthorizeCheckFailedTotalHelp   = "Total number of malformed policy decision requests"
+
+	// api key metrics constants
+	APIKeyRequestsTotal     = "api_key_requests_total"
+	APIKeyRequestsTotalHelp = "Total number of API key management requests received"
+	APIKeyCreatedTotal      = "api_key_created_total"
+	APIKeyCreatedTotalHelp  = "Total number of API keys created"
+	APIKeyRevokedTotal      = "api_key_revoked_total"
+	APIKeyRevokedTotalHelp  = "Total number of API keys revoked"
+	APIKeyFailedTotal       = "api_key_failed_total"
+	APIKeyFailedTotalHelp   = "Total number of failed API key management requests"
 )
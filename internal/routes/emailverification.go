@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// errEmailNotVerified rejects logins of unverified users when verification is required.
+var errEmailNotVerified = errors.New("email address is not verified")
+
+// VerifyEmail marks the email address of a user as verified. It is the target
+// of the link mailed at signup, which carries the verification token in the
+// "token" query parameter.
+func (r *Route) VerifyEmail(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(EmailVerificationRequestsTotal)
+	}
+
+	claims, err := auth.VerifyEmailVerificationToken(req.URL.Query().Get("token"), r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.jsonError(w, http.StatusBadRequest, err, "Invalid or expired verification link", EmailVerificationFailedTotal)
+		return
+	}
+
+	if err := r.UserService.VerifyEmail(req.Context(), claims.UserID, claims.Email); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, userservice.ErrInvalidVerificationToken) {
+			status = http.StatusBadRequest
+		}
+		r.jsonError(w, status, err, "Invalid or expired verification link", EmailVerificationFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(EmailVerificationSuccessTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.EmailVerificationResponseDTO{Message: "Email address verified"})
+}
+
+// ResendEmailVerification mails a new verification link to a user whose email
+// is not verified yet. The user is looked up in the background, so neither the
+// response nor its timing tells whether the user exists.
+func (r *Route) ResendEmailVerification(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(EmailVerificationRequestsTotal)
+	}
+
+	resendRequest := &dto.ResendVerificationRequestDTO{}
+	if !r.decodeJSONRequest(w, req, resendRequest, EmailVerificationFailedTotal) {
+		return
+	}
+
+	go r.resendEmailVerification(context.WithoutCancel(req.Context()), resendRequest.Username)
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(&dto.EmailVerificationResponseDTO{
+		Message: "If the account exists and is not verified, a verification link has been sent",
+	})
+}
+
+// resendEmailVerification mails a verification link to username if their
+// email address is not verified yet.
+func (r *Route) resendEmailVerification(ctx context.Context, username string) {
+	user, err := r.UserService.GetUser(ctx, username)
+	if err != nil {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(EmailVerificationFailedTotal)
+		}
+		return
+	}
+	if user != nil && user.Email != "" && !user.EmailVerified {
+		r.sendEmailVerification(ctx, user.Username, user.Email)
+	}
+}
+
+// sendEmailVerification mails a verification link for email to username.
+// Failures are only counted, since the link can be requested again.
+func (r *Route) sendEmailVerification(ctx context.Context, username, email string) {
+	token, err := auth.CreateEmailVerificationToken(username, email, r.EmailVerificationTTL, r.Keyring, r.TokenConfig)
+	if err == nil {
+		err = r.UserService.SendEmailVerification(ctx, email, token)
+	}
+	if r.Metrics == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		r.Metrics.IncCounter(EmailVerificationSentTotal)
+	case !errors.Is(err, userservice.ErrEmailVerificationNotConfigured):
+		r.Metrics.IncCounter(EmailVerificationFailedTotal)
+	}
+}
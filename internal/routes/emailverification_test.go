@@ -0,0 +1,306 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	mailmemory "github.com/haguru/sasuke/internal/mailer/memory"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+const testEmailVerificationURL = "https://app.example.com/verify-email"
+
+func TestRoute_Signup_EmailVerification(t *testing.T) {
+	keyring := testKeyring(t)
+
+	userRepo := mocks.NewMockUserRepository(t)
+	var added models.User
+	userRepo.On("AddUser", mock.Anything, mock.AnythingOfType("models.User")).
+		Run(func(args mock.Arguments) { added = args.Get(1).(models.User) }).
+		Return("user-id", nil).Once()
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+	mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+	mailer := mailmemory.NewMemoryMailer()
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: &userservice.UserService{UserRepo: userRepo, Mailer: mailer, EmailVerificationURL: testEmailVerificationURL},
+		Keyring:     keyring,
+		validator:   structValidator.New(),
+	}
+
+	body := `{"username":"validuser1","email":"Alice@Example.com","password":"validPass123!"}`
+	req := httptest.NewRequest(http.MethodPost, SignupRouteAPI, strings.NewReader(body))
+	req.Header.Set(ContentType, ContentTypeJson)
+	rr := httptest.NewRecorder()
+	r.Signup(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	if added.Email != "alice@example.com" || added.EmailVerified {
+		t.Errorf("expected an unverified, normalized email, got %q verified=%v", added.Email, added.EmailVerified)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].To != "alice@example.com" {
+		t.Fatalf("expected one email to alice@example.com, got %+v", messages)
+	}
+	token := linkToken(t, messages[0], testEmailVerificationURL)
+	claims, err := auth.VerifyEmailVerificationToken(token, keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("expected a valid verification token: %v", err)
+	}
+	if claims.UserID != "validuser1" || claims.Email != "alice@example.com" {
+		t.Errorf("unexpected verification claims %+v", claims)
+	}
+}
+
+func TestRoute_VerifyEmail(t *testing.T) {
+	keyring := testKeyring(t)
+
+	validToken, err := auth.CreateEmailVerificationToken("testuser", "alice@example.com", time.Hour, keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create verification token: %v", err)
+	}
+	session, err := auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create session token: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		token          string
+		user           *models.User
+		expectedStatus int
+		expectUpdate   bool
+	}{
+		{
+			name:           "Valid link",
+			method:         http.MethodGet,
+			token:          validToken,
+			user:           &models.User{Username: "testuser", Email: "alice@example.com"},
+			expectedStatus: http.StatusOK,
+			expectUpdate:   true,
+		},
+		{
+			name:           "Already verified",
+			method:         http.MethodGet,
+			token:          validToken,
+			user:           &models.User{Username: "testuser", Email: "alice@example.com", EmailVerified: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Email changed since the link was sent",
+			method:         http.MethodGet,
+			token:          validToken,
+			user:           &models.User{Username: "testuser", Email: "bob@example.com"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Session token instead of a verification token",
+			method:         http.MethodGet,
+			token:          session,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing token",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPost,
+			token:          validToken,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(tt.user, nil).Maybe()
+			if tt.expectUpdate {
+				userRepo.On("UpdateUser", mock.Anything, "testuser", map[string]interface{}{"email_verified": true}).Return(nil).Once()
+			}
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo},
+				Keyring:     keyring,
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(tt.method, VerifyEmailRouteAPI+"?token="+url.QueryEscape(tt.token), nil)
+			rr := httptest.NewRecorder()
+			r.VerifyEmail(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRoute_Login_RequireVerifiedEmail(t *testing.T) {
+	hashedPassword, err := HashString("testpass")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		require        bool
+		verified       bool
+		expectedStatus int
+	}{
+		{
+			name:           "Unverified email when required",
+			require:        true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Verified email when required",
+			require:        true,
+			verified:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Unverified email when not required",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{Username: "testuser", HashedPassword: hashedPassword, Email: "alice@example.com", EmailVerified: tt.verified}
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+			userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+			mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+			r := &Route{
+				Metrics:              mockedMetrics,
+				UserService:          &userservice.UserService{UserRepo: userRepo},
+				Keyring:              testKeyring(t),
+				RequireVerifiedEmail: tt.require,
+				validator:            structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, LoginRouteAPI, strings.NewReader(`{"username":"testuser","password":"testpass"}`))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.Login(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK && len(rr.Result().Cookies()) != 0 {
+				t.Error("expected no session cookies for an unverified user")
+			}
+		})
+	}
+}
+
+func TestRoute_ResendEmailVerification(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *models.User
+		lookupErr  error
+		expectMail bool
+	}{
+		{
+			name:       "Unverified email",
+			user:       &models.User{Username: "testuser", Email: "alice@example.com"},
+			expectMail: true,
+		},
+		{
+			name: "Verified email",
+			user: &models.User{Username: "testuser", Email: "alice@example.com", EmailVerified: true},
+		},
+		{
+			name: "Unknown user",
+		},
+		{
+			name:      "User lookup fails",
+			lookupErr: errors.New("database unavailable"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(tt.user, tt.lookupErr).Maybe()
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			mailer := mailmemory.NewMemoryMailer()
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo, Mailer: mailer, EmailVerificationURL: testEmailVerificationURL},
+				Keyring:     testKeyring(t),
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, ResendVerificationRouteAPI, strings.NewReader(`{"username":"testuser"}`))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.ResendEmailVerification(rr, req)
+
+			// the response is the same whether or not a link is sent
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+			}
+
+			messages := waitForMail(mailer, tt.expectMail)
+			if !tt.expectMail {
+				if len(messages) != 0 {
+					t.Errorf("expected no email, got %d", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0].To != tt.user.Email {
+				t.Fatalf("expected one email to %s, got %+v", tt.user.Email, messages)
+			}
+		})
+	}
+}
+
+// linkToken extracts the token of the first link to base in message.
+func linkToken(t *testing.T, message models.MailMessage, base string) string {
+	t.Helper()
+	start := strings.Index(message.Body, base)
+	if start < 0 {
+		t.Fatalf("email does not contain a link to %s: %q", base, message.Body)
+	}
+	link := strings.Fields(message.Body[start:])[0]
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid link %q: %v", link, err)
+	}
+	token := u.Query().Get("token")
+	if token == "" {
+		t.Fatalf("link %q has no token", link)
+	}
+	return token
+}
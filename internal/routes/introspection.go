@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+)
+
+// Introspect is the token introspection endpoint (RFC 7662). Confidential
+// clients POST a token and learn whether it is active and whom it was issued
+// to. Expired, revoked and unknown tokens are only reported as inactive.
+func (r *Route) Introspect(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(IntrospectRequestsTotal)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		r.introspectError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "malformed request"))
+		return
+	}
+
+	client, err := r.authenticateClient(req)
+	if err != nil {
+		r.introspectError(w, req, err)
+		return
+	}
+	// anyone could call as a public client, so only confidential ones may introspect
+	if client.IsPublic() {
+		r.introspectError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidClient, "public clients may not introspect tokens"))
+		return
+	}
+
+	token := req.PostForm.Get("token")
+	if token == "" {
+		r.introspectError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "token is required"))
+		return
+	}
+
+	response := &dto.IntrospectionResponseDTO{Active: false}
+	// a failed revocation lookup also reports the token as inactive
+	if claims, err := auth.VerifyToken(req.Context(), token, r.Keyring, r.TokenConfig, r.Revocations); err == nil {
+		response = introspectionResponse(claims)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Revoke is the token revocation endpoint (RFC 7009). Access tokens are added
+// to the revocation list until they expire; refresh tokens revoke their whole
+// family. Unknown and already invalid tokens are accepted silently, as the
+// RFC requires. The token_type_hint parameter is not needed and is ignored.
+func (r *Route) Revoke(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RevokeRequestsTotal)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		r.revokeError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "malformed request"))
+		return
+	}
+
+	client, err := r.authenticateClient(req)
+	if err != nil {
+		r.revokeError(w, req, err)
+		return
+	}
+
+	token := req.PostForm.Get("token")
+	if token == "" {
+		r.revokeError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "token is required"))
+		return
+	}
+
+	if err := r.revokeToken(req.Context(), client, token); err != nil {
+		r.revokeError(w, req, err)
+		return
+	}
+
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	w.WriteHeader(http.StatusOK)
+}
+
+// revokeToken revokes token as an access token or, failing that, as a refresh
+// token. Access tokens issued to another client are refused.
+func (r *Route) revokeToken(ctx context.Context, client *models.OAuthClient, token string) error {
+	// already revoked tokens verify too, revoking them again is harmless
+	if claims, err := auth.VerifyToken(ctx, token, r.Keyring, r.TokenConfig, nil); err == nil {
+		if claims.ClientID != "" && claims.ClientID != client.ClientID {
+			return oauthservice.NewError(oauthservice.ErrorUnauthorizedClient, "token was issued to another client")
+		}
+		if r.Revocations == nil || claims.ID == "" || claims.ExpiresAt == nil {
+			return nil
+		}
+		if err := r.Revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+		return nil
+	}
+
+	if r.UserService == nil {
+		return nil
+	}
+	if _, err := r.UserService.RevokeRefreshToken(ctx, token); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Route) introspectError(w http.ResponseWriter, req *http.Request, err error) {
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(IntrospectFailedTotal)
+	}
+	r.clientError(w, req, err)
+}
+
+func (r *Route) revokeError(w http.ResponseWriter, req *http.Request, err error) {
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RevokeFailedTotal)
+	}
+	r.clientError(w, req, err)
+}
+
+// introspectionResponse describes an active token. The subject is the user
+// for user tokens and the client for machine tokens.
+func introspectionResponse(claims *auth.CustomClaims) *dto.IntrospectionResponseDTO {
+	response := &dto.IntrospectionResponseDTO{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  claims.UserID,
+		TokenType: TokenTypeBearer,
+		Subject:   claims.UserID,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+		JTI:       claims.ID,
+	}
+	if claims.IsClient() {
+		response.Subject = claims.ClientID
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		response.NotBefore = claims.NotBefore.Unix()
+	}
+	return response
+}
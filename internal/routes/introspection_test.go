@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+// testResourceServer is a confidential client calling introspection and revocation.
+func testResourceServer() *models.OAuthClient {
+	return &models.OAuthClient{
+		ClientID:         "resource-server",
+		ClientSecretHash: auth.HashOpaqueToken("s3cret"),
+		GrantTypes:       oauthservice.GrantTypeClientCredentials,
+	}
+}
+
+func TestRoute_Introspect(t *testing.T) {
+	keyring := testKeyring(t)
+	userToken, err := auth.CreateDelegatedToken("testuser", "client-1", "openid", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateDelegatedToken() error = %v", err)
+	}
+	machineToken, err := auth.CreateClientToken("machine", "reports:read", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateClientToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		clientID       string
+		revoked        bool
+		wantStatusCode int
+		wantActive     bool
+		wantSubject    string
+		wantClientID   string
+	}{
+		{
+			name:           "Active user token",
+			token:          userToken,
+			clientID:       "resource-server",
+			wantStatusCode: http.StatusOK,
+			wantActive:     true,
+			wantSubject:    "testuser",
+			wantClientID:   "client-1",
+		},
+		{
+			name:           "Active machine token",
+			token:          machineToken,
+			clientID:       "resource-server",
+			wantStatusCode: http.StatusOK,
+			wantActive:     true,
+			wantSubject:    "machine",
+			wantClientID:   "machine",
+		},
+		{
+			name:           "Revoked token",
+			token:          userToken,
+			clientID:       "resource-server",
+			revoked:        true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Unknown token",
+			token:          "not-a-token",
+			clientID:       "resource-server",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Missing token",
+			clientID:       "resource-server",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Public client",
+			token:          userToken,
+			clientID:       "client-1",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		params := url.Values{"token": {tt.token}}
+		if tt.clientID != "resource-server" {
+			params.Set("client_id", tt.clientID)
+		}
+		req := httptest.NewRequest(http.MethodPost, IntrospectRouteAPI, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", ContentTypeForm)
+		if tt.clientID == "resource-server" {
+			req.SetBasicAuth(tt.clientID, "s3cret")
+		}
+		rr := httptest.NewRecorder()
+
+		clientRepo := mocks.NewMockClientRepository(t)
+		clientRepo.On("GetClient", mock.Anything, "resource-server").Return(testResourceServer(), nil).Maybe()
+		clientRepo.On("GetClient", mock.Anything, "client-1").Return(testOAuthClient(), nil).Maybe()
+
+		revocations := mocks.NewMockRevocationStore(t)
+		revocations.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(tt.revoked, nil).Maybe()
+		revocations.On("IsSubjectRevoked", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:      mockedMetrics,
+			Keyring:      keyring,
+			Revocations:  revocations,
+			OAuthService: oauthservice.NewOAuthService(clientRepo),
+			validator:    structValidator.New(),
+		}
+		r.Introspect(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, rr.Code, tt.wantStatusCode, rr.Body.String())
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+
+		var response dto.IntrospectionResponseDTO
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode introspection response: %v", tt.name, err)
+		}
+		if response.Active != tt.wantActive {
+			t.Errorf("%s: got active %v, want %v", tt.name, response.Active, tt.wantActive)
+		}
+		if !tt.wantActive {
+			if response.Subject != "" || response.ExpiresAt != 0 {
+				t.Errorf("%s: inactive response must not describe the token: %+v", tt.name, response)
+			}
+			continue
+		}
+		if response.Subject != tt.wantSubject || response.ClientID != tt.wantClientID || response.ExpiresAt == 0 || response.Scope == "" {
+			t.Errorf("%s: unexpected introspection response %+v", tt.name, response)
+		}
+	}
+}
+
+func TestRoute_Revoke(t *testing.T) {
+	keyring := testKeyring(t)
+	ownToken, err := auth.CreateDelegatedToken("testuser", "resource-server", "openid", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateDelegatedToken() error = %v", err)
+	}
+	otherClientToken, err := auth.CreateDelegatedToken("testuser", "client-1", "openid", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("CreateDelegatedToken() error = %v", err)
+	}
+	refreshToken := "refresh-token"
+
+	tests := []struct {
+		name           string
+		token          string
+		wantStatusCode int
+		wantRevoked    bool
+		wantFamily     bool
+	}{
+		{
+			name:           "Access token",
+			token:          ownToken,
+			wantStatusCode: http.StatusOK,
+			wantRevoked:    true,
+		},
+		{
+			name:           "Access token of another client",
+			token:          otherClientToken,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Refresh token",
+			token:          refreshToken,
+			wantStatusCode: http.StatusOK,
+			wantFamily:     true,
+		},
+		{
+			name:           "Unknown token",
+			token:          "unknown-token",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Missing token",
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		params := url.Values{"token": {tt.token}}
+		req := httptest.NewRequest(http.MethodPost, RevokeRouteAPI, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", ContentTypeForm)
+		req.SetBasicAuth("resource-server", "s3cret")
+		rr := httptest.NewRecorder()
+
+		clientRepo := mocks.NewMockClientRepository(t)
+		clientRepo.On("GetClient", mock.Anything, "resource-server").Return(testResourceServer(), nil).Maybe()
+
+		revocations := mocks.NewMockRevocationStore(t)
+		if tt.wantRevoked {
+			revocations.On("Revoke", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+		}
+
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetRefreshToken", mock.Anything, auth.HashOpaqueToken(refreshToken)).Return(&models.RefreshToken{
+			TokenHash: auth.HashOpaqueToken(refreshToken),
+			FamilyID:  "family-1",
+			Username:  "testuser",
+		}, nil).Maybe()
+		userRepo.On("GetRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Maybe()
+		if tt.wantFamily {
+			userRepo.On("RevokeRefreshTokenFamily", mock.Anything, "family-1").Return(nil).Once()
+		}
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:      mockedMetrics,
+			UserService:  &userservice.UserService{UserRepo: userRepo},
+			Keyring:      keyring,
+			Revocations:  revocations,
+			OAuthService: oauthservice.NewOAuthService(clientRepo),
+			validator:    structValidator.New(),
+		}
+		r.Revoke(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, rr.Code, tt.wantStatusCode, rr.Body.String())
+		}
+	}
+}
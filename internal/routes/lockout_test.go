@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoute_Login_Lockout(t *testing.T) {
+	hashedPassword, err := HashString("testpass")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user := &models.User{Username: "testuser", HashedPassword: hashedPassword}
+
+	// the mocked repository keeps the failed login state of user
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(func(context.Context, string) (*models.User, error) {
+		copied := *user
+		return &copied, nil
+	})
+	userRepo.On("RecordLoginFailure", mock.Anything, "testuser", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64")).
+		Return(func(_ context.Context, _ string, previous, failures, lockedUntil int64) (bool, error) {
+			if user.FailedLogins != previous {
+				return false, nil
+			}
+			user.FailedLogins, user.LockedUntil = failures, lockedUntil
+			return true, nil
+		})
+	userRepo.On("UpdateUser", mock.Anything, "testuser", map[string]interface{}{"failed_logins": int64(0), "locked_until": int64(0)}).
+		Run(func(mock.Arguments) { user.FailedLogins, user.LockedUntil = 0, 0 }).
+		Return(nil)
+	userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", AccountLockoutsTotal).Return().Once()
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+	mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+	userService := &userservice.UserService{
+		UserRepo: userRepo,
+		Lockout: userservice.LockoutPolicy{
+			FreeAttempts: 1,
+			BaseDelay:    time.Minute,
+			MaxDelay:     time.Hour,
+			MaxAttempts:  3,
+			Duration:     15 * time.Minute,
+		},
+	}
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: userService,
+		Keyring:     testKeyring(t),
+		validator:   structValidator.New(),
+	}
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body := `{"username":"testuser","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, LoginRouteAPI, strings.NewReader(body))
+		req.Header.Set(ContentType, ContentTypeJson)
+		rr := httptest.NewRecorder()
+		r.Login(rr, req)
+		return rr
+	}
+	expectRetryAfter := func(rr *httptest.ResponseRecorder, want time.Duration) {
+		t.Helper()
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("got status %d, want %d: %s", rr.Code, http.StatusTooManyRequests, rr.Body.String())
+		}
+		retryAfter, err := strconv.Atoi(rr.Header().Get(RetryAfter))
+		if err != nil || time.Duration(retryAfter)*time.Second > want+time.Second || time.Duration(retryAfter)*time.Second < want-2*time.Second {
+			t.Errorf("got Retry-After %q, want about %v", rr.Header().Get(RetryAfter), want)
+		}
+	}
+
+	// the first failure is free
+	if rr := login("wrongpass"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := login("wrongpass"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("second failure: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	// the second failure starts a backoff that refuses even the right password
+	expectRetryAfter(login("testpass"), time.Minute)
+	if user.FailedLogins != 2 {
+		t.Errorf("refused logins must not count as failures, got %d", user.FailedLogins)
+	}
+
+	// once the backoff has passed the third failure locks the account
+	user.LockedUntil = time.Now().Add(-time.Second).Unix()
+	if rr := login("wrongpass"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("third failure: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	expectRetryAfter(login("testpass"), 15*time.Minute)
+
+	// an admin unlock lets the user log in again
+	if err := userService.UnlockUser(t.Context(), "testuser"); err != nil {
+		t.Fatalf("UnlockUser() error = %v", err)
+	}
+	if rr := login("testpass"); rr.Code != http.StatusOK {
+		t.Fatalf("after unlock: got status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	// a successful login resets the count
+	user.FailedLogins = 1
+	if rr := login("testpass"); rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	if user.FailedLogins != 0 {
+		t.Errorf("expected the failed logins to be reset, got %d", user.FailedLogins)
+	}
+}
+
+func TestRoute_LoginMFA_Lockout(t *testing.T) {
+	cipher := testSecretCipher(t)
+	user := testMFAUser(t, cipher, "testpass")
+	validCode, err := auth.TOTPCode(testTOTPSecret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	wrongCode := "000000"
+	if validCode == wrongCode {
+		wrongCode = "111111"
+	}
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(func(context.Context, string) (*models.User, error) {
+		copied := *user
+		return &copied, nil
+	})
+	userRepo.On("RecordLoginFailure", mock.Anything, "testuser", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64")).
+		Return(func(_ context.Context, _ string, previous, failures, lockedUntil int64) (bool, error) {
+			if user.FailedLogins != previous {
+				return false, nil
+			}
+			user.FailedLogins, user.LockedUntil = failures, lockedUntil
+			return true, nil
+		})
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", AccountLockoutsTotal).Return().Once()
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+	mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+	r := &Route{
+		Metrics: mockedMetrics,
+		UserService: &userservice.UserService{
+			UserRepo:     userRepo,
+			SecretCipher: cipher,
+			Lockout:      userservice.LockoutPolicy{MaxAttempts: 3, Duration: 15 * time.Minute},
+		},
+		Keyring:     testKeyring(t),
+		Revocations: memory.NewMemoryRevocationStore(),
+		validator:   structValidator.New(),
+	}
+
+	login := func() string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, LoginRouteAPI, strings.NewReader(`{"username":"testuser","password":"testpass"}`))
+		req.Header.Set(ContentType, ContentTypeJson)
+		rr := httptest.NewRecorder()
+		r.Login(rr, req)
+		response := &dto.MFAChallengeResponseDTO{}
+		if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil || response.MFAToken == "" {
+			t.Fatalf("expected an MFA challenge, got status %d: %s", rr.Code, rr.Body.String())
+		}
+		return response.MFAToken
+	}
+	loginMFA := func(challenge, code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, LoginMFARouteAPI, strings.NewReader(`{"mfa_token":"`+challenge+`","code":"`+code+`"}`))
+		req.Header.Set(ContentType, ContentTypeJson)
+		rr := httptest.NewRecorder()
+		r.LoginMFA(rr, req)
+		return rr
+	}
+
+	// the right password does not reset the count of a user with a second factor
+	user.FailedLogins = 1
+	challenge := login()
+	if user.FailedLogins != 1 {
+		t.Fatalf("got %d failed logins after the password, want 1", user.FailedLogins)
+	}
+
+	if rr := loginMFA(challenge, wrongCode); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("second failure: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	// the failure that locks the account also consumes the challenge
+	if rr := loginMFA(challenge, wrongCode); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("third failure: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if user.FailedLogins != 3 || user.LockedUntil <= time.Now().Unix() {
+		t.Fatalf("expected the account to be locked, got %d failures until %d", user.FailedLogins, user.LockedUntil)
+	}
+	if rr := loginMFA(challenge, validCode); rr.Code != http.StatusUnauthorized {
+		t.Errorf("consumed challenge: got status %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	// a challenge issued before the lockout cannot complete it either
+	user.LockedUntil = 0
+	user.FailedLogins = 2
+	challenge = login()
+	user.FailedLogins, user.LockedUntil = 3, time.Now().Add(time.Minute).Unix()
+	if rr := loginMFA(challenge, validCode); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("locked account: got status %d, want %d: %s", rr.Code, http.StatusTooManyRequests, rr.Body.String())
+	}
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// RequestMagicLink mails a single-use login link to the owner of an email
+// address. The link is looked up and sent in the background so that neither
+// the response nor its timing tells whether the account exists.
+func (r *Route) RequestMagicLink(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(MagicLinkRequestsTotal)
+	}
+
+	// links can only be made single-use with a revocation store
+	if !r.UserService.MagicLinkConfigured() || r.Revocations == nil {
+		r.jsonError(w, http.StatusNotImplemented, fmt.Errorf("magic link login is not configured"), "Magic link login is not available", MagicLinkFailedTotal)
+		return
+	}
+
+	magicRequest := &dto.MagicLinkRequestDTO{}
+	if !r.decodeJSONRequest(w, req, magicRequest, MagicLinkFailedTotal) {
+		return
+	}
+
+	go r.sendMagicLink(context.WithoutCancel(req.Context()), magicRequest.Email)
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(&dto.MagicLinkResponseDTO{
+		Message: "If an account uses this email address, a login link has been sent",
+	})
+}
+
+// MagicLinkCallback logs in the user of a login link, which carries its token
+// in the "token" query parameter, and issues the same session as Login. The
+// link is consumed, so it cannot be used again. Opening it also verifies the
+// user's email address.
+func (r *Route) MagicLinkCallback(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(MagicLinkLoginRequestsTotal)
+	}
+
+	if r.Revocations == nil {
+		r.jsonError(w, http.StatusNotImplemented, fmt.Errorf("magic link login is not configured"), "Magic link login is not available", MagicLinkFailedTotal)
+		return
+	}
+
+	claims, err := auth.VerifyMagicLinkToken(req.Context(), req.URL.Query().Get("token"), r.Keyring, r.TokenConfig, r.Revocations)
+	if err != nil {
+		r.jsonError(w, http.StatusUnauthorized, err, "Invalid or expired login link", MagicLinkFailedTotal)
+		return
+	}
+
+	user, err := r.UserService.GetUser(req.Context(), claims.UserID)
+	if err != nil {
+		r.jsonError(w, http.StatusInternalServerError, err, "Failed to load user", MagicLinkFailedTotal)
+		return
+	}
+	// the link stops working once the user changes their email address
+	if user == nil || user.Email == "" || user.Email != claims.Email {
+		r.jsonError(w, http.StatusUnauthorized, fmt.Errorf("login link does not match the user"), "Invalid or expired login link", MagicLinkFailedTotal)
+		return
+	}
+
+	if err := r.Revocations.Revoke(req.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		r.jsonError(w, http.StatusInternalServerError, fmt.Errorf("failed to consume login link: %w", err), "Failed to log in", MagicLinkFailedTotal)
+		return
+	}
+
+	if !user.EmailVerified {
+		if err := r.UserService.VerifyEmail(req.Context(), user.Username, claims.Email); err != nil {
+			r.jsonError(w, http.StatusInternalServerError, err, "Failed to log in", MagicLinkFailedTotal)
+			return
+		}
+	}
+
+	amr := []string{auth.AMREmail}
+	if methods := userservice.MFAMethods(user); len(methods) > 0 {
+		r.mfaChallenge(w, user.Username, amr, methods)
+		return
+	}
+	r.completeLogin(w, req, user.Username, amr)
+}
+
+// sendMagicLink mails a login link to the user with email, if there is one.
+// Failures are only counted, since the response was already sent.
+func (r *Route) sendMagicLink(ctx context.Context, email string) {
+	user, err := r.UserService.GetUserByEmail(ctx, email)
+	if err == nil && user != nil && user.Email != "" {
+		ttl := r.MagicLinkTTL
+		if ttl <= 0 {
+			ttl = auth.DefaultMagicLinkTTL
+		}
+
+		var token string
+		token, err = auth.CreateMagicLinkToken(user.Username, user.Email, ttl, r.Keyring, r.TokenConfig)
+		if err == nil {
+			err = r.UserService.SendMagicLink(ctx, user.Email, token, ttl)
+		}
+		if err == nil && r.Metrics != nil {
+			r.Metrics.IncCounter(MagicLinkSentTotal)
+		}
+	}
+	if err != nil && r.Metrics != nil {
+		r.Metrics.IncCounter(MagicLinkFailedTotal)
+	}
+}
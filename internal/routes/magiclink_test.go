@@ -0,0 +1,286 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	mailmemory "github.com/haguru/sasuke/internal/mailer/memory"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+const testMagicLinkURL = "https://app.example.com/login/magic"
+
+func TestRoute_RequestMagicLink(t *testing.T) {
+	keyring := testKeyring(t)
+	genericResponse := ""
+
+	tests := []struct {
+		name           string
+		body           string
+		user           *models.User
+		unconfigured   bool
+		expectedStatus int
+		expectMail     bool
+	}{
+		{
+			name:           "Existing account",
+			body:           `{"email":"Alice@Example.com"}`,
+			user:           &models.User{Username: "testuser", Email: "alice@example.com"},
+			expectedStatus: http.StatusAccepted,
+			expectMail:     true,
+		},
+		{
+			name:           "Unknown account",
+			body:           `{"email":"nobody@example.com"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Invalid email",
+			body:           `{"email":"testuser"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Magic links not configured",
+			body:           `{"email":"alice@example.com"}`,
+			unconfigured:   true,
+			expectedStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			looked := make(chan struct{})
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByEmail", mock.Anything, mock.AnythingOfType("string")).
+				Run(func(args mock.Arguments) { close(looked) }).
+				Return(tt.user, nil).Maybe()
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			mailer := mailmemory.NewMemoryMailer()
+			userService := &userservice.UserService{UserRepo: userRepo, Mailer: mailer, MagicLinkURL: testMagicLinkURL}
+			if tt.unconfigured {
+				userService.MagicLinkURL = ""
+			}
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: userService,
+				Keyring:     keyring,
+				Revocations: memory.NewMemoryRevocationStore(),
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, MagicLinkRouteAPI, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.RequestMagicLink(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusAccepted {
+				return
+			}
+
+			// known and unknown accounts get the same answer
+			if genericResponse == "" {
+				genericResponse = rr.Body.String()
+			} else if rr.Body.String() != genericResponse {
+				t.Errorf("response %q differs from %q", rr.Body.String(), genericResponse)
+			}
+
+			select {
+			case <-looked:
+			case <-time.After(time.Second):
+				t.Fatal("expected the account to be looked up")
+			}
+			messages := waitForMail(mailer, tt.expectMail)
+			if !tt.expectMail {
+				if len(messages) != 0 {
+					t.Errorf("expected no email, got %+v", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0].To != "alice@example.com" {
+				t.Fatalf("expected one email to alice@example.com, got %+v", messages)
+			}
+			claims, err := auth.VerifyMagicLinkToken(t.Context(), linkToken(t, messages[0], testMagicLinkURL), keyring, auth.TokenConfig{}, nil)
+			if err != nil {
+				t.Fatalf("expected a valid magic link: %v", err)
+			}
+			if claims.UserID != "testuser" || claims.Email != "alice@example.com" {
+				t.Errorf("unexpected magic link claims %+v", claims)
+			}
+			if ttl := time.Until(claims.ExpiresAt.Time); ttl > auth.DefaultMagicLinkTTL {
+				t.Errorf("expected the link to expire within %v, got %v", auth.DefaultMagicLinkTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestRoute_MagicLinkCallback(t *testing.T) {
+	keyring := testKeyring(t)
+	cipher := testSecretCipher(t)
+
+	newLink := func(email string) string {
+		t.Helper()
+		token, err := auth.CreateMagicLinkToken("testuser", email, time.Minute, keyring, auth.TokenConfig{})
+		if err != nil {
+			t.Fatalf("Failed to create magic link: %v", err)
+		}
+		return token
+	}
+	session, err := auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create session token: %v", err)
+	}
+	mfaUser := testMFAUser(t, cipher, "testpass")
+	mfaUser.Email = "alice@example.com"
+	mfaUser.EmailVerified = true
+
+	tests := []struct {
+		name           string
+		token          string
+		user           *models.User
+		useTwice       bool
+		expectedStatus int
+		expectVerify   bool
+		expectSession  bool
+		expectMFA      bool
+	}{
+		{
+			name:           "Valid link",
+			token:          newLink("alice@example.com"),
+			user:           &models.User{Username: "testuser", Email: "alice@example.com", EmailVerified: true},
+			expectedStatus: http.StatusOK,
+			expectSession:  true,
+		},
+		{
+			name:           "Valid link verifies the email",
+			token:          newLink("alice@example.com"),
+			user:           &models.User{Username: "testuser", Email: "alice@example.com"},
+			expectedStatus: http.StatusOK,
+			expectVerify:   true,
+			expectSession:  true,
+		},
+		{
+			name:           "Link used twice",
+			token:          newLink("alice@example.com"),
+			user:           &models.User{Username: "testuser", Email: "alice@example.com", EmailVerified: true},
+			useTwice:       true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Email changed since the link was sent",
+			token:          newLink("alice@example.com"),
+			user:           &models.User{Username: "testuser", Email: "bob@example.com", EmailVerified: true},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Session token instead of a link",
+			token:          session,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "User with a second factor",
+			token:          newLink("alice@example.com"),
+			user:           mfaUser,
+			expectedStatus: http.StatusOK,
+			expectMFA:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(tt.user, nil).Maybe()
+			userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+			if tt.expectVerify {
+				userRepo.On("UpdateUser", mock.Anything, "testuser", map[string]interface{}{"email_verified": true}).Return(nil).Once()
+			}
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher},
+				Keyring:     keyring,
+				Revocations: memory.NewMemoryRevocationStore(),
+				validator:   structValidator.New(),
+			}
+
+			open := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, MagicLinkCallbackRouteAPI+"?token="+url.QueryEscape(tt.token), nil)
+				rr := httptest.NewRecorder()
+				r.MagicLinkCallback(rr, req)
+				return rr
+			}
+			rr := open()
+			if tt.useTwice {
+				if rr.Code != http.StatusOK {
+					t.Fatalf("first use: got status %d, want %d", rr.Code, http.StatusOK)
+				}
+				rr = open()
+			}
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+
+			var sessionToken string
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == SessionCookieName {
+					sessionToken = cookie.Value
+				}
+			}
+			if !tt.expectSession {
+				if sessionToken != "" && !tt.useTwice {
+					t.Error("expected no session cookie")
+				}
+				if tt.expectMFA {
+					var challenge dto.MFAChallengeResponseDTO
+					if err := json.NewDecoder(rr.Body).Decode(&challenge); err != nil || !challenge.MFARequired {
+						t.Fatalf("expected an MFA challenge, got %v, %v", challenge, err)
+					}
+					claims, err := auth.VerifyMFAChallengeToken(t.Context(), challenge.MFAToken, keyring, auth.TokenConfig{}, nil)
+					if err != nil || strings.Join(claims.AMR, " ") != auth.AMREmail {
+						t.Errorf("expected a challenge after an email login, got %v, %v", claims, err)
+					}
+				}
+				return
+			}
+
+			claims, err := auth.VerifyToken(t.Context(), sessionToken, keyring, auth.TokenConfig{}, nil)
+			if err != nil {
+				t.Fatalf("expected a valid session token: %v", err)
+			}
+			if claims.UserID != "testuser" || strings.Join(claims.AMR, " ") != auth.AMREmail {
+				t.Errorf("got user %s and amr %v", claims.UserID, claims.AMR)
+			}
+		})
+	}
+}
+
+// waitForMail returns the messages of mailer, waiting for the first one when
+// one is expected.
+func waitForMail(mailer *mailmemory.MemoryMailer, expect bool) []models.MailMessage {
+	deadline := time.Now().Add(time.Second)
+	for expect && len(mailer.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return mailer.Messages()
+}
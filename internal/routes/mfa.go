@@ -0,0 +1,312 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// LoginMFA is the second login step of users with a second factor. It
+// exchanges the challenge token returned by Login and a TOTP code, or a
+// recovery code, for a session.
+func (r *Route) LoginMFA(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LoginMFARequestsTotal)
+	}
+
+	mfaRequest := &dto.MFALoginRequestDTO{}
+	if !r.decodeJSONRequest(w, req, mfaRequest, LoginMFAFailedTotal) {
+		return
+	}
+
+	challenge, err := r.completeMFAChallenge(req.Context(), mfaRequest.MFAToken, mfaRequest.Code, mfaRequest.RecoveryCode)
+	var locked *userservice.AccountLockedError
+	if errors.As(err, &locked) {
+		r.lockedResponse(w, locked)
+		return
+	}
+	if errors.Is(err, userservice.ErrLockoutStarted) && r.Metrics != nil {
+		r.Metrics.IncCounter(AccountLockoutsTotal)
+	}
+	if err != nil {
+		status := http.StatusUnauthorized
+		if !isMFAVerificationError(err) {
+			status = http.StatusInternalServerError
+		}
+		r.jsonError(w, status, err, "Invalid or expired verification code", LoginMFAFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LoginMFASuccessTotal)
+	}
+	r.completeLogin(w, req, challenge.UserID, append(challenge.AMR, auth.AMROTP, auth.AMRMFA))
+}
+
+// EnrollTOTP starts TOTP enrollment for the caller and returns the secret and
+// its provisioning URI. It must be wrapped by middleware.AuthMiddleware.
+func (r *Route) EnrollTOTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(MFAEnrollRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, MFAFailedTotal)
+	if !ok {
+		return
+	}
+
+	enrollment, err := r.UserService.BeginTOTPEnrollment(req.Context(), username)
+	if err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to start TOTP enrollment", MFAFailedTotal)
+		return
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	// the response holds the shared secret
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.TOTPEnrollmentResponseDTO{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.URI,
+	})
+}
+
+// ConfirmTOTP enables the caller's pending TOTP enrollment once a code from
+// the authenticator app is presented and returns the first batch of recovery
+// codes. It must be wrapped by middleware.AuthMiddleware.
+func (r *Route) ConfirmTOTP(w http.ResponseWriter, req *http.Request) {
+	username, codeRequest, ok := r.mfaCodeRequest(w, req)
+	if !ok {
+		return
+	}
+
+	codes, err := r.UserService.ConfirmTOTPEnrollment(req.Context(), username, codeRequest.Code)
+	if err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to verify TOTP code", MFAFailedTotal)
+		return
+	}
+
+	r.recoveryCodesResponse(w, "TOTP enabled", codes, len(codes))
+}
+
+// DisableTOTP removes the caller's TOTP second factor and recovery codes after
+// checking a current TOTP or recovery code. It must be wrapped by
+// middleware.AuthMiddleware.
+func (r *Route) DisableTOTP(w http.ResponseWriter, req *http.Request) {
+	username, codeRequest, ok := r.mfaCodeRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := r.UserService.VerifySecondFactor(req.Context(), username, codeRequest.Code, codeRequest.RecoveryCode); err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to verify second factor", MFAFailedTotal)
+		return
+	}
+	if err := r.UserService.DisableTOTP(req.Context(), username); err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to disable TOTP", MFAFailedTotal)
+		return
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.MFAResponseDTO{Message: "TOTP disabled"})
+}
+
+// RecoveryCodes reports how many recovery codes the caller has left on GET.
+// On POST it checks a current TOTP or recovery code and replaces the caller's
+// recovery codes with a new batch. It must be wrapped by middleware.AuthMiddleware.
+func (r *Route) RecoveryCodes(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(MFAEnrollRequestsTotal)
+		}
+		username, ok := r.sessionUser(w, req, MFAFailedTotal)
+		if !ok {
+			return
+		}
+
+		remaining, err := r.UserService.RemainingRecoveryCodes(req.Context(), username)
+		if err != nil {
+			r.jsonError(w, mfaErrorStatus(err), err, "Failed to count recovery codes", MFAFailedTotal)
+			return
+		}
+		r.recoveryCodesResponse(w, "", nil, remaining)
+		return
+	}
+
+	username, codeRequest, ok := r.mfaCodeRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := r.UserService.VerifySecondFactor(req.Context(), username, codeRequest.Code, codeRequest.RecoveryCode); err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to verify second factor", MFAFailedTotal)
+		return
+	}
+	codes, err := r.UserService.GenerateRecoveryCodes(req.Context(), username)
+	if err != nil {
+		r.jsonError(w, mfaErrorStatus(err), err, "Failed to generate recovery codes", MFAFailedTotal)
+		return
+	}
+
+	r.recoveryCodesResponse(w, "Recovery codes regenerated", codes, len(codes))
+}
+
+// mfaCodeRequest checks the method and caller of a POST carrying a TOTP or
+// recovery code and decodes its body.
+func (r *Route) mfaCodeRequest(w http.ResponseWriter, req *http.Request) (string, *dto.TOTPCodeRequestDTO, bool) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return "", nil, false
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(MFAEnrollRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, MFAFailedTotal)
+	if !ok {
+		return "", nil, false
+	}
+
+	codeRequest := &dto.TOTPCodeRequestDTO{}
+	if !r.decodeJSONRequest(w, req, codeRequest, MFAFailedTotal) {
+		return "", nil, false
+	}
+	return username, codeRequest, true
+}
+
+// recoveryCodesResponse writes recovery codes, which are only ever shown once.
+func (r *Route) recoveryCodesResponse(w http.ResponseWriter, message string, codes []string, remaining int) {
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.RecoveryCodesResponseDTO{
+		Message:       message,
+		RecoveryCodes: codes,
+		Remaining:     remaining,
+	})
+}
+
+// mfaChallenge answers a successful first factor login of a user with a second
+// factor with a challenge token for LoginMFA. amr lists the methods used so far.
+func (r *Route) mfaChallenge(w http.ResponseWriter, username string, amr, methods []string) {
+	mfaToken, err := auth.CreateMFAChallengeToken(username, amr, r.MFAChallengeTTL, r.Keyring, r.TokenConfig)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to generate MFA challenge")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LoginMFAChallengesTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.MFAChallengeResponseDTO{
+		Message:     "Second factor required",
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		MFAMethods:  methods,
+	})
+}
+
+// completeMFAChallenge checks a TOTP code, or a recovery code when it is set,
+// against the user of an MFA challenge token and consumes the challenge so it
+// cannot be used again. Wrong codes count towards the lockout of the user,
+// which also consumes the challenge.
+func (r *Route) completeMFAChallenge(ctx context.Context, mfaToken, code, recoveryCode string) (*auth.MFAChallengeClaims, error) {
+	challenge, err := auth.VerifyMFAChallengeToken(ctx, mfaToken, r.Keyring, r.TokenConfig, r.Revocations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidMFAChallenge, err)
+	}
+
+	err = r.UserService.VerifySecondFactor(ctx, challenge.UserID, code, recoveryCode)
+	if err != nil && !errors.Is(err, userservice.ErrAccountLocked) && !errors.Is(err, userservice.ErrLockoutStarted) {
+		return nil, err
+	}
+
+	if r.Revocations != nil && challenge.ID != "" && challenge.ExpiresAt != nil {
+		if err := r.Revocations.Revoke(ctx, challenge.ID, challenge.ExpiresAt.Time); err != nil {
+			return nil, fmt.Errorf("failed to consume mfa challenge: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// errInvalidMFAChallenge wraps invalid, expired or already used challenge tokens.
+var errInvalidMFAChallenge = errors.New("invalid mfa challenge")
+
+// isMFAVerificationError reports whether err was caused by the caller's
+// challenge token or code rather than by the service.
+func isMFAVerificationError(err error) bool {
+	return errors.Is(err, errInvalidMFAChallenge) ||
+		errors.Is(err, userservice.ErrInvalidTOTPCode) ||
+		errors.Is(err, userservice.ErrInvalidRecoveryCode) ||
+		errors.Is(err, userservice.ErrTOTPNotEnrolled) ||
+		errors.Is(err, userservice.ErrAccountLocked)
+}
+
+// sessionUser returns the user of the caller's session. Account credentials
+// are only managed with a session the user signed in for, so tokens issued or
+// delegated to OAuth clients are rejected.
+func (r *Route) sessionUser(w http.ResponseWriter, req *http.Request, failedMetric string) (string, bool) {
+	claims, ok := auth.ClaimsFromContext(req.Context())
+	if !ok {
+		r.jsonError(w, http.StatusUnauthorized, fmt.Errorf("request is not authenticated"), "Authentication required", failedMetric)
+		return "", false
+	}
+	if !claims.IsSession() {
+		r.jsonError(w, http.StatusForbidden, errSessionRequired, "A signed-in session is required", failedMetric)
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+// errSessionRequired is reported for client tokens on account management routes.
+var errSessionRequired = errors.New("account management requires a session token")
+
+// mfaErrorStatus maps MFA service errors to HTTP status codes.
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, userservice.ErrInvalidTOTPCode), errors.Is(err, userservice.ErrInvalidRecoveryCode):
+		return http.StatusBadRequest
+	case errors.Is(err, userservice.ErrTOTPAlreadyEnabled), errors.Is(err, userservice.ErrTOTPNotEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, userservice.ErrAccountLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, userservice.ErrMFANotConfigured):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
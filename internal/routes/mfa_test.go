@@ -0,0 +1,476 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// testMFAUser returns a user with TOTP enabled and its secret encrypted by cipher.
+func testMFAUser(t *testing.T, cipher *auth.SecretCipher, password string) *models.User {
+	t.Helper()
+	hashedPassword, err := HashString(password)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	encrypted, err := cipher.Encrypt(testTOTPSecret, "testuser")
+	if err != nil {
+		t.Fatalf("Failed to encrypt TOTP secret: %v", err)
+	}
+	return &models.User{
+		Username:       "testuser",
+		HashedPassword: hashedPassword,
+		TOTPSecret:     encrypted,
+		TOTPEnabled:    true,
+	}
+}
+
+func testSecretCipher(t *testing.T) *auth.SecretCipher {
+	t.Helper()
+	cipher, err := auth.NewSecretCipher(bytes.Repeat([]byte{1}, auth.SecretKeyBytes))
+	if err != nil {
+		t.Fatalf("Failed to create secret cipher: %v", err)
+	}
+	return cipher
+}
+
+func TestRoute_Login_MFAChallenge(t *testing.T) {
+	cipher := testSecretCipher(t)
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(testMFAUser(t, cipher, "testpass"), nil)
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+	mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+	keyring := testKeyring(t)
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher},
+		Keyring:     keyring,
+		validator:   structValidator.New(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, LoginRouteAPI, strings.NewReader(`{"username":"testuser","password":"testpass"}`))
+	req.Header.Set(ContentType, ContentTypeJson)
+	rr := httptest.NewRecorder()
+	r.Login(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("expected no session cookies before the second factor")
+	}
+
+	var challenge dto.MFAChallengeResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&challenge); err != nil {
+		t.Fatalf("failed to decode challenge: %v", err)
+	}
+	if !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("expected an MFA challenge, got %+v", challenge)
+	}
+	if len(challenge.MFAMethods) != 1 || challenge.MFAMethods[0] != "totp" {
+		t.Errorf("expected totp method, got %v", challenge.MFAMethods)
+	}
+	if _, err := auth.VerifyMFAChallengeToken(req.Context(), challenge.MFAToken, keyring, auth.TokenConfig{}, nil); err != nil {
+		t.Errorf("expected a valid challenge token: %v", err)
+	}
+}
+
+func TestRoute_LoginMFA(t *testing.T) {
+	keyring := testKeyring(t)
+	cipher := testSecretCipher(t)
+
+	validCode, err := auth.TOTPCode(testTOTPSecret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	wrongCode := "000000"
+	if validCode == wrongCode {
+		wrongCode = "111111"
+	}
+	challenge, err := auth.CreateMFAChallengeToken("testuser", []string{auth.AMRPassword}, time.Minute, keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+	session, err := auth.CreateSessionToken("testuser", []string{auth.AMRPassword}, auth.Grants{}, keyring, auth.TokenConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create session token: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+	}{
+		{
+			name:           "Valid code",
+			body:           `{"mfa_token":"` + challenge + `","code":"` + validCode + `"}`,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Wrong code",
+			body:           `{"mfa_token":"` + challenge + `","code":"` + wrongCode + `"}`,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Session token as challenge",
+			body:           `{"mfa_token":"` + session + `","code":"` + validCode + `"}`,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Code is not numeric",
+			body:           `{"mfa_token":"` + challenge + `","code":"abcdef"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing challenge",
+			body:           `{"code":"` + validCode + `"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(testMFAUser(t, cipher, "testpass"), nil).Maybe()
+		userRepo.On("SetTOTPLastCounter", mock.Anything, "testuser", int64(0), mock.AnythingOfType("int64")).Return(true, nil).Maybe()
+		userRepo.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(token models.RefreshToken) bool {
+			// the refresh token keeps the methods of the login
+			return token.AMR == "pwd otp mfa"
+		})).Return(nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:     mockedMetrics,
+			UserService: &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher},
+			Keyring:     keyring,
+			Revocations: memory.NewMemoryRevocationStore(),
+			validator:   structValidator.New(),
+		}
+
+		req := httptest.NewRequest(http.MethodPost, LoginMFARouteAPI, strings.NewReader(tt.body))
+		req.Header.Set(ContentType, ContentTypeJson)
+		rr := httptest.NewRecorder()
+		r.LoginMFA(rr, req)
+
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+
+		var sessionToken string
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == SessionCookieName {
+				sessionToken = cookie.Value
+			}
+		}
+		claims, err := auth.VerifyToken(req.Context(), sessionToken, keyring, auth.TokenConfig{}, nil)
+		if err != nil {
+			t.Fatalf("%s: expected a valid session token: %v", tt.name, err)
+		}
+		if strings.Join(claims.AMR, " ") != "pwd otp mfa" {
+			t.Errorf("%s: got amr %v", tt.name, claims.AMR)
+		}
+
+		// the challenge is consumed by a successful login
+		replay := httptest.NewRequest(http.MethodPost, LoginMFARouteAPI, strings.NewReader(tt.body))
+		replay.Header.Set(ContentType, ContentTypeJson)
+		rr = httptest.NewRecorder()
+		r.LoginMFA(rr, replay)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s: replayed challenge got status %d, want %d", tt.name, rr.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestRoute_TOTPEnrollment(t *testing.T) {
+	cipher := testSecretCipher(t)
+	user := &models.User{Username: "testuser"}
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+	userRepo.On("UpdateUser", mock.Anything, "testuser", mock.Anything).Run(func(args mock.Arguments) {
+		fields := args.Get(2).(map[string]interface{})
+		if secret, ok := fields["totp_secret"].(string); ok {
+			user.TOTPSecret = secret
+		}
+		if enabled, ok := fields["totp_enabled"].(bool); ok {
+			user.TOTPEnabled = enabled
+		}
+		if codes, ok := fields["recovery_codes"].(string); ok {
+			user.RecoveryCodes = codes
+		}
+	}).Return(nil)
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher, TOTPIssuer: "sasuke"},
+		validator:   structValidator.New(),
+	}
+	claims := &auth.CustomClaims{UserID: "testuser"}
+
+	req := httptest.NewRequest(http.MethodPost, TOTPEnrollRouteAPI, nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+	rr := httptest.NewRecorder()
+	r.EnrollTOTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enroll: got status %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var enrollment dto.TOTPEnrollmentResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&enrollment); err != nil {
+		t.Fatalf("failed to decode enrollment: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/sasuke:testuser?") {
+		t.Errorf("unexpected provisioning URI %s", enrollment.ProvisioningURI)
+	}
+	if user.TOTPSecret == "" || user.TOTPSecret == enrollment.Secret {
+		t.Error("expected the secret to be stored encrypted")
+	}
+
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodPost, TOTPConfirmRouteAPI, strings.NewReader(`{"code":"`+code+`"}`))
+	req.Header.Set(ContentType, ContentTypeJson)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+	rr = httptest.NewRecorder()
+	r.ConfirmTOTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("confirm: got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	if !user.TOTPEnabled {
+		t.Error("expected TOTP to be enabled")
+	}
+	var confirmation dto.RecoveryCodesResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&confirmation); err != nil {
+		t.Fatalf("failed to decode confirmation: %v", err)
+	}
+	if len(confirmation.RecoveryCodes) != userservice.RecoveryCodeCount || confirmation.Remaining != userservice.RecoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", userservice.RecoveryCodeCount, len(confirmation.RecoveryCodes))
+	}
+
+	// enrolling again requires disabling TOTP first
+	req = httptest.NewRequest(http.MethodPost, TOTPEnrollRouteAPI, nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+	rr = httptest.NewRecorder()
+	r.EnrollTOTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("re-enroll: got status %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	// machine clients have no second factor, and clients a user delegated
+	// to cannot manage the second factor of the user
+	for _, clientClaims := range []*auth.CustomClaims{
+		{PrincipalType: auth.PrincipalTypeClient, ClientID: "machine"},
+		{UserID: "testuser", PrincipalType: auth.PrincipalTypeUser, ClientID: "client-1", Scope: "openid"},
+	} {
+		req = httptest.NewRequest(http.MethodPost, TOTPEnrollRouteAPI, nil)
+		req = req.WithContext(auth.ContextWithClaims(req.Context(), clientClaims))
+		rr = httptest.NewRecorder()
+		r.EnrollTOTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("client %s: got status %d, want %d", clientClaims.ClientID, rr.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestRoute_Authorize_MFA(t *testing.T) {
+	keyring := testKeyring(t)
+	cipher := testSecretCipher(t)
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(testMFAUser(t, cipher, "TestPassword123"), nil)
+	userRepo.On("SetTOTPLastCounter", mock.Anything, "testuser", int64(0), mock.AnythingOfType("int64")).Return(true, nil)
+
+	clientRepo := mocks.NewMockClientRepository(t)
+	clientRepo.On("GetClient", mock.Anything, "client-1").Return(testOAuthClient(), nil)
+	clientRepo.On("AddAuthorizationCode", mock.Anything, mock.AnythingOfType("models.AuthorizationCode")).Return(nil)
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+	r := &Route{
+		Metrics:      mockedMetrics,
+		UserService:  &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher},
+		Keyring:      keyring,
+		OAuthService: oauthservice.NewOAuthService(clientRepo),
+		Revocations:  memory.NewMemoryRevocationStore(),
+		validator:    structValidator.New(),
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"client-1"},
+		"redirect_uri":          {testRedirectURI},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+		"username":              {"testuser"},
+		"password":              {"TestPassword123"},
+	}
+	req := httptest.NewRequest(http.MethodPost, AuthorizeRouteAPI, strings.NewReader(params.Encode()))
+	req.Header.Set(ContentType, ContentTypeForm)
+	rr := httptest.NewRecorder()
+	r.Authorize(rr, req)
+
+	// valid credentials of a user with TOTP lead to the code form, not a code
+	if rr.Code != http.StatusOK {
+		t.Fatalf("password step: got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	match := regexp.MustCompile(`name="mfa_token" value="([^"]+)"`).FindStringSubmatch(rr.Body.String())
+	if match == nil {
+		t.Fatal("expected the form to carry an MFA challenge")
+	}
+
+	code, err := auth.TOTPCode(testTOTPSecret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	params.Del("username")
+	params.Del("password")
+	params.Set("mfa_token", match[1])
+	params.Set("code", code)
+	req = httptest.NewRequest(http.MethodPost, AuthorizeRouteAPI, strings.NewReader(params.Encode()))
+	req.Header.Set(ContentType, ContentTypeForm)
+	rr = httptest.NewRecorder()
+	r.Authorize(rr, req)
+
+	if rr.Code != http.StatusFound {
+		t.Fatalf("code step: got status %d, want %d", rr.Code, http.StatusFound)
+	}
+	location, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid Location header: %v", err)
+	}
+	if location.Query().Get("code") == "" {
+		t.Errorf("expected an authorization code, got %s", location.RawQuery)
+	}
+}
+
+func TestRoute_RecoveryCodes(t *testing.T) {
+	keyring := testKeyring(t)
+	cipher := testSecretCipher(t)
+	user := testMFAUser(t, cipher, "testpass")
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+	userRepo.On("UpdateUser", mock.Anything, "testuser", mock.Anything).Run(func(args mock.Arguments) {
+		user.RecoveryCodes = args.Get(2).(map[string]interface{})["recovery_codes"].(string)
+	}).Return(nil)
+	userRepo.On("SetRecoveryCodes", mock.Anything, "testuser", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(
+		func(_ context.Context, _, previous, codes string) bool {
+			if previous != user.RecoveryCodes {
+				return false
+			}
+			user.RecoveryCodes = codes
+			return true
+		}, nil)
+	userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: &userservice.UserService{UserRepo: userRepo, SecretCipher: cipher},
+		Keyring:     keyring,
+		Revocations: memory.NewMemoryRevocationStore(),
+		validator:   structValidator.New(),
+	}
+	claims := &auth.CustomClaims{UserID: "testuser"}
+
+	oldCodes, err := r.UserService.GenerateRecoveryCodes(context.Background(), "testuser")
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	// regenerating invalidates the previous batch
+	code, err := auth.TOTPCode(testTOTPSecret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to compute TOTP code: %v", err)
+	}
+	userRepo.On("SetTOTPLastCounter", mock.Anything, "testuser", int64(0), mock.AnythingOfType("int64")).Return(true, nil).Once()
+	req := httptest.NewRequest(http.MethodPost, RecoveryCodesRouteAPI, strings.NewReader(`{"code":"`+code+`"}`))
+	req.Header.Set(ContentType, ContentTypeJson)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+	rr := httptest.NewRecorder()
+	r.RecoveryCodes(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("regenerate: got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	var regenerated dto.RecoveryCodesResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&regenerated); err != nil {
+		t.Fatalf("failed to decode recovery codes: %v", err)
+	}
+	if len(regenerated.RecoveryCodes) != userservice.RecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", userservice.RecoveryCodeCount, len(regenerated.RecoveryCodes))
+	}
+
+	login := func(recoveryCode string) int {
+		challenge, err := auth.CreateMFAChallengeToken("testuser", []string{auth.AMRPassword}, time.Minute, keyring, auth.TokenConfig{})
+		if err != nil {
+			t.Fatalf("Failed to create challenge: %v", err)
+		}
+		body := `{"mfa_token":"` + challenge + `","recovery_code":"` + recoveryCode + `"}`
+		req := httptest.NewRequest(http.MethodPost, LoginMFARouteAPI, strings.NewReader(body))
+		req.Header.Set(ContentType, ContentTypeJson)
+		rr := httptest.NewRecorder()
+		r.LoginMFA(rr, req)
+		return rr.Code
+	}
+
+	if status := login(oldCodes[0]); status != http.StatusUnauthorized {
+		t.Errorf("code of the previous batch: got status %d, want %d", status, http.StatusUnauthorized)
+	}
+	// codes are accepted regardless of case and separator
+	if status := login(strings.ToUpper(strings.ReplaceAll(regenerated.RecoveryCodes[3], "-", ""))); status != http.StatusOK {
+		t.Errorf("recovery code: got status %d, want %d", status, http.StatusOK)
+	}
+	if status := login(regenerated.RecoveryCodes[3]); status != http.StatusUnauthorized {
+		t.Errorf("burned recovery code: got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, RecoveryCodesRouteAPI, nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+	rr = httptest.NewRecorder()
+	r.RecoveryCodes(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("count: got status %d, want %d", rr.Code, http.StatusOK)
+	}
+	var count dto.RecoveryCodesResponseDTO
+	if err := json.NewDecoder(rr.Body).Decode(&count); err != nil {
+		t.Fatalf("failed to decode recovery code count: %v", err)
+	}
+	if count.Remaining != userservice.RecoveryCodeCount-1 || len(count.RecoveryCodes) != 0 {
+		t.Errorf("expected %d remaining codes and none shown, got %+v", userservice.RecoveryCodeCount-1, count)
+	}
+}
@@ -0,0 +1,467 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// Authorize is the OAuth 2.0 authorization endpoint for the authorization code
+// flow with PKCE. A caller with a valid session cookie is issued a code right away;
+// otherwise a sign-in form is shown and its POST is checked with
+// UserService.AuthenticateUser, followed by a TOTP or recovery code for users
+// with a second factor. The code and state are returned to the client's registered
+// redirect URI.
+func (r *Route) Authorize(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(AuthorizeRequestsTotal)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		r.oauthError(w, http.StatusBadRequest, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "malformed request"))
+		return
+	}
+
+	authRequest := oauthservice.AuthorizationRequest{
+		ResponseType:        req.Form.Get("response_type"),
+		ClientID:            req.Form.Get("client_id"),
+		RedirectURI:         req.Form.Get("redirect_uri"),
+		Scope:               req.Form.Get("scope"),
+		State:               req.Form.Get("state"),
+		CodeChallenge:       req.Form.Get("code_challenge"),
+		CodeChallengeMethod: req.Form.Get("code_challenge_method"),
+		Nonce:               req.Form.Get("nonce"),
+	}
+
+	client, err := r.OAuthService.ValidateAuthorizationRequest(req.Context(), authRequest)
+	if err != nil {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(AuthorizeFailedTotal)
+		}
+		// never redirect to an unverified redirect URI
+		if client == nil {
+			r.oauthError(w, http.StatusBadRequest, err)
+			return
+		}
+		r.authorizeRedirect(w, req, authRequest, url.Values{
+			"error":             {oauthErrorCode(err)},
+			"error_description": {oauthErrorDescription(err)},
+		})
+		return
+	}
+
+	username := ""
+	var authTime time.Time
+	if req.Method == http.MethodPost {
+		loginUsername, ok := r.authorizeLogin(w, req, client, authRequest)
+		if !ok {
+			return
+		}
+		username = loginUsername
+		authTime = time.Now()
+	} else if cookie, err := req.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		// only the browser session of the user may approve a new client, not
+		// tokens issued or delegated to other clients
+		if claims, err := auth.VerifyToken(req.Context(), cookie.Value, r.Keyring, r.TokenConfig, r.Revocations); err == nil && claims.IsSession() {
+			username = claims.UserID
+			// the session token was issued when the user signed in
+			if claims.IssuedAt != nil {
+				authTime = claims.IssuedAt.Time
+			}
+		}
+	}
+
+	if username == "" {
+		r.renderAuthorizeLogin(w, http.StatusOK, client, authRequest, "")
+		return
+	}
+
+	code, err := r.issueAuthorizationCode(req.Context(), authRequest, username, authTime)
+	if err != nil {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(AuthorizeFailedTotal)
+		}
+		r.authorizeRedirect(w, req, authRequest, url.Values{
+			"error": {oauthservice.ErrorServerError},
+		})
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(AuthorizeCodesIssuedTotal)
+	}
+	r.authorizeRedirect(w, req, authRequest, url.Values{"code": {code}})
+}
+
+// issueAuthorizationCode issues a code for the requested scopes username
+// holds. Users can only delegate their own scopes, so the others are dropped
+// and the token response reports the narrower scope.
+func (r *Route) issueAuthorizationCode(ctx context.Context, authRequest oauthservice.AuthorizationRequest, username string, authTime time.Time) (string, error) {
+	grants, err := r.UserService.UserGrants(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	authRequest.Scope = oauthservice.NarrowScope(authRequest.Scope, grants.Scopes)
+	return r.OAuthService.IssueAuthorizationCode(ctx, authRequest, username, authTime)
+}
+
+// authorizeLogin checks the sign-in form of the authorization endpoint. It
+// returns false after rendering the form again, either with an error or with
+// the second factor step.
+func (r *Route) authorizeLogin(w http.ResponseWriter, req *http.Request, client *models.OAuthClient, authRequest oauthservice.AuthorizationRequest) (string, bool) {
+	if mfaToken := req.PostForm.Get("mfa_token"); mfaToken != "" {
+		// the form has a single field for TOTP and recovery codes
+		code, recoveryCode := req.PostForm.Get("code"), ""
+		if !isTOTPCode(code) {
+			code, recoveryCode = "", code
+		}
+		challenge, err := r.completeMFAChallenge(req.Context(), mfaToken, code, recoveryCode)
+		if err == nil {
+			return challenge.UserID, true
+		}
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(AuthorizeFailedTotal)
+		}
+		switch {
+		case errors.Is(err, userservice.ErrAccountLocked), errors.Is(err, userservice.ErrLockoutStarted):
+			r.renderAuthorizeLogin(w, http.StatusTooManyRequests, client, authRequest, "Too many failed sign-ins, try again later")
+		case errors.Is(err, errInvalidMFAChallenge):
+			r.renderAuthorizeLogin(w, http.StatusUnauthorized, client, authRequest, "Your sign-in expired, please sign in again")
+		default:
+			r.renderAuthorizePage(w, http.StatusUnauthorized, client, authRequest, "Invalid verification code", mfaToken)
+		}
+		return "", false
+	}
+
+	username := req.PostForm.Get("username")
+	authenticated, err := r.UserService.AuthenticateUser(req.Context(), username, req.PostForm.Get("password"))
+	if err != nil || !authenticated {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(AuthorizeFailedTotal)
+		}
+		r.renderAuthorizeLogin(w, http.StatusUnauthorized, client, authRequest, "Invalid username or password")
+		return "", false
+	}
+
+	user, err := r.UserService.GetUser(req.Context(), username)
+	if err != nil {
+		r.authorizeRedirect(w, req, authRequest, url.Values{"error": {oauthservice.ErrorServerError}})
+		return "", false
+	}
+	if r.RequireVerifiedEmail && !user.EmailVerified {
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(AuthorizeFailedTotal)
+		}
+		r.renderAuthorizeLogin(w, http.StatusForbidden, client, authRequest, "Email address not verified")
+		return "", false
+	}
+	if len(userservice.MFAMethods(user)) == 0 {
+		return username, true
+	}
+
+	mfaToken, err := auth.CreateMFAChallengeToken(username, []string{auth.AMRPassword}, r.MFAChallengeTTL, r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.authorizeRedirect(w, req, authRequest, url.Values{"error": {oauthservice.ErrorServerError}})
+		return "", false
+	}
+	r.renderAuthorizePage(w, http.StatusOK, client, authRequest, "", mfaToken)
+	return "", false
+}
+
+// isTOTPCode reports whether code has the shape of a TOTP code.
+func isTOTPCode(code string) bool {
+	if len(code) != auth.TOTPDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Token is the OAuth 2.0 token endpoint. Clients authenticate with HTTP Basic,
+// with client_id and client_secret form parameters or with a private_key_jwt
+// client assertion.
+func (r *Route) Token(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(TokenRequestsTotal)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		r.tokenError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "malformed request"))
+		return
+	}
+
+	client, err := r.authenticateClient(req)
+	if err != nil {
+		r.tokenError(w, req, err)
+		return
+	}
+
+	switch grantType := req.PostForm.Get("grant_type"); grantType {
+	case oauthservice.GrantTypeAuthorizationCode:
+		r.exchangeAuthorizationCode(w, req, client)
+	case oauthservice.GrantTypeClientCredentials:
+		r.grantClientCredentials(w, req, client)
+	case "":
+		r.tokenError(w, req, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "grant_type is required"))
+	default:
+		r.tokenError(w, req, oauthservice.NewError(oauthservice.ErrorUnsupportedGrantType, fmt.Sprintf("grant type %q is not supported", grantType)))
+	}
+}
+
+// authenticateClient authenticates the client of a token request.
+func (r *Route) authenticateClient(req *http.Request) (*models.OAuthClient, error) {
+	if assertionType := req.PostForm.Get("client_assertion_type"); assertionType != "" {
+		client, err := r.OAuthService.AuthenticateClientAssertion(req.Context(), assertionType,
+			req.PostForm.Get("client_assertion"), r.tokenEndpointAudiences(req))
+		if err != nil {
+			return nil, err
+		}
+		// client_id is optional with an assertion but must match if sent
+		if clientID := req.PostForm.Get("client_id"); clientID != "" && clientID != client.ClientID {
+			return nil, oauthservice.NewError(oauthservice.ErrorInvalidClient, "client_id does not match the client assertion")
+		}
+		return client, nil
+	}
+
+	clientID, clientSecret, basicAuth := req.BasicAuth()
+	if basicAuth {
+		// RFC 6749 section 2.3.1 form-encodes the credentials before Basic encoding
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = req.PostForm.Get("client_id")
+		clientSecret = req.PostForm.Get("client_secret")
+	}
+	return r.OAuthService.AuthenticateClient(req.Context(), clientID, clientSecret)
+}
+
+// tokenEndpointAudiences returns the audiences accepted in client assertions:
+// the token endpoint URL and the issuer.
+func (r *Route) tokenEndpointAudiences(req *http.Request) []string {
+	issuer := r.issuer()
+	return []string{endpointBaseURL(issuer, req) + TokenRouteAPI, issuer}
+}
+
+// grantClientCredentials handles the client_credentials grant. The access token
+// is issued to the client itself and no refresh token is returned.
+func (r *Route) grantClientCredentials(w http.ResponseWriter, req *http.Request, client *models.OAuthClient) {
+	scope, err := r.OAuthService.GrantClientCredentials(client, req.PostForm.Get("scope"))
+	if err != nil {
+		r.tokenError(w, req, err)
+		return
+	}
+
+	accessToken, err := auth.CreateClientToken(client.ClientID, scope, r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.tokenError(w, req, err)
+		return
+	}
+
+	r.tokenResponse(w, &dto.TokenResponseDTO{
+		AccessToken: accessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(r.tokenLifetime().Seconds()),
+		Scope:       scope,
+	})
+}
+
+// exchangeAuthorizationCode handles the authorization_code grant. Codes issued
+// for the openid scope also get an ID token.
+func (r *Route) exchangeAuthorizationCode(w http.ResponseWriter, req *http.Request, client *models.OAuthClient) {
+	code, err := r.OAuthService.ExchangeAuthorizationCode(req.Context(), client,
+		req.PostForm.Get("code"), req.PostForm.Get("redirect_uri"), req.PostForm.Get("code_verifier"))
+	if err != nil {
+		r.tokenError(w, req, err)
+		return
+	}
+
+	accessToken, err := auth.CreateDelegatedToken(code.Username, client.ClientID, code.Scope, r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.tokenError(w, req, err)
+		return
+	}
+
+	response := &dto.TokenResponseDTO{
+		AccessToken: accessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(r.tokenLifetime().Seconds()),
+		Scope:       code.Scope,
+	}
+
+	if oauthservice.HasScope(code.Scope, oauthservice.ScopeOpenID) {
+		params := auth.IDTokenParams{
+			Username:    code.Username,
+			ClientID:    client.ClientID,
+			Nonce:       code.Nonce,
+			AccessToken: accessToken,
+		}
+		if code.AuthTime > 0 {
+			params.AuthTime = time.Unix(code.AuthTime, 0)
+		}
+		response.IDToken, err = auth.CreateIDToken(params, r.Keyring, r.TokenConfig)
+		if err != nil {
+			r.tokenError(w, req, err)
+			return
+		}
+	}
+
+	r.tokenResponse(w, response)
+}
+
+// tokenResponse writes a successful token response that must not be cached.
+func (r *Route) tokenResponse(w http.ResponseWriter, response *dto.TokenResponseDTO) {
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(TokenSuccessTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// tokenError writes an RFC 6749 section 5.2 error for the token endpoint.
+func (r *Route) tokenError(w http.ResponseWriter, req *http.Request, err error) {
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(TokenFailedTotal)
+	}
+	r.clientError(w, req, err)
+}
+
+// clientError writes an RFC 6749 section 5.2 error for endpoints called by
+// clients. Client authentication failures get 401, with a Basic challenge if
+// the client used Basic auth.
+func (r *Route) clientError(w http.ResponseWriter, req *http.Request, err error) {
+	status := http.StatusBadRequest
+	switch oauthErrorCode(err) {
+	case oauthservice.ErrorInvalidClient:
+		status = http.StatusUnauthorized
+		if _, _, basicAuth := req.BasicAuth(); basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="sasuke"`)
+		}
+	case oauthservice.ErrorServerError:
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set(CacheControl, NoStore)
+	w.Header().Set(Pragma, NoCache)
+	r.oauthError(w, status, err)
+}
+
+// oauthError writes err as an OAuth JSON error body.
+func (r *Route) oauthError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&dto.OAuthErrorDTO{
+		Error:            oauthErrorCode(err),
+		ErrorDescription: oauthErrorDescription(err),
+	})
+}
+
+// authorizeRedirect sends the authorization response to the client's redirect URI.
+func (r *Route) authorizeRedirect(w http.ResponseWriter, req *http.Request, authRequest oauthservice.AuthorizationRequest, params url.Values) {
+	redirectURL, err := url.Parse(authRequest.RedirectURI)
+	if err != nil {
+		r.oauthError(w, http.StatusBadRequest, oauthservice.NewError(oauthservice.ErrorInvalidRequest, "invalid redirect_uri"))
+		return
+	}
+
+	if authRequest.State != "" {
+		params.Set("state", authRequest.State)
+	}
+	query := redirectURL.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	redirectURL.RawQuery = query.Encode()
+
+	w.Header().Set(CacheControl, NoStore)
+	http.Redirect(w, req, redirectURL.String(), http.StatusFound)
+}
+
+// renderAuthorizeLogin shows the sign-in form for an authorization request.
+func (r *Route) renderAuthorizeLogin(w http.ResponseWriter, status int, client *models.OAuthClient, authRequest oauthservice.AuthorizationRequest, message string) {
+	r.renderAuthorizePage(w, status, client, authRequest, message, "")
+}
+
+// renderAuthorizePage renders the sign-in form, or its second factor step when
+// mfaToken is set.
+func (r *Route) renderAuthorizePage(w http.ResponseWriter, status int, client *models.OAuthClient, authRequest oauthservice.AuthorizationRequest, message, mfaToken string) {
+	page := authorizeLoginPage{
+		ClientName: client.Name,
+		Action:     AuthorizeRouteAPI,
+		Error:      message,
+		MFAToken:   mfaToken,
+		Params: map[string]string{
+			"response_type":         authRequest.ResponseType,
+			"client_id":             authRequest.ClientID,
+			"redirect_uri":          authRequest.RedirectURI,
+			"scope":                 authRequest.Scope,
+			"state":                 authRequest.State,
+			"code_challenge":        authRequest.CodeChallenge,
+			"code_challenge_method": authRequest.CodeChallengeMethod,
+			"nonce":                 authRequest.Nonce,
+		},
+	}
+
+	w.Header().Set(ContentType, ContentTypeHTML)
+	w.Header().Set(CacheControl, NoStore)
+	// the sign-in form must not be framed by other sites
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	_ = authorizeLoginTemplate.Execute(w, page)
+}
+
+// tokenLifetime returns the lifetime of access tokens issued by CreateToken.
+func (r *Route) tokenLifetime() time.Duration {
+	if r.TokenConfig.Lifetime > 0 {
+		return r.TokenConfig.Lifetime
+	}
+	return auth.DefaultTokenLifetime
+}
+
+// oauthErrorCode returns the OAuth error code of err; unexpected errors are server errors.
+func oauthErrorCode(err error) string {
+	var oauthErr *oauthservice.Error
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	return oauthservice.ErrorServerError
+}
+
+// oauthErrorDescription returns the description of an OAuth error. Internal
+// error details are not disclosed.
+func oauthErrorDescription(err error) string {
+	var oauthErr *oauthservice.Error
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Description
+	}
+	return ""
+}
@@ -0,0 +1,520 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	// code verifier and challenge from RFC 7636 appendix B
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	testRedirectURI   = "https://app.example.com/callback"
+)
+
+func testOAuthClient() *models.OAuthClient {
+	return &models.OAuthClient{
+		ClientID:     "client-1",
+		Name:         "Example App",
+		RedirectURIs: testRedirectURI,
+		Scopes:       "openid profile",
+	}
+}
+
+func testKeyring(t *testing.T) *auth.Keyring {
+	t.Helper()
+	privateKey, err := auth.LoadECDSAPrivateKey("validKey.pem")
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	return keyring
+}
+
+func TestRoute_Authorize(t *testing.T) {
+	validParams := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"client-1"},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {"openid"},
+		"state":                 {"af0ifjsldkj"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		modify         func(params url.Values)
+		requireVerify  bool
+		session        func(keyring *auth.Keyring) (string, error)
+		bearer         bool
+		wantStatusCode int
+		wantRedirect   string
+		wantScope      string
+	}{
+		{
+			name:           "GET without session shows sign-in form",
+			method:         http.MethodGet,
+			modify:         func(params url.Values) {},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:   "POST with valid credentials issues a code",
+			method: http.MethodPost,
+			modify: func(params url.Values) {
+				params.Set("username", "testuser")
+				params.Set("password", "TestPassword123")
+			},
+			wantStatusCode: http.StatusFound,
+			wantRedirect:   "code",
+		},
+		{
+			name:   "Scopes the user does not hold are dropped",
+			method: http.MethodPost,
+			modify: func(params url.Values) {
+				params.Set("scope", "openid profile")
+				params.Set("username", "testuser")
+				params.Set("password", "TestPassword123")
+			},
+			wantStatusCode: http.StatusFound,
+			wantRedirect:   "code",
+			wantScope:      "openid",
+		},
+		{
+			name:   "POST with invalid credentials shows sign-in form",
+			method: http.MethodPost,
+			modify: func(params url.Values) {
+				params.Set("username", "testuser")
+				params.Set("password", "WrongPassword123")
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "POST from an unverified email when verification is required shows sign-in form",
+			method: http.MethodPost,
+			modify: func(params url.Values) {
+				params.Set("username", "testuser")
+				params.Set("password", "TestPassword123")
+			},
+			requireVerify:  true,
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:   "GET with a session cookie issues a code",
+			method: http.MethodGet,
+			modify: func(params url.Values) {},
+			session: func(keyring *auth.Keyring) (string, error) {
+				return auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+			},
+			wantStatusCode: http.StatusFound,
+			wantRedirect:   "code",
+		},
+		{
+			name:   "GET with a token delegated to another client shows sign-in form",
+			method: http.MethodGet,
+			modify: func(params url.Values) {},
+			session: func(keyring *auth.Keyring) (string, error) {
+				return auth.CreateDelegatedToken("testuser", "client-2", "openid", keyring, auth.TokenConfig{})
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:   "GET with a bearer session token shows sign-in form",
+			method: http.MethodGet,
+			modify: func(params url.Values) {},
+			session: func(keyring *auth.Keyring) (string, error) {
+				return auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+			},
+			bearer:         true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Unregistered redirect URI is not followed",
+			method:         http.MethodGet,
+			modify:         func(params url.Values) { params.Set("redirect_uri", "https://evil.example.com/") },
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing code challenge is redirected with an error",
+			method:         http.MethodGet,
+			modify:         func(params url.Values) { params.Del("code_challenge") },
+			wantStatusCode: http.StatusFound,
+			wantRedirect:   "error",
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPut,
+			modify:         func(params url.Values) {},
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		params := url.Values{}
+		for name, values := range validParams {
+			params[name] = append([]string(nil), values...)
+		}
+		tt.modify(params)
+
+		req := httptest.NewRequest(tt.method, AuthorizeRouteAPI+"?"+params.Encode(), nil)
+		if tt.method == http.MethodPost {
+			req = httptest.NewRequest(tt.method, AuthorizeRouteAPI, strings.NewReader(params.Encode()))
+			req.Header.Set("Content-Type", ContentTypeForm)
+		}
+		keyring := testKeyring(t)
+		if tt.session != nil {
+			token, err := tt.session(keyring)
+			if err != nil {
+				t.Fatalf("%s: failed to create token: %v", tt.name, err)
+			}
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer "+token)
+			} else {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+			}
+		}
+		rr := httptest.NewRecorder()
+
+		hashedPassword, err := HashString("TestPassword123")
+		if err != nil {
+			t.Fatalf("Failed to hash password: %v", err)
+		}
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&models.User{
+			Username:       "testuser",
+			HashedPassword: hashedPassword,
+		}, nil).Maybe()
+
+		clientRepo := mocks.NewMockClientRepository(t)
+		clientRepo.On("GetClient", mock.Anything, "client-1").Return(testOAuthClient(), nil).Maybe()
+		var storedScope string
+		clientRepo.On("AddAuthorizationCode", mock.Anything, mock.AnythingOfType("models.AuthorizationCode")).
+			Run(func(args mock.Arguments) {
+				storedScope = args.Get(1).(models.AuthorizationCode).Scope
+			}).
+			Return(nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:              mockedMetrics,
+			UserService:          &userservice.UserService{UserRepo: userRepo},
+			Keyring:              keyring,
+			OAuthService:         oauthservice.NewOAuthService(clientRepo),
+			RequireVerifiedEmail: tt.requireVerify,
+			validator:            structValidator.New(),
+		}
+		r.Authorize(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+
+		if tt.wantRedirect == "" {
+			continue
+		}
+		location, err := url.Parse(rr.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("%s: invalid Location header: %v", tt.name, err)
+		}
+		if !strings.HasPrefix(location.String(), testRedirectURI) {
+			t.Errorf("%s: redirected to %s, want %s", tt.name, location, testRedirectURI)
+		}
+		if location.Query().Get(tt.wantRedirect) == "" {
+			t.Errorf("%s: expected %s in redirect, got %s", tt.name, tt.wantRedirect, location.RawQuery)
+		}
+		if location.Query().Get("state") != "af0ifjsldkj" {
+			t.Errorf("%s: expected state to be returned, got %s", tt.name, location.RawQuery)
+		}
+		if tt.wantScope != "" && storedScope != tt.wantScope {
+			t.Errorf("%s: got scope %q, want %q", tt.name, storedScope, tt.wantScope)
+		}
+	}
+}
+
+func TestRoute_Token(t *testing.T) {
+	code := "authorization-code"
+	storedCode := &models.AuthorizationCode{
+		CodeHash:            auth.HashOpaqueToken(code),
+		ClientID:            "client-1",
+		Username:            "testuser",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: oauthservice.CodeChallengeMethodS256,
+		Nonce:               "n-0S6_WzA2Mj",
+		AuthTime:            time.Now().Add(-time.Minute).Unix(),
+		ExpiresAt:           time.Now().Add(time.Minute).Unix(),
+	}
+
+	validParams := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"client-1"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testCodeVerifier},
+	}
+
+	tests := []struct {
+		name           string
+		modify         func(params url.Values)
+		wantStatusCode int
+		wantError      string
+	}{
+		{
+			name:           "Valid code exchange",
+			modify:         func(params url.Values) {},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Wrong code verifier",
+			modify:         func(params url.Values) { params.Set("code_verifier", strings.Repeat("a", 43)) },
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      oauthservice.ErrorInvalidGrant,
+		},
+		{
+			name:           "Unknown client",
+			modify:         func(params url.Values) { params.Set("client_id", "unknown") },
+			wantStatusCode: http.StatusUnauthorized,
+			wantError:      oauthservice.ErrorInvalidClient,
+		},
+		{
+			name:           "Unsupported grant type",
+			modify:         func(params url.Values) { params.Set("grant_type", "password") },
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      oauthservice.ErrorUnsupportedGrantType,
+		},
+	}
+
+	for _, tt := range tests {
+		params := url.Values{}
+		for name, values := range validParams {
+			params[name] = append([]string(nil), values...)
+		}
+		tt.modify(params)
+
+		req := httptest.NewRequest(http.MethodPost, TokenRouteAPI, strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", ContentTypeForm)
+		rr := httptest.NewRecorder()
+
+		clientRepo := mocks.NewMockClientRepository(t)
+		clientRepo.On("GetClient", mock.Anything, "client-1").Return(testOAuthClient(), nil).Maybe()
+		clientRepo.On("GetClient", mock.Anything, "unknown").Return(nil, nil).Maybe()
+		clientRepo.On("GetAuthorizationCode", mock.Anything, storedCode.CodeHash).Return(storedCode, nil).Maybe()
+		clientRepo.On("MarkAuthorizationCodeUsed", mock.Anything, storedCode.CodeHash).Return(true, nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		keyring := testKeyring(t)
+		r := &Route{
+			Metrics:      mockedMetrics,
+			Keyring:      keyring,
+			OAuthService: oauthservice.NewOAuthService(clientRepo),
+			validator:    structValidator.New(),
+		}
+		r.Token(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+		if rr.Header().Get(CacheControl) != NoStore {
+			t.Errorf("%s: expected Cache-Control %s, got %s", tt.name, NoStore, rr.Header().Get(CacheControl))
+		}
+
+		if tt.wantError != "" {
+			var errorResponse dto.OAuthErrorDTO
+			if err := json.NewDecoder(rr.Body).Decode(&errorResponse); err != nil {
+				t.Fatalf("%s: failed to decode error response: %v", tt.name, err)
+			}
+			if errorResponse.Error != tt.wantError {
+				t.Errorf("%s: got error %s, want %s", tt.name, errorResponse.Error, tt.wantError)
+			}
+			continue
+		}
+
+		var tokenResponse dto.TokenResponseDTO
+		if err := json.NewDecoder(rr.Body).Decode(&tokenResponse); err != nil {
+			t.Fatalf("%s: failed to decode token response: %v", tt.name, err)
+		}
+		claims, err := auth.VerifyToken(req.Context(), tokenResponse.AccessToken, keyring, auth.TokenConfig{}, nil)
+		if err != nil {
+			t.Fatalf("%s: issued access token does not verify: %v", tt.name, err)
+		}
+		if claims.UserID != "testuser" || tokenResponse.TokenType != TokenTypeBearer {
+			t.Errorf("%s: unexpected token response %+v", tt.name, tokenResponse)
+		}
+
+		// the openid scope adds an ID token for the client
+		idClaims, err := auth.VerifyIDToken(tokenResponse.IDToken, "client-1", keyring, auth.TokenConfig{})
+		if err != nil {
+			t.Fatalf("%s: issued ID token does not verify: %v", tt.name, err)
+		}
+		if idClaims.Subject != "testuser" || idClaims.Nonce != storedCode.Nonce || idClaims.AuthTime.Unix() != storedCode.AuthTime {
+			t.Errorf("%s: unexpected ID token claims %+v", tt.name, idClaims)
+		}
+		if idClaims.AccessTokenHash == "" {
+			t.Errorf("%s: expected at_hash in ID token", tt.name)
+		}
+	}
+}
+
+func TestRoute_Token_ClientCredentials(t *testing.T) {
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	publicKey, err := auth.EncodePublicKey(&clientKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncodePublicKey() error = %v", err)
+	}
+
+	secretClient := &models.OAuthClient{
+		ClientID:         "machine",
+		ClientSecretHash: auth.HashOpaqueToken("s3cret"),
+		Scopes:           "reports:read",
+		GrantTypes:       oauthservice.GrantTypeClientCredentials,
+	}
+	keyClient := &models.OAuthClient{
+		ClientID:   "keyed-machine",
+		Scopes:     "reports:read",
+		GrantTypes: oauthservice.GrantTypeClientCredentials,
+		PublicKey:  string(publicKey),
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    "keyed-machine",
+		Subject:   "keyed-machine",
+		Audience:  jwt.ClaimStrings{"http://example.com" + TokenRouteAPI},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		ID:        "assertion-1",
+	}).SignedString(clientKey)
+	if err != nil {
+		t.Fatalf("Failed to sign assertion: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		params         url.Values
+		basicUser      string
+		basicPassword  string
+		wantStatusCode int
+		wantClientID   string
+		wantError      string
+	}{
+		{
+			name:           "Client secret with Basic auth",
+			params:         url.Values{"grant_type": {"client_credentials"}},
+			basicUser:      "machine",
+			basicPassword:  "s3cret",
+			wantStatusCode: http.StatusOK,
+			wantClientID:   "machine",
+		},
+		{
+			name:           "Wrong client secret",
+			params:         url.Values{"grant_type": {"client_credentials"}},
+			basicUser:      "machine",
+			basicPassword:  "wrong",
+			wantStatusCode: http.StatusUnauthorized,
+			wantError:      oauthservice.ErrorInvalidClient,
+		},
+		{
+			name: "Private key JWT assertion",
+			params: url.Values{
+				"grant_type":            {"client_credentials"},
+				"client_assertion_type": {auth.ClientAssertionType},
+				"client_assertion":      {assertion},
+			},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   "keyed-machine",
+		},
+		{
+			name:           "Public client",
+			params:         url.Values{"grant_type": {"client_credentials"}, "client_id": {"client-1"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      oauthservice.ErrorUnauthorizedClient,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, TokenRouteAPI, strings.NewReader(tt.params.Encode()))
+		req.Header.Set("Content-Type", ContentTypeForm)
+		if tt.basicUser != "" {
+			req.SetBasicAuth(tt.basicUser, tt.basicPassword)
+		}
+		rr := httptest.NewRecorder()
+
+		clientRepo := mocks.NewMockClientRepository(t)
+		clientRepo.On("GetClient", mock.Anything, "client-1").Return(testOAuthClient(), nil).Maybe()
+		clientRepo.On("GetClient", mock.Anything, "machine").Return(secretClient, nil).Maybe()
+		clientRepo.On("GetClient", mock.Anything, "keyed-machine").Return(keyClient, nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		keyring := testKeyring(t)
+		r := &Route{
+			Metrics:      mockedMetrics,
+			Keyring:      keyring,
+			OAuthService: oauthservice.NewOAuthService(clientRepo),
+			validator:    structValidator.New(),
+		}
+		r.Token(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, rr.Code, tt.wantStatusCode, rr.Body.String())
+			continue
+		}
+
+		if tt.wantError != "" {
+			var errorResponse dto.OAuthErrorDTO
+			if err := json.NewDecoder(rr.Body).Decode(&errorResponse); err != nil {
+				t.Fatalf("%s: failed to decode error response: %v", tt.name, err)
+			}
+			if errorResponse.Error != tt.wantError {
+				t.Errorf("%s: got error %s, want %s", tt.name, errorResponse.Error, tt.wantError)
+			}
+			if tt.basicUser != "" && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("%s: expected a Basic challenge", tt.name)
+			}
+			continue
+		}
+
+		var tokenResponse dto.TokenResponseDTO
+		if err := json.NewDecoder(rr.Body).Decode(&tokenResponse); err != nil {
+			t.Fatalf("%s: failed to decode token response: %v", tt.name, err)
+		}
+		claims, err := auth.VerifyToken(req.Context(), tokenResponse.AccessToken, keyring, auth.TokenConfig{}, nil)
+		if err != nil {
+			t.Fatalf("%s: issued access token does not verify: %v", tt.name, err)
+		}
+		if !claims.IsClient() || claims.ClientID != tt.wantClientID || claims.UserID != "" {
+			t.Errorf("%s: expected a token for client %s, got %+v", tt.name, tt.wantClientID, claims)
+		}
+		if tokenResponse.RefreshToken != "" || tokenResponse.Scope != "reports:read" {
+			t.Errorf("%s: unexpected token response %+v", tt.name, tokenResponse)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+)
+
+// OpenIDConfiguration serves the OpenID Provider metadata so relying parties
+// can discover the endpoints and signing algorithm of sasuke.
+func (r *Route) OpenIDConfiguration(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	alg, err := auth.SigningAlgorithm(r.Keyring, r.TokenConfig)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to determine signing algorithm")
+		return
+	}
+
+	issuer := r.issuer()
+	baseURL := endpointBaseURL(issuer, req)
+	configuration := &dto.OpenIDConfigurationDTO{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             baseURL + AuthorizeRouteAPI,
+		TokenEndpoint:                     baseURL + TokenRouteAPI,
+		UserInfoEndpoint:                  baseURL + UserInfoRouteAPI,
+		JWKSURI:                           baseURL + JWKSRouteAPI,
+		IntrospectionEndpoint:             baseURL + IntrospectRouteAPI,
+		RevocationEndpoint:                baseURL + RevokeRouteAPI,
+		ScopesSupported:                   []string{oauthservice.ScopeOpenID},
+		ResponseTypesSupported:            []string{oauthservice.ResponseTypeCode},
+		GrantTypesSupported:               oauthservice.SupportedGrantTypes,
+		SubjectTypesSupported:             []string{SubjectTypePublic},
+		IDTokenSigningAlgValuesSupported:  []string{alg},
+		TokenEndpointAuthMethodsSupported: []string{AuthMethodClientSecretBasic, AuthMethodClientSecretPost, AuthMethodPrivateKeyJWT, AuthMethodNone},
+		TokenEndpointAuthSigningAlgValues: auth.SupportedAlgorithms,
+		CodeChallengeMethodsSupported:     []string{oauthservice.CodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "at_hash", "amr", "preferred_username"},
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, JWKSCacheControl)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(configuration)
+}
+
+// UserInfo returns the claims of the user an access token was issued to.
+// Tokens of machine clients are rejected. It must be wrapped by middleware.AuthMiddleware, which provides the caller's claims.
+func (r *Route) UserInfo(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(UserInfoRequestsTotal)
+	}
+
+	claims, ok := auth.ClaimsFromContext(req.Context())
+	if !ok || claims.IsClient() {
+		r.userInfoError(w, http.StatusUnauthorized, fmt.Errorf("request is not authenticated"), "Authentication required")
+		return
+	}
+
+	user, err := r.UserService.GetUser(req.Context(), claims.UserID)
+	if err != nil {
+		r.userInfoError(w, http.StatusInternalServerError, err, "Failed to retrieve user")
+		return
+	}
+	if user == nil {
+		// the token outlived its user
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		r.userInfoError(w, http.StatusUnauthorized, fmt.Errorf("user %s not found", claims.UserID), "Invalid access token")
+		return
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.UserInfoDTO{
+		Subject:           user.Username,
+		PreferredUsername: user.Username,
+	})
+}
+
+func (r *Route) userInfoError(w http.ResponseWriter, status int, err error, message string) {
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(UserInfoFailedTotal)
+	}
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(status)
+	r.errorResponse(w, err, message)
+}
+
+// issuer returns the iss claim of issued tokens.
+func (r *Route) issuer() string {
+	if r.TokenConfig.Issuer != "" {
+		return r.TokenConfig.Issuer
+	}
+	return auth.ISSUER
+}
+
+// endpointBaseURL returns the URL the endpoints in the discovery document are
+// relative to. An issuer that is an http(s) URL is used as is, as OpenID
+// Connect requires; otherwise the URL the request was made to is used.
+func endpointBaseURL(issuer string, req *http.Request) string {
+	if parsed, err := url.Parse(issuer); err == nil && parsed.Host != "" &&
+		(parsed.Scheme == "https" || parsed.Scheme == "http") {
+		return strings.TrimSuffix(issuer, "/")
+	}
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host
+}
@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoute_OpenIDConfiguration(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		issuer         string
+		wantStatusCode int
+		wantIssuer     string
+		wantTokenURL   string
+	}{
+		{
+			name:           "Issuer URL is the endpoint base",
+			method:         http.MethodGet,
+			issuer:         "https://auth.example.com/",
+			wantStatusCode: http.StatusOK,
+			wantIssuer:     "https://auth.example.com/",
+			wantTokenURL:   "https://auth.example.com/token",
+		},
+		{
+			name:           "Non-URL issuer falls back to request host",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusOK,
+			wantIssuer:     auth.ISSUER,
+			wantTokenURL:   "http://example.com/token",
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPost,
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, OpenIDConfigurationRouteAPI, nil)
+		rr := httptest.NewRecorder()
+
+		r := &Route{
+			Keyring:     testKeyring(t),
+			TokenConfig: auth.TokenConfig{Issuer: tt.issuer},
+			validator:   structValidator.New(),
+		}
+		r.OpenIDConfiguration(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+
+		var configuration dto.OpenIDConfigurationDTO
+		if err := json.NewDecoder(rr.Body).Decode(&configuration); err != nil {
+			t.Fatalf("%s: failed to decode discovery document: %v", tt.name, err)
+		}
+		if configuration.Issuer != tt.wantIssuer {
+			t.Errorf("%s: got issuer %s, want %s", tt.name, configuration.Issuer, tt.wantIssuer)
+		}
+		if configuration.TokenEndpoint != tt.wantTokenURL {
+			t.Errorf("%s: got token endpoint %s, want %s", tt.name, configuration.TokenEndpoint, tt.wantTokenURL)
+		}
+		if len(configuration.IDTokenSigningAlgValuesSupported) != 1 || configuration.IDTokenSigningAlgValuesSupported[0] != auth.AlgorithmES256 {
+			t.Errorf("%s: unexpected signing algorithms %v", tt.name, configuration.IDTokenSigningAlgValuesSupported)
+		}
+	}
+}
+
+func TestRoute_UserInfo(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		user           *models.User
+		userrepoError  error
+		wantStatusCode int
+	}{
+		{
+			name:           "Known user",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			user:           &models.User{Username: "testuser"},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Deleted user",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Repository error",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			userrepoError:  errors.New("database unavailable"),
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:           "Machine client",
+			claims:         &auth.CustomClaims{PrincipalType: auth.PrincipalTypeClient, ClientID: "machine"},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unauthenticated caller",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, UserInfoRouteAPI, nil)
+		if tt.claims != nil {
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+		}
+		rr := httptest.NewRecorder()
+
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(tt.user, tt.userrepoError).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:     mockedMetrics,
+			UserService: &userservice.UserService{UserRepo: userRepo},
+			validator:   structValidator.New(),
+		}
+		r.UserInfo(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+
+		var userInfo dto.UserInfoDTO
+		if err := json.NewDecoder(rr.Body).Decode(&userInfo); err != nil {
+			t.Fatalf("%s: failed to decode userinfo: %v", tt.name, err)
+		}
+		if userInfo.Subject != "testuser" {
+			t.Errorf("%s: got sub %s, want testuser", tt.name, userInfo.Subject)
+		}
+	}
+}
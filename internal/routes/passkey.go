@@ -0,0 +1,332 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/haguru/sasuke/internal/webauthn"
+)
+
+const (
+	// PublicKeyCredentialType is the only WebAuthn credential type.
+	PublicKeyCredentialType = "public-key"
+	// DefaultPasskeyAttestation is the attestation conveyance preference when none is configured.
+	DefaultPasskeyAttestation = "none"
+
+	// userVerification and residentKey requirement values
+	requirementRequired  = "required"
+	requirementPreferred = "preferred"
+)
+
+// BeginPasskeyRegistration starts registering a passkey for the caller and
+// returns the options for navigator.credentials.create(). It must be wrapped
+// by middleware.AuthMiddleware.
+func (r *Route) BeginPasskeyRegistration(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyRegisterRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, PasskeyRegisterFailedTotal)
+	if !ok {
+		return
+	}
+
+	registration, err := r.UserService.BeginPasskeyRegistration(req.Context(), username)
+	if err != nil {
+		r.jsonError(w, passkeyErrorStatus(err), err, "Failed to start passkey registration", PasskeyRegisterFailedTotal)
+		return
+	}
+	ceremonyToken, err := auth.CreateWebAuthnCeremonyToken(auth.WebAuthnRegistrationAudience, username, registration.Challenge, r.WebAuthnCeremonyTTL, r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.jsonError(w, http.StatusInternalServerError, err, "Failed to start passkey registration", PasskeyRegisterFailedTotal)
+		return
+	}
+
+	rp := r.UserService.RelyingParty
+	options := &dto.PublicKeyCredentialCreationOptionsDTO{
+		Challenge: base64.RawURLEncoding.EncodeToString(registration.Challenge),
+		RP:        dto.RelyingPartyDTO{ID: rp.ID, Name: rp.Name},
+		User: dto.PasskeyUserDTO{
+			ID:          base64.RawURLEncoding.EncodeToString(registration.UserHandle),
+			Name:        username,
+			DisplayName: username,
+		},
+		Timeout:            r.passkeyTimeout(),
+		ExcludeCredentials: passkeyDescriptors(registration.ExcludeCredentials),
+		AuthenticatorSelection: dto.AuthenticatorSelectionDTO{
+			ResidentKey:        requirementRequired,
+			RequireResidentKey: true,
+			UserVerification:   userVerification(rp),
+		},
+		Attestation: r.WebAuthnAttestation,
+	}
+	if options.Attestation == "" {
+		options.Attestation = DefaultPasskeyAttestation
+	}
+	for _, alg := range webauthn.SupportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, dto.PubKeyCredParamDTO{Type: PublicKeyCredentialType, Alg: alg})
+	}
+
+	r.passkeyCeremonyResponse(w, ceremonyToken, options)
+}
+
+// FinishPasskeyRegistration verifies the credential created by the
+// authenticator and stores it for the caller. It must be wrapped by
+// middleware.AuthMiddleware.
+func (r *Route) FinishPasskeyRegistration(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyRegisterRequestsTotal)
+	}
+
+	username, ok := r.sessionUser(w, req, PasskeyRegisterFailedTotal)
+	if !ok {
+		return
+	}
+
+	registerRequest := &dto.PasskeyRegisterRequestDTO{}
+	if !r.decodeJSONRequest(w, req, registerRequest, PasskeyRegisterFailedTotal) {
+		return
+	}
+
+	ceremony, err := auth.VerifyWebAuthnCeremonyToken(req.Context(), registerRequest.CeremonyToken, auth.WebAuthnRegistrationAudience, r.Keyring, r.TokenConfig, r.Revocations)
+	if err == nil && ceremony.UserID != username {
+		err = fmt.Errorf("ceremony was started by another user")
+	}
+	if err != nil {
+		r.jsonError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidPasskeyCeremony, err), "Invalid or expired passkey ceremony", PasskeyRegisterFailedTotal)
+		return
+	}
+	challenge, err := ceremony.ChallengeBytes()
+	if err != nil {
+		r.jsonError(w, http.StatusBadRequest, err, "Invalid or expired passkey ceremony", PasskeyRegisterFailedTotal)
+		return
+	}
+
+	response := registerRequest.Credential.Response
+	clientDataJSON, err1 := base64.RawURLEncoding.DecodeString(response.ClientDataJSON)
+	attestationObject, err2 := base64.RawURLEncoding.DecodeString(response.AttestationObject)
+	if err := errors.Join(err1, err2); err != nil {
+		r.jsonError(w, http.StatusBadRequest, err, "Credential fields must be base64url encoded", PasskeyRegisterFailedTotal)
+		return
+	}
+
+	if err := r.consumePasskeyCeremony(req, ceremony); err != nil {
+		r.jsonError(w, http.StatusInternalServerError, err, "Failed to register passkey", PasskeyRegisterFailedTotal)
+		return
+	}
+	credential, err := r.UserService.FinishPasskeyRegistration(req.Context(), username, registerRequest.Name, challenge, clientDataJSON, attestationObject, response.Transports)
+	if err != nil {
+		r.jsonError(w, passkeyErrorStatus(err), err, "Failed to register passkey", PasskeyRegisterFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyRegisterSuccessTotal)
+	}
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(&dto.PasskeyResponseDTO{
+		Message:      "Passkey registered",
+		CredentialID: credential.CredentialID,
+		Name:         credential.Name,
+	})
+}
+
+// BeginPasskeyLogin starts a passkey login and returns the options for
+// navigator.credentials.get(). No username is needed: the authenticator
+// offers its discoverable credentials for the relying party.
+func (r *Route) BeginPasskeyLogin(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyLoginRequestsTotal)
+	}
+
+	challenge, err := r.UserService.BeginPasskeyLogin()
+	if err != nil {
+		r.jsonError(w, passkeyErrorStatus(err), err, "Failed to start passkey login", PasskeyLoginFailedTotal)
+		return
+	}
+	ceremonyToken, err := auth.CreateWebAuthnCeremonyToken(auth.WebAuthnLoginAudience, "", challenge, r.WebAuthnCeremonyTTL, r.Keyring, r.TokenConfig)
+	if err != nil {
+		r.jsonError(w, http.StatusInternalServerError, err, "Failed to start passkey login", PasskeyLoginFailedTotal)
+		return
+	}
+
+	rp := r.UserService.RelyingParty
+	r.passkeyCeremonyResponse(w, ceremonyToken, &dto.PublicKeyCredentialRequestOptionsDTO{
+		Challenge:        base64.RawURLEncoding.EncodeToString(challenge),
+		RPID:             rp.ID,
+		Timeout:          r.passkeyTimeout(),
+		AllowCredentials: []dto.PasskeyDescriptorDTO{},
+		UserVerification: userVerification(rp),
+	})
+}
+
+// FinishPasskeyLogin verifies the assertion of a passkey and issues the same
+// session and refresh tokens as Login. A passkey that verified the user is
+// multi-factor on its own, so no second factor is asked for; otherwise users
+// with a second factor get an MFA challenge like after a password login.
+func (r *Route) FinishPasskeyLogin(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyLoginRequestsTotal)
+	}
+
+	loginRequest := &dto.PasskeyLoginRequestDTO{}
+	if !r.decodeJSONRequest(w, req, loginRequest, PasskeyLoginFailedTotal) {
+		return
+	}
+
+	ceremony, err := auth.VerifyWebAuthnCeremonyToken(req.Context(), loginRequest.CeremonyToken, auth.WebAuthnLoginAudience, r.Keyring, r.TokenConfig, r.Revocations)
+	if err != nil {
+		r.jsonError(w, http.StatusUnauthorized, fmt.Errorf("%w: %v", errInvalidPasskeyCeremony, err), "Invalid or expired passkey ceremony", PasskeyLoginFailedTotal)
+		return
+	}
+	challenge, err := ceremony.ChallengeBytes()
+	if err != nil {
+		r.jsonError(w, http.StatusUnauthorized, err, "Invalid or expired passkey ceremony", PasskeyLoginFailedTotal)
+		return
+	}
+
+	response := loginRequest.Credential.Response
+	assertion := userservice.PasskeyAssertion{CredentialID: loginRequest.Credential.ID}
+	var errs [4]error
+	assertion.ClientDataJSON, errs[0] = base64.RawURLEncoding.DecodeString(response.ClientDataJSON)
+	assertion.AuthenticatorData, errs[1] = base64.RawURLEncoding.DecodeString(response.AuthenticatorData)
+	assertion.Signature, errs[2] = base64.RawURLEncoding.DecodeString(response.Signature)
+	if response.UserHandle != "" {
+		assertion.UserHandle, errs[3] = base64.RawURLEncoding.DecodeString(response.UserHandle)
+	}
+	if err := errors.Join(errs[:]...); err != nil {
+		r.jsonError(w, http.StatusBadRequest, err, "Credential fields must be base64url encoded", PasskeyLoginFailedTotal)
+		return
+	}
+
+	// a ceremony is consumed even when it fails so its challenge cannot be retried
+	if err := r.consumePasskeyCeremony(req, ceremony); err != nil {
+		r.jsonError(w, http.StatusInternalServerError, err, "Failed to verify passkey", PasskeyLoginFailedTotal)
+		return
+	}
+	username, userVerified, err := r.UserService.FinishPasskeyLogin(req.Context(), challenge, assertion)
+	if err != nil {
+		status := passkeyErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusUnauthorized
+		}
+		r.jsonError(w, status, err, "Invalid passkey", PasskeyLoginFailedTotal)
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	amr := []string{auth.AMRHardwareKey}
+	if !userVerified {
+		user, err := r.UserService.GetUser(req.Context(), username)
+		if err != nil {
+			r.jsonError(w, http.StatusInternalServerError, err, "Failed to load user", PasskeyLoginFailedTotal)
+			return
+		}
+		// a passkey without user verification is only possession
+		if methods := userservice.MFAMethods(user); len(methods) > 0 {
+			r.mfaChallenge(w, username, amr, methods)
+			return
+		}
+	} else {
+		amr = append(amr, auth.AMRMFA)
+	}
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasskeyLoginSuccessTotal)
+	}
+	r.completeLogin(w, req, username, amr)
+}
+
+// consumePasskeyCeremony revokes a ceremony token so it is single use.
+func (r *Route) consumePasskeyCeremony(req *http.Request, ceremony *auth.WebAuthnCeremonyClaims) error {
+	if r.Revocations == nil || ceremony.ID == "" || ceremony.ExpiresAt == nil {
+		return nil
+	}
+	if err := r.Revocations.Revoke(req.Context(), ceremony.ID, ceremony.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("failed to consume passkey ceremony: %w", err)
+	}
+	return nil
+}
+
+func (r *Route) passkeyCeremonyResponse(w http.ResponseWriter, ceremonyToken string, options interface{}) {
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, NoStore)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.PasskeyCeremonyResponseDTO{
+		CeremonyToken: ceremonyToken,
+		PublicKey:     options,
+	})
+}
+
+// passkeyTimeout is the ceremony timeout in milliseconds.
+func (r *Route) passkeyTimeout() int64 {
+	ttl := r.WebAuthnCeremonyTTL
+	if ttl <= 0 {
+		ttl = auth.DefaultWebAuthnCeremonyTTL
+	}
+	return ttl.Milliseconds()
+}
+
+func passkeyDescriptors(credentialIDs []string) []dto.PasskeyDescriptorDTO {
+	descriptors := make([]dto.PasskeyDescriptorDTO, 0, len(credentialIDs))
+	for _, id := range credentialIDs {
+		descriptors = append(descriptors, dto.PasskeyDescriptorDTO{Type: PublicKeyCredentialType, ID: id})
+	}
+	return descriptors
+}
+
+func userVerification(rp *webauthn.RelyingParty) string {
+	if rp.RequireUserVerification {
+		return requirementRequired
+	}
+	return requirementPreferred
+}
+
+// errInvalidPasskeyCeremony wraps invalid, expired or already used ceremony tokens.
+var errInvalidPasskeyCeremony = errors.New("invalid passkey ceremony")
+
+// passkeyErrorStatus maps passkey service errors to HTTP status codes.
+func passkeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, userservice.ErrInvalidPasskey):
+		return http.StatusBadRequest
+	case errors.Is(err, userservice.ErrPasskeyAlreadyRegistered):
+		return http.StatusConflict
+	case errors.Is(err, userservice.ErrPasskeysNotConfigured):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
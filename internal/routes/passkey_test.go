@@ -0,0 +1,383 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/haguru/sasuke/internal/webauthn"
+	"github.com/haguru/sasuke/internal/webauthn/webauthntest"
+	"github.com/stretchr/testify/mock"
+)
+
+const testPasskeyOrigin = "https://example.com"
+
+func testRelyingParty() *webauthn.RelyingParty {
+	return &webauthn.RelyingParty{ID: "example.com", Name: "Example", Origins: []string{testPasskeyOrigin}}
+}
+
+// passkeyCeremony decodes the response of a begin route into its ceremony
+// token and the decoded challenge.
+func passkeyCeremony(t *testing.T, rr *httptest.ResponseRecorder) (string, []byte) {
+	t.Helper()
+	var response struct {
+		CeremonyToken string `json:"ceremony_token"`
+		PublicKey     struct {
+			Challenge string `json:"challenge"`
+		} `json:"public_key"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode ceremony: %v", err)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(response.PublicKey.Challenge)
+	if err != nil {
+		t.Fatalf("invalid challenge: %v", err)
+	}
+	return response.CeremonyToken, challenge
+}
+
+func TestRoute_PasskeyRegistration(t *testing.T) {
+	keyring := testKeyring(t)
+	session := &auth.CustomClaims{UserID: "testuser"}
+
+	userRepo := mocks.NewMockUserRepository(t)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&models.User{Username: "testuser"}, nil)
+
+	var stored models.WebAuthnCredential
+	credentials := mocks.NewMockCredentialRepository(t)
+	credentials.On("ListCredentials", mock.Anything, "testuser").Return([]models.WebAuthnCredential{{CredentialID: "existing"}}, nil)
+	credentials.On("GetCredential", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
+	credentials.On("AddCredential", mock.Anything, mock.AnythingOfType("models.WebAuthnCredential")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(models.WebAuthnCredential)
+	}).Return(nil).Once()
+
+	mockedMetrics := mocks.NewMockMetrics(t)
+	mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+	r := &Route{
+		Metrics:     mockedMetrics,
+		UserService: &userservice.UserService{UserRepo: userRepo, Credentials: credentials, RelyingParty: testRelyingParty()},
+		Keyring:     keyring,
+		Revocations: memory.NewMemoryRevocationStore(),
+		validator:   structValidator.New(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, PasskeyRegisterBeginRouteAPI, nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), session))
+	rr := httptest.NewRecorder()
+	r.BeginPasskeyRegistration(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("begin: got status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var options struct {
+		PublicKey dto.PublicKeyCredentialCreationOptionsDTO `json:"public_key"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &options); err != nil {
+		t.Fatalf("failed to decode creation options: %v", err)
+	}
+	if options.PublicKey.RP.ID != "example.com" || len(options.PublicKey.ExcludeCredentials) != 1 || len(options.PublicKey.PubKeyCredParams) == 0 {
+		t.Errorf("unexpected creation options %+v", options.PublicKey)
+	}
+	ceremonyToken, challenge := passkeyCeremony(t, rr)
+
+	authenticator, err := webauthntest.NewAuthenticator("example.com", testPasskeyOrigin)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	authenticator.Attestation = webauthntest.AttestationPacked
+	clientDataJSON, attestationObject, err := authenticator.Create(challenge, webauthn.UserHandle("testuser"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	body, _ := json.Marshal(dto.PasskeyRegisterRequestDTO{
+		CeremonyToken: ceremonyToken,
+		Name:          "laptop",
+		Credential: dto.PasskeyAttestationCredentialDTO{
+			ID:   base64.RawURLEncoding.EncodeToString(authenticator.CredentialID),
+			Type: PublicKeyCredentialType,
+			Response: dto.PasskeyAttestationResponseDTO{
+				ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientDataJSON),
+				AttestationObject: base64.RawURLEncoding.EncodeToString(attestationObject),
+				Transports:        []string{"internal"},
+			},
+		},
+	})
+
+	tests := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		wantStatusCode int
+	}{
+		{
+			name:           "Another user's ceremony",
+			claims:         &auth.CustomClaims{UserID: "otheruser"},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Token delegated to a client",
+			claims:         &auth.CustomClaims{UserID: "testuser", PrincipalType: auth.PrincipalTypeUser, ClientID: "client-1", Scope: "openid"},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "Valid registration",
+			claims:         session,
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "Replayed ceremony",
+			claims:         session,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthenticated caller",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, PasskeyRegisterFinishRouteAPI, strings.NewReader(string(body)))
+		req.Header.Set(ContentType, ContentTypeJson)
+		if tt.claims != nil {
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+		}
+		rr := httptest.NewRecorder()
+		r.FinishPasskeyRegistration(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, rr.Code, tt.wantStatusCode, rr.Body.String())
+		}
+	}
+
+	if stored.Username != "testuser" || stored.Name != "laptop" || stored.AttestationFormat != "packed" || stored.Transports != "internal" {
+		t.Errorf("unexpected stored credential %+v", stored)
+	}
+	if stored.CredentialID != base64.RawURLEncoding.EncodeToString(authenticator.CredentialID) {
+		t.Errorf("got credential ID %s", stored.CredentialID)
+	}
+}
+
+func TestRoute_PasskeyLogin(t *testing.T) {
+	keyring := testKeyring(t)
+	rp := testRelyingParty()
+
+	tests := []struct {
+		name            string
+		setup           func(a *webauthntest.Authenticator)
+		storedSignCount int64
+		userHandle      []byte
+		unknown         bool
+		notConfigured   bool
+		replay          bool
+		totpEnabled     bool
+		wantStatusCode  int
+		wantAMR         string
+		wantMFA         bool
+	}{
+		{
+			name:           "Valid passkey",
+			wantStatusCode: http.StatusOK,
+			wantAMR:        "hwk",
+		},
+		{
+			name: "User verified passkey",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = true
+				a.CountSignatures = true
+			},
+			wantStatusCode: http.StatusOK,
+			wantAMR:        "hwk mfa",
+		},
+		{
+			name:           "Passkey of a user with TOTP",
+			totpEnabled:    true,
+			wantStatusCode: http.StatusOK,
+			wantMFA:        true,
+		},
+		{
+			name: "User verified passkey of a user with TOTP",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = true
+			},
+			totpEnabled:    true,
+			wantStatusCode: http.StatusOK,
+			wantAMR:        "hwk mfa",
+		},
+		{
+			// a clone of the authenticator reports a count the original already used
+			name:            "Sign count did not increase",
+			setup:           func(a *webauthntest.Authenticator) { a.CountSignatures = true },
+			storedSignCount: 5,
+			wantStatusCode:  http.StatusUnauthorized,
+		},
+		{
+			name:           "Wrong origin",
+			setup:          func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" },
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "User handle of another user",
+			userHandle:     webauthn.UserHandle("otheruser"),
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unknown credential",
+			unknown:        true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Replayed ceremony",
+			replay:         true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Passkeys not configured",
+			notConfigured:  true,
+			wantStatusCode: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		authenticator, err := webauthntest.NewAuthenticator(rp.ID, testPasskeyOrigin)
+		if err != nil {
+			t.Fatalf("NewAuthenticator() error = %v", err)
+		}
+		challenge, _ := webauthn.NewChallenge()
+		clientDataJSON, attestationObject, err := authenticator.Create(challenge, webauthn.UserHandle("testuser"))
+		if err != nil {
+			t.Fatalf("%s: Create() error = %v", tt.name, err)
+		}
+		registration, err := rp.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+		if err != nil {
+			t.Fatalf("%s: VerifyRegistration() error = %v", tt.name, err)
+		}
+		credentialID := base64.RawURLEncoding.EncodeToString(registration.CredentialID)
+
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&models.User{Username: "testuser", TOTPEnabled: tt.totpEnabled}, nil).Maybe()
+		userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+
+		credentials := mocks.NewMockCredentialRepository(t)
+		var stored *models.WebAuthnCredential
+		if !tt.unknown {
+			stored = &models.WebAuthnCredential{
+				CredentialID: credentialID,
+				Username:     "testuser",
+				PublicKey:    base64.StdEncoding.EncodeToString(registration.PublicKey),
+				SignCount:    tt.storedSignCount,
+			}
+		}
+		credentials.On("GetCredential", mock.Anything, credentialID).Return(stored, nil).Maybe()
+		credentials.On("UpdateSignCount", mock.Anything, credentialID, tt.storedSignCount, mock.AnythingOfType("int64"), mock.AnythingOfType("int64")).Return(true, nil).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		userService := &userservice.UserService{UserRepo: userRepo, Credentials: credentials, RelyingParty: rp}
+		if tt.notConfigured {
+			userService.RelyingParty = nil
+		}
+		r := &Route{
+			Metrics:     mockedMetrics,
+			UserService: userService,
+			Keyring:     keyring,
+			Revocations: memory.NewMemoryRevocationStore(),
+			validator:   structValidator.New(),
+		}
+
+		var ceremonyToken string
+		if tt.notConfigured {
+			challenge, _ = webauthn.NewChallenge()
+			ceremonyToken, _ = auth.CreateWebAuthnCeremonyToken(auth.WebAuthnLoginAudience, "", challenge, 0, keyring, auth.TokenConfig{})
+		} else {
+			req := httptest.NewRequest(http.MethodPost, PasskeyLoginBeginRouteAPI, nil)
+			rr := httptest.NewRecorder()
+			r.BeginPasskeyLogin(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("%s: begin: got status %d: %s", tt.name, rr.Code, rr.Body.String())
+			}
+			ceremonyToken, challenge = passkeyCeremony(t, rr)
+		}
+
+		if tt.setup != nil {
+			tt.setup(authenticator)
+		}
+		clientDataJSON, authData, signature, err := authenticator.Get(challenge)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", tt.name, err)
+		}
+		userHandle := authenticator.UserHandle
+		if tt.userHandle != nil {
+			userHandle = tt.userHandle
+		}
+		body, _ := json.Marshal(dto.PasskeyLoginRequestDTO{
+			CeremonyToken: ceremonyToken,
+			Credential: dto.PasskeyAssertionCredentialDTO{
+				ID:   credentialID,
+				Type: PublicKeyCredentialType,
+				Response: dto.PasskeyAssertionResponseDTO{
+					ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientDataJSON),
+					AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+					Signature:         base64.RawURLEncoding.EncodeToString(signature),
+					UserHandle:        base64.RawURLEncoding.EncodeToString(userHandle),
+				},
+			},
+		})
+
+		finish := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, PasskeyLoginFinishRouteAPI, strings.NewReader(string(body)))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.FinishPasskeyLogin(rr, req)
+			return rr
+		}
+		if tt.replay {
+			if rr := finish(); rr.Code != http.StatusOK {
+				t.Fatalf("%s: first login: got status %d: %s", tt.name, rr.Code, rr.Body.String())
+			}
+		}
+
+		rr := finish()
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, rr.Code, tt.wantStatusCode, rr.Body.String())
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+		if tt.wantMFA {
+			// a passkey that did not verify the user is only the first factor
+			challenge := &dto.MFAChallengeResponseDTO{}
+			if err := json.Unmarshal(rr.Body.Bytes(), challenge); err != nil || !challenge.MFARequired || challenge.MFAToken == "" {
+				t.Errorf("%s: expected an MFA challenge, got %s", tt.name, rr.Body.String())
+			}
+			if len(rr.Result().Cookies()) != 0 {
+				t.Errorf("%s: expected no session before the second factor", tt.name)
+			}
+			continue
+		}
+
+		var sessionToken string
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == SessionCookieName {
+				sessionToken = cookie.Value
+			}
+		}
+		claims, err := auth.VerifyToken(t.Context(), sessionToken, keyring, auth.TokenConfig{}, nil)
+		if err != nil {
+			t.Fatalf("%s: expected a valid session token: %v", tt.name, err)
+		}
+		if claims.UserID != "testuser" || strings.Join(claims.AMR, " ") != tt.wantAMR {
+			t.Errorf("%s: got user %s and amr %v", tt.name, claims.UserID, claims.AMR)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/passwordhash"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRoute_Login_PasswordRehash(t *testing.T) {
+	argon2idHasher, err := passwordhash.NewArgon2idHasher(passwordhash.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %v", err)
+	}
+	outdatedArgon2idHasher, _ := passwordhash.NewArgon2idHasher(passwordhash.Argon2idParams{Memory: 32, Iterations: 1, Parallelism: 1})
+	bcryptHasher, _ := passwordhash.NewBcryptHasher(bcrypt.MinCost)
+
+	// pepper 1 is retired, new hashes use pepper 2
+	peppers, err := passwordhash.NewPeppers("2", map[string][]byte{
+		"1": bytes.Repeat([]byte{1}, passwordhash.MinPepperBytes),
+		"2": bytes.Repeat([]byte{2}, passwordhash.MinPepperBytes),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create peppers: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		storedBy       interfaces.PasswordHasher
+		hashPepper     string
+		storedPepper   string
+		password       string
+		expectedStatus int
+		expectRehash   bool
+	}{
+		{name: "bcrypt hash without pepper is upgraded", storedBy: bcryptHasher, password: "testpass", expectedStatus: http.StatusOK, expectRehash: true},
+		{name: "outdated parameters are upgraded", storedBy: outdatedArgon2idHasher, hashPepper: "2", storedPepper: "2", password: "testpass", expectedStatus: http.StatusOK, expectRehash: true},
+		{name: "retired pepper is rotated", storedBy: argon2idHasher, hashPepper: "1", storedPepper: "1", password: "testpass", expectedStatus: http.StatusOK, expectRehash: true},
+		{name: "current hash is kept", storedBy: argon2idHasher, hashPepper: "2", storedPepper: "2", password: "testpass", expectedStatus: http.StatusOK},
+		{name: "wrong password is not rehashed", storedBy: bcryptHasher, password: "wrongpass", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong pepper does not verify", storedBy: argon2idHasher, hashPepper: "1", storedPepper: "2", password: "testpass", expectedStatus: http.StatusUnauthorized},
+		{name: "unknown pepper is refused", storedBy: argon2idHasher, hashPepper: "1", storedPepper: "3", password: "testpass", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peppered, err := peppers.Apply(tt.hashPepper, "testpass")
+			if err != nil {
+				t.Fatalf("Failed to apply pepper: %v", err)
+			}
+			stored, err := tt.storedBy.Hash(peppered)
+			if err != nil {
+				t.Fatalf("Failed to hash password: %v", err)
+			}
+
+			user := &models.User{Username: "testuser", HashedPassword: stored, PepperID: tt.storedPepper}
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+			userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
+			var fields map[string]interface{}
+			if tt.expectRehash {
+				userRepo.On("UpdateUser", mock.Anything, "testuser", mock.Anything).
+					Run(func(args mock.Arguments) {
+						fields, _ = args.Get(2).(map[string]interface{})
+					}).
+					Return(nil).Once()
+			}
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+			mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo, PasswordHasher: argon2idHasher, Peppers: peppers},
+				Keyring:     testKeyring(t),
+				validator:   structValidator.New(),
+			}
+
+			body := `{"username":"testuser","password":"` + tt.password + `"}`
+			req := httptest.NewRequest(http.MethodPost, LoginRouteAPI, strings.NewReader(body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.Login(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if !tt.expectRehash {
+				return
+			}
+
+			rehashed, _ := fields["hashed_password"].(string)
+			if fields["pepper_id"] != "2" {
+				t.Errorf("expected the current pepper to be stored, got %v", fields["pepper_id"])
+			}
+			if argon2idHasher.NeedsRehash(rehashed) {
+				t.Errorf("expected the password to be rehashed with the current parameters, got %q", rehashed)
+			}
+			peppered, _ = peppers.Apply("2", "testpass")
+			if ok, err := argon2idHasher.Verify(peppered, rehashed); err != nil || !ok {
+				t.Errorf("expected the rehashed password to verify with the current pepper, got %v, %v", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// passwordPolicyResponse answers 400 listing the violations when err is a
+// PasswordPolicyError, and reports whether it did.
+func (r *Route) passwordPolicyResponse(w http.ResponseWriter, err error, message, failedMetric string) bool {
+	var policyErr *userservice.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	response := &dto.PasswordPolicyErrorResponseDTO{
+		Error:      policyErr.Error(),
+		Message:    message,
+		Violations: make([]dto.PasswordViolationDTO, 0, len(policyErr.Violations)),
+	}
+	for _, violation := range policyErr.Violations {
+		response.Violations = append(response.Violations, dto.PasswordViolationDTO{Code: violation.Code, Message: violation.Message})
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(response)
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasswordPolicyRejectedTotal)
+		r.Metrics.IncCounter(failedMetric)
+	}
+	return true
+}
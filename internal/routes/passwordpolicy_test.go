@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/passwordpolicy"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoute_PasswordPolicy(t *testing.T) {
+	policy := &passwordpolicy.Policy{
+		MinLength:        10,
+		MaxLength:        64,
+		RequireDigit:     true,
+		DisallowUsername: true,
+		MinStrength:      3,
+	}
+
+	tests := []struct {
+		name           string
+		route          string
+		body           string
+		expectedStatus int
+		wantViolations []string
+	}{
+		{
+			name:           "Signup with accepted password",
+			route:          SignupRouteAPI,
+			body:           `{"username":"validuser","email":"valid@example.com","password":"zW8$kLp3@rT6"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Signup with refused password",
+			route:          SignupRouteAPI,
+			body:           `{"username":"validuser","email":"valid@example.com","password":"validuser"}`,
+			expectedStatus: http.StatusBadRequest,
+			wantViolations: []string{passwordpolicy.CodeTooShort, passwordpolicy.CodeMissingDigit, passwordpolicy.CodeContainsUsername, passwordpolicy.CodeTooWeak},
+		},
+		{
+			name:           "Signup with password too long for login",
+			route:          SignupRouteAPI,
+			body:           `{"username":"validuser","email":"valid@example.com","password":"` + strings.Repeat("zW8$kLp3@rT6", 6) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+			wantViolations: []string{passwordpolicy.CodeTooLong},
+		},
+		{
+			name:           "Reset with refused password keeps the token",
+			route:          ResetPasswordRouteAPI,
+			body:           `{"token":"valid-token","password":"password123"}`,
+			expectedStatus: http.StatusBadRequest,
+			wantViolations: []string{passwordpolicy.CodeTooWeak},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenHash := auth.HashOpaqueToken("valid-token")
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("AddUser", mock.Anything, mock.AnythingOfType("models.User")).Return("user-id", nil).Maybe()
+			userRepo.On("GetPasswordResetToken", mock.Anything, tokenHash).
+				Return(&models.PasswordResetToken{TokenHash: tokenHash, Username: "validuser", ExpiresAt: time.Now().Add(time.Minute).Unix()}, nil).Maybe()
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			if tt.wantViolations != nil {
+				mockedMetrics.On("IncCounter", PasswordPolicyRejectedTotal).Return().Once()
+			}
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+			mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo, PasswordPolicy: policy},
+				Keyring:     testKeyring(t),
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tt.route, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			if tt.route == SignupRouteAPI {
+				r.Signup(rr, req)
+			} else {
+				r.ResetPassword(rr, req)
+			}
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.wantViolations == nil {
+				return
+			}
+
+			response := &dto.PasswordPolicyErrorResponseDTO{}
+			if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var got []string
+			for _, violation := range response.Violations {
+				got = append(got, violation.Code)
+			}
+			if !reflect.DeepEqual(got, tt.wantViolations) {
+				t.Errorf("got violations %v, want %v", got, tt.wantViolations)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// ForgotPassword mails a password reset link to the requested user. The link
+// is sent in the background, so neither the response nor its timing tells
+// whether the account exists; delivery failures are only visible in the
+// PasswordResetFailedTotal metric.
+func (r *Route) ForgotPassword(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasswordResetRequestsTotal)
+	}
+
+	if !r.UserService.PasswordResetConfigured() {
+		r.jsonError(w, http.StatusNotImplemented, userservice.ErrPasswordResetNotConfigured, "Password reset is not available", PasswordResetFailedTotal)
+		return
+	}
+
+	forgotRequest := &dto.ForgotPasswordRequestDTO{}
+	if !r.decodeJSONRequest(w, req, forgotRequest, PasswordResetFailedTotal) {
+		return
+	}
+
+	go r.requestPasswordReset(context.WithoutCancel(req.Context()), forgotRequest.Username)
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(&dto.PasswordResetResponseDTO{
+		Message: "If the account exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password with the token of a password reset link.
+// Every session and refresh token of the user is revoked, so the user has to
+// log in again everywhere. Sessions are revoked before the password changes,
+// so a failed revocation leaves the old password in place.
+func (r *Route) ResetPassword(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasswordResetRequestsTotal)
+	}
+
+	resetRequest := &dto.ResetPasswordRequestDTO{}
+	if !r.decodeJSONRequest(w, req, resetRequest, PasswordResetFailedTotal) {
+		return
+	}
+
+	var revokeSessions func(ctx context.Context, username string) error
+	if r.Revocations != nil {
+		revokeSessions = func(ctx context.Context, username string) error {
+			return auth.RevokeUserSessions(ctx, r.Revocations, username, r.TokenConfig)
+		}
+	}
+
+	_, err := r.UserService.ResetPassword(req.Context(), resetRequest.Token, resetRequest.Password, revokeSessions)
+	if r.passwordPolicyResponse(w, err, "Password does not meet the password policy", PasswordResetFailedTotal) {
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, userservice.ErrInvalidResetToken) {
+			status = http.StatusBadRequest
+		}
+		r.jsonError(w, status, err, "Failed to reset password", PasswordResetFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(PasswordResetSuccessTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(&dto.PasswordResetResponseDTO{Message: "Password has been reset"})
+}
+
+// requestPasswordReset mails a password reset link to username. Failures are
+// only counted, since the response was already sent.
+func (r *Route) requestPasswordReset(ctx context.Context, username string) {
+	if err := r.UserService.RequestPasswordReset(ctx, username); err != nil && r.Metrics != nil {
+		r.Metrics.IncCounter(PasswordResetFailedTotal)
+	}
+}
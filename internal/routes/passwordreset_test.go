@@ -0,0 +1,285 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	mailmemory "github.com/haguru/sasuke/internal/mailer/memory"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/revocationstore/memory"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPasswordResetURL = "https://app.example.com/reset-password"
+
+func TestRoute_ForgotPassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		user           *models.User
+		unconfigured   bool
+		expectedStatus int
+		expectMail     bool
+	}{
+		{
+			name:           "Existing user with an email username",
+			body:           `{"username":"alice@example.com"}`,
+			user:           &models.User{Username: "alice@example.com"},
+			expectedStatus: http.StatusAccepted,
+			expectMail:     true,
+		},
+		{
+			name:           "Unknown user",
+			body:           `{"username":"nobody@example.com"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Username without an email address",
+			body:           `{"username":"testuser"}`,
+			user:           &models.User{Username: "testuser"},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Password reset not configured",
+			body:           `{"username":"alice@example.com"}`,
+			unconfigured:   true,
+			expectedStatus: http.StatusNotImplemented,
+		},
+		{
+			name:           "Missing username",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, mock.AnythingOfType("string")).Return(tt.user, nil).Maybe()
+			var stored models.PasswordResetToken
+			userRepo.On("AddPasswordResetToken", mock.Anything, mock.AnythingOfType("models.PasswordResetToken")).
+				Run(func(args mock.Arguments) { stored = args.Get(1).(models.PasswordResetToken) }).
+				Return(nil).Maybe()
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			mailer := mailmemory.NewMemoryMailer()
+			userService := &userservice.UserService{UserRepo: userRepo, Mailer: mailer, PasswordResetURL: testPasswordResetURL}
+			if tt.unconfigured {
+				userService.Mailer = nil
+			}
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: userService,
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, ForgotPasswordRouteAPI, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			r.ForgotPassword(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+
+			messages := waitForMail(mailer, tt.expectMail)
+			if !tt.expectMail {
+				if len(messages) != 0 {
+					t.Errorf("expected no email, got %d", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0].To != tt.user.Username {
+				t.Fatalf("expected one email to %s, got %+v", tt.user.Username, messages)
+			}
+
+			token := linkToken(t, messages[0], testPasswordResetURL)
+			if stored.TokenHash != auth.HashOpaqueToken(token) {
+				t.Error("expected only the hash of the mailed token to be stored")
+			}
+			if stored.Username != tt.user.Username || stored.Used {
+				t.Errorf("unexpected stored token %+v", stored)
+			}
+			if ttl := time.Until(time.Unix(stored.ExpiresAt, 0)); ttl <= 0 || ttl > userservice.DefaultPasswordResetTTL {
+				t.Errorf("expected the token to expire within %v, got %v", userservice.DefaultPasswordResetTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestRoute_ResetPassword(t *testing.T) {
+	keyring := testKeyring(t)
+	const username = "alice@example.com"
+
+	tests := []struct {
+		name           string
+		body           string
+		stored         *models.PasswordResetToken
+		markUsed       bool
+		revokeErr      error
+		expectedStatus int
+		expectReset    bool
+	}{
+		{
+			name:           "Valid token",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			stored:         &models.PasswordResetToken{Username: username, ExpiresAt: time.Now().Add(time.Minute).Unix()},
+			markUsed:       true,
+			expectedStatus: http.StatusOK,
+			expectReset:    true,
+		},
+		{
+			name:           "Unknown token",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Already used token",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			stored:         &models.PasswordResetToken{Username: username, ExpiresAt: time.Now().Add(time.Minute).Unix(), Used: true},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Token used concurrently",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			stored:         &models.PasswordResetToken{Username: username, ExpiresAt: time.Now().Add(time.Minute).Unix()},
+			markUsed:       false,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Expired token",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			stored:         &models.PasswordResetToken{Username: username, ExpiresAt: time.Now().Add(-time.Minute).Unix()},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Session revocation fails",
+			body:           `{"token":"valid-token","password":"n3w-Passw0rd"}`,
+			stored:         &models.PasswordResetToken{Username: username, ExpiresAt: time.Now().Add(time.Minute).Unix()},
+			markUsed:       true,
+			revokeErr:      errors.New("revocation store unavailable"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Password too short",
+			body:           `{"token":"valid-token","password":"short"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenHash := auth.HashOpaqueToken("valid-token")
+			if tt.stored != nil {
+				tt.stored.TokenHash = tokenHash
+			}
+
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetPasswordResetToken", mock.Anything, tokenHash).Return(tt.stored, nil).Maybe()
+			userRepo.On("MarkPasswordResetTokenUsed", mock.Anything, tokenHash).Return(tt.markUsed, nil).Maybe()
+			var newHash string
+			if tt.expectReset {
+				userRepo.On("UpdateUser", mock.Anything, username, mock.Anything).
+					Run(func(args mock.Arguments) {
+						newHash, _ = args.Get(2).(map[string]interface{})["hashed_password"].(string)
+					}).
+					Return(nil).Once()
+				userRepo.On("DeletePasswordResetTokens", mock.Anything, username).Return(nil).Once()
+				userRepo.On("RevokeUserRefreshTokens", mock.Anything, username).Return(nil).Once()
+			}
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			var revocations interfaces.RevocationStore = memory.NewMemoryRevocationStore()
+			if tt.revokeErr != nil {
+				// the password must not change without the sessions revoked
+				failing := mocks.NewMockRevocationStore(t)
+				failing.On("RevokeSubject", mock.Anything, username, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(tt.revokeErr).Once()
+				failing.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(false, nil).Maybe()
+				failing.On("IsSubjectRevoked", mock.Anything, username, mock.AnythingOfType("time.Time")).Return(false, nil).Maybe()
+				revocations = failing
+			}
+			session := sessionIssuedAt(t, keyring, username, time.Now().Add(-time.Minute))
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo},
+				Keyring:     keyring,
+				Revocations: revocations,
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, ResetPasswordRouteAPI, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			resetAt := time.Now()
+			r.ResetPassword(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+
+			_, err := auth.VerifyToken(req.Context(), session, keyring, auth.TokenConfig{}, revocations)
+			if !tt.expectReset {
+				if err != nil {
+					t.Errorf("expected the session to stay valid: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, auth.ErrTokenRevoked) {
+				t.Errorf("expected the session to be revoked, got %v", err)
+			}
+
+			// a session stolen earlier in the second of the reset is revoked too
+			sameSecond := sessionIssuedAt(t, keyring, username, resetAt.Truncate(time.Second))
+			if _, err := auth.VerifyToken(req.Context(), sameSecond, keyring, auth.TokenConfig{}, revocations); !errors.Is(err, auth.ErrTokenRevoked) {
+				t.Errorf("expected a session issued in the second of the reset to be revoked, got %v", err)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(newHash), []byte("n3w-Passw0rd")) != nil {
+				t.Error("expected the new password to be stored hashed")
+			}
+		})
+	}
+}
+
+// sessionIssuedAt signs a session token for username issued at issuedAt.
+func sessionIssuedAt(t *testing.T, keyring *auth.Keyring, username string, issuedAt time.Time) string {
+	t.Helper()
+	kid, privateKey, err := keyring.SigningKey()
+	if err != nil {
+		t.Fatalf("Failed to get signing key: %v", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, auth.CustomClaims{
+		UserID:        username,
+		PrincipalType: auth.PrincipalTypeUser,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    auth.ISSUER,
+			Subject:   auth.SUBJECT,
+			Audience:  jwt.ClaimStrings{auth.DefaultAudience},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(auth.DefaultTokenLifetime)),
+			ID:        "session-before-reset",
+		},
+	})
+	token.Header[auth.KeyIDHeader] = kid
+	session, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign session token: %v", err)
+	}
+	return session
+}
@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+)
+
+// UserRoles returns the roles of the user named by the username query
+// parameter and the permissions they grant.
+func (r *Route) UserRoles(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RoleRequestsTotal)
+	}
+
+	username := req.URL.Query().Get("username")
+	if username == "" {
+		r.jsonError(w, http.StatusBadRequest, fmt.Errorf("username is missing"), "Username is required", RoleFailedTotal)
+		return
+	}
+
+	r.userRolesResponse(w, req, username, "")
+}
+
+// AssignRole assigns a defined role to a user. The role is carried by the
+// session tokens the user obtains from then on.
+func (r *Route) AssignRole(w http.ResponseWriter, req *http.Request) {
+	assignment, ok := r.roleAssignmentRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := r.UserService.AssignRole(req.Context(), assignment.Username, assignment.Role); err != nil {
+		r.jsonError(w, roleErrorStatus(err), err, "Failed to assign role", RoleFailedTotal)
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RoleAssignmentsTotal)
+	}
+	r.userRolesResponse(w, req, assignment.Username, "Role assigned")
+}
+
+// RevokeRole removes a role from a user and revokes the sessions of the user,
+// whose tokens still carry the role.
+func (r *Route) RevokeRole(w http.ResponseWriter, req *http.Request) {
+	assignment, ok := r.roleAssignmentRequest(w, req)
+	if !ok {
+		return
+	}
+
+	if err := r.UserService.RevokeRole(req.Context(), assignment.Username, assignment.Role); err != nil {
+		r.jsonError(w, roleErrorStatus(err), err, "Failed to revoke role", RoleFailedTotal)
+		return
+	}
+	if r.Revocations != nil {
+		if err := auth.RevokeUserSessions(req.Context(), r.Revocations, assignment.Username, r.TokenConfig); err != nil {
+			r.jsonError(w, http.StatusInternalServerError, err, "Failed to revoke sessions", RoleFailedTotal)
+			return
+		}
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RoleRevocationsTotal)
+	}
+	r.userRolesResponse(w, req, assignment.Username, "Role revoked")
+}
+
+// roleAssignmentRequest checks the method of a role change and decodes its body.
+func (r *Route) roleAssignmentRequest(w http.ResponseWriter, req *http.Request) (*dto.RoleAssignmentRequestDTO, bool) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return nil, false
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RoleRequestsTotal)
+	}
+
+	assignment := &dto.RoleAssignmentRequestDTO{}
+	if !r.decodeJSONRequest(w, req, assignment, RoleFailedTotal) {
+		return nil, false
+	}
+	return assignment, true
+}
+
+// userRolesResponse answers with the current roles and permissions of username.
+func (r *Route) userRolesResponse(w http.ResponseWriter, req *http.Request, username, message string) {
+	grants, err := r.UserService.UserGrants(req.Context(), username)
+	if err != nil {
+		r.jsonError(w, roleErrorStatus(err), err, "Failed to load user roles", RoleFailedTotal)
+		return
+	}
+
+	response := &dto.UserRolesResponseDTO{
+		Message:     message,
+		Username:    username,
+		Roles:       grants.Roles,
+		Permissions: grants.Permissions,
+	}
+	if response.Roles == nil {
+		response.Roles = []string{}
+	}
+	if response.Permissions == nil {
+		response.Permissions = []string{}
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// roleErrorStatus maps role service errors to HTTP status codes.
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, userservice.ErrUnknownRole):
+		return http.StatusBadRequest
+	case errors.Is(err, userservice.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/interfaces/mocks"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/userservice"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoute_Roles(t *testing.T) {
+	roles := auth.RoleSet{
+		"admin":  {auth.PermissionCreate, auth.PermissionRolesRead, auth.PermissionRolesAssign},
+		"editor": {auth.PermissionCreate},
+		"user":   {},
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		route           string
+		body            string
+		storedRoles     string
+		expectedStatus  int
+		wantStored      string
+		wantRevocation  bool
+		wantRoles       []string
+		wantPermissions []string
+	}{
+		{
+			name:            "List roles",
+			method:          http.MethodGet,
+			route:           RolesRouteAPI + "?username=testuser",
+			storedRoles:     "user editor",
+			expectedStatus:  http.StatusOK,
+			wantRoles:       []string{"editor", "user"},
+			wantPermissions: []string{auth.PermissionCreate},
+		},
+		{
+			name:           "List roles without username",
+			method:         http.MethodGet,
+			route:          RolesRouteAPI,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "List roles of unknown user",
+			method:         http.MethodGet,
+			route:          RolesRouteAPI + "?username=nobody",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:            "Assign role",
+			method:          http.MethodPost,
+			route:           AssignRoleRouteAPI,
+			body:            `{"username":"testuser","role":"editor"}`,
+			storedRoles:     "user",
+			expectedStatus:  http.StatusOK,
+			wantStored:      "user editor",
+			wantRoles:       []string{"editor", "user"},
+			wantPermissions: []string{auth.PermissionCreate},
+		},
+		{
+			name:           "Assign role already held",
+			method:         http.MethodPost,
+			route:          AssignRoleRouteAPI,
+			body:           `{"username":"testuser","role":"user"}`,
+			storedRoles:    "user",
+			expectedStatus: http.StatusOK,
+			wantRoles:      []string{"user"},
+		},
+		{
+			name:           "Assign undefined role",
+			method:         http.MethodPost,
+			route:          AssignRoleRouteAPI,
+			body:           `{"username":"testuser","role":"root"}`,
+			storedRoles:    "user",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Assign role to unknown user",
+			method:         http.MethodPost,
+			route:          AssignRoleRouteAPI,
+			body:           `{"username":"nobody","role":"editor"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Assign role without role",
+			method:         http.MethodPost,
+			route:          AssignRoleRouteAPI,
+			body:           `{"username":"testuser"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Revoke role revokes sessions",
+			method:         http.MethodPost,
+			route:          RevokeRoleRouteAPI,
+			body:           `{"username":"testuser","role":"admin"}`,
+			storedRoles:    "admin user",
+			expectedStatus: http.StatusOK,
+			wantStored:     "user",
+			wantRevocation: true,
+			wantRoles:      []string{"user"},
+		},
+		{
+			name:           "Assign role with wrong method",
+			method:         http.MethodGet,
+			route:          AssignRoleRouteAPI,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{Username: "testuser", Roles: tt.storedRoles}
+			userRepo := mocks.NewMockUserRepository(t)
+			userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(func(_ context.Context, _ string) (*models.User, error) {
+				stored := *user
+				return &stored, nil
+			}).Maybe()
+			userRepo.On("GetUserByUsername", mock.Anything, "nobody").Return(nil, nil).Maybe()
+			if tt.wantStored != "" {
+				userRepo.On("SetUserRoles", mock.Anything, "testuser", tt.storedRoles, tt.wantStored).
+					Run(func(args mock.Arguments) {
+						user.Roles = args.String(3)
+					}).
+					Return(true, nil).Once()
+			}
+
+			revocations := mocks.NewMockRevocationStore(t)
+			if tt.wantRevocation {
+				revocations.On("RevokeSubject", mock.Anything, "testuser", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil).Once()
+			}
+
+			mockedMetrics := mocks.NewMockMetrics(t)
+			mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+			r := &Route{
+				Metrics:     mockedMetrics,
+				UserService: &userservice.UserService{UserRepo: userRepo, Roles: roles},
+				Revocations: revocations,
+				validator:   structValidator.New(),
+			}
+
+			req := httptest.NewRequest(tt.method, tt.route, strings.NewReader(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+			rr := httptest.NewRecorder()
+			switch {
+			case strings.HasPrefix(tt.route, AssignRoleRouteAPI):
+				r.AssignRole(rr, req)
+			case strings.HasPrefix(tt.route, RevokeRoleRouteAPI):
+				r.RevokeRole(rr, req)
+			default:
+				r.UserRoles(rr, req)
+			}
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("got status %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			response := &dto.UserRolesResponseDTO{}
+			if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(response.Roles, tt.wantRoles) {
+				t.Errorf("got roles %v, want %v", response.Roles, tt.wantRoles)
+			}
+			wantPermissions := tt.wantPermissions
+			if wantPermissions == nil {
+				wantPermissions = []string{}
+			}
+			if !reflect.DeepEqual(response.Permissions, wantPermissions) {
+				t.Errorf("got permissions %v, want %v", response.Permissions, wantPermissions)
+			}
+		})
+	}
+}
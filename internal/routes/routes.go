@@ -1,36 +1,64 @@
 package routes
 
 import (
-	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/haguru/sasuke/config"
 	"github.com/haguru/sasuke/internal/auth"
 	"github.com/haguru/sasuke/internal/interfaces"
 	"github.com/haguru/sasuke/internal/models/dto"
+	"github.com/haguru/sasuke/internal/oauthservice"
+	"github.com/haguru/sasuke/internal/policy"
 	"github.com/haguru/sasuke/internal/userservice"
 
 	structValidator "github.com/go-playground/validator/v10"
 )
 
 type Route struct {
-	Metrics     interfaces.Metrics
-	UserService *userservice.UserService
-	PrivateKey  *ecdsa.PrivateKey
-	validator   *structValidator.Validate
+	Metrics      interfaces.Metrics
+	UserService  *userservice.UserService
+	OAuthService *oauthservice.OAuthService
+	Keyring      *auth.Keyring
+	Revocations  interfaces.RevocationStore
+	TokenConfig  auth.TokenConfig
+	Cookie       config.CookieConfig
+	// MFAChallengeTTL is the lifetime of the token between the password and
+	// second factor login steps.
+	MFAChallengeTTL time.Duration
+	// WebAuthnCeremonyTTL is the lifetime of passkey registration and login
+	// ceremonies; WebAuthnAttestation is the attestation conveyance preference
+	// sent to authenticators.
+	WebAuthnCeremonyTTL time.Duration
+	WebAuthnAttestation string
+	// RequireVerifiedEmail rejects password logins of users who have not
+	// verified their email; EmailVerificationTTL is the lifetime of links.
+	RequireVerifiedEmail bool
+	EmailVerificationTTL time.Duration
+	// MagicLinkTTL is the lifetime of emailed login links.
+	MagicLinkTTL time.Duration
+	// Policies decides the requests of the policy decision endpoint.
+	Policies  *policy.Engine
+	validator *structValidator.Validate
 }
 
 // NewRoute creates a new Route instance.
 func NewRoute(metrics interfaces.Metrics, userService *userservice.UserService,
-	privateKey *ecdsa.PrivateKey, validator *structValidator.Validate,
+	keyring *auth.Keyring, revocations interfaces.RevocationStore, validator *structValidator.Validate,
 ) *Route {
 
 	return &Route{
 		Metrics:     metrics,
 		UserService: userService,
-		PrivateKey:  privateKey,
+		Keyring:     keyring,
+		Revocations: revocations,
 		validator:   validator,
 	}
 }
@@ -83,7 +111,10 @@ func (r *Route) Signup(w http.ResponseWriter, req *http.Request) {
 		startTime = time.Now()
 	}
 
-	userID, err := r.UserService.RegisterUser(req.Context(), signupRequest.Username, signupRequest.Password)
+	userID, err := r.UserService.RegisterUser(req.Context(), signupRequest.Username, signupRequest.Email, signupRequest.Password)
+	if r.passwordPolicyResponse(w, err, "Password does not meet the password policy", SignupErrorsTotal) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusConflict)
 		r.errorResponse(w, err, "Failed to register user")
@@ -99,6 +130,9 @@ func (r *Route) Signup(w http.ResponseWriter, req *http.Request) {
 		r.Metrics.ObserveHistogram(SignupDurationSeconds, duration)
 	}
 
+	// the account exists either way; a lost email can be sent again
+	r.sendEmailVerification(req.Context(), signupRequest.Username, userservice.NormalizeEmail(signupRequest.Email))
+
 	w.Header().Set(ContentType, ContentTypeJson)
 	w.WriteHeader(http.StatusCreated)
 
@@ -169,6 +203,14 @@ func (r *Route) Login(w http.ResponseWriter, req *http.Request) {
 	}
 
 	authenticated, err := r.UserService.AuthenticateUser(req.Context(), loginRequest.Username, loginRequest.Password)
+	var locked *userservice.AccountLockedError
+	if errors.As(err, &locked) {
+		r.lockedResponse(w, locked)
+		return
+	}
+	if errors.Is(err, userservice.ErrLockoutStarted) && r.Metrics != nil {
+		r.Metrics.IncCounter(AccountLockoutsTotal)
+	}
 	if err != nil || !authenticated {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -182,12 +224,70 @@ func (r *Route) Login(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if r.Metrics != nil {
-		r.Metrics.IncCounter(LoginSuccessTotal)
 		duration := time.Since(startTime).Seconds()
 		r.Metrics.ObserveHistogram(LoginDurationSeconds, duration)
 	}
 
-	sessionToken, err := auth.CreateToken(loginRequest.Username, r.PrivateKey)
+	user, err := r.UserService.GetUser(req.Context(), loginRequest.Username)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to load user")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	if r.RequireVerifiedEmail && !user.EmailVerified {
+		w.Header().Set(ContentType, ContentTypeJson)
+		w.WriteHeader(http.StatusForbidden)
+		r.errorResponse(w, errEmailNotVerified, "Email address not verified")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	// users with a second factor get a challenge instead of a session
+	if methods := userservice.MFAMethods(user); len(methods) > 0 {
+		r.mfaChallenge(w, loginRequest.Username, []string{auth.AMRPassword}, methods)
+		return
+	}
+
+	r.completeLogin(w, req, loginRequest.Username, []string{auth.AMRPassword})
+}
+
+// lockedResponse refuses a login of a locked account until the lock ends.
+func (r *Route) lockedResponse(w http.ResponseWriter, locked *userservice.AccountLockedError) {
+	retryAfter := int64(math.Ceil(time.Until(locked.Until).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(RetryAfter, strconv.FormatInt(retryAfter, 10))
+	w.WriteHeader(http.StatusTooManyRequests)
+	r.errorResponse(w, locked, "Too many failed logins, try again later")
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LoginFailedTotal)
+		r.Metrics.IncCounter(LoginLockedTotal)
+	}
+}
+
+// completeLogin issues the session and refresh tokens of an authenticated user.
+// amr lists the authentication methods the user completed.
+func (r *Route) completeLogin(w http.ResponseWriter, req *http.Request, username string, amr []string) {
+	grants, err := r.UserService.UserGrants(req.Context(), username)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to load user roles")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	sessionToken, err := auth.CreateSessionToken(username, amr, grants, r.Keyring, r.TokenConfig)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		r.errorResponse(w, err, "Failed to generate session token")
@@ -197,19 +297,28 @@ func (r *Route) Login(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
-	})
+	refreshToken, err := r.UserService.IssueRefreshToken(req.Context(), username, amr)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to generate refresh token")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LoginFailedTotal)
+		}
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LoginSuccessTotal)
+	}
+
+	r.setSessionCookies(w, sessionToken, refreshToken)
 
 	w.Header().Set(ContentType, ContentTypeJson)
 
 	w.WriteHeader(http.StatusOK)
 	response := &dto.LoginResponseDTO{
-		Message: "Login successful",
+		Message:      "Login successful",
+		RefreshToken: refreshToken,
 	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -221,14 +330,287 @@ func (r *Route) Login(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// Create route
+// Refresh exchanges a refresh token for a new session token and a rotated refresh token.
+// The refresh token is read from the refresh_token cookie or from a JSON body.
+func (r *Route) Refresh(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RefreshRequestsTotal)
+	}
+
+	refreshToken := ""
+	if cookie, err := req.Cookie(RefreshCookieName); err == nil {
+		refreshToken = cookie.Value
+	}
+	if refreshToken == "" && req.Header.Get(ContentType) == ContentTypeJson {
+		refreshRequest := &dto.RefreshRequestDTO{}
+		if err := json.NewDecoder(req.Body).Decode(refreshRequest); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			r.errorResponse(w, err, "Invalid request body")
+			if r.Metrics != nil {
+				r.Metrics.IncCounter(RefreshFailedTotal)
+			}
+			return
+		}
+		refreshToken = refreshRequest.RefreshToken
+	}
+
+	if refreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		r.errorResponse(w, fmt.Errorf("refresh token is missing"), "Refresh token is required")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(RefreshFailedTotal)
+		}
+		return
+	}
+
+	stored, newRefreshToken, err := r.UserService.RotateRefreshToken(req.Context(), refreshToken)
+	if err != nil {
+		w.Header().Set(ContentType, ContentTypeJson)
+		status := http.StatusUnauthorized
+		if !errors.Is(err, userservice.ErrInvalidRefreshToken) && !errors.Is(err, userservice.ErrRefreshTokenReused) {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		r.errorResponse(w, err, "Failed to refresh session")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(RefreshFailedTotal)
+			if errors.Is(err, userservice.ErrRefreshTokenReused) {
+				r.Metrics.IncCounter(RefreshReuseTotal)
+			}
+		}
+		return
+	}
+
+	// roles changed since the last login take effect with the next session token
+	grants, err := r.UserService.UserGrants(req.Context(), stored.Username)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to load user roles")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(RefreshFailedTotal)
+		}
+		return
+	}
+
+	sessionToken, err := auth.CreateSessionToken(stored.Username, strings.Fields(stored.AMR), grants, r.Keyring, r.TokenConfig)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to generate session token")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(RefreshFailedTotal)
+		}
+		return
+	}
+
+	r.setSessionCookies(w, sessionToken, newRefreshToken)
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(RefreshSuccessTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	response := &dto.RefreshResponseDTO{
+		Message:      "Session refreshed",
+		RefreshToken: newRefreshToken,
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Logout revokes the caller's session token and the family of its refresh
+// token, then clears the session cookies. The session token is read from the
+// session_token cookie or an Authorization: Bearer header, the refresh token
+// from the refresh_token cookie or a JSON body.
+func (r *Route) Logout(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LogoutRequestsTotal)
+	}
+
+	sessionToken := auth.TokenFromRequest(req)
+	if sessionToken == "" {
+		w.Header().Set(ContentType, ContentTypeJson)
+		w.WriteHeader(http.StatusUnauthorized)
+		r.errorResponse(w, fmt.Errorf("session token is missing"), "Session token is required")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LogoutFailedTotal)
+		}
+		return
+	}
+
+	claims, err := auth.VerifyToken(req.Context(), sessionToken, r.Keyring, r.TokenConfig, r.Revocations)
+	if err != nil {
+		r.clearSessionCookies(w)
+		w.Header().Set(ContentType, ContentTypeJson)
+		w.WriteHeader(http.StatusUnauthorized)
+		r.errorResponse(w, err, "Invalid session token")
+		if r.Metrics != nil {
+			r.Metrics.IncCounter(LogoutFailedTotal)
+		}
+		return
+	}
+
+	refreshToken := ""
+	if cookie, err := req.Cookie(RefreshCookieName); err == nil {
+		refreshToken = cookie.Value
+	}
+	if refreshToken == "" && req.Header.Get(ContentType) == ContentTypeJson {
+		refreshRequest := &dto.RefreshRequestDTO{}
+		if err := json.NewDecoder(req.Body).Decode(refreshRequest); err != nil && !errors.Is(err, io.EOF) {
+			w.Header().Set(ContentType, ContentTypeJson)
+			w.WriteHeader(http.StatusBadRequest)
+			r.errorResponse(w, err, "Invalid request body")
+			if r.Metrics != nil {
+				r.Metrics.IncCounter(LogoutFailedTotal)
+			}
+			return
+		}
+		refreshToken = refreshRequest.RefreshToken
+	}
+
+	if refreshToken != "" {
+		if _, err := r.UserService.RevokeRefreshToken(req.Context(), refreshToken); err != nil {
+			w.Header().Set(ContentType, ContentTypeJson)
+			w.WriteHeader(http.StatusInternalServerError)
+			r.errorResponse(w, err, "Failed to revoke refresh token")
+			if r.Metrics != nil {
+				r.Metrics.IncCounter(LogoutFailedTotal)
+			}
+			return
+		}
+	}
+
+	if r.Revocations != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		if err := r.Revocations.Revoke(req.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			w.Header().Set(ContentType, ContentTypeJson)
+			w.WriteHeader(http.StatusInternalServerError)
+			r.errorResponse(w, err, "Failed to revoke session token")
+			if r.Metrics != nil {
+				r.Metrics.IncCounter(LogoutFailedTotal)
+			}
+			return
+		}
+	}
+
+	r.clearSessionCookies(w)
+
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(LogoutSuccessTotal)
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	response := &dto.LogoutResponseDTO{
+		Message: "Logout successful",
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// JWKS publishes the public half of every key in the keyring as a JSON Web Key Set.
+func (r *Route) JWKS(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		r.errorResponse(w, fmt.Errorf("method %s not allowed", req.Method), "Method not allowed")
+		return
+	}
+
+	jwks, err := auth.NewJWKSet(r.Keyring.PublicKeys()...)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		r.errorResponse(w, err, "Failed to build JWK set")
+		return
+	}
+
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.Header().Set(CacheControl, JWKSCacheControl)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(jwks)
+}
+
+// Create route. It must be wrapped by middleware.AuthMiddleware, which
+// provides the caller's claims.
 // TODO complete API
 func (r *Route) Create(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set(ContentType, ContentTypeJson)
+
+	if _, ok := auth.ClaimsFromContext(req.Context()); !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		r.errorResponse(w, fmt.Errorf("request is not authenticated"), "Authentication required")
+		return
+	}
+
 	w.WriteHeader(http.StatusNotImplemented)
 	r.errorResponse(w, fmt.Errorf("create route not implemented"), "Create route has not been implemented yet")
 }
 
+// setSessionCookies sets the session token cookie and, scoped to the refresh route,
+// the refresh token cookie.
+func (r *Route) setSessionCookies(w http.ResponseWriter, sessionToken, refreshToken string) {
+	sessionMaxAge := r.tokenLifetime()
+	refreshMaxAge := r.UserService.RefreshTokenTTL
+	if refreshMaxAge <= 0 {
+		refreshMaxAge = userservice.DefaultRefreshTokenTTL
+	}
+	if r.Cookie.MaxAge > 0 {
+		sessionMaxAge = r.Cookie.MaxAge
+		refreshMaxAge = r.Cookie.MaxAge
+	}
+
+	http.SetCookie(w, r.cookie(SessionCookieName, sessionToken, "/", sessionMaxAge))
+	http.SetCookie(w, r.cookie(RefreshCookieName, refreshToken, RefreshRouteAPI, refreshMaxAge))
+}
+
+// clearSessionCookies expires the cookies set by setSessionCookies.
+func (r *Route) clearSessionCookies(w http.ResponseWriter) {
+	session := r.cookie(SessionCookieName, "", "/", 0)
+	session.MaxAge = -1
+	http.SetCookie(w, session)
+
+	refresh := r.cookie(RefreshCookieName, "", RefreshRouteAPI, 0)
+	refresh.MaxAge = -1
+	http.SetCookie(w, refresh)
+}
+
+// cookie builds an HttpOnly cookie with the configured domain, Secure and SameSite attributes.
+func (r *Route) cookie(name, value, path string, maxAge time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   r.Cookie.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.Cookie.Secure,
+		SameSite: sameSiteMode(r.Cookie.SameSite),
+	}
+}
+
+// sameSiteMode maps the configured same_site value to its http.SameSite mode.
+func sameSiteMode(sameSite string) http.SameSite {
+	switch strings.ToLower(sameSite) {
+	case SameSiteStrict:
+		return http.SameSiteStrictMode
+	case SameSiteNone:
+		return http.SameSiteNoneMode
+	case SameSiteLax:
+		return http.SameSiteLaxMode
+	default:
+		// leave the attribute unset
+		return 0
+	}
+}
+
 func (r *Route) errorResponse(w http.ResponseWriter, err error, message string) {
 	jsonResponse := map[string]string{
 		"error":   err.Error(),
@@ -236,3 +618,33 @@ func (r *Route) errorResponse(w http.ResponseWriter, err error, message string)
 	}
 	_ = json.NewEncoder(w).Encode(jsonResponse)
 }
+
+// decodeJSONRequest decodes and validates a JSON request body into body.
+func (r *Route) decodeJSONRequest(w http.ResponseWriter, req *http.Request, body interface{}, failedMetric string) bool {
+	if req.Header.Get(ContentType) != ContentTypeJson {
+		r.jsonError(w, http.StatusBadRequest, fmt.Errorf("invalid content-type: %s", req.Header.Get(ContentType)), "Content-Type must be application/json", failedMetric)
+		return false
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+		r.jsonError(w, http.StatusBadRequest, err, "Invalid request body", failedMetric)
+		return false
+	}
+
+	if err := r.validator.Struct(body); err != nil {
+		errors := err.(structValidator.ValidationErrors)
+		r.jsonError(w, http.StatusBadRequest, fmt.Errorf("invalid request data: %s", errors), "Request validation failed", failedMetric)
+		return false
+	}
+	return true
+}
+
+// jsonError answers with status and a JSON error and counts failedMetric.
+func (r *Route) jsonError(w http.ResponseWriter, status int, err error, message, failedMetric string) {
+	w.Header().Set(ContentType, ContentTypeJson)
+	w.WriteHeader(status)
+	r.errorResponse(w, err, message)
+	if r.Metrics != nil {
+		r.Metrics.IncCounter(failedMetric)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -13,11 +14,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	structValidator "github.com/go-playground/validator/v10"
+	"github.com/haguru/sasuke/config"
 	"github.com/haguru/sasuke/internal/auth"
 	"github.com/haguru/sasuke/internal/interfaces/mocks"
 	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/models/dto"
 	"github.com/haguru/sasuke/internal/userservice"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -183,6 +187,7 @@ func TestRoute_Login(t *testing.T) {
 
 		// Mock the GetUserByUsername method to return a user with a hashed password
 		userRepo.On("GetUserByUsername", mock.Anything, username).Return(returnedUser, tt.userrepoError).Maybe()
+		userRepo.On("AddRefreshToken", mock.Anything, mock.AnythingOfType("models.RefreshToken")).Return(nil).Maybe()
 
 		userService := &userservice.UserService{
 			UserRepo: userRepo, // Use a mock or a real implementation
@@ -196,11 +201,16 @@ func TestRoute_Login(t *testing.T) {
 		mockedMetrics := mocks.NewMockMetrics(t)
 		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
 		mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
-		// Create a new Route instance with the mock user service and private key
+		keyring, err := auth.NewKeyring(privateKey)
+		if err != nil {
+			t.Fatalf("Failed to create keyring: %v", err)
+		}
+
+		// Create a new Route instance with the mock user service and keyring
 		r := &Route{
 			Metrics:     mockedMetrics,
 			UserService: userService,
-			PrivateKey:  privateKey,
+			Keyring:     keyring,
 			validator:   structValidator.New(),
 		}
 		// Call the Login method with the recorder and request
@@ -224,7 +234,7 @@ func TestRoute_Signup(t *testing.T) {
 			name:           "Valid signup request",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"validuser1","password":"validPass123!"}`,
+			body:           `{"username":"validuser1","email":"valid@example.com","password":"validPass123!"}`,
 			wantStatusCode: http.StatusCreated,
 		},
 		{
@@ -238,49 +248,63 @@ func TestRoute_Signup(t *testing.T) {
 			name:           "Missing Content-Type",
 			method:         http.MethodPost,
 			contentType:    "",
-			body:           `{"username":"validuser2","password":"validPass123!"}`,
+			body:           `{"username":"validuser2","email":"valid@example.com","password":"validPass123!"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "Invalid JSON body",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"validuser3""password":"validPass123!"}`,
+			body:           `{"username":"validuser3""email":"valid@example.com","password":"validPass123!"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "Short username",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"short","password":"validPass123!"}`,
+			body:           `{"username":"short","email":"valid@example.com","password":"validPass123!"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "Long password",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"validuser4","password":"` + string(make([]byte, 65)) + `"}`,
+			body:           `{"username":"validuser4","email":"valid@example.com","password":"` + string(make([]byte, 65)) + `"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "Missing username",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"password":"validPass123!"}`,
+			body:           `{"email":"valid@example.com","password":"validPass123!"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "Missing password",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"validuser5"}`,
+			body:           `{"username":"validuser5","email":"valid@example.com"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing email",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"username":"validuser6","password":"validPass123!"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid email",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"username":"validuser7","email":"not-an-email","password":"validPass123!"}`,
 			wantStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:           "User already exists",
 			method:         http.MethodPost,
 			contentType:    "application/json",
-			body:           `{"username":"existinguser","password":"validPass123!"}`,
+			body:           `{"username":"existinguser","email":"valid@example.com","password":"validPass123!"}`,
 			userrepoError:  fmt.Errorf("user already exists"),
 			wantStatusCode: http.StatusConflict,
 		},
@@ -317,11 +341,16 @@ func TestRoute_Signup(t *testing.T) {
 		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
 		mockedMetrics.On("ObserveHistogram", mock.AnythingOfType("string"), mock.AnythingOfType("float64")).Return().Maybe()
 
-		// Create a new Route instance with the mock user service and private key
+		keyring, err := auth.NewKeyring(privateKey)
+		if err != nil {
+			t.Fatalf("Failed to create keyring: %v", err)
+		}
+
+		// Create a new Route instance with the mock user service and keyring
 		r := &Route{
 			Metrics:     mockedMetrics,
 			UserService: userService,
-			PrivateKey:  privateKey,
+			Keyring:     keyring,
 			validator:   structValidator.New(),
 		}
 		r.Signup(rr, req)
@@ -331,6 +360,457 @@ func TestRoute_Signup(t *testing.T) {
 	}
 }
 
+func TestRoute_Refresh(t *testing.T) {
+	validToken := "valid-refresh-token"
+	usedToken := "used-refresh-token"
+	expiredToken := "expired-refresh-token"
+
+	storedTokens := map[string]*models.RefreshToken{
+		auth.HashOpaqueToken(validToken): {
+			TokenHash: auth.HashOpaqueToken(validToken),
+			FamilyID:  "family-1",
+			Username:  "testuser",
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+		auth.HashOpaqueToken(usedToken): {
+			TokenHash: auth.HashOpaqueToken(usedToken),
+			FamilyID:  "family-2",
+			Username:  "testuser",
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			Used:      true,
+		},
+		auth.HashOpaqueToken(expiredToken): {
+			TokenHash: auth.HashOpaqueToken(expiredToken),
+			FamilyID:  "family-3",
+			Username:  "testuser",
+			ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		cookie         string
+		body           string
+		wantRevoked    string
+		wantStatusCode int
+	}{
+		{
+			name:           "Valid refresh token in cookie",
+			method:         http.MethodPost,
+			cookie:         validToken,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Valid refresh token in body",
+			method:         http.MethodPost,
+			body:           fmt.Sprintf(`{"refresh_token":"%s"}`, validToken),
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Reused refresh token revokes family",
+			method:         http.MethodPost,
+			cookie:         usedToken,
+			wantRevoked:    "family-2",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Expired refresh token",
+			method:         http.MethodPost,
+			cookie:         expiredToken,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unknown refresh token",
+			method:         http.MethodPost,
+			cookie:         "unknown-refresh-token",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Missing refresh token",
+			method:         http.MethodPost,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			cookie:         validToken,
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	privateKey, err := auth.LoadECDSAPrivateKey("validKey.pem")
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, RefreshRouteAPI, nil)
+		if tt.body != "" {
+			req = httptest.NewRequest(tt.method, RefreshRouteAPI, bytes.NewBufferString(tt.body))
+			req.Header.Set(ContentType, ContentTypeJson)
+		}
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: tt.cookie})
+		}
+		rr := httptest.NewRecorder()
+
+		userRepo := mocks.NewMockUserRepository(t)
+		userRepo.On("GetRefreshToken", mock.Anything, mock.AnythingOfType("string")).
+			Return(func(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+				if stored, ok := storedTokens[tokenHash]; ok {
+					copied := *stored
+					return &copied, nil
+				}
+				return nil, nil
+			}).Maybe()
+		userRepo.On("MarkRefreshTokenUsed", mock.Anything, mock.AnythingOfType("string")).Return(true, nil).Maybe()
+		userRepo.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(token models.RefreshToken) bool {
+			return token.FamilyID == "family-1" && token.Username == "testuser"
+		})).Return(nil).Maybe()
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&models.User{Username: "testuser"}, nil).Maybe()
+		if tt.wantRevoked != "" {
+			userRepo.On("RevokeRefreshTokenFamily", mock.Anything, tt.wantRevoked).Return(nil).Once()
+		}
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		r := &Route{
+			Metrics:     mockedMetrics,
+			UserService: &userservice.UserService{UserRepo: userRepo},
+			Keyring:     keyring,
+			validator:   structValidator.New(),
+		}
+		r.Refresh(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+
+		if tt.wantStatusCode == http.StatusOK {
+			response := &dto.RefreshResponseDTO{}
+			if err := json.NewDecoder(rr.Body).Decode(response); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", tt.name, err)
+			}
+			if response.RefreshToken == "" || response.RefreshToken == validToken {
+				t.Errorf("%s: expected a rotated refresh token, got %q", tt.name, response.RefreshToken)
+			}
+		}
+	}
+}
+
+func TestRoute_Logout(t *testing.T) {
+	privateKey, err := auth.LoadECDSAPrivateKey("validKey.pem")
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		useCookie      bool
+		useBearer      bool
+		token          string
+		revoked        bool
+		revokeError    error
+		refreshCookie  bool
+		refreshBody    bool
+		familyError    error
+		wantStatusCode int
+	}{
+		{
+			name:           "Valid session cookie",
+			method:         http.MethodPost,
+			useCookie:      true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Refresh token cookie revokes family",
+			method:         http.MethodPost,
+			useCookie:      true,
+			refreshCookie:  true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Refresh token body revokes family",
+			method:         http.MethodPost,
+			useBearer:      true,
+			refreshBody:    true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Refresh token family revocation failure",
+			method:         http.MethodPost,
+			useCookie:      true,
+			refreshCookie:  true,
+			familyError:    fmt.Errorf("store unavailable"),
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:           "Valid bearer token",
+			method:         http.MethodPost,
+			useBearer:      true,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Already revoked token",
+			method:         http.MethodPost,
+			useCookie:      true,
+			revoked:        true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Invalid token",
+			method:         http.MethodPost,
+			useCookie:      true,
+			token:          "invalid.token.value",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Missing token",
+			method:         http.MethodPost,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "Revocation store failure",
+			method:         http.MethodPost,
+			useCookie:      true,
+			revokeError:    fmt.Errorf("store unavailable"),
+			wantStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodGet,
+			useCookie:      true,
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		token := tt.token
+		if token == "" {
+			token, err = auth.CreateToken("testuser", keyring, auth.TokenConfig{})
+			if err != nil {
+				t.Fatalf("Failed to create token: %v", err)
+			}
+		}
+
+		body := ""
+		if tt.refreshBody {
+			body = `{"refresh_token":"refresh-token"}`
+		}
+		req := httptest.NewRequest(tt.method, LogoutRouteAPI, bytes.NewBufferString(body))
+		req.Header.Set(ContentType, ContentTypeJson)
+		if tt.useCookie {
+			req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+		}
+		if tt.refreshCookie {
+			req.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: "refresh-token"})
+		}
+		if tt.useBearer {
+			req.Header.Set(auth.AuthorizationHeader, auth.BearerPrefix+token)
+		}
+		rr := httptest.NewRecorder()
+
+		revocations := mocks.NewMockRevocationStore(t)
+		revocations.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(tt.revoked, nil).Maybe()
+		revocations.On("IsSubjectRevoked", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil).Maybe()
+		revocations.On("Revoke", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+			Return(tt.revokeError).Maybe()
+
+		mockedMetrics := mocks.NewMockMetrics(t)
+		mockedMetrics.On("IncCounter", mock.AnythingOfType("string")).Return().Maybe()
+
+		userRepo := mocks.NewMockUserRepository(t)
+		if tt.refreshCookie || tt.refreshBody {
+			userRepo.On("GetRefreshToken", mock.Anything, auth.HashOpaqueToken("refresh-token")).
+				Return(&models.RefreshToken{FamilyID: "family-1", Username: "testuser"}, nil).Once()
+			userRepo.On("RevokeRefreshTokenFamily", mock.Anything, "family-1").Return(tt.familyError).Once()
+		}
+
+		r := &Route{
+			Metrics:     mockedMetrics,
+			UserService: userservice.NewUserService(userRepo),
+			Keyring:     keyring,
+			Revocations: revocations,
+			validator:   structValidator.New(),
+		}
+		r.Logout(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+
+		if tt.wantStatusCode == http.StatusOK {
+			revocations.AssertCalled(t, "Revoke", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time"))
+
+			cleared := false
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == SessionCookieName && cookie.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			if !cleared {
+				t.Errorf("%s: expected the session cookie to be cleared", tt.name)
+			}
+		}
+	}
+}
+
+func TestRoute_JWKS(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		wantStatusCode int
+	}{
+		{
+			name:           "Valid JWKS request",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Invalid method",
+			method:         http.MethodPost,
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	privateKey, err := auth.LoadECDSAPrivateKey("validKey.pem")
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	keyring, err := auth.NewKeyring(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, JWKSRouteAPI, nil)
+		rr := httptest.NewRecorder()
+
+		r := &Route{
+			Keyring:   keyring,
+			validator: structValidator.New(),
+		}
+		r.JWKS(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+			continue
+		}
+		if tt.wantStatusCode != http.StatusOK {
+			continue
+		}
+
+		if got := rr.Header().Get(CacheControl); got != JWKSCacheControl {
+			t.Errorf("%s: got Cache-Control %q, want %q", tt.name, got, JWKSCacheControl)
+		}
+
+		jwks := &auth.JWKSet{}
+		if err := json.NewDecoder(rr.Body).Decode(jwks); err != nil {
+			t.Fatalf("%s: failed to decode JWK set: %v", tt.name, err)
+		}
+		if len(jwks.Keys) != 1 {
+			t.Fatalf("%s: expected 1 key, got %d", tt.name, len(jwks.Keys))
+		}
+		wantKid, _ := auth.KeyID(&privateKey.PublicKey)
+		if jwks.Keys[0].Kid != wantKid {
+			t.Errorf("%s: got kid %s, want %s", tt.name, jwks.Keys[0].Kid, wantKid)
+		}
+	}
+}
+
+func TestRoute_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		wantStatusCode int
+	}{
+		{
+			name:           "Authenticated caller",
+			claims:         &auth.CustomClaims{UserID: "testuser"},
+			wantStatusCode: http.StatusNotImplemented,
+		},
+		{
+			name:           "Unauthenticated caller",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, CreateRouteAPI, nil)
+		if tt.claims != nil {
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims))
+		}
+		rr := httptest.NewRecorder()
+
+		r := &Route{validator: structValidator.New()}
+		r.Create(rr, req)
+		if rr.Code != tt.wantStatusCode {
+			t.Errorf("%s: got status %d, want %d", tt.name, rr.Code, tt.wantStatusCode)
+		}
+	}
+}
+
+func TestRoute_SetSessionCookies(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookie       config.CookieConfig
+		tokenConfig  auth.TokenConfig
+		wantSameSite http.SameSite
+		wantMaxAge   int
+	}{
+		{
+			name:         "Defaults",
+			wantSameSite: 0,
+			wantMaxAge:   int(auth.DefaultTokenLifetime.Seconds()),
+		},
+		{
+			name:         "Configured attributes",
+			cookie:       config.CookieConfig{Domain: "example.com", Secure: true, SameSite: "strict"},
+			tokenConfig:  auth.TokenConfig{Lifetime: 5 * time.Minute},
+			wantSameSite: http.SameSiteStrictMode,
+			wantMaxAge:   300,
+		},
+		{
+			name:         "Explicit max age",
+			cookie:       config.CookieConfig{SameSite: "lax", MaxAge: time.Hour},
+			wantSameSite: http.SameSiteLaxMode,
+			wantMaxAge:   3600,
+		},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		r := &Route{
+			UserService: &userservice.UserService{},
+			TokenConfig: tt.tokenConfig,
+			Cookie:      tt.cookie,
+		}
+		r.setSessionCookies(rr, "session", "refresh")
+
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Domain != tt.cookie.Domain || cookie.Secure != tt.cookie.Secure || !cookie.HttpOnly {
+				t.Errorf("%s: unexpected attributes on cookie %s: %+v", tt.name, cookie.Name, cookie)
+			}
+			if cookie.SameSite != tt.wantSameSite {
+				t.Errorf("%s: got SameSite %v on cookie %s, want %v", tt.name, cookie.SameSite, cookie.Name, tt.wantSameSite)
+			}
+			if cookie.Name == SessionCookieName && cookie.MaxAge != tt.wantMaxAge {
+				t.Errorf("%s: got session cookie max age %d, want %d", tt.name, cookie.MaxAge, tt.wantMaxAge)
+			}
+		}
+	}
+}
+
 // HashString creates a bcrypt hash of the input string
 func HashString(input string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(input), bcrypt.DefaultCost)
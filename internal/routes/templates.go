@@ -0,0 +1,34 @@
+package routes
+
+import "html/template"
+
+// authorizeLoginTemplate is the sign-in form shown by the authorization endpoint.
+// The authorization request is carried in hidden fields so it survives the POST.
+// Users with a second factor are shown it again with the MFA challenge token
+// and a field for a TOTP or recovery code in place of their credentials.
+var authorizeLoginTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<h1>Sign in to {{.ClientName}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="POST" action="{{.Action}}">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}{{if .MFAToken}}<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label>Verification or recovery code <input name="code" autocomplete="one-time-code" required></label>
+{{else}}<label>Username <input name="username" autocomplete="username" required></label>
+<label>Password <input name="password" type="password" autocomplete="current-password" required></label>
+{{end}}<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+// authorizeLoginPage is the data rendered by authorizeLoginTemplate.
+type authorizeLoginPage struct {
+	ClientName string
+	Action     string
+	Error      string
+	MFAToken   string
+	Params     map[string]string
+}
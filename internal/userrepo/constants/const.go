@@ -1,5 +1,7 @@
 package constants
 
 const (
-	UsersCollection = "users"
+	UsersCollection               = "users"
+	RefreshTokensCollection       = "refresh_tokens"
+	PasswordResetTokensCollection = "password_reset_tokens"
 )
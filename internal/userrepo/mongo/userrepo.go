@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -50,7 +51,7 @@ func (r *MongoUserRepository) AddUser(ctx context.Context, user models.User) (st
 	insertedID, err := r.dbClient.InsertOne(ctx, constants.UsersCollection, usermap)
 	if err != nil {
 		if strings.Contains(err.Error(), DuplicateKeyErrorCode) { // MongoDB specific duplicate key error check
-			return "", fmt.Errorf("username '%s' already exists", user.Username)
+			return "", fmt.Errorf("username '%s' or email '%s' already exists", user.Username, user.Email)
 		}
 		return "", fmt.Errorf("failed to add user to MongoDB: %w", err)
 	}
@@ -69,7 +70,7 @@ func (r *MongoUserRepository) GetUserByUsername(ctx context.Context, username st
 	err := r.dbClient.FindOne(ctx, constants.UsersCollection, filter, &user)
 	if err != nil {
 		// If FindOne returns non-nil error, it's a database issue. If no document, it returns nil error.
-		if err.Error() == mongosdk.ErrNoDocuments.Error() { // Check for specific no documents error if FindOne doesn't convert it to nil
+		if errors.Is(err, mongosdk.ErrNoDocuments) { // FindOne wraps the driver's no documents error
 			return nil, nil // User not found
 		}
 		return nil, fmt.Errorf("failed to get user by username from MongoDB: %w", err)
@@ -78,14 +79,244 @@ func (r *MongoUserRepository) GetUserByUsername(ctx context.Context, username st
 	return &user, nil
 }
 
-// EnsureIndices creates a unique index for username in MongoDB.
+// GetUserByEmail fetches a user by email address, returns nil if not found.
+func (r *MongoUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	filter := map[string]any{"email": email}
+	err := r.dbClient.FindOne(ctx, constants.UsersCollection, filter, &user)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to get user by email from MongoDB: %w", err)
+	}
+
+	return &user, nil
+}
+
+// UpdateUser sets the given fields of a user in MongoDB.
+func (r *MongoUserRepository) UpdateUser(ctx context.Context, username string, fields map[string]interface{}) error {
+	filter := map[string]any{"username": username}
+	update := map[string]any{"$set": fields}
+	if _, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update); err != nil {
+		return fmt.Errorf("failed to update user in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// SetTOTPLastCounter replaces the last accepted TOTP time step if it is unchanged.
+func (r *MongoUserRepository) SetTOTPLastCounter(ctx context.Context, username string, previous, counter int64) (bool, error) {
+	filter := map[string]any{"username": username, "totp_last_counter": previous}
+	update := map[string]any{"$set": map[string]any{"totp_last_counter": counter}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update TOTP counter in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// RecordLoginFailure updates the failed login count and lockout of a user if the count is unchanged.
+func (r *MongoUserRepository) RecordLoginFailure(ctx context.Context, username string, previous, failures, lockedUntil int64) (bool, error) {
+	filter := map[string]any{"username": username, "failed_logins": previous}
+	update := map[string]any{"$set": map[string]any{"failed_logins": failures, "locked_until": lockedUntil}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to record login failure in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// SetUserRoles replaces the roles of a user if they are unchanged.
+func (r *MongoUserRepository) SetUserRoles(ctx context.Context, username, previous, roles string) (bool, error) {
+	filter := map[string]any{"username": username, "roles": previous}
+	update := map[string]any{"$set": map[string]any{"roles": roles}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update roles in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// SetRecoveryCodes replaces the recovery code hashes of a user if they are unchanged.
+func (r *MongoUserRepository) SetRecoveryCodes(ctx context.Context, username, previous, codes string) (bool, error) {
+	filter := map[string]any{"username": username, "recovery_codes": previous}
+	update := map[string]any{"$set": map[string]any{"recovery_codes": codes}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update recovery codes in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// AddRefreshToken saves a new refresh token to MongoDB via DBClient.
+func (r *MongoUserRepository) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	tokenMap := make(map[string]interface{})
+	err := mapstructure.Decode(token, &tokenMap)
+	if err != nil {
+		return fmt.Errorf("failed to decode refresh token model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.RefreshTokensCollection, tokenMap); err != nil {
+		return fmt.Errorf("failed to add refresh token to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshToken fetches a refresh token by hash, returns nil if not found.
+func (r *MongoUserRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	filter := map[string]any{"token_hash": tokenHash}
+	err := r.dbClient.FindOne(ctx, constants.RefreshTokensCollection, filter, &token)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // Token not found
+		}
+		return nil, fmt.Errorf("failed to get refresh token from MongoDB: %w", err)
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed flags the refresh token as used if it was not already.
+func (r *MongoUserRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	filter := map[string]any{"token_hash": tokenHash, "used": false}
+	update := map[string]any{"$set": map[string]any{"used": true}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.RefreshTokensCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// RevokeRefreshTokenFamily deletes all refresh tokens of a family.
+func (r *MongoUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	filter := map[string]any{"family_id": familyID}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.RefreshTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens deletes all refresh tokens of a user.
+func (r *MongoUserRepository) RevokeUserRefreshTokens(ctx context.Context, username string) error {
+	filter := map[string]any{"username": username}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.RefreshTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// AddPasswordResetToken saves a new password reset token to MongoDB via DBClient.
+func (r *MongoUserRepository) AddPasswordResetToken(ctx context.Context, token models.PasswordResetToken) error {
+	tokenMap := make(map[string]interface{})
+	if err := mapstructure.Decode(token, &tokenMap); err != nil {
+		return fmt.Errorf("failed to decode password reset token model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.PasswordResetTokensCollection, tokenMap); err != nil {
+		return fmt.Errorf("failed to add password reset token to MongoDB: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordResetToken fetches a password reset token by hash, returns nil if not found.
+func (r *MongoUserRepository) GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	filter := map[string]any{"token_hash": tokenHash}
+	err := r.dbClient.FindOne(ctx, constants.PasswordResetTokensCollection, filter, &token)
+	if err != nil {
+		if errors.Is(err, mongosdk.ErrNoDocuments) {
+			return nil, nil // Token not found
+		}
+		return nil, fmt.Errorf("failed to get password reset token from MongoDB: %w", err)
+	}
+
+	return &token, nil
+}
+
+// MarkPasswordResetTokenUsed flags the password reset token as used if it was not already.
+func (r *MongoUserRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	filter := map[string]any{"token_hash": tokenHash, "used": false}
+	update := map[string]any{"$set": map[string]any{"used": true}}
+	modified, err := r.dbClient.UpdateOne(ctx, constants.PasswordResetTokensCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark password reset token used in MongoDB: %w", err)
+	}
+
+	return modified == 1, nil
+}
+
+// DeletePasswordResetTokens deletes all password reset tokens of a user.
+func (r *MongoUserRepository) DeletePasswordResetTokens(ctx context.Context, username string) error {
+	filter := map[string]any{"username": username}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.PasswordResetTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens in MongoDB: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndices creates unique indices for username and email in MongoDB,
+// plus the indices used to look up refresh and password reset tokens.
 func (r *MongoUserRepository) EnsureIndices(ctx context.Context) error {
 	indexModel := mongosdk.IndexModel{
 		Keys:    bson.M{"username": 1},
 		Options: options.Index().SetUnique(true),
 	}
 	// Call MongoDB-specific method for index creation.
-	return r.dbClient.EnsureSchema(ctx, constants.UsersCollection, indexModel)
+	if err := r.dbClient.EnsureSchema(ctx, constants.UsersCollection, indexModel); err != nil {
+		return err
+	}
+
+	// users created before emails were introduced have none, so only
+	// non-empty addresses have to be unique
+	emailIndex := mongosdk.IndexModel{
+		Keys: bson.M{"email": 1},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email": bson.M{"$gt": ""}}),
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.UsersCollection, emailIndex); err != nil {
+		return err
+	}
+
+	refreshTokenIndices := []mongosdk.IndexModel{
+		{
+			Keys:    bson.M{"token_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"family_id": 1},
+		},
+		{
+			Keys: bson.M{"username": 1},
+		},
+	}
+	for _, model := range refreshTokenIndices {
+		if err := r.dbClient.EnsureSchema(ctx, constants.RefreshTokensCollection, model); err != nil {
+			return err
+		}
+	}
+
+	passwordResetTokenIndices := []mongosdk.IndexModel{
+		{
+			Keys:    bson.M{"token_hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"username": 1},
+		},
+	}
+	for _, model := range passwordResetTokenIndices {
+		if err := r.dbClient.EnsureSchema(ctx, constants.PasswordResetTokensCollection, model); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Close disconnects the MongoDB client.
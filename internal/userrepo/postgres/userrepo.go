@@ -22,9 +22,46 @@ var ensureSchemaSQL = `
 		CREATE TABLE IF NOT EXISTS users (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			username TEXT NOT NULL UNIQUE,
-			password TEXT NOT NULL
+			hashed_password TEXT NOT NULL
 		);
 		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_codes TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE email <> '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_logins BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS pepper_id TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS roles TEXT NOT NULL DEFAULT '';
+	`
+
+var ensureRefreshTokensSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			token_hash TEXT NOT NULL UNIQUE,
+			family_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			used BOOLEAN NOT NULL DEFAULT FALSE,
+			amr TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+		ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS amr TEXT NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS idx_refresh_tokens_username ON refresh_tokens (username);
+	`
+
+var ensurePasswordResetTokensSchemaSQL = `
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			token_hash TEXT NOT NULL UNIQUE,
+			username TEXT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			used BOOLEAN NOT NULL DEFAULT FALSE
+		);
+		CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_username ON password_reset_tokens (username);
 	`
 
 
@@ -59,7 +96,7 @@ func (r *PostgresUserRepository) AddUser(ctx context.Context, user models.User)
 	if err != nil {
 		// PostgreSQL specific duplicate key error check (example for `pq` driver)
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == Unique_ErrorCode { // 23505 is unique_violation
-			return "", fmt.Errorf("username '%s' already exists", user.Username)
+			return "", fmt.Errorf("username '%s' or email '%s' already exists", user.Username, user.Email)
 		}
 		return "", fmt.Errorf("failed to add user to PostgreSQL: %w", err)
 	}
@@ -82,9 +119,196 @@ func (r *PostgresUserRepository) GetUserByUsername(ctx context.Context, username
 	return &user, nil
 }
 
-// EnsureIndices creates a table and unique index and returns an error if the table creation fails.
+// GetUserByEmail retrieves a user by email address and returns nil if the user is not found.
+func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	filter := map[string]interface{}{"email": email}
+	if err := r.dbClient.FindOne(ctx, constants.UsersCollection, filter, &user); err != nil {
+		return nil, fmt.Errorf("failed to get user by email from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if user.Username == "" {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// UpdateUser sets the given columns of a user.
+func (r *PostgresUserRepository) UpdateUser(ctx context.Context, username string, fields map[string]interface{}) error {
+	filter := map[string]interface{}{"username": username}
+	if _, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, fields); err != nil {
+		return fmt.Errorf("failed to update user in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// SetTOTPLastCounter replaces the last accepted TOTP time step if it is unchanged.
+func (r *PostgresUserRepository) SetTOTPLastCounter(ctx context.Context, username string, previous, counter int64) (bool, error) {
+	filter := map[string]interface{}{"username": username, "totp_last_counter": previous}
+	update := map[string]interface{}{"totp_last_counter": counter}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update TOTP counter in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// RecordLoginFailure updates the failed login count and lockout of a user if the count is unchanged.
+func (r *PostgresUserRepository) RecordLoginFailure(ctx context.Context, username string, previous, failures, lockedUntil int64) (bool, error) {
+	filter := map[string]interface{}{"username": username, "failed_logins": previous}
+	update := map[string]interface{}{"failed_logins": failures, "locked_until": lockedUntil}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to record login failure in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// SetUserRoles replaces the roles of a user if they are unchanged.
+func (r *PostgresUserRepository) SetUserRoles(ctx context.Context, username, previous, roles string) (bool, error) {
+	filter := map[string]interface{}{"username": username, "roles": previous}
+	update := map[string]interface{}{"roles": roles}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update roles in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// SetRecoveryCodes replaces the recovery code hashes of a user if they are unchanged.
+func (r *PostgresUserRepository) SetRecoveryCodes(ctx context.Context, username, previous, codes string) (bool, error) {
+	filter := map[string]interface{}{"username": username, "recovery_codes": previous}
+	update := map[string]interface{}{"recovery_codes": codes}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.UsersCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update recovery codes in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// AddRefreshToken inserts a refresh token.
+func (r *PostgresUserRepository) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	doc := make(map[string]interface{})
+	err := mapstructure.Decode(token, &doc)
+	if err != nil {
+		return fmt.Errorf("failed to decode refresh token model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.RefreshTokensCollection, doc); err != nil {
+		return fmt.Errorf("failed to add refresh token to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by hash and returns nil if it is not found.
+func (r *PostgresUserRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	filter := map[string]interface{}{"token_hash": tokenHash}
+	err := r.dbClient.FindOne(ctx, constants.RefreshTokensCollection, filter, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if token.TokenHash == "" {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed flags the refresh token as used if it was not already.
+func (r *PostgresUserRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	filter := map[string]interface{}{"token_hash": tokenHash, "used": false}
+	update := map[string]interface{}{"used": true}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.RefreshTokensCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// RevokeRefreshTokenFamily deletes all refresh tokens of a family.
+func (r *PostgresUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	filter := map[string]interface{}{"family_id": familyID}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.RefreshTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens deletes all refresh tokens of a user.
+func (r *PostgresUserRepository) RevokeUserRefreshTokens(ctx context.Context, username string) error {
+	filter := map[string]interface{}{"username": username}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.RefreshTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of user in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// AddPasswordResetToken inserts a password reset token.
+func (r *PostgresUserRepository) AddPasswordResetToken(ctx context.Context, token models.PasswordResetToken) error {
+	doc := make(map[string]interface{})
+	if err := mapstructure.Decode(token, &doc); err != nil {
+		return fmt.Errorf("failed to decode password reset token model: %w", err)
+	}
+
+	if _, err := r.dbClient.InsertOne(ctx, constants.PasswordResetTokensCollection, doc); err != nil {
+		return fmt.Errorf("failed to add password reset token to PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordResetToken retrieves a password reset token by hash and returns nil if it is not found.
+func (r *PostgresUserRepository) GetPasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	filter := map[string]interface{}{"token_hash": tokenHash}
+	if err := r.dbClient.FindOne(ctx, constants.PasswordResetTokensCollection, filter, &token); err != nil {
+		return nil, fmt.Errorf("failed to get password reset token from PostgreSQL: %w", err)
+	}
+
+	// FindOne leaves the struct empty when no row matches
+	if token.TokenHash == "" {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// MarkPasswordResetTokenUsed flags the password reset token as used if it was not already.
+func (r *PostgresUserRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	filter := map[string]interface{}{"token_hash": tokenHash, "used": false}
+	update := map[string]interface{}{"used": true}
+	updated, err := r.dbClient.UpdateOne(ctx, constants.PasswordResetTokensCollection, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark password reset token used in PostgreSQL: %w", err)
+	}
+
+	return updated == 1, nil
+}
+
+// DeletePasswordResetTokens deletes all password reset tokens of a user.
+func (r *PostgresUserRepository) DeletePasswordResetTokens(ctx context.Context, username string) error {
+	filter := map[string]interface{}{"username": username}
+	if _, err := r.dbClient.DeleteMany(ctx, constants.PasswordResetTokensCollection, filter); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens in PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndices creates the tables and unique indices and returns an error if the table creation fails.
 func (r *PostgresUserRepository) EnsureIndices(ctx context.Context) error {
-	return r.dbClient.EnsureSchema(ctx, constants.UsersCollection, ensureSchemaSQL)
+	if err := r.dbClient.EnsureSchema(ctx, constants.UsersCollection, ensureSchemaSQL); err != nil {
+		return err
+	}
+	if err := r.dbClient.EnsureSchema(ctx, constants.RefreshTokensCollection, ensureRefreshTokensSchemaSQL); err != nil {
+		return err
+	}
+	return r.dbClient.EnsureSchema(ctx, constants.PasswordResetTokensCollection, ensurePasswordResetTokensSchemaSQL)
 }
 
 // Close closes database connection and returns an error if the disconnection fails.
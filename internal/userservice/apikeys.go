@@ -0,0 +1,154 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// DefaultAPIKeyTTL is the lifetime of API keys when none is configured or requested.
+	DefaultAPIKeyTTL = 30 * 24 * time.Hour
+	// DefaultAPIKeyMaxTTL bounds the lifetime of API keys when no maximum is configured.
+	DefaultAPIKeyMaxTTL = 365 * 24 * time.Hour
+
+	// apiKeyLastUsedInterval limits how often the last use of a key is written.
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var (
+	// ErrAPIKeysNotConfigured is returned when no API key repository is configured.
+	ErrAPIKeysNotConfigured = errors.New("api keys are not configured")
+	// ErrInvalidAPIKey is returned for unknown and expired API keys.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not have.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrAPIKeyScope is returned when a key asks for a scope the user does not hold.
+	ErrAPIKeyScope = errors.New("scope is not held by the user")
+	// ErrAPIKeyTTL is returned when a key asks for a lifetime above the maximum.
+	ErrAPIKeyTTL = errors.New("api key lifetime exceeds the maximum")
+)
+
+// CreateAPIKey creates an API key of username limited to scopes, which the
+// user must hold, valid for ttl or the default lifetime when ttl is 0. The key
+// is returned once; only its hash is stored.
+func (s *UserService) CreateAPIKey(ctx context.Context, username, name string, scopes []string, ttl time.Duration) (string, *models.APIKey, error) {
+	if s.APIKeys == nil {
+		return "", nil, ErrAPIKeysNotConfigured
+	}
+
+	maxTTL := s.APIKeyMaxTTL
+	if maxTTL <= 0 {
+		maxTTL = DefaultAPIKeyMaxTTL
+	}
+	if ttl <= 0 {
+		ttl = s.APIKeyTTL
+	}
+	if ttl <= 0 {
+		ttl = min(DefaultAPIKeyTTL, maxTTL)
+	}
+	if ttl > maxTTL {
+		return "", nil, fmt.Errorf("%w of %s", ErrAPIKeyTTL, maxTTL)
+	}
+
+	grants, err := s.UserGrants(ctx, username)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(grants.Scopes, scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrAPIKeyScope, scope)
+		}
+	}
+
+	key, keyHash, err := auth.NewAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	apiKey := models.APIKey{
+		KeyID:     uuid.NewString(),
+		KeyHash:   keyHash,
+		Username:  username,
+		Name:      name,
+		Scopes:    strings.Join(slices.Compact(slices.Sorted(slices.Values(scopes))), " "),
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	if err := s.APIKeys.AddAPIKey(ctx, apiKey); err != nil {
+		return "", nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+	return key, &apiKey, nil
+}
+
+// ListAPIKeys returns the API keys of username, expired ones included.
+func (s *UserService) ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	if s.APIKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	return s.APIKeys.ListAPIKeys(ctx, username)
+}
+
+// RevokeAPIKey deletes the API key keyID of username.
+func (s *UserService) RevokeAPIKey(ctx context.Context, username, keyID string) error {
+	if s.APIKeys == nil {
+		return ErrAPIKeysNotConfigured
+	}
+	deleted, err := s.APIKeys.DeleteAPIKey(ctx, username, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey verifies key and returns the claims of the request it
+// authenticates. The scopes of the key are narrowed to those its user still
+// holds, and the permissions of the user to the scopes of the key.
+func (s *UserService) AuthenticateAPIKey(ctx context.Context, key string) (*auth.CustomClaims, error) {
+	if s.APIKeys == nil || !auth.IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	stored, err := s.APIKeys.GetAPIKey(ctx, auth.HashOpaqueToken(key))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving api key: %w", err)
+	}
+	now := time.Now()
+	if stored == nil || now.Unix() >= stored.ExpiresAt {
+		return nil, ErrInvalidAPIKey
+	}
+
+	grants, err := s.UserGrants(ctx, stored.Username)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyScopes := stored.ScopeList()
+	grants.Scopes = slices.DeleteFunc(grants.Scopes, func(scope string) bool {
+		return !slices.Contains(keyScopes, scope)
+	})
+	grants.Permissions = slices.DeleteFunc(grants.Permissions, func(permission string) bool {
+		return !slices.Contains(keyScopes, permission)
+	})
+
+	if now.Sub(time.Unix(stored.LastUsedAt, 0)) >= apiKeyLastUsedInterval {
+		if err := s.APIKeys.SetAPIKeyLastUsed(ctx, stored.KeyID, now.Unix()); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.APIKeyClaims(stored.Username, stored.KeyID, grants, time.Unix(stored.CreatedAt, 0), time.Unix(stored.ExpiresAt, 0)), nil
+}
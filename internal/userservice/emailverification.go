@@ -0,0 +1,72 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// EmailVerificationSubject is the subject of email verification emails.
+	EmailVerificationSubject = "Verify your email address"
+)
+
+var (
+	// ErrInvalidVerificationToken is returned when a verification link does not
+	// match the current email address of its user.
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	// ErrEmailVerificationNotConfigured is returned when no mailer or verification URL is configured.
+	ErrEmailVerificationNotConfigured = errors.New("email verification is not configured")
+)
+
+// NormalizeEmail returns email trimmed and lower-cased, the form in which
+// addresses are stored and compared.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SendEmailVerification mails a link carrying token, which proves ownership
+// of email, to that address.
+func (s *UserService) SendEmailVerification(ctx context.Context, email, token string) error {
+	if s.Mailer == nil || s.EmailVerificationURL == "" {
+		return ErrEmailVerificationNotConfigured
+	}
+
+	link, err := tokenLink(s.EmailVerificationURL, token)
+	if err != nil {
+		return err
+	}
+	message := models.MailMessage{
+		To:      email,
+		Subject: EmailVerificationSubject,
+		Body: fmt.Sprintf("Open the link below to verify your email address:\n\n%s\n\n"+
+			"If you did not sign up, you can ignore this email.", link),
+	}
+	if err := s.Mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email of username as verified if it is still email,
+// the address the verification link was sent to.
+func (s *UserService) VerifyEmail(ctx context.Context, username, email string) error {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" || user.Email != NormalizeEmail(email) {
+		return ErrInvalidVerificationToken
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.UserRepo.UpdateUser(ctx, username, map[string]interface{}{"email_verified": true}); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// maxLoginFailureUpdates bounds the retries of recording a failed login
+	// that races with other logins of the same user.
+	maxLoginFailureUpdates = 3
+	// maxBackoffShift keeps the doubling of the backoff delay from overflowing.
+	maxBackoffShift = 30
+)
+
+var (
+	// ErrAccountLocked is matched by AccountLockedError.
+	ErrAccountLocked = errors.New("account is temporarily locked")
+	// ErrLockoutStarted is wrapped into the error of the failed login that
+	// locked the account.
+	ErrLockoutStarted = errors.New("too many failed logins, account locked")
+)
+
+// AccountLockedError is returned for password logins attempted while the
+// account is in a backoff delay or locked out.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrAccountLocked, e.Until.UTC().Format(time.RFC3339))
+}
+
+// Is lets errors.Is match AccountLockedError against ErrAccountLocked.
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LockoutPolicy throttles password guessing per account. After FreeAttempts
+// consecutive failures each further failure refuses logins for BaseDelay,
+// doubling up to MaxDelay; after MaxAttempts failures logins are refused for
+// Duration. A successful login resets the count. The zero policy disables
+// both backoff and lockout.
+type LockoutPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxAttempts  int
+	Duration     time.Duration
+}
+
+// Enabled reports whether failed logins are tracked at all.
+func (p LockoutPolicy) Enabled() bool {
+	return p.BaseDelay > 0 || (p.MaxAttempts > 0 && p.Duration > 0)
+}
+
+// Delay returns how long logins are refused after the given number of
+// consecutive failures, and whether that delay is a full lockout.
+func (p LockoutPolicy) Delay(failures int64) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && p.Duration > 0 && failures >= int64(p.MaxAttempts) {
+		return p.Duration, true
+	}
+	if p.BaseDelay <= 0 || failures <= int64(p.FreeAttempts) {
+		return 0, false
+	}
+
+	shift := failures - int64(p.FreeAttempts) - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	delay := p.BaseDelay << shift
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, false
+}
+
+// UnlockUser clears the failed logins and any lockout of username.
+func (s *UserService) UnlockUser(ctx context.Context, username string) error {
+	if _, err := s.getExistingUser(ctx, username); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{"failed_logins": int64(0), "locked_until": int64(0)}
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+	return nil
+}
+
+// checkLockout returns an AccountLockedError if logins of user are refused now.
+func (s *UserService) checkLockout(user *models.User, now time.Time) error {
+	if !s.Lockout.Enabled() || user.LockedUntil <= now.Unix() {
+		return nil
+	}
+	return &AccountLockedError{Until: time.Unix(user.LockedUntil, 0)}
+}
+
+// recordLoginFailure counts a failed login of user and refuses further logins
+// for the delay of the policy. It reports whether the account got locked out.
+func (s *UserService) recordLoginFailure(ctx context.Context, user *models.User, now time.Time) (bool, error) {
+	if !s.Lockout.Enabled() {
+		return false, nil
+	}
+
+	for range maxLoginFailureUpdates {
+		failures := user.FailedLogins + 1
+		delay, lockout := s.Lockout.Delay(failures)
+
+		lockedUntil := int64(0)
+		if delay > 0 {
+			// round up so the delay is never cut short by the seconds resolution
+			until := now.Add(delay)
+			lockedUntil = until.Unix()
+			if until.Nanosecond() > 0 {
+				lockedUntil++
+			}
+		}
+
+		// a concurrent login may have failed since the user was read
+		recorded, err := s.UserRepo.RecordLoginFailure(ctx, user.Username, user.FailedLogins, failures, lockedUntil)
+		if err != nil {
+			return false, fmt.Errorf("failed to record login failure: %w", err)
+		}
+		if recorded {
+			return lockout, nil
+		}
+
+		if user, err = s.getExistingUser(ctx, user.Username); err != nil {
+			return false, err
+		}
+	}
+	return false, fmt.Errorf("failed to record login failure: too many concurrent updates")
+}
+
+// resetLoginFailures clears the failed login count of user after a successful login.
+func (s *UserService) resetLoginFailures(ctx context.Context, user *models.User) error {
+	if user.FailedLogins == 0 && user.LockedUntil == 0 {
+		return nil
+	}
+
+	fields := map[string]interface{}{"failed_logins": int64(0), "locked_until": int64(0)}
+	if err := s.UserRepo.UpdateUser(ctx, user.Username, fields); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// MagicLinkSubject is the subject of login link emails.
+	MagicLinkSubject = "Your login link"
+)
+
+// MagicLinkConfigured reports whether login links can be sent.
+func (s *UserService) MagicLinkConfigured() bool {
+	return s.Mailer != nil && s.MagicLinkURL != ""
+}
+
+// GetUserByEmail returns the user with the given email address, or nil if there is none.
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	email = NormalizeEmail(email)
+	if email == "" {
+		return nil, nil
+	}
+
+	user, err := s.UserRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	return user, nil
+}
+
+// SendMagicLink mails a login link carrying token, valid for ttl, to email.
+func (s *UserService) SendMagicLink(ctx context.Context, email, token string, ttl time.Duration) error {
+	if !s.MagicLinkConfigured() {
+		return fmt.Errorf("magic link login is not configured")
+	}
+
+	link, err := tokenLink(s.MagicLinkURL, token)
+	if err != nil {
+		return err
+	}
+	message := models.MailMessage{
+		To:      email,
+		Subject: MagicLinkSubject,
+		Body: fmt.Sprintf("Open the link below within %v to log in. It can only be used once:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.", ttl, link),
+	}
+	if err := s.Mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("failed to send login link email: %w", err)
+	}
+	return nil
+}
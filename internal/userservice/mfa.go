@@ -0,0 +1,219 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// Second factors reported by MFAMethods
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+
+	// DefaultTOTPIssuer labels enrolled accounts in authenticator apps when no issuer is configured.
+	DefaultTOTPIssuer = "sasuke"
+)
+
+var (
+	// ErrInvalidTOTPCode is returned for wrong, expired or already used TOTP codes.
+	ErrInvalidTOTPCode = errors.New("invalid TOTP code")
+	// ErrTOTPAlreadyEnabled is returned when enrolling a user whose TOTP is already confirmed.
+	ErrTOTPAlreadyEnabled = errors.New("TOTP is already enabled")
+	// ErrTOTPNotEnrolled is returned when confirming or disabling TOTP that was never set up.
+	ErrTOTPNotEnrolled = errors.New("TOTP is not enrolled")
+	// ErrMFANotConfigured is returned when no secret encryption key is configured.
+	ErrMFANotConfigured = errors.New("multi-factor authentication is not configured")
+	// ErrUserNotFound is returned when no user has the given username.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// TOTPEnrollment holds what a user needs to add an account to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// BeginTOTPEnrollment generates and stores a new, not yet enabled, TOTP secret
+// for username. Enrollment takes effect once ConfirmTOTPEnrollment succeeds.
+func (s *UserService) BeginTOTPEnrollment(ctx context.Context, username string) (*TOTPEnrollment, error) {
+	if s.SecretCipher == nil {
+		return nil, ErrMFANotConfigured
+	}
+
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.SecretCipher.Encrypt(secret, username)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{"totp_secret": encrypted, "totp_enabled": false}
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	issuer := s.TOTPIssuer
+	if issuer == "" {
+		issuer = DefaultTOTPIssuer
+	}
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPProvisioningURI(issuer, username, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables TOTP for username once code proves the
+// authenticator app holds the secret from BeginTOTPEnrollment. It returns the
+// first batch of recovery codes.
+func (s *UserService) ConfirmTOTPEnrollment(ctx context.Context, username, code string) ([]string, error) {
+	user, secret, err := s.totpSecret(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	counter, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	fields := map[string]interface{}{"totp_enabled": true, "totp_last_counter": counter}
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return nil, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	return s.GenerateRecoveryCodes(ctx, username)
+}
+
+// VerifySecondFactor checks a TOTP code or, when recoveryCode is set, burns a
+// recovery code of username. Wrong codes count as failed logins of the
+// lockout policy, so a known password does not allow guessing codes without
+// limit; while the account is locked an AccountLockedError is returned
+// without checking the code.
+func (s *UserService) VerifySecondFactor(ctx context.Context, username, code, recoveryCode string) error {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.checkLockout(user, now); err != nil {
+		return err
+	}
+
+	if recoveryCode != "" {
+		err = s.UseRecoveryCode(ctx, username, recoveryCode)
+	} else {
+		err = s.VerifyTOTP(ctx, username, code)
+	}
+	if errors.Is(err, ErrInvalidTOTPCode) || errors.Is(err, ErrInvalidRecoveryCode) {
+		lockedOut, recordErr := s.recordLoginFailure(ctx, user, now)
+		if recordErr != nil {
+			return recordErr
+		}
+		if lockedOut {
+			return fmt.Errorf("%w: %w", err, ErrLockoutStarted)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return s.resetLoginFailures(ctx, user)
+}
+
+// VerifyTOTP checks a TOTP code of a user with TOTP enabled. Each time step is
+// accepted at most once, so an observed code cannot be replayed.
+func (s *UserService) VerifyTOTP(ctx context.Context, username, code string) error {
+	user, secret, err := s.totpSecret(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	counter, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok || counter <= user.TOTPLastCounter {
+		return ErrInvalidTOTPCode
+	}
+
+	// a concurrent login may have used a code since the user was read
+	swapped, err := s.UserRepo.SetTOTPLastCounter(ctx, username, user.TOTPLastCounter, counter)
+	if err != nil {
+		return fmt.Errorf("failed to record TOTP use: %w", err)
+	}
+	if !swapped {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// DisableTOTP removes the TOTP secret and recovery codes of username. Callers
+// must first check a current TOTP or recovery code.
+func (s *UserService) DisableTOTP(ctx context.Context, username string) error {
+	fields := map[string]interface{}{"totp_secret": "", "totp_enabled": false, "totp_last_counter": int64(0), "recovery_codes": ""}
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	return nil
+}
+
+// MFAMethods returns the second factors enabled for user, empty if none.
+func MFAMethods(user *models.User) []string {
+	var methods []string
+	if user != nil && user.TOTPEnabled {
+		methods = append(methods, MFAMethodTOTP)
+		if user.RecoveryCodes != "" {
+			methods = append(methods, MFAMethodRecoveryCode)
+		}
+	}
+	return methods
+}
+
+func (s *UserService) totpSecret(ctx context.Context, username string) (*models.User, string, error) {
+	if s.SecretCipher == nil {
+		return nil, "", ErrMFANotConfigured
+	}
+
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.TOTPSecret == "" {
+		return nil, "", ErrTOTPNotEnrolled
+	}
+
+	secret, err := s.SecretCipher.Decrypt(user.TOTPSecret, username)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, secret, nil
+}
+
+func (s *UserService) getExistingUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.UserRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	// the PostgreSQL repository returns an empty user when none matches
+	if user == nil || user.Username == "" {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,167 @@
+package userservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/webauthn"
+)
+
+var (
+	// ErrPasskeysNotConfigured is returned when no WebAuthn relying party is configured.
+	ErrPasskeysNotConfigured = errors.New("passkeys are not configured")
+	// ErrInvalidPasskey is returned for registration or login responses that fail verification.
+	ErrInvalidPasskey = errors.New("invalid passkey")
+	// ErrPasskeyAlreadyRegistered is returned when a credential ID is registered twice.
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
+)
+
+// PasskeyRegistration holds what a client needs to create a passkey for a user.
+type PasskeyRegistration struct {
+	Challenge  []byte
+	UserHandle []byte
+	// ExcludeCredentials are the user's existing credential IDs (base64url),
+	// so an authenticator does not register twice.
+	ExcludeCredentials []string
+}
+
+// PasskeyAssertion is an authentication ceremony response. UserHandle is
+// optional; when present it must belong to the credential's user.
+type PasskeyAssertion struct {
+	CredentialID      string // base64url
+	UserHandle        []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+// BeginPasskeyRegistration returns a new registration challenge for username.
+func (s *UserService) BeginPasskeyRegistration(ctx context.Context, username string) (*PasskeyRegistration, error) {
+	if s.RelyingParty == nil || s.Credentials == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	if _, err := s.getExistingUser(ctx, username); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.Credentials.ListCredentials(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	registration := &PasskeyRegistration{
+		Challenge:  challenge,
+		UserHandle: webauthn.UserHandle(username),
+	}
+	for _, credential := range credentials {
+		registration.ExcludeCredentials = append(registration.ExcludeCredentials, credential.CredentialID)
+	}
+	return registration, nil
+}
+
+// FinishPasskeyRegistration verifies the response to the registration
+// challenge of username and stores the new credential under name.
+func (s *UserService) FinishPasskeyRegistration(ctx context.Context, username, name string, challenge, clientDataJSON, attestationObject []byte, transports []string) (*models.WebAuthnCredential, error) {
+	if s.RelyingParty == nil || s.Credentials == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+
+	registration, err := s.RelyingParty.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(registration.CredentialID)
+	existing, err := s.Credentials.GetCredential(ctx, credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up passkey: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrPasskeyAlreadyRegistered
+	}
+
+	now := time.Now().Unix()
+	credential := models.WebAuthnCredential{
+		CredentialID:      credentialID,
+		Username:          username,
+		Name:              name,
+		PublicKey:         base64.StdEncoding.EncodeToString(registration.PublicKey),
+		SignCount:         int64(registration.SignCount),
+		AAGUID:            hex.EncodeToString(registration.AAGUID),
+		AttestationFormat: registration.AttestationFormat,
+		Transports:        strings.Join(transports, " "),
+		CreatedAt:         now,
+	}
+	if err := s.Credentials.AddCredential(ctx, credential); err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+	return &credential, nil
+}
+
+// BeginPasskeyLogin returns a new authentication challenge. Passkey logins
+// use discoverable credentials, so the user is not known until they finish.
+func (s *UserService) BeginPasskeyLogin() ([]byte, error) {
+	if s.RelyingParty == nil || s.Credentials == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	return webauthn.NewChallenge()
+}
+
+// FinishPasskeyLogin verifies an assertion for challenge and returns the user
+// of the credential and whether the authenticator verified them. The stored
+// sign count is advanced; a count that did not increase suggests a cloned
+// authenticator and fails the login.
+func (s *UserService) FinishPasskeyLogin(ctx context.Context, challenge []byte, assertion PasskeyAssertion) (string, bool, error) {
+	if s.RelyingParty == nil || s.Credentials == nil {
+		return "", false, ErrPasskeysNotConfigured
+	}
+
+	credential, err := s.Credentials.GetCredential(ctx, assertion.CredentialID)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up passkey: %w", err)
+	}
+	if credential == nil {
+		return "", false, fmt.Errorf("%w: unknown credential", ErrInvalidPasskey)
+	}
+	if assertion.UserHandle != nil && !bytes.Equal(assertion.UserHandle, webauthn.UserHandle(credential.Username)) {
+		return "", false, fmt.Errorf("%w: user handle does not match the credential", ErrInvalidPasskey)
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(credential.PublicKey)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid stored passkey public key: %w", err)
+	}
+	authData, err := s.RelyingParty.VerifyAssertion(challenge, publicKey, assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature)
+	if err != nil {
+		return "", false, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	// authenticators that do not count signatures always report zero
+	signCount := int64(authData.SignCount)
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return "", false, fmt.Errorf("%w: sign count did not increase", ErrInvalidPasskey)
+	}
+	updated, err := s.Credentials.UpdateSignCount(ctx, credential.CredentialID, credential.SignCount, signCount, time.Now().Unix())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to record passkey use: %w", err)
+	}
+	if !updated {
+		return "", false, fmt.Errorf("%w: sign count changed concurrently", ErrInvalidPasskey)
+	}
+
+	if _, err := s.getExistingUser(ctx, credential.Username); err != nil {
+		return "", false, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+	return credential.Username, authData.UserVerified(), nil
+}
@@ -0,0 +1,68 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/haguru/sasuke/internal/interfaces"
+	"github.com/haguru/sasuke/internal/models"
+	"github.com/haguru/sasuke/internal/passwordhash"
+)
+
+// hashPassword applies the current pepper to password and hashes it,
+// returning the hash and the ID of the pepper.
+func (s *UserService) hashPassword(password string) (string, string, error) {
+	pepperID := s.Peppers.CurrentID()
+	peppered, err := s.Peppers.Apply(pepperID, password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	hashedPassword, err := s.passwordHasher().Hash(peppered)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hashedPassword, pepperID, nil
+}
+
+// verifyPassword reports whether password is the password of user.
+func (s *UserService) verifyPassword(user *models.User, password string) (bool, error) {
+	peppered, err := s.Peppers.Apply(user.PepperID, password)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+	matched, err := s.passwordHasher().Verify(peppered, user.HashedPassword)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+	return matched, nil
+}
+
+// needsRehash reports whether the password hash of user is outdated.
+func (s *UserService) needsRehash(user *models.User) bool {
+	return user.PepperID != s.Peppers.CurrentID() || s.passwordHasher().NeedsRehash(user.HashedPassword)
+}
+
+// setPassword hashes password and stores it as the password of username,
+// together with any other fields.
+func (s *UserService) setPassword(ctx context.Context, username, password string, fields map[string]interface{}) error {
+	hashedPassword, pepperID, err := s.hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["hashed_password"] = hashedPassword
+	fields["pepper_id"] = pepperID
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+func (s *UserService) passwordHasher() interfaces.PasswordHasher {
+	if s.PasswordHasher != nil {
+		return s.PasswordHasher
+	}
+	return &passwordhash.BcryptHasher{Cost: passwordhash.DefaultBcryptCost}
+}
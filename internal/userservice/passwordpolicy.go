@@ -0,0 +1,49 @@
+package userservice
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/passwordpolicy"
+)
+
+// ErrPasswordPolicy is matched by PasswordPolicyError.
+var ErrPasswordPolicy = errors.New("password does not meet the password policy")
+
+// PasswordPolicyError is returned when a new password breaks the password
+// policy, listing every rule it breaks.
+type PasswordPolicyError struct {
+	Violations []passwordpolicy.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return fmt.Sprintf("%v: %s", ErrPasswordPolicy, strings.Join(messages, "; "))
+}
+
+// Is lets errors.Is match PasswordPolicyError against ErrPasswordPolicy.
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// checkPassword checks a password username wants to set against the
+// configured password policy, or the default policy if none is configured.
+func (s *UserService) checkPassword(password, username string) error {
+	policy := s.PasswordPolicy
+	if policy == nil {
+		policy = passwordpolicy.Default()
+	}
+
+	violations, err := policy.Check(password, username)
+	if err != nil {
+		return fmt.Errorf("failed to check password: %w", err)
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
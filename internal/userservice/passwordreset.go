@@ -0,0 +1,159 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+)
+
+const (
+	// DefaultPasswordResetTTL is used when no password reset token lifetime is configured.
+	DefaultPasswordResetTTL = 30 * time.Minute
+
+	// PasswordResetSubject is the subject of password reset emails.
+	PasswordResetSubject = "Reset your password"
+)
+
+var (
+	// ErrInvalidResetToken is returned for unknown, used or expired password reset tokens.
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+	// ErrPasswordResetNotConfigured is returned when no mailer or reset URL is configured.
+	ErrPasswordResetNotConfigured = errors.New("password reset is not configured")
+)
+
+// PasswordResetConfigured reports whether password reset links can be sent.
+func (s *UserService) PasswordResetConfigured() bool {
+	return s.Mailer != nil && s.PasswordResetURL != ""
+}
+
+// RequestPasswordReset mails a single-use password reset link to the email
+// address of username, or to the username itself when it is an address.
+// Unknown and unreachable users are silently ignored so callers cannot tell
+// which accounts exist.
+func (s *UserService) RequestPasswordReset(ctx context.Context, username string) error {
+	if !s.PasswordResetConfigured() {
+		return ErrPasswordResetNotConfigured
+	}
+
+	user, err := s.UserRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+	// the PostgreSQL repository returns an empty user when none matches
+	if user == nil || user.Username == "" {
+		return nil
+	}
+	recipient := user.Email
+	if recipient == "" {
+		recipient = user.Username
+	}
+	address, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return nil
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := s.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = DefaultPasswordResetTTL
+	}
+
+	resetToken := models.PasswordResetToken{
+		TokenHash: tokenHash,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		Used:      false,
+	}
+	if err := s.UserRepo.AddPasswordResetToken(ctx, resetToken); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	link, err := tokenLink(s.PasswordResetURL, token)
+	if err != nil {
+		return err
+	}
+	message := models.MailMessage{
+		To:      address.Address,
+		Subject: PasswordResetSubject,
+		Body: fmt.Sprintf("A password reset was requested for your account.\n\n"+
+			"Open the link below within %v to choose a new password:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.", ttl, link),
+	}
+	if err := s.Mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword consumes a password reset token and sets the password of its
+// user to newPassword, which must meet the password policy. Every outstanding
+// reset token and refresh token of the user is revoked. revokeSessions, if not
+// nil, revokes the user's session tokens before the password is changed, so
+// the password stays unchanged when it fails.
+func (s *UserService) ResetPassword(ctx context.Context, token, newPassword string, revokeSessions func(ctx context.Context, username string) error) (string, error) {
+	tokenHash := auth.HashOpaqueToken(token)
+
+	stored, err := s.UserRepo.GetPasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving password reset token: %w", err)
+	}
+	if stored == nil || stored.Used || time.Now().Unix() >= stored.ExpiresAt {
+		return "", ErrInvalidResetToken
+	}
+	// the token stays usable for another try when the password is refused
+	if err := s.checkPassword(newPassword, stored.Username); err != nil {
+		return "", err
+	}
+
+	// a concurrent request may have used the token since it was read
+	marked, err := s.UserRepo.MarkPasswordResetTokenUsed(ctx, tokenHash)
+	if err != nil {
+		return "", fmt.Errorf("error consuming password reset token: %w", err)
+	}
+	if !marked {
+		return "", ErrInvalidResetToken
+	}
+
+	// sessions opened with the old password must not outlive it
+	if revokeSessions != nil {
+		if err := revokeSessions(ctx, stored.Username); err != nil {
+			return "", fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	// the new password also lifts any lockout caused by guessing the old one
+	fields := map[string]interface{}{"failed_logins": int64(0), "locked_until": int64(0)}
+	if err := s.setPassword(ctx, stored.Username, newPassword, fields); err != nil {
+		return "", err
+	}
+
+	if err := s.UserRepo.DeletePasswordResetTokens(ctx, stored.Username); err != nil {
+		return "", fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+	if err := s.UserRepo.RevokeUserRefreshTokens(ctx, stored.Username); err != nil {
+		return "", fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return stored.Username, nil
+}
+
+// tokenLink appends token as the "token" query parameter of base.
+func tokenLink(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid link URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,119 @@
+package userservice
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// RecoveryCodeCount is the number of recovery codes in a batch.
+	RecoveryCodeCount = 10
+	// recoveryCodeLength is the number of characters of a code, without the separator.
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet leaves out characters that are easily confused.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	// ErrInvalidRecoveryCode is returned for unknown or already used recovery codes.
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+)
+
+// GenerateRecoveryCodes replaces the recovery codes of username with a new
+// batch and returns it. Codes of the previous batch stop working. Only users
+// with TOTP enabled have recovery codes.
+func (s *UserService) GenerateRecoveryCodes(ctx context.Context, username string) ([]string, error) {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		// shown with a separator for readability
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = string(hash)
+	}
+
+	fields := map[string]interface{}{"recovery_codes": strings.Join(hashes, " ")}
+	if err := s.UserRepo.UpdateUser(ctx, username, fields); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode checks code against the unused recovery codes of username
+// and burns it, so each code is accepted once.
+func (s *UserService) UseRecoveryCode(ctx context.Context, username, code string) error {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	code = normalizeRecoveryCode(code)
+	hashes := strings.Fields(user.RecoveryCodes)
+	for i, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) != nil {
+			continue
+		}
+
+		remaining := append(append([]string(nil), hashes[:i]...), hashes[i+1:]...)
+		// a concurrent login may have burned a code since the user was read
+		swapped, err := s.UserRepo.SetRecoveryCodes(ctx, username, user.RecoveryCodes, strings.Join(remaining, " "))
+		if err != nil {
+			return fmt.Errorf("failed to burn recovery code: %w", err)
+		}
+		if !swapped {
+			return ErrInvalidRecoveryCode
+		}
+		return nil
+	}
+	return ErrInvalidRecoveryCode
+}
+
+// RemainingRecoveryCodes returns the number of unused recovery codes of username.
+func (s *UserService) RemainingRecoveryCodes(ctx context.Context, username string) (int, error) {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	return len(strings.Fields(user.RecoveryCodes)), nil
+}
+
+func newRecoveryCode() (string, error) {
+	random := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+
+	code := make([]byte, recoveryCodeLength)
+	for i, b := range random {
+		// 256 is not a multiple of the alphabet size; the slight bias is
+		// negligible at this code length
+		code[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// normalizeRecoveryCode drops separators and case so codes can be typed loosely.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
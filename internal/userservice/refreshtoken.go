@@ -0,0 +1,121 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haguru/sasuke/internal/auth"
+	"github.com/haguru/sasuke/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultRefreshTokenTTL is used when no refresh token lifetime is configured.
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, revoked or expired refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already used refresh token is presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// IssueRefreshToken starts a new refresh token family for username and returns its
+// first token. amr lists the authentication methods of the login.
+func (s *UserService) IssueRefreshToken(ctx context.Context, username string, amr []string) (string, error) {
+	return s.issueRefreshToken(ctx, username, uuid.NewString(), strings.Join(amr, " "))
+}
+
+// RotateRefreshToken consumes refreshToken and returns it along with its
+// replacement from the same family. Presenting a token that was already used
+// revokes the whole family.
+func (s *UserService) RotateRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, string, error) {
+	tokenHash := auth.HashOpaqueToken(refreshToken)
+
+	stored, err := s.UserRepo.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, "", fmt.Errorf("error retrieving refresh token: %w", err)
+	}
+	if stored == nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if stored.Used {
+		return nil, "", s.revokeReusedFamily(ctx, stored.FamilyID)
+	}
+
+	if time.Now().Unix() >= stored.ExpiresAt {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	// a concurrent request may have used the token since it was read
+	marked, err := s.UserRepo.MarkRefreshTokenUsed(ctx, tokenHash)
+	if err != nil {
+		return nil, "", fmt.Errorf("error consuming refresh token: %w", err)
+	}
+	if !marked {
+		return nil, "", s.revokeReusedFamily(ctx, stored.FamilyID)
+	}
+
+	newToken, err := s.issueRefreshToken(ctx, stored.Username, stored.FamilyID, stored.AMR)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return stored, newToken, nil
+}
+
+func (s *UserService) issueRefreshToken(ctx context.Context, username, familyID, amr string) (string, error) {
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	ttl := s.RefreshTokenTTL
+	if ttl <= 0 {
+		ttl = DefaultRefreshTokenTTL
+	}
+
+	refreshToken := models.RefreshToken{
+		TokenHash: tokenHash,
+		FamilyID:  familyID,
+		Username:  username,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		Used:      false,
+		AMR:       amr,
+	}
+
+	if err := s.UserRepo.AddRefreshToken(ctx, refreshToken); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *UserService) revokeReusedFamily(ctx context.Context, familyID string) error {
+	if err := s.UserRepo.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("%w: failed to revoke token family: %v", ErrRefreshTokenReused, err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeRefreshToken revokes the family of refreshToken so neither it nor any
+// token rotated from it can be used. It reports whether the token was known.
+func (s *UserService) RevokeRefreshToken(ctx context.Context, refreshToken string) (bool, error) {
+	stored, err := s.UserRepo.GetRefreshToken(ctx, auth.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return false, fmt.Errorf("error retrieving refresh token: %w", err)
+	}
+	if stored == nil {
+		return false, nil
+	}
+
+	if err := s.UserRepo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		return false, fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return true, nil
+}
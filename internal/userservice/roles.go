@@ -0,0 +1,86 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/haguru/sasuke/internal/auth"
+)
+
+// maxRoleUpdates bounds the retries of a role change that races with other
+// changes to the roles of the same user.
+const maxRoleUpdates = 3
+
+// DefaultUserScopes are the scopes held by every user when none are configured.
+var DefaultUserScopes = []string{"openid", auth.ScopeAuthorize}
+
+// ErrUnknownRole is returned when assigning a role that is not defined.
+var ErrUnknownRole = errors.New("unknown role")
+
+// UserGrants returns the roles of username, the permissions they grant and
+// the scopes of the user, which are the user scopes and the permissions.
+func (s *UserService) UserGrants(ctx context.Context, username string) (auth.Grants, error) {
+	user, err := s.getExistingUser(ctx, username)
+	if err != nil {
+		return auth.Grants{}, err
+	}
+
+	grants := s.Roles.Grants(strings.Fields(user.Roles))
+	userScopes := s.UserScopes
+	if userScopes == nil {
+		userScopes = DefaultUserScopes
+	}
+	grants.Scopes = slices.Concat(userScopes, grants.Permissions)
+	slices.Sort(grants.Scopes)
+	grants.Scopes = slices.Compact(grants.Scopes)
+	return grants, nil
+}
+
+// AssignRole adds role to the roles of username. Assigning a role the user
+// already has is not an error.
+func (s *UserService) AssignRole(ctx context.Context, username, role string) error {
+	if _, ok := s.Roles[role]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+	}
+	return s.updateRoles(ctx, username, func(roles []string) []string {
+		if slices.Contains(roles, role) {
+			return roles
+		}
+		return append(roles, role)
+	})
+}
+
+// RevokeRole removes role from the roles of username. Callers should revoke
+// the sessions of the user, whose tokens still carry the role.
+func (s *UserService) RevokeRole(ctx context.Context, username, role string) error {
+	return s.updateRoles(ctx, username, func(roles []string) []string {
+		return slices.DeleteFunc(roles, func(r string) bool { return r == role })
+	})
+}
+
+// updateRoles replaces the roles of username with what change makes of them.
+func (s *UserService) updateRoles(ctx context.Context, username string, change func([]string) []string) error {
+	for range maxRoleUpdates {
+		user, err := s.getExistingUser(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		roles := strings.Join(change(strings.Fields(user.Roles)), " ")
+		if roles == user.Roles {
+			return nil
+		}
+		// a concurrent change may have updated the roles since the user was read
+		swapped, err := s.UserRepo.SetUserRoles(ctx, username, user.Roles, roles)
+		if err != nil {
+			return fmt.Errorf("failed to update roles: %w", err)
+		}
+		if swapped {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to update roles: too many concurrent changes")
+}
@@ -3,16 +3,66 @@ package userservice
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/haguru/sasuke/internal/auth"
 	"github.com/haguru/sasuke/internal/interfaces"
 	"github.com/haguru/sasuke/internal/models"
-
-	"golang.org/x/crypto/bcrypt"
+	"github.com/haguru/sasuke/internal/passwordhash"
+	"github.com/haguru/sasuke/internal/passwordpolicy"
+	"github.com/haguru/sasuke/internal/webauthn"
 )
 
-
 type UserService struct {
 	UserRepo interfaces.UserRepository
+	// RefreshTokenTTL is the lifetime of issued refresh tokens.
+	RefreshTokenTTL time.Duration
+	// SecretCipher encrypts TOTP secrets at rest; MFA enrollment is
+	// unavailable when it is nil.
+	SecretCipher *auth.SecretCipher
+	// TOTPIssuer labels enrolled accounts in authenticator apps.
+	TOTPIssuer string
+	// Credentials stores passkeys, which are verified for RelyingParty;
+	// passkeys are unavailable when either is nil.
+	Credentials  interfaces.CredentialRepository
+	RelyingParty *webauthn.RelyingParty
+	// Mailer delivers password reset links pointing at PasswordResetURL,
+	// valid for PasswordResetTTL; password reset is unavailable when the
+	// mailer or URL is unset.
+	Mailer           interfaces.Mailer
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+	// EmailVerificationURL is the page verification links point to; email
+	// verification links are not sent when it or the mailer is unset.
+	EmailVerificationURL string
+	// MagicLinkURL is the page login links point to; magic link login is
+	// unavailable when it or the mailer is unset.
+	MagicLinkURL string
+	// Lockout throttles failed password logins per account.
+	Lockout LockoutPolicy
+	// PasswordPolicy decides which new passwords are accepted; only the
+	// length is bounded when it is nil.
+	PasswordPolicy *passwordpolicy.Policy
+	// PasswordHasher hashes new passwords, bcrypt with the default cost when
+	// it is nil.
+	PasswordHasher interfaces.PasswordHasher
+	// Peppers are applied to passwords before hashing; passwords are hashed
+	// without pepper when it is nil.
+	Peppers *passwordhash.Peppers
+	// Roles defines the roles that may be assigned and their permissions;
+	// DefaultRoles are assigned at signup.
+	Roles        auth.RoleSet
+	DefaultRoles []string
+	// UserScopes are the scopes held by every user, in addition to the
+	// permissions of their roles; DefaultUserScopes when nil.
+	UserScopes []string
+	// APIKeys stores the API keys of users, which are unavailable when it is
+	// nil. Keys live for APIKeyTTL unless their creator asks for another
+	// lifetime of at most APIKeyMaxTTL.
+	APIKeys      interfaces.APIKeyRepository
+	APIKeyTTL    time.Duration
+	APIKeyMaxTTL time.Duration
 }
 
 // NewUserService creates a new UserService instance.
@@ -20,16 +70,25 @@ func NewUserService(repo interfaces.UserRepository) *UserService {
 	return &UserService{UserRepo: repo}
 }
 
-// RegisterUser hashes the password and adds the user via the repository.
-func (s *UserService) RegisterUser(ctx context.Context, username, password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// RegisterUser checks the password against the password policy, hashes it and
+// adds the user via the repository with the default roles. The email address
+// is stored unverified.
+func (s *UserService) RegisterUser(ctx context.Context, username, email, password string) (string, error) {
+	if err := s.checkPassword(password, username); err != nil {
+		return "", err
+	}
+
+	hashedPassword, pepperID, err := s.hashPassword(password)
 	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+		return "", err
 	}
 
 	user := models.User{
 		Username:       username,
-		HashedPassword: string(hashedPassword), // Pass hashed password to repository
+		HashedPassword: hashedPassword, // Pass hashed password to repository
+		Email:          NormalizeEmail(email),
+		PepperID:       pepperID,
+		Roles:          strings.Join(s.DefaultRoles, " "),
 	}
 
 	userID, err := s.UserRepo.AddUser(ctx, user)
@@ -40,19 +99,62 @@ func (s *UserService) RegisterUser(ctx context.Context, username, password strin
 }
 
 // AuthenticateUser verifies a user's credentials and returns their ID or an error.
+// Password hashes of an outdated algorithm, outdated parameters or a retired
+// pepper are replaced once the password is verified.
+// Failed attempts are throttled per account by the lockout policy: while an
+// account is locked an AccountLockedError is returned without checking the
+// password, and the failure that locks it wraps ErrLockoutStarted.
 func (s *UserService) AuthenticateUser(ctx context.Context, username, password string) (bool, error) {
 	user, err := s.UserRepo.GetUserByUsername(ctx, username)
 	if err != nil {
 		return false, fmt.Errorf("error retrieving user: %w", err)
 	}
-	if user == nil {
+	// the PostgreSQL repository returns an empty user when none matches
+	if user == nil || user.Username == "" {
 		return false, fmt.Errorf("user not found")
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password))
+	now := time.Now()
+	if err := s.checkLockout(user, now); err != nil {
+		return false, err
+	}
+
+	matched, err := s.verifyPassword(user, password)
 	if err != nil {
+		return false, err
+	}
+	if !matched {
+		lockedOut, err := s.recordLoginFailure(ctx, user, now)
+		if err != nil {
+			return false, err
+		}
+		if lockedOut {
+			return false, fmt.Errorf("invalid password: %w", ErrLockoutStarted)
+		}
 		return false, fmt.Errorf("invalid password")
 	}
 
+	// users with a second factor are only reset once it is verified, so
+	// that guessing it is throttled even by someone who knows the password
+	if len(MFAMethods(user)) == 0 {
+		if err := s.resetLoginFailures(ctx, user); err != nil {
+			return false, err
+		}
+	}
+	// the password is only known now, so outdated hashes are upgraded on login
+	if s.needsRehash(user) {
+		if err := s.setPassword(ctx, username, password, nil); err != nil {
+			return false, err
+		}
+	}
 	return true, nil // Authentication successful, return true
 }
+
+// GetUser returns the user with the given username, or nil if there is none.
+func (s *UserService) GetUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.UserRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving user: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,124 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// Attestation statement formats that are verified.
+const (
+	AttestationFormatNone   = "none"
+	AttestationFormatPacked = "packed"
+)
+
+// idFIDOGenCeAAGUID is the certificate extension carrying the authenticator
+// AAGUID in packed attestation certificates.
+var idFIDOGenCeAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// verifyAttestationStatement verifies the attestation statement of a new
+// credential and returns the format it was verified as. Packed statements
+// with a certificate are checked against that certificate only; without an
+// attestation trust store the authenticator model is not vouched for, which
+// passkey login does not rely on. Statements of other formats, such as tpm,
+// apple or android-key, are therefore accepted as no attestation rather than
+// failing the registration.
+func verifyAttestationStatement(format string, statement map[interface{}]interface{}, rawAuthData, clientDataHash []byte, authData *AuthenticatorData, credentialKey *PublicKey) (string, error) {
+	switch format {
+	case AttestationFormatNone:
+		if len(statement) != 0 {
+			return "", fmt.Errorf("none attestation must have an empty statement")
+		}
+		return AttestationFormatNone, nil
+	case AttestationFormatPacked:
+		if err := verifyPackedAttestation(statement, rawAuthData, clientDataHash, authData, credentialKey); err != nil {
+			return "", err
+		}
+		return AttestationFormatPacked, nil
+	default:
+		return AttestationFormatNone, nil
+	}
+}
+
+// verifyPackedAttestation implements WebAuthn Level 2 section 8.2.
+func verifyPackedAttestation(statement map[interface{}]interface{}, rawAuthData, clientDataHash []byte, authData *AuthenticatorData, credentialKey *PublicKey) error {
+	alg, ok := statement["alg"].(int64)
+	if !ok {
+		return fmt.Errorf("packed attestation is missing alg")
+	}
+	signature, ok := statement["sig"].([]byte)
+	if !ok {
+		return fmt.Errorf("packed attestation is missing sig")
+	}
+	if _, ok := statement["ecdaaKeyId"]; ok {
+		return fmt.Errorf("ECDAA attestation is not supported")
+	}
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash...)
+
+	chain, hasCertificates := statement["x5c"].([]interface{})
+	if !hasCertificates {
+		// self attestation is signed by the credential key itself
+		if alg != credentialKey.Algorithm {
+			return fmt.Errorf("self attestation algorithm %d does not match the credential", alg)
+		}
+		if err := credentialKey.Verify(signed, signature); err != nil {
+			return fmt.Errorf("invalid self attestation: %v", err)
+		}
+		return nil
+	}
+
+	if len(chain) == 0 {
+		return fmt.Errorf("packed attestation has an empty x5c")
+	}
+	der, ok := chain[0].([]byte)
+	if !ok {
+		return fmt.Errorf("invalid packed attestation certificate")
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("invalid packed attestation certificate: %v", err)
+	}
+	if err := checkPackedCertificate(certificate, authData.AAGUID); err != nil {
+		return err
+	}
+	if err := verifySignature(alg, certificate.PublicKey, signed, signature); err != nil {
+		return fmt.Errorf("invalid packed attestation: %v", err)
+	}
+	return nil
+}
+
+// checkPackedCertificate checks the attestation certificate requirements of
+// WebAuthn Level 2 section 8.2.1.
+func checkPackedCertificate(certificate *x509.Certificate, aaguid []byte) error {
+	if certificate.Version != 3 {
+		return fmt.Errorf("attestation certificate must be version 3")
+	}
+	subject := certificate.Subject
+	if len(subject.Country) == 0 || len(subject.Organization) == 0 || subject.CommonName == "" {
+		return fmt.Errorf("attestation certificate subject is incomplete")
+	}
+	if len(subject.OrganizationalUnit) != 1 || subject.OrganizationalUnit[0] != "Authenticator Attestation" {
+		return fmt.Errorf("attestation certificate subject OU must be Authenticator Attestation")
+	}
+	if certificate.IsCA {
+		return fmt.Errorf("attestation certificate must not be a CA")
+	}
+
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(idFIDOGenCeAAGUID) {
+			continue
+		}
+		if extension.Critical {
+			return fmt.Errorf("attestation certificate AAGUID extension must not be critical")
+		}
+		var certificateAAGUID []byte
+		if _, err := asn1.Unmarshal(extension.Value, &certificateAAGUID); err != nil {
+			return fmt.Errorf("invalid attestation certificate AAGUID: %v", err)
+		}
+		if !bytes.Equal(certificateAAGUID, aaguid) {
+			return fmt.Errorf("attestation certificate AAGUID does not match the authenticator")
+		}
+	}
+	return nil
+}
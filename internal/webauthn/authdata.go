@@ -0,0 +1,96 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Authenticator data flags (WebAuthn Level 2 section 6.1).
+const (
+	FlagUserPresent            = 0x01
+	FlagUserVerified           = 0x04
+	FlagAttestedCredentialData = 0x40
+	FlagExtensionData          = 0x80
+)
+
+const (
+	rpIDHashSize = 32
+	aaguidSize   = 16
+	// minAuthenticatorDataSize is rpIdHash, flags and signCount.
+	minAuthenticatorDataSize = rpIDHashSize + 1 + 4
+	// MaxCredentialIDSize is the largest credential ID accepted (WebAuthn Level 3).
+	MaxCredentialIDSize = 1023
+)
+
+// AuthenticatorData is the parsed authenticator data of a ceremony.
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// set when FlagAttestedCredentialData is present
+	AAGUID       []byte
+	CredentialID []byte
+	// PublicKey is the CBOR encoded COSE_Key of the credential.
+	PublicKey []byte
+}
+
+// UserPresent reports whether the user was present.
+func (a *AuthenticatorData) UserPresent() bool {
+	return a.Flags&FlagUserPresent != 0
+}
+
+// UserVerified reports whether the authenticator verified the user, for
+// example with a PIN or biometric.
+func (a *AuthenticatorData) UserVerified() bool {
+	return a.Flags&FlagUserVerified != 0
+}
+
+// ParseAuthenticatorData parses raw authenticator data.
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < minAuthenticatorDataSize {
+		return nil, fmt.Errorf("authenticator data is too short")
+	}
+
+	authData := &AuthenticatorData{
+		RPIDHash:  data[:rpIDHashSize],
+		Flags:     data[rpIDHashSize],
+		SignCount: binary.BigEndian.Uint32(data[rpIDHashSize+1 : minAuthenticatorDataSize]),
+	}
+	rest := data[minAuthenticatorDataSize:]
+
+	if authData.Flags&FlagAttestedCredentialData != 0 {
+		if len(rest) < aaguidSize+2 {
+			return nil, fmt.Errorf("attested credential data is too short")
+		}
+		authData.AAGUID = rest[:aaguidSize]
+		idLength := int(binary.BigEndian.Uint16(rest[aaguidSize : aaguidSize+2]))
+		rest = rest[aaguidSize+2:]
+		if idLength > MaxCredentialIDSize || len(rest) < idLength {
+			return nil, fmt.Errorf("invalid credential ID length %d", idLength)
+		}
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		// the key is followed by extensions, so its length is only known once decoded
+		_, keyLength, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential public key: %v", err)
+		}
+		authData.PublicKey = rest[:keyLength]
+		rest = rest[keyLength:]
+	}
+
+	if authData.Flags&FlagExtensionData != 0 {
+		_, extensionsLength, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extension data: %v", err)
+		}
+		rest = rest[extensionsLength:]
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("authenticator data has %d trailing bytes", len(rest))
+	}
+	return authData, nil
+}
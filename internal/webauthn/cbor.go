@@ -0,0 +1,202 @@
+package webauthn
+
+import (
+	"fmt"
+	"math"
+)
+
+// CBOR (RFC 8949) major types
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborTag      = 6
+	cborSimple   = 7
+)
+
+// maxCBORDepth bounds nesting so hostile input cannot exhaust the stack.
+const maxCBORDepth = 16
+
+// decodeCBOR decodes the first CBOR data item of data and returns it along
+// with the number of bytes it used. Integers decode to int64, byte strings to
+// []byte, text to string, arrays to []interface{} and maps to
+// map[interface{}]interface{} with int64 or string keys. Indefinite lengths,
+// which WebAuthn authenticators do not emit, are rejected.
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	d := cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.offset, nil
+}
+
+type cborDecoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > maxCBORDepth {
+		return nil, fmt.Errorf("cbor: nesting is too deep")
+	}
+
+	major, info, argument, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflows int64")
+		}
+		return int64(argument), nil
+	case cborNegative:
+		if argument > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflows int64")
+		}
+		return -1 - int64(argument), nil
+	case cborBytes:
+		return d.bytes(argument)
+	case cborText:
+		text, err := d.bytes(argument)
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	case cborArray:
+		// every element takes at least one byte
+		if argument > uint64(len(d.data)-d.offset) {
+			return nil, fmt.Errorf("cbor: array length exceeds input")
+		}
+		array := make([]interface{}, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case cborMap:
+		if argument > uint64(len(d.data)-d.offset)/2 {
+			return nil, fmt.Errorf("cbor: map length exceeds input")
+		}
+		m := make(map[interface{}]interface{}, argument)
+		for i := uint64(0); i < argument; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if _, ok := m[key]; ok {
+				return nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case cborTag:
+		// tags only annotate the item that follows
+		return d.decode(depth + 1)
+	default:
+		return simpleValue(info, argument)
+	}
+}
+
+// head reads the initial byte of a data item and returns its major type,
+// additional information and argument.
+func (d *cborDecoder) head() (byte, byte, uint64, error) {
+	if d.offset >= len(d.data) {
+		return 0, 0, 0, fmt.Errorf("cbor: unexpected end of input")
+	}
+	initial := d.data[d.offset]
+	d.offset++
+
+	major, info := initial>>5, initial&0x1f
+	if info < 24 {
+		return major, info, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, 0, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+
+	size := 1 << (info - 24)
+	if len(d.data)-d.offset < size {
+		return 0, 0, 0, fmt.Errorf("cbor: unexpected end of input")
+	}
+	raw := d.data[d.offset : d.offset+size]
+	d.offset += size
+
+	var argument uint64
+	for _, b := range raw {
+		argument = argument<<8 | uint64(b)
+	}
+	return major, info, argument, nil
+}
+
+func (d *cborDecoder) bytes(length uint64) ([]byte, error) {
+	if length > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("cbor: string length exceeds input")
+	}
+	value := make([]byte, length)
+	copy(value, d.data[d.offset:])
+	d.offset += int(length)
+	return value, nil
+}
+
+// simpleValue decodes major type 7 items. For floats argument holds the raw bits.
+func simpleValue(info byte, argument uint64) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		// null and undefined
+		return nil, nil
+	case 25:
+		return float16ToFloat64(uint16(argument)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(argument))), nil
+	case 27:
+		return math.Float64frombits(argument), nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", argument)
+	}
+}
+
+// float16ToFloat64 converts an IEEE 754 half precision value (RFC 8949 appendix D).
+func float16ToFloat64(half uint16) float64 {
+	exponent := int(half>>10) & 0x1f
+	mantissa := float64(half & 0x3ff)
+
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if half&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,58 @@
+package webauthn
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	// vectors from RFC 8949 appendix A
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Small integer", input: "17", want: int64(23)},
+		{name: "One byte integer", input: "1818", want: int64(24)},
+		{name: "Eight byte integer", input: "1b000000e8d4a51000", want: int64(1000000000000)},
+		{name: "Negative integer", input: "3903e7", want: int64(-1000)},
+		{name: "Byte string", input: "4401020304", want: []byte{1, 2, 3, 4}},
+		{name: "Text string", input: "6449455446", want: "IETF"},
+		{name: "Array", input: "83010203", want: []interface{}{int64(1), int64(2), int64(3)}},
+		{name: "Map", input: "a201020304", want: map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{name: "Text keyed map", input: "a26161016162820203", want: map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{name: "Boolean", input: "f5", want: true},
+		{name: "Null", input: "f6", want: nil},
+		{name: "Half float", input: "f93c00", want: float64(1)},
+		{name: "Tag is skipped", input: "c11a514b67b0", want: int64(1363896240)},
+		{name: "Integer overflow", input: "1bffffffffffffffff", wantErr: true},
+		{name: "Truncated string", input: "4401", wantErr: true},
+		{name: "Indefinite length", input: "5f42010243030405ff", wantErr: true},
+		{name: "Duplicate map key", input: "a201020103", wantErr: true},
+		{name: "Array longer than input", input: "9bffffffffffffffff", wantErr: true},
+		{name: "Nesting too deep", input: "818181818181818181818181818181818101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		input, err := hex.DecodeString(tt.input)
+		if err != nil {
+			t.Fatalf("%s: invalid test vector: %v", tt.name, err)
+		}
+		got, n, err := decodeCBOR(input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: decodeCBOR() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if n != len(input) {
+			t.Errorf("%s: decodeCBOR() consumed %d of %d bytes", tt.name, n, len(input))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decodeCBOR() = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
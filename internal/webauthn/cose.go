@@ -0,0 +1,152 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) accepted for credentials.
+const (
+	AlgorithmES256 = -7
+	AlgorithmEdDSA = -8
+	AlgorithmRS256 = -257
+)
+
+// SupportedAlgorithms lists the COSE algorithms offered in registration
+// options, in order of preference.
+var SupportedAlgorithms = []int64{AlgorithmES256, AlgorithmEdDSA, AlgorithmRS256}
+
+// COSE key parameters (RFC 9052 section 7 and RFC 9053 section 7).
+const (
+	coseKeyType      = 1
+	coseKeyAlgorithm = 3
+	coseCurve        = -1
+	coseX            = -2
+	coseY            = -3
+	coseRSAModulus   = -1
+	coseRSAExponent  = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// minRSAKeyBits rejects RSA credential keys that are too weak.
+const minRSAKeyBits = 2048
+
+// PublicKey is a parsed COSE_Key credential public key.
+type PublicKey struct {
+	Algorithm int64
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey parses a CBOR encoded COSE_Key of one of SupportedAlgorithms.
+func ParsePublicKey(coseKey []byte) (*PublicKey, error) {
+	value, n, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %v", err)
+	}
+	if n != len(coseKey) {
+		return nil, fmt.Errorf("invalid credential public key: trailing data")
+	}
+	params, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid credential public key: not a map")
+	}
+	return publicKeyFromCOSE(params)
+}
+
+func publicKeyFromCOSE(params map[interface{}]interface{}) (*PublicKey, error) {
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	alg, _ := params[int64(coseKeyAlgorithm)].(int64)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && alg == AlgorithmES256:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid ES256 credential public key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("ES256 credential public key is not on the curve")
+		}
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+
+	case keyType == coseKeyTypeOKP && alg == AlgorithmEdDSA:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid EdDSA credential public key")
+		}
+		return &PublicKey{Algorithm: alg, Key: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && alg == AlgorithmRS256:
+		modulus, _ := params[int64(coseRSAModulus)].([]byte)
+		exponent, _ := params[int64(coseRSAExponent)].([]byte)
+		if len(exponent) == 0 || len(exponent) > 4 {
+			return nil, fmt.Errorf("invalid RS256 credential public key")
+		}
+		e := 0
+		for _, b := range exponent {
+			e = e<<8 | int(b)
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: e}
+		if key.N.BitLen() < minRSAKeyBits || e < 3 || e%2 == 0 {
+			return nil, fmt.Errorf("RS256 credential public key is too weak")
+		}
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported credential key type %d with algorithm %d", keyType, alg)
+	}
+}
+
+// Verify checks signature over data with the key's algorithm. ECDSA
+// signatures are ASN.1 DER encoded as WebAuthn specifies.
+func (k *PublicKey) Verify(data, signature []byte) error {
+	return verifySignature(k.Algorithm, k.Key, data, signature)
+}
+
+func verifySignature(alg int64, key crypto.PublicKey, data, signature []byte) error {
+	valid := false
+	switch alg {
+	case AlgorithmES256:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %d", alg)
+		}
+		digest := sha256.Sum256(data)
+		valid = ecdsa.VerifyASN1(ecKey, digest[:], signature)
+	case AlgorithmEdDSA:
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %d", alg)
+		}
+		valid = ed25519.Verify(edKey, data, signature)
+	case AlgorithmRS256:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %d", alg)
+		}
+		digest := sha256.Sum256(data)
+		valid = rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return fmt.Errorf("unsupported algorithm %d", alg)
+	}
+
+	if !valid {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package webauthn verifies WebAuthn registration and authentication
+// ceremonies (https://www.w3.org/TR/webauthn-2/) for passkey login.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Client data types of the two ceremonies.
+const (
+	ClientDataTypeCreate = "webauthn.create"
+	ClientDataTypeGet    = "webauthn.get"
+)
+
+// ChallengeSize is the size of generated ceremony challenges.
+const ChallengeSize = 32
+
+// RelyingParty verifies ceremonies for one relying party ID, the registrable
+// domain passkeys are scoped to, and the origins allowed to use it.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+	// RequireUserVerification rejects ceremonies where the authenticator did
+	// not verify the user.
+	RequireUserVerification bool
+}
+
+// Registration is a credential created by a verified registration ceremony.
+type Registration struct {
+	CredentialID []byte
+	// PublicKey is the CBOR encoded COSE_Key of the credential.
+	PublicKey         []byte
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	UserVerified      bool
+}
+
+// collectedClientData is the JSON client data signed by the authenticator.
+type collectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// NewChallenge returns a random ceremony challenge.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// UserHandle returns the opaque user handle of username. It is stable, so
+// credentials can be matched to their user, without revealing the username.
+func UserHandle(username string) []byte {
+	handle := sha256.Sum256([]byte("webauthn-user:" + username))
+	return handle[:]
+}
+
+// VerifyRegistration verifies the response to a registration ceremony for
+// challenge and returns the new credential (WebAuthn Level 2 section 7.1).
+func (rp *RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte) (*Registration, error) {
+	if err := rp.verifyClientData(clientDataJSON, ClientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	value, n, err := decodeCBOR(attestationObject)
+	if err != nil || n != len(attestationObject) {
+		return nil, fmt.Errorf("invalid attestation object: %v", err)
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid attestation object")
+	}
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if statement == nil || rawAuthData == nil {
+		return nil, fmt.Errorf("attestation object is missing attStmt or authData")
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, fmt.Errorf("authenticator data has no attested credential")
+	}
+
+	publicKey, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	format, err = verifyAttestationStatement(format, statement, rawAuthData, clientDataHash[:], authData, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		CredentialID:      authData.CredentialID,
+		PublicKey:         authData.PublicKey,
+		SignCount:         authData.SignCount,
+		AAGUID:            authData.AAGUID,
+		AttestationFormat: format,
+		UserVerified:      authData.UserVerified(),
+	}, nil
+}
+
+// VerifyAssertion verifies the response to an authentication ceremony for
+// challenge with the stored COSE public key of the credential
+// (WebAuthn Level 2 section 7.2). Callers must check the returned sign count.
+func (rp *RelyingParty) VerifyAssertion(challenge, publicKey, clientDataJSON, rawAuthData, signature []byte) (*AuthenticatorData, error) {
+	if err := rp.verifyClientData(clientDataJSON, ClientDataTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+	if err := key.Verify(signed, signature); err != nil {
+		return nil, fmt.Errorf("invalid assertion signature: %v", err)
+	}
+	return authData, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, wantType string, challenge []byte) error {
+	var clientData collectedClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return fmt.Errorf("invalid client data: %v", err)
+	}
+	if clientData.Type != wantType {
+		return fmt.Errorf("unexpected client data type %q", clientData.Type)
+	}
+
+	received, err := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return fmt.Errorf("client data challenge does not match")
+	}
+
+	if clientData.CrossOrigin {
+		return fmt.Errorf("cross-origin ceremonies are not allowed")
+	}
+	for _, origin := range rp.Origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q is not allowed", clientData.Origin)
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *AuthenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rpIDHash[:]) != 1 {
+		return fmt.Errorf("authenticator data is for another relying party")
+	}
+	if !authData.UserPresent() {
+		return fmt.Errorf("user was not present")
+	}
+	if rp.RequireUserVerification && !authData.UserVerified() {
+		return fmt.Errorf("user was not verified")
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/haguru/sasuke/internal/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func testRelyingParty() *RelyingParty {
+	return &RelyingParty{ID: testRPID, Name: "Example", Origins: []string{testOrigin}}
+}
+
+func TestRelyingParty_VerifyRegistration(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(a *webauthntest.Authenticator)
+		rp         *RelyingParty
+		challenge  []byte
+		wantFormat string
+		wantErr    bool
+	}{
+		{
+			name:       "None attestation",
+			wantFormat: AttestationFormatNone,
+		},
+		{
+			name:       "Packed self attestation",
+			setup:      func(a *webauthntest.Authenticator) { a.Attestation = webauthntest.AttestationPacked },
+			wantFormat: AttestationFormatPacked,
+		},
+		{
+			name: "Packed certificate attestation",
+			setup: func(a *webauthntest.Authenticator) {
+				a.Attestation = webauthntest.AttestationPacked
+				a.AAGUID = bytes.Repeat([]byte{0x42}, 16)
+				key, certificate, err := webauthntest.NewAttestationCertificate(a.AAGUID)
+				if err != nil {
+					t.Fatalf("NewAttestationCertificate() error = %v", err)
+				}
+				a.AttestationKey, a.AttestationCertificate = key, certificate
+			},
+			wantFormat: AttestationFormatPacked,
+		},
+		{
+			name: "Packed certificate for another AAGUID",
+			setup: func(a *webauthntest.Authenticator) {
+				a.Attestation = webauthntest.AttestationPacked
+				key, certificate, err := webauthntest.NewAttestationCertificate(bytes.Repeat([]byte{0x42}, 16))
+				if err != nil {
+					t.Fatalf("NewAttestationCertificate() error = %v", err)
+				}
+				a.AttestationKey, a.AttestationCertificate = key, certificate
+			},
+			wantErr: true,
+		},
+		{
+			// formats that cannot be verified count as no attestation
+			name:       "Unsupported attestation format",
+			setup:      func(a *webauthntest.Authenticator) { a.Attestation = "tpm" },
+			wantFormat: AttestationFormatNone,
+		},
+		{
+			name:      "Wrong challenge",
+			challenge: []byte("another challenge"),
+			wantErr:   true,
+		},
+		{
+			name:    "Wrong origin",
+			setup:   func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" },
+			wantErr: true,
+		},
+		{
+			name:    "Wrong relying party",
+			setup:   func(a *webauthntest.Authenticator) { a.RPID = "evil.example" },
+			wantErr: true,
+		},
+		{
+			name:    "User verification required",
+			rp:      &RelyingParty{ID: testRPID, Origins: []string{testOrigin}, RequireUserVerification: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		authenticator, err := webauthntest.NewAuthenticator(testRPID, testOrigin)
+		if err != nil {
+			t.Fatalf("NewAuthenticator() error = %v", err)
+		}
+		if tt.setup != nil {
+			tt.setup(authenticator)
+		}
+		rp := tt.rp
+		if rp == nil {
+			rp = testRelyingParty()
+		}
+
+		challenge, err := NewChallenge()
+		if err != nil {
+			t.Fatalf("NewChallenge() error = %v", err)
+		}
+		clientDataJSON, attestationObject, err := authenticator.Create(challenge, UserHandle("testuser"))
+		if err != nil {
+			t.Fatalf("%s: Create() error = %v", tt.name, err)
+		}
+		if tt.challenge != nil {
+			challenge = tt.challenge
+		}
+
+		registration, err := rp.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: VerifyRegistration() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !bytes.Equal(registration.CredentialID, authenticator.CredentialID) {
+			t.Errorf("%s: got credential ID %x, want %x", tt.name, registration.CredentialID, authenticator.CredentialID)
+		}
+		if registration.AttestationFormat != tt.wantFormat {
+			t.Errorf("%s: got attestation format %s, want %s", tt.name, registration.AttestationFormat, tt.wantFormat)
+		}
+		if _, err := ParsePublicKey(registration.PublicKey); err != nil {
+			t.Errorf("%s: registered public key does not parse: %v", tt.name, err)
+		}
+	}
+}
+
+func TestRelyingParty_VerifyAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(a *webauthntest.Authenticator)
+		challenge []byte
+		tamper    func(authData, signature []byte)
+		wantErr   bool
+	}{
+		{
+			name: "Valid assertion",
+		},
+		{
+			name:  "Counting authenticator",
+			setup: func(a *webauthntest.Authenticator) { a.CountSignatures = true },
+		},
+		{
+			name:      "Wrong challenge",
+			challenge: []byte("another challenge"),
+			wantErr:   true,
+		},
+		{
+			name:    "Wrong origin",
+			setup:   func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" },
+			wantErr: true,
+		},
+		{
+			name:    "Wrong relying party",
+			setup:   func(a *webauthntest.Authenticator) { a.RPID = "evil.example" },
+			wantErr: true,
+		},
+		{
+			name:    "Tampered authenticator data",
+			tamper:  func(authData, _ []byte) { authData[len(authData)-1]++ },
+			wantErr: true,
+		},
+		{
+			name:    "Tampered signature",
+			tamper:  func(_, signature []byte) { signature[len(signature)-1]++ },
+			wantErr: true,
+		},
+	}
+
+	rp := testRelyingParty()
+	for _, tt := range tests {
+		authenticator, err := webauthntest.NewAuthenticator(testRPID, testOrigin)
+		if err != nil {
+			t.Fatalf("NewAuthenticator() error = %v", err)
+		}
+		challenge, err := NewChallenge()
+		if err != nil {
+			t.Fatalf("NewChallenge() error = %v", err)
+		}
+		clientDataJSON, attestationObject, err := authenticator.Create(challenge, UserHandle("testuser"))
+		if err != nil {
+			t.Fatalf("%s: Create() error = %v", tt.name, err)
+		}
+		registration, err := rp.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+		if err != nil {
+			t.Fatalf("%s: VerifyRegistration() error = %v", tt.name, err)
+		}
+
+		if tt.setup != nil {
+			tt.setup(authenticator)
+		}
+		challenge, err = NewChallenge()
+		if err != nil {
+			t.Fatalf("NewChallenge() error = %v", err)
+		}
+		clientDataJSON, authData, signature, err := authenticator.Get(challenge)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", tt.name, err)
+		}
+		if tt.challenge != nil {
+			challenge = tt.challenge
+		}
+		if tt.tamper != nil {
+			tt.tamper(authData, signature)
+		}
+
+		asserted, err := rp.VerifyAssertion(challenge, registration.PublicKey, clientDataJSON, authData, signature)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: VerifyAssertion() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && asserted.SignCount != authenticator.SignCount {
+			t.Errorf("%s: got sign count %d, want %d", tt.name, asserted.SignCount, authenticator.SignCount)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package webauthntest provides a software WebAuthn authenticator so
+// registration and passkey login can be tested without hardware.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Attestation formats the authenticator can produce.
+const (
+	AttestationNone   = "none"
+	AttestationPacked = "packed"
+)
+
+// authenticator data flags
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// Authenticator is a software authenticator holding a single ES256 passkey.
+type Authenticator struct {
+	RPID   string
+	Origin string
+	AAGUID []byte
+	// Attestation is the format of attestation statements, AttestationNone by default.
+	Attestation string
+	// AttestationKey and AttestationCertificate sign packed attestation
+	// statements; when unset packed statements are self attestations.
+	AttestationKey         *ecdsa.PrivateKey
+	AttestationCertificate []byte
+	// UserVerified sets the user verified flag of ceremonies.
+	UserVerified bool
+	// SignCount is the signature counter, incremented before each assertion
+	// when CountSignatures is set.
+	SignCount       uint32
+	CountSignatures bool
+
+	CredentialID []byte
+	UserHandle   []byte
+	key          *ecdsa.PrivateKey
+}
+
+// NewAuthenticator returns an authenticator for rpID that signs client data
+// of origin.
+func NewAuthenticator(rpID, origin string) (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credential key: %w", err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err := rand.Read(credentialID); err != nil {
+		return nil, fmt.Errorf("failed to generate credential ID: %w", err)
+	}
+	return &Authenticator{
+		RPID:         rpID,
+		Origin:       origin,
+		AAGUID:       make([]byte, 16),
+		Attestation:  AttestationNone,
+		CredentialID: credentialID,
+		key:          key,
+	}, nil
+}
+
+// Create performs the authenticator side of a registration ceremony and
+// returns the client data JSON and attestation object.
+func (a *Authenticator) Create(challenge, userHandle []byte) ([]byte, []byte, error) {
+	a.UserHandle = userHandle
+	clientDataJSON, err := a.clientData("webauthn.create", challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, err := EncodeCBOR(Map{
+		{Key: 1, Value: 2},  // kty: EC2
+		{Key: 3, Value: -7}, // alg: ES256
+		{Key: -1, Value: 1}, // crv: P-256
+		{Key: -2, Value: a.key.X.FillBytes(make([]byte, 32))},
+		{Key: -3, Value: a.key.Y.FillBytes(make([]byte, 32))},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authData := a.authenticatorData(flagAttestedCredentialData)
+	authData = append(authData, a.AAGUID...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, publicKey...)
+
+	statement := Map{}
+	if a.Attestation == AttestationPacked {
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		signingKey := a.key
+		if a.AttestationKey != nil {
+			signingKey = a.AttestationKey
+		}
+		signature, err := sign(signingKey, append(append([]byte(nil), authData...), clientDataHash[:]...))
+		if err != nil {
+			return nil, nil, err
+		}
+		statement = Map{{Key: "alg", Value: -7}, {Key: "sig", Value: signature}}
+		if a.AttestationCertificate != nil {
+			statement = append(statement, Pair{Key: "x5c", Value: []interface{}{a.AttestationCertificate}})
+		}
+	}
+
+	attestationObject, err := EncodeCBOR(Map{
+		{Key: "fmt", Value: a.Attestation},
+		{Key: "attStmt", Value: statement},
+		{Key: "authData", Value: authData},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientDataJSON, attestationObject, nil
+}
+
+// Get performs the authenticator side of an authentication ceremony and
+// returns the client data JSON, authenticator data and signature.
+func (a *Authenticator) Get(challenge []byte) ([]byte, []byte, []byte, error) {
+	clientDataJSON, err := a.clientData("webauthn.get", challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if a.CountSignatures {
+		a.SignCount++
+	}
+	authData := a.authenticatorData(0)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature, err := sign(a.key, append(append([]byte(nil), authData...), clientDataHash[:]...))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return clientDataJSON, authData, signature, nil
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	flags |= flagUserPresent
+	if a.UserVerified {
+		flags |= flagUserVerified
+	}
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func sign(key *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
+
+// NewAttestationCertificate returns a key and self-signed certificate that
+// meet the packed attestation certificate requirements for aaguid.
+func NewAttestationCertificate(aaguid []byte) (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate attestation key: %w", err)
+	}
+	aaguidExtension, err := asn1.Marshal(aaguid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"sasuke test authenticator"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "sasuke test attestation",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: aaguidExtension},
+		},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create attestation certificate: %w", err)
+	}
+	return key, certificate, nil
+}
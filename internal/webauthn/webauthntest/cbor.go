@@ -0,0 +1,91 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Pair is a map entry. Maps are encoded from pairs so the key order, which
+// CTAP2 canonical encoding fixes, is under the caller's control.
+type Pair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Map is an ordered CBOR map.
+type Map []Pair
+
+// EncodeCBOR encodes integers, byte and text strings, booleans, nil, arrays
+// ([]interface{}) and Maps. It covers what authenticators emit.
+func EncodeCBOR(value interface{}) ([]byte, error) {
+	var out []byte
+	return appendCBOR(out, value)
+}
+
+func appendCBOR(out []byte, value interface{}) ([]byte, error) {
+	var err error
+	switch v := value.(type) {
+	case int:
+		return appendInt(out, int64(v)), nil
+	case int64:
+		return appendInt(out, v), nil
+	case []byte:
+		out = appendHead(out, 2, uint64(len(v)))
+		return append(out, v...), nil
+	case string:
+		out = appendHead(out, 3, uint64(len(v)))
+		return append(out, v...), nil
+	case bool:
+		if v {
+			return append(out, 0xf5), nil
+		}
+		return append(out, 0xf4), nil
+	case nil:
+		return append(out, 0xf6), nil
+	case []interface{}:
+		out = appendHead(out, 4, uint64(len(v)))
+		for _, item := range v {
+			if out, err = appendCBOR(out, item); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case Map:
+		out = appendHead(out, 5, uint64(len(v)))
+		for _, pair := range v {
+			if out, err = appendCBOR(out, pair.Key); err != nil {
+				return nil, err
+			}
+			if out, err = appendCBOR(out, pair.Value); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported type %T", value)
+	}
+}
+
+func appendInt(out []byte, v int64) []byte {
+	if v < 0 {
+		return appendHead(out, 1, uint64(-1-v))
+	}
+	return appendHead(out, 0, uint64(v))
+}
+
+// appendHead writes the shortest head for major type and argument.
+func appendHead(out []byte, major byte, argument uint64) []byte {
+	major <<= 5
+	switch {
+	case argument < 24:
+		return append(out, major|byte(argument))
+	case argument <= 0xff:
+		return append(out, major|24, byte(argument))
+	case argument <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major|25), uint16(argument))
+	case argument <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(out, major|26), uint32(argument))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, major|27), argument)
+	}
+}
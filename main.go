@@ -1,11 +1,49 @@
 package main
 
 import (
+	"os"
+
 	"github.com/haguru/sasuke/config"
 	"github.com/haguru/sasuke/internal/app"
 )
 
+const (
+	// RotateKeysCommand rotates the signing key in the configured keyring directory.
+	RotateKeysCommand = "rotate-keys"
+	// RegisterClientCommand registers an OAuth client.
+	RegisterClientCommand = "register-client"
+	// UnlockUserCommand clears the failed logins and lockout of a user.
+	UnlockUserCommand = "unlock-user"
+	// AssignRoleCommand assigns a role to a user.
+	AssignRoleCommand = "assign-role"
+)
+
 func main() {
+	// admin commands
+	if len(os.Args) > 1 && os.Args[1] == RotateKeysCommand {
+		if err := app.RotateKeys(config.CONFIG_PATH); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == RegisterClientCommand {
+		if err := app.RegisterClient(config.CONFIG_PATH, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == UnlockUserCommand {
+		if err := app.UnlockUser(config.CONFIG_PATH, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == AssignRoleCommand {
+		if err := app.AssignRole(config.CONFIG_PATH, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
 
 	// create and initialize the app
 	app, err := app.NewApp(config.CONFIG_PATH)
@@ -20,6 +20,13 @@ const (
 	IDFIELD     = "_id"
 )
 
+// updateOperators are the update operators accepted by UpdateOne.
+var updateOperators = map[string]bool{
+	"$set":   true,
+	"$unset": true,
+	"$inc":   true,
+}
+
 // MongoDBClient implements the interfaces.DBClient interface for MongoDB operations.
 type MongoDBClient struct {
 	ServerOpts       *options.ServerAPIOptions
@@ -160,7 +167,7 @@ func (m *MongoDBClient) FindOne(ctx context.Context, collectionName string, filt
 	err := m.db.Collection(collectionName).FindOne(ctx, sanitizedFilter).Decode(result)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return fmt.Errorf("MongoDBClient: No document found in %s with filter: %v", collectionName, filter)
+			return fmt.Errorf("MongoDBClient: No document found in %s with filter: %v: %w", collectionName, filter, err)
 		}
 		return fmt.Errorf("MongoDBClient: Failed to find one in %s with filter: %v: %v", collectionName, filter, err)
 	}
@@ -222,7 +229,7 @@ func (m *MongoDBClient) UpdateOne(ctx context.Context, collectionName string, fi
 
 	// Sanitize filter and update
 	sanitizedFilter := m.sanitizeDocument(filter)
-	sanitizedUpdate := m.sanitizeDocument(update)
+	sanitizedUpdate := m.sanitizeUpdate(update)
 
 	res, err := m.db.Collection(collectionName).UpdateOne(ctx, sanitizedFilter, sanitizedUpdate)
 	if err != nil {
@@ -332,6 +339,32 @@ func (m *MongoDBClient) EnsureSchema(ctx context.Context, collectionName string,
 	return err
 }
 
+// sanitizeUpdate sanitizes an update document made of update operators such as $set.
+// Only the operators in updateOperators are kept and their fields are sanitized like any document.
+func (m *MongoDBClient) sanitizeUpdate(update interfaces.Document) interfaces.Document {
+	updateMap, ok := update.(map[string]interface{})
+	if !ok {
+		fmt.Println("MongoDBClient: Update is not of type map[string]interface{}, cannot sanitize")
+		return nil
+	}
+
+	sanitized := make(map[string]interface{})
+	for operator, fields := range updateMap {
+		if !updateOperators[operator] {
+			fmt.Printf("MongoDBClient: Skipping unsupported update operator: %s\n", operator)
+			continue
+		}
+
+		sanitizedFields := m.sanitizeDocument(fields)
+		if sanitizedFields == nil {
+			continue
+		}
+		sanitized[operator] = sanitizedFields
+	}
+
+	return sanitized
+}
+
 // SanitizeDocument ensures that the document does not contain any malicious content.
 // It checks for the presence of the ID field and removes it if found.
 // It also checks for any special characters in the keys that could lead to NoSQL injection attacks.
@@ -148,7 +148,7 @@ func (p *PostgresDatabaseClient) FindOne(ctx context.Context, tableName string,
 
 	for i := range columns {
 		field := elem.Type().Field(i)
-		columns[i] = strings.ToLower(field.Name) // Convert field name to snake_case or whatever your DB uses
+		columns[i] = columnName(field)
 		fieldPointers[i] = elem.Field(i).Addr().Interface()
 	}
 
@@ -373,6 +373,26 @@ func (p *PostgresDatabaseClient) DeleteMany(ctx context.Context, tableName strin
 	return rowsAffected, nil
 }
 
+// DeleteBefore deletes the rows of a PostgreSQL table whose column holds a
+// value below before, such as entries past their expiry time.
+func (p *PostgresDatabaseClient) DeleteBefore(ctx context.Context, tableName, column string, before interface{}) (int64, error) {
+	if !p.validTables[tableName] {
+		return 0, fmt.Errorf("invalid table name: %s", tableName)
+	}
+	if strings.ContainsAny(column, "();--") || !p.validColumns[column] {
+		return 0, fmt.Errorf("invalid column name: %s", column)
+	}
+
+	// Table and column names are validated; safe for fmt.Sprintf.
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s < $1", tableName, column) // #nosec G201
+
+	res, err := p.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // Ping checks the health of the PostgreSQL connection.
 func (p *PostgresDatabaseClient) Ping(ctx context.Context) error {
 	return p.db.PingContext(ctx)
@@ -393,6 +413,14 @@ func (p *PostgresDatabaseClient) EnsureSchema(ctx context.Context, tableName str
 	return err
 }
 
+// columnName returns the column for a struct field, preferring its `db` tag.
+func columnName(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("db"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return strings.ToLower(field.Name)
+}
+
 // SanitizeDocument removes the ID field and invalid keys to prevent SQL injection.
 func (p *PostgresDatabaseClient) sanitizeDocument(document interfaces.Document) (map[string]interface{}, error) {
 	if document == nil {